@@ -0,0 +1,80 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SigningKeySetSpec identifies the signer reporting its key set
+type SigningKeySetSpec struct {
+	// SecretName is the Secret the signer loads its JWT signing keys from
+	SecretName string `json:"secretName"`
+}
+
+// SigningKeySetStatus reports the JWT signing keys a signer has loaded
+type SigningKeySetStatus struct {
+	// Kids lists the loaded key IDs, sorted
+	// +optional
+	Kids []string `json:"kids,omitempty"`
+
+	// KeyCount is the number of loaded keys
+	KeyCount int `json:"keyCount"`
+
+	// LatestKid is the newest key ID in the Secret
+	// +optional
+	LatestKid string `json:"latestKid,omitempty"`
+
+	// SigningKid is the key ID currently used to sign new tokens.
+	// It lags LatestKid while the newest key is in its cooloff period.
+	// +optional
+	SigningKid string `json:"signingKid,omitempty"`
+
+	// ValidationOnlyKids lists loaded keys that only validate tokens, never sign them
+	// +optional
+	ValidationOnlyKids []string `json:"validationOnlyKids,omitempty"`
+
+	// LastReportTime is when the signer last published this status
+	// +optional
+	LastReportTime *metav1.Time `json:"lastReportTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Secret",type="string",JSONPath=".spec.secretName"
+// +kubebuilder:printcolumn:name="Keys",type="integer",JSONPath=".status.keyCount"
+// +kubebuilder:printcolumn:name="Latest",type="string",JSONPath=".status.latestKid"
+// +kubebuilder:printcolumn:name="Signing",type="string",JSONPath=".status.signingKid"
+// +kubebuilder:printcolumn:name="Reported",type="date",JSONPath=".status.lastReportTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// SigningKeySet is the Schema for the signingkeysets API.
+// Each auth middleware replica publishes the signing keys it has loaded
+// into a SigningKeySet named after its pod.
+type SigningKeySet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec identifies the reporting signer
+	Spec SigningKeySetSpec `json:"spec"`
+
+	// Status reports the loaded signing keys
+	// +optional
+	Status SigningKeySetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SigningKeySetList contains a list of SigningKeySet
+type SigningKeySetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SigningKeySet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SigningKeySet{}, &SigningKeySetList{})
+}
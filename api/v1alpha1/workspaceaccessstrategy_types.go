@@ -174,6 +174,13 @@ type WorkspaceAccessStrategySpec struct {
 	// exist in the API server.
 	// +optional
 	AccessStartupProbe *AccessStartupProbe `json:"accessStartupProbe,omitempty"`
+
+	// TokenTTL caps the lifetime of tokens issued by the extension API for workspaces
+	// using this access strategy. It only takes effect when shorter than the global
+	// token expiration; longer values are ignored.
+	// Example: "30m"
+	// +optional
+	TokenTTL *metav1.Duration `json:"tokenTTL,omitempty"`
 }
 
 // WorkspaceAccessStrategyStatus defines the observed state of WorkspaceAccessStrategy
@@ -373,6 +373,109 @@ func (in *ResourceRange) DeepCopy() *ResourceRange {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigningKeySet) DeepCopyInto(out *SigningKeySet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningKeySet.
+func (in *SigningKeySet) DeepCopy() *SigningKeySet {
+	if in == nil {
+		return nil
+	}
+	out := new(SigningKeySet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SigningKeySet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigningKeySetList) DeepCopyInto(out *SigningKeySetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SigningKeySet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningKeySetList.
+func (in *SigningKeySetList) DeepCopy() *SigningKeySetList {
+	if in == nil {
+		return nil
+	}
+	out := new(SigningKeySetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SigningKeySetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigningKeySetSpec) DeepCopyInto(out *SigningKeySetSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningKeySetSpec.
+func (in *SigningKeySetSpec) DeepCopy() *SigningKeySetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SigningKeySetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigningKeySetStatus) DeepCopyInto(out *SigningKeySetStatus) {
+	*out = *in
+	if in.Kids != nil {
+		in, out := &in.Kids, &out.Kids
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValidationOnlyKids != nil {
+		in, out := &in.ValidationOnlyKids, &out.ValidationOnlyKids
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastReportTime != nil {
+		in, out := &in.LastReportTime, &out.LastReportTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningKeySetStatus.
+func (in *SigningKeySetStatus) DeepCopy() *SigningKeySetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SigningKeySetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 	*out = *in
@@ -595,6 +698,11 @@ func (in *WorkspaceAccessStrategySpec) DeepCopyInto(out *WorkspaceAccessStrategy
 		*out = new(AccessStartupProbe)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TokenTTL != nil {
+		in, out := &in.TokenTTL, &out.TokenTTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceAccessStrategySpec.
@@ -10,6 +10,7 @@ package main
 import (
 	"os"
 
+	workspacev1alpha1 "github.com/jupyter-infra/jupyter-k8s/api/v1alpha1"
 	"github.com/jupyter-infra/jupyter-k8s/internal/authmiddleware"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -53,9 +54,10 @@ func main() {
 
 	setupLog.Info("Configuring manager to watch single namespace", "namespace", cfg.Namespace)
 
-	// Create scheme and add corev1 for Secret informers
+	// Create scheme and add corev1 for Secret informers, and SigningKeySet for status reporting
 	scheme := runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(workspacev1alpha1.AddToScheme(scheme))
 
 	// Create manager with namespace-scoped cache
 	mgr, err := ctrl.NewManager(k8sConfig, ctrl.Options{
@@ -76,8 +78,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Share one context between setup and the manager so both stop on the same signal
+	ctx := ctrl.SetupSignalHandler()
+
 	// Setup authmiddleware with manager
-	if err := authmiddleware.SetupAuthMiddlewareWithManager(mgr, cfg); err != nil {
+	if err := authmiddleware.SetupAuthMiddlewareWithManager(ctx, mgr, cfg); err != nil {
 		setupLog.Error(err, "Failed to setup authmiddleware")
 		os.Exit(1)
 	}
@@ -97,7 +102,7 @@ func main() {
 	setupLog.Info("Starting authmiddleware manager")
 
 	// Start manager (blocks until signal or error)
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "Manager exited with error")
 		os.Exit(1)
 	}
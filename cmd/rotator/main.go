@@ -8,27 +8,55 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
-	"math"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
 	"github.com/jupyter-infra/jupyter-k8s/internal/rotator"
+	"github.com/prometheus/client_golang/prometheus/push"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
 
 // Environment variable names
 const (
-	EnvSecretName       = "SECRET_NAME"
-	EnvSecretNamespace  = "SECRET_NAMESPACE"
-	EnvNumberOfKeys     = "NUMBER_OF_KEYS"
-	EnvDryRun           = "DRY_RUN"
-	EnvTokenTTL         = "TOKEN_TTL"
-	EnvRotationInterval = "ROTATION_INTERVAL"
+	EnvSecretName        = "SECRET_NAME"
+	EnvSecretNamespace   = "SECRET_NAMESPACE"
+	EnvNumberOfKeys      = "NUMBER_OF_KEYS"
+	EnvDryRun            = "DRY_RUN"
+	EnvTokenTTL          = "TOKEN_TTL"
+	EnvRotationInterval  = "ROTATION_INTERVAL"
+	EnvEnforceRetention  = "ENFORCE_RETENTION"
+	EnvPruneStrayKeys    = "PRUNE_STRAY_KEYS"
+	EnvMinKeyAge         = "MIN_KEY_AGE"
+	EnvMaxKeyAge         = "MAX_KEY_AGE"
+	EnvPushgatewayURL    = "PUSHGATEWAY_URL"
+	EnvKeyPrefix         = "KEY_PREFIX"
+	EnvLegacyKeyPrefixes = "LEGACY_KEY_PREFIXES"
+	EnvKeyEncoding       = "KEY_ENCODING"
+	EnvRunMode           = "RUN_MODE"
+)
+
+// Values of RUN_MODE
+const (
+	// RunModeOnce rotates once and exits, for a Job or CronJob
+	RunModeOnce = "once"
+	// RunModeScheduled rotates every ROTATION_INTERVAL until terminated, for a Deployment
+	RunModeScheduled = "scheduled"
+	// RunModeValidate only checks the secret and reports its keys, without writing,
+	// and exits non-zero if the secret is unhealthy
+	RunModeValidate = "validate"
 )
 
 // Default values
@@ -37,6 +65,18 @@ const (
 	DefaultNumberOfKeys = 6
 )
 
+// eventSourceComponent is reported as the source of events recorded on the secret
+const eventSourceComponent = "jwt-rotator"
+
+// metricsJobName is the Pushgateway job that rotation metrics are grouped under
+const metricsJobName = "jwt-rotator"
+
+// rotationTimeout bounds a single rotation, including secret validation and metrics push
+const rotationTimeout = 30 * time.Second
+
+// eventFlushDelay gives the broadcaster time to send recorded events before the process exits
+const eventFlushDelay = 2 * time.Second
+
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
@@ -44,15 +84,52 @@ func main() {
 	secretName := getEnv(EnvSecretName, DefaultSecretName)
 	secretNamespace := os.Getenv(EnvSecretNamespace)
 	dryRun := getEnvBool(EnvDryRun, false)
+	pruneStrayKeys := getEnvList(EnvPruneStrayKeys)
+	minKeyAge := getEnvDuration(EnvMinKeyAge, 0)
+	maxKeyAge := getEnvDuration(EnvMaxKeyAge, 0)
+	pushgatewayURL := os.Getenv(EnvPushgatewayURL)
+	keyPrefix := getEnv(EnvKeyPrefix, jwt.KeyPrefix)
+	legacyKeyPrefixes := getEnvList(EnvLegacyKeyPrefixes)
+	keyPrefixes := append([]string{keyPrefix}, legacyKeyPrefixes...)
+	keyEncoding := strings.ToLower(getEnv(EnvKeyEncoding, jwt.KeyEncodingRaw))
+	if err := jwt.ValidateKeyEncoding(keyEncoding); err != nil {
+		log.Fatalf("Invalid value for %s: %v", EnvKeyEncoding, err)
+	}
+
+	runMode := getEnv(EnvRunMode, RunModeOnce)
+	if runMode != RunModeOnce && runMode != RunModeScheduled && runMode != RunModeValidate {
+		log.Fatalf("Invalid value for %s: %s (must be %s, %s or %s)",
+			EnvRunMode, runMode, RunModeOnce, RunModeScheduled, RunModeValidate)
+	}
 
 	// Determine numberOfKeys: derived from TOKEN_TTL + ROTATION_INTERVAL, or explicit NUMBER_OF_KEYS
 	numberOfKeys := resolveNumberOfKeys()
 
-	log.Printf("Starting JWT key rotation...")
+	if runMode == RunModeValidate {
+		log.Printf("Starting JWT secret validation...")
+	} else {
+		log.Printf("Starting JWT key rotation...")
+	}
 	log.Printf("  Secret: %s", secretName)
 	log.Printf("  Namespace: %s", secretNamespace)
 	log.Printf("  Number of keys: %d", numberOfKeys)
+	log.Printf("  Min key age: %s", minKeyAge)
+	if maxKeyAge > 0 {
+		log.Printf("  Max key age: %s", maxKeyAge)
+	}
 	log.Printf("  Dry run: %v", dryRun)
+	log.Printf("  Run mode: %s", runMode)
+	if len(pruneStrayKeys) > 0 {
+		log.Printf("  Prune stray keys: %v", pruneStrayKeys)
+	}
+	if pushgatewayURL != "" {
+		log.Printf("  Pushgateway: %s", pushgatewayURL)
+	}
+	log.Printf("  Key prefix: %s", keyPrefix)
+	if len(legacyKeyPrefixes) > 0 {
+		log.Printf("  Legacy key prefixes: %v", legacyKeyPrefixes)
+	}
+	log.Printf("  Key encoding: %s", keyEncoding)
 
 	// Validate namespace is set
 	if secretNamespace == "" {
@@ -81,55 +158,266 @@ func main() {
 		log.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	r := &rotation{
+		client:          k8sClient,
+		secretName:      secretName,
+		secretNamespace: secretNamespace,
+		numberOfKeys:    numberOfKeys,
+		minKeyAge:       minKeyAge,
+		dryRun:          dryRun,
+		pruneStrayKeys:  pruneStrayKeys,
+		keyPrefixes:     keyPrefixes,
+		keyEncoding:     keyEncoding,
+		pushgatewayURL:  pushgatewayURL,
+		opts: []rotator.RotateOption{
+			rotator.WithKeyPrefix(keyPrefix),
+			rotator.WithLegacyKeyPrefixes(legacyKeyPrefixes...),
+			rotator.WithMaxKeyAge(maxKeyAge),
+			rotator.WithKeyEncoding(keyEncoding),
+		},
+	}
+
+	if runMode == RunModeValidate {
+		ctx, cancel := context.WithTimeout(context.Background(), rotationTimeout)
+		defer cancel()
+		if err := r.validate(ctx, time.Now()); err != nil {
+			log.Fatalf("Secret validation failed: %v", err)
+		}
+		return
+	}
+
+	// Events are best effort: rotate without them if the recorder cannot be created
+	if !dryRun {
+		recorder, flushEvents, err := newEventRecorder(config, scheme)
+		if err != nil {
+			log.Printf("Warning: failed to create event recorder, rotation events will not be recorded: %v", err)
+		} else {
+			defer flushEvents()
+			r.opts = append(r.opts, rotator.WithEventRecorder(recorder))
+		}
+	}
+
+	if runMode == RunModeScheduled {
+		if err := runScheduled(config, scheme, r); err != nil {
+			log.Fatalf("Scheduled rotation failed: %v", err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rotationTimeout)
 	defer cancel()
+	if err := r.run(ctx); err != nil {
+		log.Fatalf("Key rotation failed: %v", err)
+	}
+}
+
+// rotation holds the settings of one rotator run
+type rotation struct {
+	client          client.Client
+	secretName      string
+	secretNamespace string
+	numberOfKeys    int
+	minKeyAge       time.Duration
+	dryRun          bool
+	pruneStrayKeys  []string
+	keyPrefixes     []string
+	keyEncoding     string
+	pushgatewayURL  string
+	opts            []rotator.RotateOption
+}
 
+// run validates the secret, then rotates it, pushes metrics and prunes stray keys.
+// A dry run only logs the rotation plan.
+func (r *rotation) run(ctx context.Context) error {
 	// Validate secret exists and has valid keys before rotation
-	log.Printf("Validating secret %s in namespace %s...", secretName, secretNamespace)
-	if err := rotator.ValidateSecret(ctx, k8sClient, secretName, secretNamespace); err != nil {
+	log.Printf("Validating secret %s in namespace %s...", r.secretName, r.secretNamespace)
+	if err := rotator.ValidateSecret(ctx, r.client, r.secretName, r.secretNamespace, r.keyPrefixes...); err != nil {
 		log.Printf("Warning: secret validation failed (this is OK for first run): %v", err)
 	} else {
 		log.Printf("Secret validation passed")
 	}
 
-	if dryRun {
-		log.Printf("DRY RUN: Would rotate keys in secret %s/%s (numberOfKeys=%d)",
-			secretNamespace, secretName, numberOfKeys)
+	if r.dryRun {
+		plan, err := rotator.RotateSecretDryRun(ctx, r.client, r.secretName, r.secretNamespace,
+			r.numberOfKeys, r.minKeyAge, r.opts...)
+		if err != nil {
+			return fmt.Errorf("failed to plan dry run rotation: %w", err)
+		}
+		logRotationPlan(plan)
 		log.Printf("DRY RUN: Skipping actual rotation")
-		os.Exit(0)
+		return nil
 	}
 
 	// Perform rotation
 	log.Printf("Rotating keys...")
-	if err := rotator.RotateSecret(ctx, k8sClient, secretName, secretNamespace, numberOfKeys); err != nil {
-		log.Fatalf("Failed to rotate keys: %v", err)
+	err := rotator.RotateSecret(ctx, r.client, r.secretName, r.secretNamespace, r.numberOfKeys, r.minKeyAge, r.opts...)
+	if r.pushgatewayURL != "" {
+		pushMetrics(ctx, r.pushgatewayURL, r.secretName, r.secretNamespace)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rotate keys: %w", err)
+	}
+
+	if len(r.pruneStrayKeys) > 0 {
+		if _, err := rotator.PruneStrayKeys(ctx, r.client, r.secretName, r.secretNamespace,
+			r.pruneStrayKeys, r.keyPrefixes...); err != nil {
+			return fmt.Errorf("failed to prune stray keys: %w", err)
+		}
 	}
 
 	log.Printf("Key rotation completed successfully")
+	return nil
+}
+
+// validate checks the secret like a rotation does, without writing to it, and logs its
+// key count, latest kid and the age of each key. It fails if the signers would reject
+// the secret.
+func (r *rotation) validate(ctx context.Context, now time.Time) error {
+	log.Printf("Validating secret %s in namespace %s...", r.secretName, r.secretNamespace)
+	if err := rotator.ValidateSecret(ctx, r.client, r.secretName, r.secretNamespace, r.keyPrefixes...); err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: r.secretName, Namespace: r.secretNamespace}, secret); err != nil {
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+	latestKid, err := rotator.GetLatestEncodedKeyID(secret, r.keyEncoding, r.keyPrefixes...)
+	if err != nil {
+		return err
+	}
+
+	keyAges := rotator.GetKeyAges(secret, now, r.keyPrefixes...)
+	log.Printf("Secret %s/%s is valid", r.secretNamespace, r.secretName)
+	log.Printf("  Keys: %d", len(keyAges))
+	log.Printf("  Latest kid: %s", latestKid)
+	for _, k := range keyAges {
+		log.Printf("  %s: age %s", k.Name, k.Age.Round(time.Second))
+	}
+	return nil
+}
+
+// runScheduled rotates every ROTATION_INTERVAL until SIGTERM or SIGINT. Replicas elect
+// a leader through a Lease next to the secret, and only the leader rotates.
+func runScheduled(config *rest.Config, scheme *runtime.Scheme, r *rotation) error {
+	interval := getEnvDuration(EnvRotationInterval, 0)
+	if interval == 0 {
+		return fmt.Errorf("%s must be set when %s is %s", EnvRotationInterval, EnvRunMode, RunModeScheduled)
+	}
+
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme:                        scheme,
+		Metrics:                       metricsserver.Options{BindAddress: "0"},
+		LeaderElection:                true,
+		LeaderElectionID:              leaderElectionID(r.secretName),
+		LeaderElectionNamespace:       r.secretNamespace,
+		LeaderElectionReleaseOnCancel: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create manager: %w", err)
+	}
+
+	scheduler, err := rotator.NewScheduler(interval, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, rotationTimeout)
+		defer cancel()
+		return r.run(ctx)
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(scheduler); err != nil {
+		return fmt.Errorf("failed to add scheduler to manager: %w", err)
+	}
+
+	log.Printf("Rotating every %s once elected leader (lease %s/%s)",
+		interval, r.secretNamespace, leaderElectionID(r.secretName))
+	return mgr.Start(ctrl.SetupSignalHandler())
+}
+
+// leaderElectionID names the Lease held by the rotating replica, one per secret
+func leaderElectionID(secretName string) string {
+	return "jwt-rotator-" + secretName
+}
+
+// logRotationPlan reports the changes a dry run found the rotation would make
+func logRotationPlan(plan *rotator.RotationPlan) {
+	if plan.Skipped {
+		log.Printf("DRY RUN: Would add no key, newest key age %s < %s", plan.NewestKeyAge, EnvMaxKeyAge)
+	} else {
+		log.Printf("DRY RUN: Would add key %s (kid %s)", plan.NewKeyName, plan.NewKid)
+	}
+	if len(plan.PrunedKeys) > 0 {
+		log.Printf("DRY RUN: Would prune %d keys: %v", len(plan.PrunedKeys), plan.PrunedKeys)
+	} else {
+		log.Printf("DRY RUN: Would prune no keys")
+	}
+	if len(plan.DeferredKeys) > 0 {
+		log.Printf("DRY RUN: Would defer pruning of %d keys younger than MIN_KEY_AGE: %v",
+			len(plan.DeferredKeys), plan.DeferredKeys)
+	}
+	if len(plan.MalformedKeys) > 0 {
+		log.Printf("DRY RUN: Would skip %d malformed keys: %v", len(plan.MalformedKeys), plan.MalformedKeys)
+	}
+	log.Printf("DRY RUN: Secret would hold %d entries", plan.RemainingKeys)
+}
+
+// newEventRecorder creates a recorder that sends events to the API server, and a function
+// that waits briefly for queued events to be sent before shutting the broadcaster down
+func newEventRecorder(config *rest.Config, scheme *runtime.Scheme) (record.EventRecorder, func(), error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme, corev1.EventSource{Component: eventSourceComponent})
+
+	flush := func() {
+		time.Sleep(eventFlushDelay)
+		broadcaster.Shutdown()
+	}
+	return recorder, flush, nil
+}
+
+// pushMetrics sends the rotation metrics to a Pushgateway, grouped by secret so rotators
+// for different secrets do not overwrite each other. Failures are logged, not fatal.
+func pushMetrics(ctx context.Context, url string, secretName string, secretNamespace string) {
+	pusher := push.New(url, metricsJobName).
+		Grouping("namespace", secretNamespace).
+		Grouping("secret", secretName)
+	for _, collector := range rotator.MetricsCollectors() {
+		pusher = pusher.Collector(collector)
+	}
+	if err := pusher.PushContext(ctx); err != nil {
+		log.Printf("Warning: failed to push metrics to %s: %v", url, err)
+		return
+	}
+	log.Printf("Pushed rotation metrics to %s", url)
 }
 
 // resolveNumberOfKeys determines the number of keys to retain.
 // Explicit NUMBER_OF_KEYS takes precedence. Otherwise derives from TOKEN_TTL + ROTATION_INTERVAL.
+// When an explicit value is too low to cover TOKEN_TTL, logs a warning, or exits if ENFORCE_RETENTION is set.
 func resolveNumberOfKeys() int {
+	tokenTTL, rotationInterval, hasRetentionInputs := getRetentionInputs()
+
 	// Explicit NUMBER_OF_KEYS takes precedence
 	if v := os.Getenv(EnvNumberOfKeys); v != "" {
-		return getEnvInt(EnvNumberOfKeys, DefaultNumberOfKeys)
+		n := getEnvInt(EnvNumberOfKeys, DefaultNumberOfKeys)
+		if hasRetentionInputs {
+			if err := rotator.CheckRetention(n, tokenTTL, rotationInterval); err != nil {
+				if getEnvBool(EnvEnforceRetention, false) {
+					log.Fatalf("Invalid %s: %v", EnvNumberOfKeys, err)
+				}
+				log.Printf("Warning: %v; tokens may fail validation after their signing key is pruned", err)
+			}
+		}
+		return n
 	}
 
 	// Derive from TOKEN_TTL + ROTATION_INTERVAL
-	tokenTTLStr := os.Getenv(EnvTokenTTL)
-	rotationIntervalStr := os.Getenv(EnvRotationInterval)
-
-	if tokenTTLStr != "" && rotationIntervalStr != "" {
-		tokenTTL, err := time.ParseDuration(tokenTTLStr)
-		if err != nil {
-			log.Fatalf("Invalid %s value %q: %v", EnvTokenTTL, tokenTTLStr, err)
-		}
-		rotationInterval, err := time.ParseDuration(rotationIntervalStr)
-		if err != nil {
-			log.Fatalf("Invalid %s value %q: %v", EnvRotationInterval, rotationIntervalStr, err)
-		}
+	if hasRetentionInputs {
 		n := deriveNumberOfKeys(tokenTTL, rotationInterval)
 		log.Printf("Derived numberOfKeys=%d from TOKEN_TTL=%s, ROTATION_INTERVAL=%s", n, tokenTTL, rotationInterval)
 		return n
@@ -138,13 +426,34 @@ func resolveNumberOfKeys() int {
 	return DefaultNumberOfKeys
 }
 
+// getRetentionInputs parses TOKEN_TTL and ROTATION_INTERVAL.
+// Returns false if either is unset.
+func getRetentionInputs() (time.Duration, time.Duration, bool) {
+	tokenTTLStr := os.Getenv(EnvTokenTTL)
+	rotationIntervalStr := os.Getenv(EnvRotationInterval)
+	if tokenTTLStr == "" || rotationIntervalStr == "" {
+		return 0, 0, false
+	}
+
+	tokenTTL, err := time.ParseDuration(tokenTTLStr)
+	if err != nil {
+		log.Fatalf("Invalid %s value %q: %v", EnvTokenTTL, tokenTTLStr, err)
+	}
+	rotationInterval, err := time.ParseDuration(rotationIntervalStr)
+	if err != nil {
+		log.Fatalf("Invalid %s value %q: %v", EnvRotationInterval, rotationIntervalStr, err)
+	}
+	return tokenTTL, rotationInterval, true
+}
+
 // deriveNumberOfKeys computes ceil(ttl / interval) + 1.
 // The +1 ensures the previous signing key is always available during rotation.
 func deriveNumberOfKeys(ttl, interval time.Duration) int {
-	if interval <= 0 {
-		log.Fatalf("ROTATION_INTERVAL must be > 0")
+	n, err := rotator.MinimumNumberOfKeys(ttl, interval)
+	if err != nil {
+		log.Fatalf("Invalid %s/%s: %v", EnvTokenTTL, EnvRotationInterval, err)
 	}
-	return int(math.Ceil(float64(ttl)/float64(interval))) + 1
+	return n
 }
 
 // getEnv retrieves an environment variable or returns a default value
@@ -155,6 +464,17 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList retrieves a comma-separated environment variable as a list of trimmed, non-empty values
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 // getEnvInt retrieves an integer environment variable or returns a default value
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
@@ -167,6 +487,18 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvDuration retrieves a duration environment variable or returns a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid value for %s: %s (must be a non-negative duration)", key, value)
+		}
+		return d
+	}
+	return defaultValue
+}
+
 // getEnvBool retrieves a boolean environment variable or returns a default value
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
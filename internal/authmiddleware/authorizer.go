@@ -0,0 +1,192 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+// Authorizer decides whether an authenticated request may proceed.
+// It is called by /verify after the token, path and domain checks pass,
+// so implementations only see validated claims.
+// A denial returns 403 to the client with the reason.
+type Authorizer interface {
+	Authorize(ctx context.Context, claims *jwt.Claims, r *http.Request) (allowed bool, reason string)
+}
+
+// AllowAllAuthorizer allows every authenticated request. This is the default.
+type AllowAllAuthorizer struct{}
+
+// Authorize implements Authorizer
+func (AllowAllAuthorizer) Authorize(context.Context, *jwt.Claims, *http.Request) (bool, string) {
+	return true, ""
+}
+
+// GroupAuthorizer allows requests from users in at least one of the configured groups
+type GroupAuthorizer struct {
+	groups map[string]struct{}
+}
+
+// NewGroupAuthorizer creates a GroupAuthorizer allowing the given groups
+func NewGroupAuthorizer(groups ...string) *GroupAuthorizer {
+	a := &GroupAuthorizer{groups: make(map[string]struct{}, len(groups))}
+	for _, group := range groups {
+		a.groups[group] = struct{}{}
+	}
+	return a
+}
+
+// Authorize implements Authorizer
+func (a *GroupAuthorizer) Authorize(_ context.Context, claims *jwt.Claims, _ *http.Request) (bool, string) {
+	if a.isMember(claims) {
+		return true, ""
+	}
+	return false, "user is not a member of an authorized group"
+}
+
+// isMember reports whether claims carry at least one of the configured groups
+func (a *GroupAuthorizer) isMember(claims *jwt.Claims) bool {
+	for _, group := range claims.Groups {
+		if _, ok := a.groups[group]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupRule requires membership in one of Groups for requests whose forwarded host
+// matches Host and whose forwarded path is PathPrefix or below it
+type GroupRule struct {
+	// Host is an exact host, or "*.domain" to match any subdomain of domain
+	Host string
+	// PathPrefix limits the rule to a path subtree; empty matches every path
+	PathPrefix string
+	Groups     []string
+}
+
+// matches reports whether the rule applies to host and path
+func (r GroupRule) matches(host, path string) bool {
+	if suffix, ok := strings.CutPrefix(r.Host, "*."); ok {
+		if !strings.HasSuffix(host, "."+suffix) {
+			return false
+		}
+	} else if host != r.Host {
+		return false
+	}
+	if r.PathPrefix == "" || path == r.PathPrefix {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(r.PathPrefix, "/")+"/")
+}
+
+// ParseGroupRules parses rules of the form "host[/path]=group1,group2", separated by
+// semicolons, e.g. "admin.example.com=admins;*.example.com/lab=users,admins"
+func ParseGroupRules(spec string) ([]GroupRule, error) {
+	var rules []GroupRule
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		target, groupList, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("rule %q: expected host[/path]=groups", entry)
+		}
+		target = strings.TrimSpace(target)
+		host, path, _ := strings.Cut(target, "/")
+		if host == "" {
+			return nil, fmt.Errorf("rule %q: host must not be empty", entry)
+		}
+		rule := GroupRule{Host: strings.ToLower(host)}
+		if path != "" {
+			rule.PathPrefix = "/" + path
+		}
+		for _, group := range strings.Split(groupList, ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				rule.Groups = append(rule.Groups, group)
+			}
+		}
+		if len(rule.Groups) == 0 {
+			return nil, fmt.Errorf("rule %q: at least one group is required", entry)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// RuleAuthorizer applies GroupRules to the forwarded host and URI of each request.
+// The first matching rule decides; requests matching no rule are allowed unless
+// WithDenyUnmatched is set.
+type RuleAuthorizer struct {
+	rules []GroupRule
+	// members holds the group check for each rule
+	members []*GroupAuthorizer
+	// hostHeader and uriHeader override the forwarded header names when set
+	hostHeader string
+	uriHeader  string
+	// denyUnmatched denies requests matching no rule, set via WithDenyUnmatched
+	denyUnmatched bool
+}
+
+// NewRuleAuthorizer creates a RuleAuthorizer evaluating rules in order
+func NewRuleAuthorizer(rules ...GroupRule) *RuleAuthorizer {
+	a := &RuleAuthorizer{rules: rules, members: make([]*GroupAuthorizer, len(rules))}
+	for i, rule := range rules {
+		a.members[i] = NewGroupAuthorizer(rule.Groups...)
+	}
+	return a
+}
+
+// WithForwardedHeaders reads the host and URI from the named headers instead of
+// X-Forwarded-Host and X-Forwarded-Uri. Empty names keep the default.
+func (a *RuleAuthorizer) WithForwardedHeaders(hostHeader, uriHeader string) *RuleAuthorizer {
+	a.hostHeader = hostHeader
+	a.uriHeader = uriHeader
+	return a
+}
+
+// WithDenyUnmatched denies requests matching no rule instead of allowing them
+func (a *RuleAuthorizer) WithDenyUnmatched() *RuleAuthorizer {
+	a.denyUnmatched = true
+	return a
+}
+
+// Authorize implements Authorizer
+func (a *RuleAuthorizer) Authorize(_ context.Context, claims *jwt.Claims, r *http.Request) (bool, string) {
+	host := strings.ToLower(r.Header.Get(headerOrDefault(a.hostHeader, HeaderForwardedHost)))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	path, _, _ := strings.Cut(r.Header.Get(headerOrDefault(a.uriHeader, HeaderForwardedURI)), "?")
+
+	for i, rule := range a.rules {
+		if !rule.matches(host, path) {
+			continue
+		}
+		if a.members[i].isMember(claims) {
+			return true, ""
+		}
+		return false, "user is not a member of a group authorized for this host"
+	}
+	if a.denyUnmatched {
+		return false, "no authorization rule matches this host"
+	}
+	return true, ""
+}
+
+// getAuthorizer returns the configured Authorizer, falling back to AllowAllAuthorizer
+func (s *Server) getAuthorizer() Authorizer {
+	if s.authorizer == nil {
+		return AllowAllAuthorizer{}
+	}
+	return s.authorizer
+}
@@ -0,0 +1,205 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// AuthorizerFunc adapts a function to the Authorizer interface
+type AuthorizerFunc func(ctx context.Context, claims *jwt.Claims, r *http.Request) (bool, string)
+
+func (f AuthorizerFunc) Authorize(ctx context.Context, claims *jwt.Claims, r *http.Request) (bool, string) {
+	return f(ctx, claims, r)
+}
+
+// runVerifyWithAuthorizer calls /verify with a valid session token for testAppPath
+func runVerifyWithAuthorizer(t *testing.T, authorizer Authorizer, validateErr error) *httptest.ResponseRecorder {
+	t.Helper()
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{
+		ValidateTokenFunc: func(string) (*jwt.Claims, error) {
+			if validateErr != nil {
+				return nil, validateErr
+			}
+			return &jwt.Claims{
+				User:      "alice",
+				Groups:    []string{"data-science"},
+				Path:      testAppPath,
+				Domain:    "example.com",
+				TokenType: jwt.TokenTypeSession,
+			}, nil
+		},
+	})
+	WithAuthorizer(authorizer)(server)
+
+	req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+	req.Header.Set(HeaderForwardedURI, testAppPath+"/lab")
+	req.Header.Set(HeaderForwardedHost, "example.com")
+	w := httptest.NewRecorder()
+	server.handleVerify(w, req)
+	return w
+}
+
+func TestHandleVerify_CustomAuthorizerAllows(t *testing.T) {
+	var seen *jwt.Claims
+	w := runVerifyWithAuthorizer(t, AuthorizerFunc(func(_ context.Context, claims *jwt.Claims, r *http.Request) (bool, string) {
+		seen = claims
+		assert.Equal(t, testAppPath+"/lab", r.Header.Get(HeaderForwardedURI))
+		return true, ""
+	}), nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, seen) {
+		assert.Equal(t, "alice", seen.User)
+	}
+}
+
+func TestHandleVerify_CustomAuthorizerDenies(t *testing.T) {
+	w := runVerifyWithAuthorizer(t, AuthorizerFunc(func(context.Context, *jwt.Claims, *http.Request) (bool, string) {
+		return false, "outside working hours"
+	}), nil)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "outside working hours")
+}
+
+func TestHandleVerify_AuthorizerNotCalledForInvalidToken(t *testing.T) {
+	called := false
+	w := runVerifyWithAuthorizer(t, AuthorizerFunc(func(context.Context, *jwt.Claims, *http.Request) (bool, string) {
+		called = true
+		return true, ""
+	}), errors.New("bad token"))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, called, "authorizer must only see validated claims")
+}
+
+func TestHandleVerify_DefaultAuthorizerAllows(t *testing.T) {
+	w := runVerifyWithAuthorizer(t, nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGroupAuthorizer(t *testing.T) {
+	authorizer := NewGroupAuthorizer("admins", "data-science")
+	req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+
+	allowed, _ := authorizer.Authorize(context.Background(), &jwt.Claims{Groups: []string{"users", "data-science"}}, req)
+	assert.True(t, allowed)
+
+	allowed, reason := authorizer.Authorize(context.Background(), &jwt.Claims{Groups: []string{"users"}}, req)
+	assert.False(t, allowed)
+	assert.NotEmpty(t, reason)
+
+	allowed, _ = authorizer.Authorize(context.Background(), &jwt.Claims{}, req)
+	assert.False(t, allowed)
+}
+
+func TestHandleVerify_GroupAuthorizer(t *testing.T) {
+	w := runVerifyWithAuthorizer(t, NewGroupAuthorizer("data-science"), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = runVerifyWithAuthorizer(t, NewGroupAuthorizer("admins"), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestParseGroupRules(t *testing.T) {
+	rules, err := ParseGroupRules(" Admin.Example.com=admins ; *.example.com/lab/ = users, admins ;")
+	assert.NoError(t, err)
+	assert.Equal(t, []GroupRule{
+		{Host: "admin.example.com", Groups: []string{"admins"}},
+		{Host: "*.example.com", PathPrefix: "/lab/", Groups: []string{"users", "admins"}},
+	}, rules)
+
+	for _, spec := range []string{"example.com", "=admins", "example.com=", "example.com= , "} {
+		_, err := ParseGroupRules(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRuleAuthorizer(t *testing.T) {
+	authorizer := NewRuleAuthorizer(
+		GroupRule{Host: "admin.example.com", Groups: []string{"admins"}},
+		GroupRule{Host: "*.example.com", PathPrefix: "/lab", Groups: []string{"users"}},
+	)
+	users := &jwt.Claims{Groups: []string{"users"}}
+	admins := &jwt.Claims{Groups: []string{"admins"}}
+
+	tests := []struct {
+		name    string
+		host    string
+		uri     string
+		claims  *jwt.Claims
+		allowed bool
+	}{
+		{name: "exact host, member", host: "admin.example.com", uri: "/", claims: admins, allowed: true},
+		{name: "exact host, not a member", host: "admin.example.com", uri: "/lab", claims: users, allowed: false},
+		{name: "host with port", host: "Admin.Example.com:443", uri: "/", claims: users, allowed: false},
+		{name: "wildcard path, member", host: "ws.example.com", uri: "/lab/tree?x=1", claims: users, allowed: true},
+		{name: "wildcard path, not a member", host: "ws.example.com", uri: "/lab", claims: admins, allowed: false},
+		{name: "path prefix is segment aligned", host: "ws.example.com", uri: "/laboratory", claims: admins, allowed: true},
+		{name: "wildcard does not match apex", host: "example.com", uri: "/lab", claims: admins, allowed: true},
+		{name: "no rule matches", host: "other.org", uri: "/lab", claims: &jwt.Claims{}, allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+			req.Header.Set(HeaderForwardedHost, tt.host)
+			req.Header.Set(HeaderForwardedURI, tt.uri)
+
+			allowed, reason := authorizer.Authorize(context.Background(), tt.claims, req)
+			assert.Equal(t, tt.allowed, allowed)
+			if !allowed {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+}
+
+func TestRuleAuthorizer_DenyUnmatched(t *testing.T) {
+	authorizer := NewRuleAuthorizer(GroupRule{Host: "admin.example.com", Groups: []string{"admins"}}).WithDenyUnmatched()
+	admins := &jwt.Claims{Groups: []string{"admins"}}
+
+	req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+	req.Header.Set(HeaderForwardedHost, "admin.example.com")
+	allowed, _ := authorizer.Authorize(context.Background(), admins, req)
+	assert.True(t, allowed)
+
+	req.Header.Set(HeaderForwardedHost, "other.org")
+	allowed, reason := authorizer.Authorize(context.Background(), admins, req)
+	assert.False(t, allowed)
+	assert.NotEmpty(t, reason)
+}
+
+func TestRuleAuthorizer_CustomForwardedHeaders(t *testing.T) {
+	authorizer := NewRuleAuthorizer(GroupRule{Host: "admin.example.com", PathPrefix: "/lab", Groups: []string{"admins"}}).
+		WithForwardedHeaders("X-Original-Host", "X-Original-Uri")
+
+	req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+	req.Header.Set(HeaderForwardedHost, "other.org")
+	req.Header.Set(HeaderForwardedURI, "/")
+	req.Header.Set("X-Original-Host", "admin.example.com")
+	req.Header.Set("X-Original-Uri", "/lab")
+
+	allowed, _ := authorizer.Authorize(context.Background(), &jwt.Claims{Groups: []string{"users"}}, req)
+	assert.False(t, allowed)
+}
+
+func TestHandleVerify_RuleAuthorizer(t *testing.T) {
+	w := runVerifyWithAuthorizer(t, NewRuleAuthorizer(
+		GroupRule{Host: "example.com", PathPrefix: testAppPath, Groups: []string{"data-science"}}), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = runVerifyWithAuthorizer(t, NewRuleAuthorizer(
+		GroupRule{Host: "example.com", PathPrefix: testAppPath, Groups: []string{"admins"}}), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrUnsafeClaimValue is returned when an identity claim contains control characters,
+// which could inject headers or forge log lines once the claim is written out
+var ErrUnsafeClaimValue = errors.New("identity claim contains control characters")
+
+// hasControlChars reports whether s contains a control character such as CR or LF
+func hasControlChars(s string) bool {
+	return strings.IndexFunc(s, unicode.IsControl) >= 0
+}
+
+// stripControlChars removes control characters from s
+func stripControlChars(s string) string {
+	if !hasControlChars(s) {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// validateIdentityClaims rejects an identity whose user, UID, groups or extra claims
+// contain control characters. The error names the offending claim but not its value.
+func validateIdentityClaims(user string, uid string, groups []string, extra map[string][]string) error {
+	if hasControlChars(user) {
+		return fmt.Errorf("%w: user", ErrUnsafeClaimValue)
+	}
+	if hasControlChars(uid) {
+		return fmt.Errorf("%w: uid", ErrUnsafeClaimValue)
+	}
+	for i, group := range groups {
+		if hasControlChars(group) {
+			return fmt.Errorf("%w: group %d", ErrUnsafeClaimValue, i)
+		}
+	}
+	for key, values := range extra {
+		if hasControlChars(key) {
+			return fmt.Errorf("%w: extra key", ErrUnsafeClaimValue)
+		}
+		for _, value := range values {
+			if hasControlChars(value) {
+				return fmt.Errorf("%w: extra %q", ErrUnsafeClaimValue, stripControlChars(key))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+const injectedGroup = "admin\r\nX-Admin: true"
+
+func TestValidateIdentityClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   string
+		uid    string
+		groups []string
+		extra  map[string][]string
+		valid  bool
+	}{
+		{name: "clean identity", user: "alice", uid: "uid-1", groups: []string{"g1"},
+			extra: map[string][]string{"department": {"research"}}, valid: true},
+		{name: "unicode is allowed", user: "zoë", groups: []string{"équipe"}, valid: true},
+		{name: "line break in user", user: "alice\nbob"},
+		{name: "control char in uid", user: "alice", uid: "uid\x00"},
+		{name: "header injection in group", user: "alice", groups: []string{"g1", injectedGroup}},
+		{name: "tab in extra value", user: "alice", extra: map[string][]string{"department": {"a\tb"}}},
+		{name: "control char in extra key", user: "alice", extra: map[string][]string{"dept\r": {"a"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateIdentityClaims(tt.user, tt.uid, tt.groups, tt.extra)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrUnsafeClaimValue)
+			assert.NotContains(t, err.Error(), "X-Admin", "error should not echo the claim value")
+		})
+	}
+}
+
+func TestStripControlChars(t *testing.T) {
+	assert.Equal(t, "adminX-Admin: true", stripControlChars(injectedGroup))
+	assert.Equal(t, "ab", stripControlChars("a\x00\x1b\x7fb"))
+	assert.Equal(t, "zoë", stripControlChars("zoë"))
+}
+
+func TestSetAccessLogIdentity_StripsControlChars(t *testing.T) {
+	identity := &accessLogIdentity{}
+	ctx := context.WithValue(context.Background(), accessLogIdentityKey{}, identity)
+
+	setAccessLogIdentity(ctx, "alice\n", []string{injectedGroup})
+
+	assert.Equal(t, "alice", identity.user)
+	assert.Equal(t, []string{"adminX-Admin: true"}, identity.groups)
+}
+
+func TestHandleVerify_RejectsUnsafeClaims(t *testing.T) {
+	cookieHandler := &MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return testCookieToken, nil
+		},
+	}
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc: func(string) (*jwt.Claims, error) {
+			return &jwt.Claims{
+				User:      "alice",
+				Groups:    []string{injectedGroup},
+				Path:      testAppPath2,
+				Domain:    "example.com",
+				TokenType: jwt.TokenTypeSession,
+			}, nil
+		},
+	}
+	server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+
+	req := httptest.NewRequest(http.MethodGet, routeVerify, nil)
+	req.Header.Set(HeaderForwardedURI, testAppPath2+"/lab")
+	req.Header.Set(HeaderForwardedHost, "example.com")
+	w := httptest.NewRecorder()
+	server.handleVerify(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderAuthGroups))
+	assert.Empty(t, w.Header().Get("X-Admin"))
+}
+
+func TestHandleRefresh_RejectsUnsafeClaims(t *testing.T) {
+	claims := refreshTestClaims()
+	claims.User = "alice\r\n"
+	cookieHandler := &MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return testCookieToken, nil
+		},
+		SetCookieFunc: func(w http.ResponseWriter, token string, path string, domain string) {
+			t.Error("SetCookie should not be called for unsafe claims")
+		},
+	}
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc: func(string) (*jwt.Claims, error) { return claims, nil },
+	}
+	server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+
+	w := httptest.NewRecorder()
+	server.handleRefresh(w, newRefreshRequest())
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -8,10 +8,19 @@ Distributed under the terms of the MIT license
 package authmiddleware
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
 )
 
 // Environment variable names
@@ -21,13 +30,36 @@ const (
 	EnvReadTimeout     = "READ_TIMEOUT"
 	EnvWriteTimeout    = "WRITE_TIMEOUT"
 	EnvShutdownTimeout = "SHUTDOWN_TIMEOUT"
-	EnvTrustedProxies  = "TRUSTED_PROXIES"
-	EnvMetricsAddr     = "METRICS_ADDR"
-	EnvProbeAddr       = "PROBE_ADDR"
-	EnvNamespace       = "NAMESPACE"
+	EnvMaxHeaderBytes  = "MAX_HEADER_BYTES"
+
+	EnvVerifyAllowedMethods  = "VERIFY_ALLOWED_METHODS"
+	EnvDenyResponseFloor     = "DENY_RESPONSE_FLOOR"
+	EnvDenyResponseJitter    = "DENY_RESPONSE_JITTER"
+	EnvRateLimitRPS          = "RATE_LIMIT_RPS"
+	EnvRateLimitBurst        = "RATE_LIMIT_BURST"
+	EnvTrustedProxies        = "TRUSTED_PROXIES"
+	EnvForwardedHostHeader   = "FORWARDED_HOST_HEADER"
+	EnvForwardedURIHeader    = "FORWARDED_URI_HEADER"
+	EnvForwardedProtoHeader  = "FORWARDED_PROTO_HEADER"
+	EnvIdentityUserHeader    = "IDENTITY_USER_HEADER"
+	EnvIdentityGroupsHeader  = "IDENTITY_GROUPS_HEADER"
+	EnvIdentityUIDHeader     = "IDENTITY_UID_HEADER"
+	EnvIdentityExtraHeaders  = "IDENTITY_EXTRA_HEADERS"
+	EnvMetricsAddr           = "METRICS_ADDR"
+	EnvProbeAddr             = "PROBE_ADDR"
+	EnvNamespace             = "NAMESPACE"
+	EnvPodName               = "POD_NAME"
+	EnvPodUID                = "POD_UID"
+	EnvSigningStatusInterval = "SIGNING_STATUS_INTERVAL"
+	EnvAccessLog             = "ACCESS_LOG"
+	EnvCORSAllowedOrigins    = "CORS_ALLOWED_ORIGINS"
+	EnvCORSAllowedMethods    = "CORS_ALLOWED_METHODS"
+	EnvCORSAllowedHeaders    = "CORS_ALLOWED_HEADERS"
+	EnvCORSAllowCredentials  = "CORS_ALLOW_CREDENTIALS"
 
 	// Auth configuration
 	EnvJwtSigningType    = "JWT_SIGNING_TYPE"
+	EnvJwtAlgorithm      = "JWT_ALGORITHM"
 	EnvJwtIssuer         = "JWT_ISSUER"
 	EnvJwtAudience       = "JWT_AUDIENCE"
 	EnvJwtExpiration     = "JWT_EXPIRATION"
@@ -36,13 +68,67 @@ const (
 	EnvJwtRefreshHorizon = "JWT_REFRESH_HORIZON"
 	EnvJwtSecretName     = "JWT_SECRET_NAME"
 	EnvJwtNewKeyUseDelay = "NEW_KEY_USE_DELAY"
-	EnvEnableOAuth       = "ENABLE_OAUTH"
-	EnvEnableBearerAuth  = "ENABLE_BEARER_URL_AUTH"
+	EnvJwtKeyPrefixes    = "JWT_KEY_PREFIXES"
+	EnvJwtRejectWeakKeys = "JWT_REJECT_WEAK_KEYS"
+	EnvForceSigningKid   = "FORCE_SIGNING_KID"
+
+	EnvJwtAdditionalSecretNames = "JWT_ADDITIONAL_SECRET_NAMES"
+	EnvJwtKeyEncoding           = "JWT_KEY_ENCODING"
+	EnvJwtMaxKeyRetention       = "JWT_MAX_KEY_RETENTION"
+
+	EnvJwtSecretWatchSyncTimeout = "JWT_SECRET_WATCH_SYNC_TIMEOUT"
+
+	EnvJwtClockSkewLeeway = "JWT_CLOCK_SKEW_LEEWAY"
+
+	EnvJwtJwksURL             = "JWT_JWKS_URL"
+	EnvJwtJwksRefreshInterval = "JWT_JWKS_REFRESH_INTERVAL"
+	EnvJwtJwksAllowInsecure   = "JWT_JWKS_ALLOW_INSECURE_HTTP"
+
+	EnvInitialSecretLoadTimeout     = "INITIAL_SECRET_LOAD_TIMEOUT"
+	EnvInitialSecretLoadMaxAttempts = "INITIAL_SECRET_LOAD_MAX_ATTEMPTS"
+	EnvSigningKeyHealthGracePeriod  = "SIGNING_KEY_HEALTH_GRACE_PERIOD"
+
+	EnvJwtKeyRotationInterval = "JWT_KEY_ROTATION_INTERVAL"
+	EnvJwtKeyRetentionCount   = "JWT_KEY_RETENTION_COUNT"
+
+	EnvJwtValidationCacheEnable = "JWT_VALIDATION_CACHE_ENABLE"
+	EnvJwtValidationCacheSize   = "JWT_VALIDATION_CACHE_SIZE"
+
+	EnvJwtMaxGroups          = "JWT_MAX_GROUPS"
+	EnvJwtMaxClaimBytes      = "JWT_MAX_CLAIM_BYTES"
+	EnvJwtClaimLimitTruncate = "JWT_CLAIM_LIMIT_TRUNCATE"
+
+	EnvJwtPreviousIssuer        = "JWT_PREVIOUS_ISSUER"
+	EnvJwtPreviousAudience      = "JWT_PREVIOUS_AUDIENCE"
+	EnvJwtIssuerMigrationStart  = "JWT_ISSUER_MIGRATION_START"
+	EnvJwtIssuerMigrationWindow = "JWT_ISSUER_MIGRATION_WINDOW"
+
+	EnvJwtFallbackIssuer   = "JWT_FALLBACK_ISSUER"
+	EnvJwtFallbackAudience = "JWT_FALLBACK_AUDIENCE"
+
+	EnvJwtSubjectMatch = "JWT_SUBJECT_MATCH"
+
+	EnvEnableOAuth      = "ENABLE_OAUTH"
+	EnvEnableBearerAuth = "ENABLE_BEARER_URL_AUTH"
+
+	EnvRevocationAdminToken = "REVOCATION_ADMIN_TOKEN"
+
+	EnvEnableKeyUsageReport = "ENABLE_KEY_USAGE_REPORT"
+
+	EnvEnableTokenIntrospection = "ENABLE_TOKEN_INTROSPECTION"
+	EnvIntrospectionClientToken = "INTROSPECTION_CLIENT_TOKEN"
+
+	EnvJwtScope             = "JWT_SCOPE"
+	EnvVerifyRequiredScopes = "VERIFY_REQUIRED_SCOPES"
+	EnvAuthzGroupRules      = "AUTHZ_GROUP_RULES"
+
+	EnvValidationOnlyKeyRefresh = "VALIDATION_ONLY_KEY_REFRESH"
 
 	// Routing configuration
 	EnvRoutingMode                      = "ROUTING_MODE"
 	EnvWorkspaceNamespaceSubdomainRegex = "WORKSPACE_NAMESPACE_SUBDOMAIN_REGEX"
 	EnvWorkspaceNameSubdomainRegex      = "WORKSPACE_NAME_SUBDOMAIN_REGEX"
+	EnvBaseDomain                       = "BASE_DOMAIN"
 
 	// Cookie configuration
 	EnvCookieName     = "COOKIE_NAME"
@@ -53,6 +139,9 @@ const (
 	EnvCookieHttpOnly = "COOKIE_HTTP_ONLY"
 	EnvCookieSameSite = "COOKIE_SAME_SITE"
 
+	EnvSessionCookieMaxAge = "SESSION_COOKIE_MAX_AGE"
+	EnvTokenSource         = "TOKEN_SOURCE"
+
 	// Path configuration
 	EnvPathRegexPattern            = "PATH_REGEX_PATTERN"
 	EnvWorkspaceNamespacePathRegex = "WORKSPACE_NAMESPACE_PATH_REGEX"
@@ -64,11 +153,43 @@ const (
 	EnvOIDCIssuerURL       = "OIDC_ISSUER_URL"
 	EnvOIDCClientID        = "OIDC_CLIENT_ID"
 	EnvOIDCInitTimeoutSecs = "OIDC_INIT_TIMEOUT_SECONDS"
+
+	EnvOIDCPinnedJWKSURI        = "OIDC_PINNED_JWKS_URI"
+	EnvOIDCPinnedKeyThumbprints = "OIDC_PINNED_KEY_THUMBPRINTS"
+
+	// OIDC claim mapping
+	EnvOidcUsernameClaim = "OIDC_USERNAME_CLAIM"
+	EnvOidcGroupsClaim   = "OIDC_GROUPS_CLAIM"
+	EnvOidcUIDClaim      = "OIDC_UID_CLAIM"
 )
 
 // JWT signing types
 const (
 	JWTSigningTypeStandard = "standard"
+	// JWTSigningTypeAsymmetric signs with RSA or ECDSA keys and publishes the public keys at /jwks.json
+	JWTSigningTypeAsymmetric = "asymmetric"
+	// JWTSigningTypeJWKS only verifies RS256 or ES256 tokens from an external issuer
+	// against the key set at JWT_JWKS_URL, and cannot issue tokens
+	JWTSigningTypeJWKS = "jwks"
+)
+
+// Responses to a refresh of a token signed by a validation-only key
+const (
+	// ValidationOnlyKeyRefreshReauthenticate clears the cookie and returns 401 so the user signs in again
+	ValidationOnlyKeyRefreshReauthenticate = "reauthenticate"
+	// ValidationOnlyKeyRefreshExpire lets the token run until it expires without refreshing it
+	ValidationOnlyKeyRefreshExpire = "expire"
+)
+
+// Enforcement of the sub claim matching the User claim on validation
+const (
+	// JWTSubjectMatchOff does not compare the subject with the user
+	JWTSubjectMatchOff = "off"
+	// JWTSubjectMatchWhenPresent rejects tokens whose subject differs from the user,
+	// and accepts tokens without a subject
+	JWTSubjectMatchWhenPresent = "when-present"
+	// JWTSubjectMatchRequired also rejects tokens without a subject
+	JWTSubjectMatchRequired = "required"
 )
 
 // Default values
@@ -78,22 +199,63 @@ const (
 	DefaultReadTimeout     = 10 * time.Second
 	DefaultWriteTimeout    = 10 * time.Second
 	DefaultShutdownTimeout = 30 * time.Second
-	DefaultMetricsAddr     = ":9090"
-	DefaultProbeAddr       = ":9091"
+	DefaultMaxHeaderBytes  = http.DefaultMaxHeaderBytes
+
+	// DefaultVerifyAllowedMethods is a slice, defined in createDefaultConfig
+	// DefaultRateLimitRPS of zero disables per-client rate limiting
+	DefaultRateLimitRPS   = 0
+	DefaultRateLimitBurst = 20
+	DefaultMetricsAddr    = ":9090"
+	DefaultProbeAddr      = ":9091"
+	// DefaultAccessLog enables one structured log entry per auth check
+	DefaultAccessLog = true
 	// DefaultTrustedProxies is a slice, defined in createDefaultConfig
+	// DefaultForwardedHostHeader and DefaultForwardedURIHeader name the headers the
+	// proxy forwards the original host and URI in
+	DefaultForwardedHostHeader = HeaderForwardedHost
+	DefaultForwardedURIHeader  = HeaderForwardedURI
+	// DefaultForwardedProtoHeader names the header carrying the original request scheme
+	DefaultForwardedProtoHeader = HeaderForwardedProto
 
 	// Auth defaults
-	DefaultJwtSigningType    = JWTSigningTypeStandard
-	DefaultJwtIssuer         = "workspaces-auth"
-	DefaultJwtAudience       = "workspace-users"
-	DefaultJwtExpiration     = 1 * time.Hour
-	DefaultJwtRefreshEnable  = true
-	DefaultJwtRefreshWindow  = 15 * time.Minute // 25% of the default expiration
-	DefaultJwtRefreshHorizon = 12 * time.Hour
-	DefaultJwtSecretName     = "authmiddleware-secrets"
-	DefaultJwtNewKeyUseDelay = 5 * time.Second // Cooloff period before using a new key
-	DefaultEnableOAuth       = true
-	DefaultEnableBearerAuth  = false
+	DefaultJwtSigningType = JWTSigningTypeStandard
+	DefaultJwtAlgorithm   = jwt.DefaultHMACAlgorithm
+	// DefaultJwtKeyEncoding reads secret values as the raw key bytes
+	DefaultJwtKeyEncoding = jwt.KeyEncodingRaw
+	// DefaultJwtAsymmetricAlgorithm is used instead of DefaultJwtAlgorithm with asymmetric signing
+	DefaultJwtAsymmetricAlgorithm = jwt.AlgorithmRS256
+	DefaultJwtIssuer              = "workspaces-auth"
+	DefaultJwtAudience            = "workspace-users"
+	DefaultJwtExpiration          = 1 * time.Hour
+	DefaultJwtRefreshEnable       = true
+	DefaultJwtRefreshWindow       = 15 * time.Minute // 25% of the default expiration
+	DefaultJwtRefreshHorizon      = 12 * time.Hour
+	DefaultJwtSecretName          = "authmiddleware-secrets"
+	DefaultJwtNewKeyUseDelay      = 5 * time.Second // Cooloff period before using a new key
+	DefaultEnableOAuth            = true
+	DefaultEnableBearerAuth       = false
+
+	// DefaultJwtSecretWatchSyncTimeout bounds how long startup waits for the secret watch
+	DefaultJwtSecretWatchSyncTimeout = jwt.DefaultSecretWatchSyncTimeout
+
+	// DefaultJwtClockSkewLeeway is the clock skew between replicas tolerated on exp and nbf
+	DefaultJwtClockSkewLeeway = jwt.DefaultLeeway
+
+	// DefaultJwtJwksRefreshInterval is how often the key set is re-fetched with jwks signing
+	DefaultJwtJwksRefreshInterval = jwt.DefaultJWKSRefreshInterval
+
+	// DefaultInitialSecretLoadTimeout and DefaultInitialSecretLoadMaxAttempts bound
+	// the retries while loading the signing keys at startup
+	DefaultInitialSecretLoadTimeout     = DefaultInitialSecretLoadBudget
+	DefaultInitialSecretLoadMaxAttempts = DefaultInitialSecretMaxAttempts
+
+	// DefaultValidationOnlyKeyRefresh prompts re-authentication when a token cannot be re-signed
+	DefaultValidationOnlyKeyRefresh = ValidationOnlyKeyRefreshReauthenticate
+	// DefaultJwtSubjectMatch keeps accepting tokens regardless of their subject
+	DefaultJwtSubjectMatch = JWTSubjectMatchOff
+
+	DefaultJwtValidationCacheEnable = false
+	DefaultJwtValidationCacheSize   = 10000
 
 	// Cookie defaults
 	DefaultCookieName     = "workspace_auth"
@@ -102,6 +264,8 @@ const (
 	DefaultCookieMaxAge   = 24 * time.Hour
 	DefaultCookieHttpOnly = true
 	DefaultCookieSameSite = SameSiteLax
+	// DefaultTokenSource reads session tokens from the cookie only, as /verify always has
+	DefaultTokenSource = TokenSourceCookieOnly
 
 	// Path defaults
 	DefaultPathRegexPattern            = `^(/workspaces/[^/]+/[^/]+)(?:/.*)?$`
@@ -117,6 +281,9 @@ const (
 	DefaultOidcUsernamePrefix  = "github:"
 	DefaultOidcGroupsPrefix    = "github:"
 	DefaultOIDCInitTimeoutSecs = 30
+	DefaultOidcUsernameClaim   = OIDCClaimPreferredUsername
+	DefaultOidcGroupsClaim     = OIDCClaimGroups
+	DefaultOidcUIDClaim        = OIDCClaimSubject
 )
 
 // Config holds all configuration for the workspaces-auth service
@@ -126,13 +293,65 @@ type Config struct {
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
+	MaxHeaderBytes  int // Maximum size of request headers, including the request line
 	TrustedProxies  []string
-	MetricsAddr     string
-	ProbeAddr       string
-	Namespace       string // Namespace to watch for secrets
+
+	// ForwardedHostHeader and ForwardedURIHeader name the headers the proxy forwards
+	// the original host and URI in, which differ between ingress controllers
+	ForwardedHostHeader string
+	ForwardedURIHeader  string
+	// ForwardedProtoHeader names the header carrying the original request scheme
+	ForwardedProtoHeader string
+
+	// IdentityUserHeader, IdentityGroupsHeader and IdentityUIDHeader name the /verify
+	// response headers carrying the authenticated user, groups and UID, for the proxy
+	// to forward upstream. IdentityExtraHeaders maps extra claim keys to the headers
+	// their values are returned in; other extra claims are not returned.
+	IdentityUserHeader   string
+	IdentityGroupsHeader string
+	IdentityUIDHeader    string
+	IdentityExtraHeaders map[string]string
+
+	// VerifyAllowedMethods lists the HTTP methods accepted by /verify;
+	// GET and HEAD are used when empty
+	VerifyAllowedMethods []string
+
+	// DenyResponseFloor is the minimum latency of a denied /verify response; zero disables padding.
+	// DenyResponseJitter adds a random delay of up to this duration on top of the floor.
+	DenyResponseFloor  time.Duration
+	DenyResponseJitter time.Duration
+
+	// RateLimitRPS is the sustained requests per second each client IP may make to the
+	// auth endpoints, with bursts of up to RateLimitBurst; zero disables rate limiting
+	RateLimitRPS   float64
+	RateLimitBurst int
+
+	// AccessLog logs the outcome of every /auth, /bearer-auth and /verify request
+	AccessLog bool
+
+	// CORSAllowedOrigins lists the browser origins allowed to call /refresh and
+	// /introspect; empty disables CORS handling. CORSAllowedMethods and
+	// CORSAllowedHeaders are answered to preflight requests, and CORSAllowCredentials
+	// lets browsers send cookies cross-origin.
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool
+
+	MetricsAddr string
+	ProbeAddr   string
+	Namespace   string // Namespace to watch for secrets
+
+	// SigningStatusInterval is how often this replica publishes its loaded signing keys
+	// to a SigningKeySet named PodName; zero disables reporting. PodUID, when set,
+	// makes the pod own the SigningKeySet so it is deleted with the pod.
+	SigningStatusInterval time.Duration
+	PodName               string
+	PodUID                string
 
 	// Auth configuration
 	JWTSigningType    string
+	JWTAlgorithm      string
 	JWTIssuer         string
 	JWTAudience       string
 	JWTExpiration     time.Duration
@@ -144,14 +363,144 @@ type Config struct {
 	EnableOAuth       bool
 	EnableBearerAuth  bool
 
+	// JwtSecretWatchSyncTimeout bounds getting the secret informer during setup, and
+	// how long the secret watch may take to sync once the manager starts
+	JwtSecretWatchSyncTimeout time.Duration
+
+	// JWTClockSkewLeeway is tolerated on the exp and nbf claims when validating, and
+	// backdates nbf on issued tokens. Zero uses DefaultJwtClockSkewLeeway.
+	JWTClockSkewLeeway time.Duration
+
+	// JWTJWKSURL is the key set verified against with jwks signing, re-fetched every
+	// JWTJWKSRefreshInterval and whenever a token names an unknown kid
+	JWTJWKSURL             string
+	JWTJWKSRefreshInterval time.Duration
+	// JWTJWKSAllowInsecure accepts a plain http JWTJWKSURL; otherwise https is required,
+	// since whoever can tamper with the key set can mint tokens
+	JWTJWKSAllowInsecure bool
+
+	// InitialSecretLoadTimeout bounds the time spent retrying the signing secret read
+	// at startup, and InitialSecretLoadMaxAttempts the number of reads (0 for no limit)
+	InitialSecretLoadTimeout     time.Duration
+	InitialSecretLoadMaxAttempts int
+	// SigningKeyHealthGracePeriod is how long the pod may be unable to sign tokens
+	// before its liveness check fails
+	SigningKeyHealthGracePeriod time.Duration
+
+	// JWTKeyRotationInterval and JWTKeyRetentionCount mirror the rotator's schedule and
+	// numberOfKeys. When both are set, issuing a token that outlives the expected
+	// retention of its signing key logs a warning. Zero disables the check.
+	JWTKeyRotationInterval time.Duration
+	JWTKeyRetentionCount   int
+
+	// JWTValidationCacheEnable caches validated tokens so repeated requests skip
+	// signature verification; JWTValidationCacheSize bounds the number of entries
+	JWTValidationCacheEnable bool
+	JWTValidationCacheSize   int
+
+	// JWTMaxGroups and JWTMaxClaimBytes bound the groups and the encoded size of groups
+	// and extra claims in issued tokens; zero disables a limit. Tokens over a limit are
+	// refused, or truncated with a logged warning when JWTClaimLimitTruncate is set.
+	JWTMaxGroups          int
+	JWTMaxClaimBytes      int
+	JWTClaimLimitTruncate bool
+
+	// JWTAudiences, when set, replaces JWTAudience with several audiences: all are set on
+	// issued tokens and a token carrying any of them is accepted. JWT_AUDIENCE takes a
+	// comma-separated list; a single value only sets JWTAudience.
+	JWTAudiences []string
+
+	// JWTKeyPrefixes are the secret key name prefixes signing keys are read under;
+	// jwt.KeyPrefix when empty. Listing two lets keys migrate to a new prefix.
+	JWTKeyPrefixes []string
+
+	// JWTRejectWeakKeys fails loading a Secret holding a key that is too short for the
+	// algorithm, all zeros or a repeated short pattern. When false such keys are logged.
+	JWTRejectWeakKeys bool
+
+	// ForceSigningKid, when set, signs with this kid instead of the latest key while it
+	// is loaded and past its cooloff, for pinning a pod to a known-good key. Standard
+	// signing only; empty selects the latest key.
+	ForceSigningKid string
+
+	// JWTAdditionalSecretNames are read-only secrets, in the same namespace, whose keys are
+	// merged with those of JwtSecretName and watched alike, e.g. to hold archived keys.
+	// Standard signing only.
+	JWTAdditionalSecretNames []string
+
+	// JWTKeyEncoding is how HMAC key values are stored in the secrets: raw, base64 or
+	// hex. Values are decoded before use, and length checks apply to the decoded key.
+	JWTKeyEncoding string
+
+	// JWTMaxKeyRetention drops a loaded key this long after the pod first saw it, even
+	// without a secret update, so a pod whose secret watch stalls does not keep retired
+	// keys forever. The latest key is kept. Zero disables it. Standard signing only.
+	JWTMaxKeyRetention time.Duration
+
+	// JWTPreviousIssuer and JWTPreviousAudience are still accepted on validation after a
+	// rename, until JWTIssuerMigrationStart plus JWTIssuerMigrationWindow. Empty disables.
+	JWTPreviousIssuer        string
+	JWTPreviousAudience      string
+	JWTIssuerMigrationStart  time.Time
+	JWTIssuerMigrationWindow time.Duration
+
+	// JWTFallbackIssuer and JWTFallbackAudience are assumed for tokens that omit the
+	// claim, for trusted integrations only. A token carrying a wrong value is still
+	// rejected. Empty keeps the claim required.
+	JWTFallbackIssuer   string
+	JWTFallbackAudience string
+
+	// JWTSubjectMatch enforces that a token's sub claim equals its User claim:
+	// JWTSubjectMatchOff, JWTSubjectMatchWhenPresent or JWTSubjectMatchRequired
+	JWTSubjectMatch string
+
+	// RevocationAdminToken enables the /revoke endpoint; callers must present it
+	// as a bearer token. Empty disables token revocation.
+	RevocationAdminToken string
+
+	// EnableKeyUsageReport serves per-kid sign and validation counts at /debug/keys
+	EnableKeyUsageReport bool
+
+	// EnableTokenIntrospection serves RFC 7662 token introspection at /introspect
+	EnableTokenIntrospection bool
+
+	// IntrospectionClientToken is the bearer token callers of /introspect must present.
+	// Required when EnableTokenIntrospection is set.
+	IntrospectionClientToken string
+
+	// JWTScope is the space-delimited scope claim set on issued tokens. Empty issues
+	// tokens without a scope.
+	JWTScope string
+	// VerifyRequiredScopes are the scopes a token must carry to pass /verify.
+	// Empty requires none.
+	VerifyRequiredScopes []string
+	// AuthzGroupRules require group membership on /verify for matching hosts and paths,
+	// see ParseGroupRules. Empty allows every authenticated request.
+	AuthzGroupRules []GroupRule
+
+	// ValidationOnlyKeyRefresh is the /verify response when a token due for refresh was
+	// signed by a validation-only key: ValidationOnlyKeyRefreshReauthenticate or
+	// ValidationOnlyKeyRefreshExpire
+	ValidationOnlyKeyRefresh string
+
 	// Cookie configuration
-	CookieName     string
-	CookieSecure   bool
-	CookieDomain   string
-	CookiePath     string
-	CookieMaxAge   time.Duration
-	CookieHTTPOnly bool
-	CookieSameSite string
+	CookieName   string
+	CookieSecure bool
+	// CookieSecureAuto sets Secure per request from the forwarded proto (COOKIE_SECURE=auto)
+	CookieSecureAuto bool
+	CookieDomain     string
+	CookiePath       string
+	CookieMaxAge     time.Duration
+	CookieHTTPOnly   bool
+	CookieSameSite   string
+
+	// TokenSource decides whether /verify and /refresh read the session token from the
+	// Authorization header, the cookie, or both and in which order
+	TokenSource string
+
+	// SessionCookieMaxAge overrides CookieMaxAge for session cookies so they persist
+	// across browser restarts; must not exceed JWTExpiration. Zero means unset.
+	SessionCookieMaxAge time.Duration
 
 	// Path configuration
 	PathRegexPattern            string // Regex pattern to extract app path from full path
@@ -162,6 +511,7 @@ type Config struct {
 	RoutingMode                      string // Routing mode: RoutingModePath or RoutingModeSubdomain
 	WorkspaceNamespaceSubdomainRegex string // Regex pattern to extract workspace namespace from subdomain
 	WorkspaceNameSubdomainRegex      string // Regex pattern to extract workspace name from subdomain
+	BaseDomain                       string // Trusted parent domain of workspace subdomains; any domain when empty
 
 	// OIDC configuration
 	OidcUsernamePrefix  string
@@ -169,6 +519,21 @@ type Config struct {
 	OIDCIssuerURL       string
 	OIDCClientID        string
 	OIDCInitTimeoutSecs int
+
+	// OIDCPinnedJWKSURI is the jwks_uri the issuer discovery document must advertise.
+	// Empty disables the check.
+	OIDCPinnedJWKSURI string
+
+	// OIDCPinnedKeyThumbprints lists the RFC 7638 SHA-256 thumbprints (base64url) of the
+	// issuer keys trusted to sign ID tokens. Empty trusts every key the issuer publishes.
+	OIDCPinnedKeyThumbprints []string
+
+	// OidcUsernameClaim, OidcGroupsClaim and OidcUIDClaim name the ID token claims mapped
+	// to the user, groups and UID of issued tokens. Names not found at the top level are
+	// read as dot-separated paths into nested claims.
+	OidcUsernameClaim string
+	OidcGroupsClaim   string
+	OidcUIDClaim      string
 }
 
 // NewConfig creates a Config with values from environment variables
@@ -208,9 +573,23 @@ func createDefaultConfig() *Config {
 		ReadTimeout:     DefaultReadTimeout,
 		WriteTimeout:    DefaultWriteTimeout,
 		ShutdownTimeout: DefaultShutdownTimeout,
-		TrustedProxies:  []string{"127.0.0.1", "::1"}, // Default trusted proxies
-		MetricsAddr:     DefaultMetricsAddr,
-		ProbeAddr:       DefaultProbeAddr,
+		MaxHeaderBytes:  DefaultMaxHeaderBytes,
+
+		VerifyAllowedMethods: []string{http.MethodGet, http.MethodHead},
+		RateLimitRPS:         DefaultRateLimitRPS,
+		RateLimitBurst:       DefaultRateLimitBurst,
+		TrustedProxies:       []string{"127.0.0.1", "::1"}, // Default trusted proxies
+		ForwardedHostHeader:  DefaultForwardedHostHeader,
+		ForwardedURIHeader:   DefaultForwardedURIHeader,
+		ForwardedProtoHeader: DefaultForwardedProtoHeader,
+		IdentityUserHeader:   HeaderAuthUser,
+		IdentityGroupsHeader: HeaderAuthGroups,
+		IdentityUIDHeader:    HeaderAuthUID,
+		CORSAllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		CORSAllowedHeaders:   []string{HeaderAuthorization, "Content-Type"},
+		MetricsAddr:          DefaultMetricsAddr,
+		ProbeAddr:            DefaultProbeAddr,
+		AccessLog:            DefaultAccessLog,
 
 		// Auth defaults
 		JWTSigningType:    DefaultJwtSigningType,
@@ -222,9 +601,25 @@ func createDefaultConfig() *Config {
 		JWTRefreshHorizon: DefaultJwtRefreshHorizon,
 		JwtSecretName:     DefaultJwtSecretName,
 		JwtNewKeyUseDelay: DefaultJwtNewKeyUseDelay,
+		JWTKeyEncoding:    DefaultJwtKeyEncoding,
 		EnableOAuth:       DefaultEnableOAuth,
 		EnableBearerAuth:  DefaultEnableBearerAuth,
 
+		JwtSecretWatchSyncTimeout: DefaultJwtSecretWatchSyncTimeout,
+
+		JWTClockSkewLeeway: DefaultJwtClockSkewLeeway,
+
+		JWTJWKSRefreshInterval: DefaultJwtJwksRefreshInterval,
+
+		InitialSecretLoadTimeout:     DefaultInitialSecretLoadTimeout,
+		InitialSecretLoadMaxAttempts: DefaultInitialSecretLoadMaxAttempts,
+		SigningKeyHealthGracePeriod:  DefaultSigningKeyHealthGracePeriod,
+
+		ValidationOnlyKeyRefresh: DefaultValidationOnlyKeyRefresh,
+		JWTSubjectMatch:          DefaultJwtSubjectMatch,
+		JWTValidationCacheEnable: DefaultJwtValidationCacheEnable,
+		JWTValidationCacheSize:   DefaultJwtValidationCacheSize,
+
 		// Cookie defaults
 		CookieName:     DefaultCookieName,
 		CookieSecure:   DefaultCookieSecure,
@@ -232,6 +627,7 @@ func createDefaultConfig() *Config {
 		CookieMaxAge:   DefaultCookieMaxAge,
 		CookieHTTPOnly: DefaultCookieHttpOnly,
 		CookieSameSite: DefaultCookieSameSite,
+		TokenSource:    DefaultTokenSource,
 
 		// Path defaults
 		// This regex extracts application path: /workspaces/<namespace>/<app-name>
@@ -250,6 +646,9 @@ func createDefaultConfig() *Config {
 		OidcUsernamePrefix:  DefaultOidcUsernamePrefix,
 		OidcGroupsPrefix:    DefaultOidcGroupsPrefix,
 		OIDCInitTimeoutSecs: DefaultOIDCInitTimeoutSecs,
+		OidcUsernameClaim:   DefaultOidcUsernameClaim,
+		OidcGroupsClaim:     DefaultOidcGroupsClaim,
+		OidcUIDClaim:        DefaultOidcUIDClaim,
 	}
 }
 
@@ -287,8 +686,111 @@ func applyServerConfig(config *Config) error {
 		config.ShutdownTimeout = d
 	}
 
+	if maxHeaderBytes := os.Getenv(EnvMaxHeaderBytes); maxHeaderBytes != "" {
+		n, err := strconv.Atoi(maxHeaderBytes)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvMaxHeaderBytes, err)
+		}
+		if n <= 0 {
+			return fmt.Errorf("%s must be a positive integer, got %d", EnvMaxHeaderBytes, n)
+		}
+		config.MaxHeaderBytes = n
+	}
+
+	if verifyMethods := os.Getenv(EnvVerifyAllowedMethods); verifyMethods != "" {
+		methods, err := parseHTTPMethods(verifyMethods)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvVerifyAllowedMethods, err)
+		}
+		config.VerifyAllowedMethods = methods
+	}
+
+	if denyFloor := os.Getenv(EnvDenyResponseFloor); denyFloor != "" {
+		d, err := time.ParseDuration(denyFloor)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvDenyResponseFloor, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("%s must not be negative, got %v", EnvDenyResponseFloor, d)
+		}
+		config.DenyResponseFloor = d
+	}
+
+	if denyJitter := os.Getenv(EnvDenyResponseJitter); denyJitter != "" {
+		d, err := time.ParseDuration(denyJitter)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvDenyResponseJitter, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("%s must not be negative, got %v", EnvDenyResponseJitter, d)
+		}
+		config.DenyResponseJitter = d
+	}
+
+	if rateLimitRPS := os.Getenv(EnvRateLimitRPS); rateLimitRPS != "" {
+		rps, err := strconv.ParseFloat(rateLimitRPS, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvRateLimitRPS, err)
+		}
+		if rps < 0 || math.IsNaN(rps) || math.IsInf(rps, 0) {
+			return fmt.Errorf("%s must be a non-negative number, got %v", EnvRateLimitRPS, rateLimitRPS)
+		}
+		config.RateLimitRPS = rps
+	}
+
+	if rateLimitBurst := os.Getenv(EnvRateLimitBurst); rateLimitBurst != "" {
+		burst, err := strconv.Atoi(rateLimitBurst)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvRateLimitBurst, err)
+		}
+		if burst < 1 {
+			return fmt.Errorf("%s must be at least 1, got %d", EnvRateLimitBurst, burst)
+		}
+		config.RateLimitBurst = burst
+	}
+
+	if accessLog := os.Getenv(EnvAccessLog); accessLog != "" {
+		enable, err := strconv.ParseBool(accessLog)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvAccessLog, err)
+		}
+		config.AccessLog = enable
+	}
+
 	if trustedProxies := os.Getenv(EnvTrustedProxies); trustedProxies != "" {
 		config.TrustedProxies = splitAndTrim(trustedProxies, ",")
+		if _, err := parseTrustedProxies(config.TrustedProxies); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvTrustedProxies, err)
+		}
+	}
+
+	if hostHeader := os.Getenv(EnvForwardedHostHeader); hostHeader != "" {
+		if !validHeaderName(hostHeader) {
+			return fmt.Errorf("invalid %s: %q is not a valid header name", EnvForwardedHostHeader, hostHeader)
+		}
+		config.ForwardedHostHeader = hostHeader
+	}
+
+	if uriHeader := os.Getenv(EnvForwardedURIHeader); uriHeader != "" {
+		if !validHeaderName(uriHeader) {
+			return fmt.Errorf("invalid %s: %q is not a valid header name", EnvForwardedURIHeader, uriHeader)
+		}
+		config.ForwardedURIHeader = uriHeader
+	}
+
+	if protoHeader := os.Getenv(EnvForwardedProtoHeader); protoHeader != "" {
+		if !validHeaderName(protoHeader) {
+			return fmt.Errorf("invalid %s: %q is not a valid header name", EnvForwardedProtoHeader, protoHeader)
+		}
+		config.ForwardedProtoHeader = protoHeader
+	}
+
+	if err := applyIdentityHeaderConfig(config); err != nil {
+		return err
+	}
+
+	if err := applyCORSConfig(config); err != nil {
+		return err
 	}
 
 	if metricsAddr := os.Getenv(EnvMetricsAddr); metricsAddr != "" {
@@ -303,6 +805,23 @@ func applyServerConfig(config *Config) error {
 		config.Namespace = namespace
 	}
 
+	config.PodName = os.Getenv(EnvPodName)
+	config.PodUID = os.Getenv(EnvPodUID)
+
+	if statusInterval := os.Getenv(EnvSigningStatusInterval); statusInterval != "" {
+		d, err := time.ParseDuration(statusInterval)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvSigningStatusInterval, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("%s must not be negative, got %v", EnvSigningStatusInterval, d)
+		}
+		if d > 0 && config.PodName == "" {
+			return fmt.Errorf("%s requires %s", EnvSigningStatusInterval, EnvPodName)
+		}
+		config.SigningStatusInterval = d
+	}
+
 	return nil
 }
 
@@ -313,12 +832,26 @@ func applyJWTConfig(config *Config) error {
 		config.JWTSigningType = signingType
 	}
 
+	if err := applyJWTAlgorithm(config); err != nil {
+		return err
+	}
+
 	if issuer := os.Getenv(EnvJwtIssuer); issuer != "" {
 		config.JWTIssuer = issuer
 	}
 
 	if audience := os.Getenv(EnvJwtAudience); audience != "" {
-		config.JWTAudience = audience
+		var audiences []string
+		for _, aud := range strings.Split(audience, ",") {
+			if aud = strings.TrimSpace(aud); aud != "" {
+				audiences = append(audiences, aud)
+			}
+		}
+		if len(audiences) == 0 {
+			return fmt.Errorf("invalid %s: no audience in %q", EnvJwtAudience, audience)
+		}
+		config.JWTAudience = audiences[0]
+		config.JWTAudiences = audiences
 	}
 
 	if expiration := os.Getenv(EnvJwtExpiration); expiration != "" {
@@ -353,6 +886,21 @@ func applyJWTConfig(config *Config) error {
 		config.JWTRefreshHorizon = d
 	}
 
+	if leeway := os.Getenv(EnvJwtClockSkewLeeway); leeway != "" {
+		d, err := time.ParseDuration(leeway)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtClockSkewLeeway, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid %s: must be positive, got %s", EnvJwtClockSkewLeeway, d)
+		}
+		config.JWTClockSkewLeeway = d
+	}
+
+	if err := applyJWKSConfig(config); err != nil {
+		return err
+	}
+
 	if newKeyUseDelay := os.Getenv(EnvJwtNewKeyUseDelay); newKeyUseDelay != "" {
 		d, err := time.ParseDuration(newKeyUseDelay)
 		if err != nil {
@@ -361,6 +909,77 @@ func applyJWTConfig(config *Config) error {
 		config.JwtNewKeyUseDelay = d
 	}
 
+	if rotationInterval := os.Getenv(EnvJwtKeyRotationInterval); rotationInterval != "" {
+		d, err := time.ParseDuration(rotationInterval)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtKeyRotationInterval, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("invalid %s: must not be negative, got %v", EnvJwtKeyRotationInterval, d)
+		}
+		config.JWTKeyRotationInterval = d
+	}
+
+	if retentionCount := os.Getenv(EnvJwtKeyRetentionCount); retentionCount != "" {
+		n, err := strconv.Atoi(retentionCount)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtKeyRetentionCount, err)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid %s: must not be negative, got %d", EnvJwtKeyRetentionCount, n)
+		}
+		config.JWTKeyRetentionCount = n
+	}
+
+	if cacheEnable := os.Getenv(EnvJwtValidationCacheEnable); cacheEnable != "" {
+		enable, err := strconv.ParseBool(cacheEnable)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtValidationCacheEnable, err)
+		}
+		config.JWTValidationCacheEnable = enable
+	}
+
+	if cacheSize := os.Getenv(EnvJwtValidationCacheSize); cacheSize != "" {
+		n, err := strconv.Atoi(cacheSize)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtValidationCacheSize, err)
+		}
+		if n < 1 {
+			return fmt.Errorf("invalid %s: must be at least 1, got %d", EnvJwtValidationCacheSize, n)
+		}
+		config.JWTValidationCacheSize = n
+	}
+
+	if maxGroups := os.Getenv(EnvJwtMaxGroups); maxGroups != "" {
+		n, err := strconv.Atoi(maxGroups)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtMaxGroups, err)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid %s: must not be negative, got %d", EnvJwtMaxGroups, n)
+		}
+		config.JWTMaxGroups = n
+	}
+
+	if maxClaimBytes := os.Getenv(EnvJwtMaxClaimBytes); maxClaimBytes != "" {
+		n, err := strconv.Atoi(maxClaimBytes)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtMaxClaimBytes, err)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid %s: must not be negative, got %d", EnvJwtMaxClaimBytes, n)
+		}
+		config.JWTMaxClaimBytes = n
+	}
+
+	if truncate := os.Getenv(EnvJwtClaimLimitTruncate); truncate != "" {
+		enable, err := strconv.ParseBool(truncate)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtClaimLimitTruncate, err)
+		}
+		config.JWTClaimLimitTruncate = enable
+	}
+
 	if enableOAuth := os.Getenv(EnvEnableOAuth); enableOAuth != "" {
 		enable, err := strconv.ParseBool(enableOAuth)
 		if err != nil {
@@ -377,6 +996,76 @@ func applyJWTConfig(config *Config) error {
 		config.EnableBearerAuth = enable
 	}
 
+	if err := applyIssuerMigrationConfig(config); err != nil {
+		return err
+	}
+
+	config.JWTFallbackIssuer = os.Getenv(EnvJwtFallbackIssuer)
+	config.JWTFallbackAudience = os.Getenv(EnvJwtFallbackAudience)
+
+	if subjectMatch := os.Getenv(EnvJwtSubjectMatch); subjectMatch != "" {
+		switch subjectMatch {
+		case JWTSubjectMatchOff, JWTSubjectMatchWhenPresent, JWTSubjectMatchRequired:
+			config.JWTSubjectMatch = subjectMatch
+		default:
+			return fmt.Errorf("invalid %s: must be %q, %q or %q, got %q", EnvJwtSubjectMatch,
+				JWTSubjectMatchOff, JWTSubjectMatchWhenPresent, JWTSubjectMatchRequired, subjectMatch)
+		}
+	}
+
+	if revocationAdminToken := os.Getenv(EnvRevocationAdminToken); revocationAdminToken != "" {
+		config.RevocationAdminToken = revocationAdminToken
+	}
+
+	if enableKeyUsageReport := os.Getenv(EnvEnableKeyUsageReport); enableKeyUsageReport != "" {
+		enable, err := strconv.ParseBool(enableKeyUsageReport)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvEnableKeyUsageReport, err)
+		}
+		config.EnableKeyUsageReport = enable
+	}
+
+	if enableIntrospection := os.Getenv(EnvEnableTokenIntrospection); enableIntrospection != "" {
+		enable, err := strconv.ParseBool(enableIntrospection)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvEnableTokenIntrospection, err)
+		}
+		config.EnableTokenIntrospection = enable
+	}
+
+	if introspectionClientToken := os.Getenv(EnvIntrospectionClientToken); introspectionClientToken != "" {
+		config.IntrospectionClientToken = introspectionClientToken
+	}
+	if config.EnableTokenIntrospection && config.IntrospectionClientToken == "" {
+		return fmt.Errorf("%s requires %s", EnvEnableTokenIntrospection, EnvIntrospectionClientToken)
+	}
+
+	if scope := os.Getenv(EnvJwtScope); scope != "" {
+		config.JWTScope = strings.Join(strings.Fields(scope), " ")
+	}
+
+	if requiredScopes := os.Getenv(EnvVerifyRequiredScopes); requiredScopes != "" {
+		config.VerifyRequiredScopes = strings.Fields(requiredScopes)
+	}
+
+	if groupRules := os.Getenv(EnvAuthzGroupRules); groupRules != "" {
+		rules, err := ParseGroupRules(groupRules)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvAuthzGroupRules, err)
+		}
+		config.AuthzGroupRules = rules
+	}
+
+	if validationOnlyKeyRefresh := os.Getenv(EnvValidationOnlyKeyRefresh); validationOnlyKeyRefresh != "" {
+		switch validationOnlyKeyRefresh {
+		case ValidationOnlyKeyRefreshReauthenticate, ValidationOnlyKeyRefreshExpire:
+			config.ValidationOnlyKeyRefresh = validationOnlyKeyRefresh
+		default:
+			return fmt.Errorf("invalid %s: must be %q or %q, got %q", EnvValidationOnlyKeyRefresh,
+				ValidationOnlyKeyRefreshReauthenticate, ValidationOnlyKeyRefreshExpire, validationOnlyKeyRefresh)
+		}
+	}
+
 	// Routing configuration
 	if routingMode := os.Getenv(EnvRoutingMode); routingMode != "" {
 		config.RoutingMode = routingMode
@@ -390,10 +1079,121 @@ func applyJWTConfig(config *Config) error {
 		config.WorkspaceNameSubdomainRegex = nameSubdomainRegex
 	}
 
+	if baseDomain := os.Getenv(EnvBaseDomain); baseDomain != "" {
+		config.BaseDomain = strings.ToLower(strings.Trim(baseDomain, "."))
+	}
+
 	if jwtSecretName := os.Getenv(EnvJwtSecretName); jwtSecretName != "" {
 		config.JwtSecretName = jwtSecretName
 	}
 
+	if syncTimeout := os.Getenv(EnvJwtSecretWatchSyncTimeout); syncTimeout != "" {
+		d, err := time.ParseDuration(syncTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtSecretWatchSyncTimeout, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid %s: must be positive, got %s", EnvJwtSecretWatchSyncTimeout, d)
+		}
+		config.JwtSecretWatchSyncTimeout = d
+	}
+
+	if loadTimeout := os.Getenv(EnvInitialSecretLoadTimeout); loadTimeout != "" {
+		d, err := time.ParseDuration(loadTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvInitialSecretLoadTimeout, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid %s: must be positive, got %s", EnvInitialSecretLoadTimeout, d)
+		}
+		config.InitialSecretLoadTimeout = d
+	}
+
+	if maxAttempts := os.Getenv(EnvInitialSecretLoadMaxAttempts); maxAttempts != "" {
+		n, err := strconv.Atoi(maxAttempts)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvInitialSecretLoadMaxAttempts, err)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid %s: must not be negative, got %d", EnvInitialSecretLoadMaxAttempts, n)
+		}
+		config.InitialSecretLoadMaxAttempts = n
+	}
+
+	if gracePeriod := os.Getenv(EnvSigningKeyHealthGracePeriod); gracePeriod != "" {
+		d, err := time.ParseDuration(gracePeriod)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvSigningKeyHealthGracePeriod, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid %s: must be positive, got %s", EnvSigningKeyHealthGracePeriod, d)
+		}
+		config.SigningKeyHealthGracePeriod = d
+	}
+
+	if keyPrefixes := os.Getenv(EnvJwtKeyPrefixes); keyPrefixes != "" {
+		config.JWTKeyPrefixes = nil
+		for _, prefix := range strings.Split(keyPrefixes, ",") {
+			if prefix = strings.TrimSpace(prefix); prefix != "" {
+				config.JWTKeyPrefixes = append(config.JWTKeyPrefixes, prefix)
+			}
+		}
+	}
+
+	if rejectWeakKeys := os.Getenv(EnvJwtRejectWeakKeys); rejectWeakKeys != "" {
+		reject, err := strconv.ParseBool(rejectWeakKeys)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtRejectWeakKeys, err)
+		}
+		config.JWTRejectWeakKeys = reject
+	}
+
+	config.ForceSigningKid = strings.TrimSpace(os.Getenv(EnvForceSigningKid))
+
+	if secretNames := os.Getenv(EnvJwtAdditionalSecretNames); secretNames != "" {
+		config.JWTAdditionalSecretNames = nil
+		for _, name := range strings.Split(secretNames, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || slices.Contains(config.JWTAdditionalSecretNames, name) {
+				continue
+			}
+			if name == config.JwtSecretName {
+				return fmt.Errorf("invalid %s: %s is already the primary secret", EnvJwtAdditionalSecretNames, name)
+			}
+			config.JWTAdditionalSecretNames = append(config.JWTAdditionalSecretNames, name)
+		}
+	}
+
+	if keyEncoding := os.Getenv(EnvJwtKeyEncoding); keyEncoding != "" {
+		keyEncoding = strings.ToLower(strings.TrimSpace(keyEncoding))
+		if err := jwt.ValidateKeyEncoding(keyEncoding); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtKeyEncoding, err)
+		}
+		config.JWTKeyEncoding = keyEncoding
+	}
+
+	if maxKeyRetention := os.Getenv(EnvJwtMaxKeyRetention); maxKeyRetention != "" {
+		d, err := time.ParseDuration(maxKeyRetention)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtMaxKeyRetention, err)
+		}
+		if d < config.JWTExpiration {
+			return fmt.Errorf("invalid %s: must be at least %s (%s), got %s",
+				EnvJwtMaxKeyRetention, EnvJwtExpiration, config.JWTExpiration, d)
+		}
+		config.JWTMaxKeyRetention = d
+	}
+
+	if err := checkStandardSigningOnly(config); err != nil {
+		return err
+	}
+
+	// External tokens carry no workspace domain, so only group rules keep an issuer's
+	// users from reaching every workspace
+	if config.JWTSigningType == JWTSigningTypeJWKS && len(config.AuthzGroupRules) == 0 {
+		return fmt.Errorf("%s is required with %s=%s", EnvAuthzGroupRules, EnvJwtSigningType, JWTSigningTypeJWKS)
+	}
+
 	// Validate that JWTExpiration >= JWTRefreshWindow
 	if config.JWTRefreshWindow > config.JWTExpiration {
 		return fmt.Errorf("JWT refresh window (%s) must be less than or equal to JWT expiration (%s)",
@@ -408,13 +1208,233 @@ func applyJWTConfig(config *Config) error {
 	return nil
 }
 
+// applyIssuerMigrationConfig reads the previous issuer and audience accepted during a rename.
+// A start timestamp is required so the previous values are dropped once the window elapses;
+// the window defaults to JWTExpiration, after which every token issued before the start has expired.
+func applyIssuerMigrationConfig(config *Config) error {
+	config.JWTPreviousIssuer = os.Getenv(EnvJwtPreviousIssuer)
+	config.JWTPreviousAudience = os.Getenv(EnvJwtPreviousAudience)
+
+	if start := os.Getenv(EnvJwtIssuerMigrationStart); start != "" {
+		t, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtIssuerMigrationStart, err)
+		}
+		config.JWTIssuerMigrationStart = t
+	}
+
+	if window := os.Getenv(EnvJwtIssuerMigrationWindow); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtIssuerMigrationWindow, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid %s: must be positive, got %s", EnvJwtIssuerMigrationWindow, d)
+		}
+		config.JWTIssuerMigrationWindow = d
+	}
+
+	migrating := config.JWTPreviousIssuer != "" || config.JWTPreviousAudience != ""
+	if !migrating {
+		if !config.JWTIssuerMigrationStart.IsZero() {
+			return fmt.Errorf("%s requires %s or %s",
+				EnvJwtIssuerMigrationStart, EnvJwtPreviousIssuer, EnvJwtPreviousAudience)
+		}
+		return nil
+	}
+	if config.JWTIssuerMigrationStart.IsZero() {
+		return fmt.Errorf("%s is required when %s or %s is set",
+			EnvJwtIssuerMigrationStart, EnvJwtPreviousIssuer, EnvJwtPreviousAudience)
+	}
+	if config.JWTIssuerMigrationWindow == 0 {
+		config.JWTIssuerMigrationWindow = config.JWTExpiration
+	}
+	return nil
+}
+
+// standardSigningOnlyEnvs configure the HMAC keys of standard signing, and would be
+// silently ignored with another signing type
+var standardSigningOnlyEnvs = []string{
+	EnvForceSigningKid,
+	EnvJwtAdditionalSecretNames,
+	EnvJwtKeyEncoding,
+	EnvJwtRejectWeakKeys,
+	EnvJwtMaxKeyRetention,
+}
+
+// checkStandardSigningOnly rejects settings that only apply to standard signing when
+// another signing type is configured
+func checkStandardSigningOnly(config *Config) error {
+	if config.JWTSigningType == JWTSigningTypeStandard || config.JWTSigningType == "" {
+		return nil
+	}
+	for _, env := range standardSigningOnlyEnvs {
+		if os.Getenv(env) != "" {
+			return fmt.Errorf("%s is only supported with %s=%s, got %s",
+				env, EnvJwtSigningType, JWTSigningTypeStandard, config.JWTSigningType)
+		}
+	}
+	return nil
+}
+
+// applyJWKSConfig reads the key set URL and refresh interval; the URL is required
+// with jwks signing, and must be https unless plain http is explicitly allowed
+func applyJWKSConfig(config *Config) error {
+	if allowInsecure := os.Getenv(EnvJwtJwksAllowInsecure); allowInsecure != "" {
+		allow, err := strconv.ParseBool(allowInsecure)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtJwksAllowInsecure, err)
+		}
+		config.JWTJWKSAllowInsecure = allow
+	}
+
+	if jwksURL := os.Getenv(EnvJwtJwksURL); jwksURL != "" {
+		parsed, err := url.Parse(jwksURL)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtJwksURL, err)
+		}
+		if parsed.Host == "" || (parsed.Scheme != "https" && (parsed.Scheme != "http" || !config.JWTJWKSAllowInsecure)) {
+			return fmt.Errorf("invalid %s: must be an absolute https URL (http only with %s=true), got %q",
+				EnvJwtJwksURL, EnvJwtJwksAllowInsecure, jwksURL)
+		}
+		config.JWTJWKSURL = jwksURL
+	}
+
+	if refreshInterval := os.Getenv(EnvJwtJwksRefreshInterval); refreshInterval != "" {
+		d, err := time.ParseDuration(refreshInterval)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvJwtJwksRefreshInterval, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid %s: must be positive, got %s", EnvJwtJwksRefreshInterval, d)
+		}
+		config.JWTJWKSRefreshInterval = d
+	}
+
+	if config.JWTSigningType == JWTSigningTypeJWKS && config.JWTJWKSURL == "" {
+		return fmt.Errorf("%s is required with %s=%s", EnvJwtJwksURL, EnvJwtSigningType, JWTSigningTypeJWKS)
+	}
+	return nil
+}
+
+// applyJWTAlgorithm sets the signing algorithm, defaulting by signing type, and checks
+// that it matches the signing type: HMAC algorithms for standard signing,
+// RS256 or ES256 for asymmetric signing
+func applyJWTAlgorithm(config *Config) error {
+	algorithm := os.Getenv(EnvJwtAlgorithm)
+
+	switch config.JWTSigningType {
+	case JWTSigningTypeAsymmetric:
+		if algorithm == "" {
+			algorithm = DefaultJwtAsymmetricAlgorithm
+		}
+		if algorithm != jwt.AlgorithmRS256 && algorithm != jwt.AlgorithmES256 {
+			return fmt.Errorf("invalid %s for %s signing: %q, must be %s or %s",
+				EnvJwtAlgorithm, JWTSigningTypeAsymmetric, algorithm, jwt.AlgorithmRS256, jwt.AlgorithmES256)
+		}
+	case JWTSigningTypeStandard, "":
+		if algorithm == "" {
+			algorithm = DefaultJwtAlgorithm
+		}
+		if err := jwt.ValidateHMACAlgorithm(algorithm); err != nil {
+			return fmt.Errorf("invalid %s for %s signing: %w", EnvJwtAlgorithm, JWTSigningTypeStandard, err)
+		}
+	}
+
+	config.JWTAlgorithm = algorithm
+	return nil
+}
+
+// applyIdentityHeaderConfig applies identity response header environment variable overrides
+func applyIdentityHeaderConfig(config *Config) error {
+	for env, field := range map[string]*string{
+		EnvIdentityUserHeader:   &config.IdentityUserHeader,
+		EnvIdentityGroupsHeader: &config.IdentityGroupsHeader,
+		EnvIdentityUIDHeader:    &config.IdentityUIDHeader,
+	} {
+		if header := os.Getenv(env); header != "" {
+			if !validHeaderName(header) {
+				return fmt.Errorf("invalid %s: %q is not a valid header name", env, header)
+			}
+			*field = header
+		}
+	}
+
+	// Extra claims are selected as comma-separated key=Header-Name pairs
+	if extraHeaders := os.Getenv(EnvIdentityExtraHeaders); extraHeaders != "" {
+		config.IdentityExtraHeaders = make(map[string]string)
+		for _, pair := range splitAndTrim(extraHeaders, ",") {
+			key, header, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			key, header = strings.TrimSpace(key), strings.TrimSpace(header)
+			if !ok || key == "" || !validHeaderName(header) {
+				return fmt.Errorf("invalid %s: %q must be key=Header-Name", EnvIdentityExtraHeaders, pair)
+			}
+			config.IdentityExtraHeaders[key] = header
+		}
+	}
+
+	return nil
+}
+
+// applyCORSConfig applies CORS environment variable overrides
+func applyCORSConfig(config *Config) error {
+	if origins := os.Getenv(EnvCORSAllowedOrigins); origins != "" {
+		config.CORSAllowedOrigins = splitAndTrim(origins, ",")
+		for i, origin := range config.CORSAllowedOrigins {
+			origin = strings.TrimSpace(origin)
+			config.CORSAllowedOrigins[i] = origin
+			if err := validateCORSOrigin(origin); err != nil {
+				return fmt.Errorf("invalid %s: %w", EnvCORSAllowedOrigins, err)
+			}
+		}
+	}
+
+	if methods := os.Getenv(EnvCORSAllowedMethods); methods != "" {
+		parsed, err := parseHTTPMethods(methods)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvCORSAllowedMethods, err)
+		}
+		config.CORSAllowedMethods = parsed
+	}
+
+	if headers := os.Getenv(EnvCORSAllowedHeaders); headers != "" {
+		config.CORSAllowedHeaders = splitAndTrim(headers, ",")
+		for i, header := range config.CORSAllowedHeaders {
+			header = strings.TrimSpace(header)
+			config.CORSAllowedHeaders[i] = header
+			if !validHeaderName(header) {
+				return fmt.Errorf("invalid %s: %q is not a valid header name", EnvCORSAllowedHeaders, header)
+			}
+		}
+	}
+
+	if allowCredentials := os.Getenv(EnvCORSAllowCredentials); allowCredentials != "" {
+		allow, err := strconv.ParseBool(allowCredentials)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvCORSAllowCredentials, err)
+		}
+		config.CORSAllowCredentials = allow
+	}
+
+	// Browsers refuse credentialed responses to a wildcard origin
+	if config.CORSAllowCredentials && slices.Contains(config.CORSAllowedOrigins, corsAnyOrigin) {
+		return fmt.Errorf("%s cannot contain %q when %s is true",
+			EnvCORSAllowedOrigins, corsAnyOrigin, EnvCORSAllowCredentials)
+	}
+
+	return nil
+}
+
 // applyCookieConfig applies cookie-related environment variable overrides
 func applyCookieConfig(config *Config) error {
 	if cookieName := os.Getenv(EnvCookieName); cookieName != "" {
 		config.CookieName = cookieName
 	}
 
-	if cookieSecure := os.Getenv(EnvCookieSecure); cookieSecure != "" {
+	if cookieSecure := os.Getenv(EnvCookieSecure); strings.EqualFold(cookieSecure, CookieSecureAuto) {
+		config.CookieSecure = true
+		config.CookieSecureAuto = true
+	} else if cookieSecure != "" {
 		secure, err := strconv.ParseBool(cookieSecure)
 		if err != nil {
 			return fmt.Errorf("invalid %s: %w", EnvCookieSecure, err)
@@ -438,6 +1458,14 @@ func applyCookieConfig(config *Config) error {
 		config.CookieMaxAge = d
 	}
 
+	if sessionCookieMaxAge := os.Getenv(EnvSessionCookieMaxAge); sessionCookieMaxAge != "" {
+		d, err := time.ParseDuration(sessionCookieMaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvSessionCookieMaxAge, err)
+		}
+		config.SessionCookieMaxAge = d
+	}
+
 	if cookieHTTPOnly := os.Getenv(EnvCookieHttpOnly); cookieHTTPOnly != "" {
 		httpOnly, err := strconv.ParseBool(cookieHTTPOnly)
 		if err != nil {
@@ -450,6 +1478,16 @@ func applyCookieConfig(config *Config) error {
 		config.CookieSameSite = cookieSameSite
 	}
 
+	if tokenSource := os.Getenv(EnvTokenSource); tokenSource != "" {
+		switch tokenSource {
+		case TokenSourceHeaderFirst, TokenSourceCookieFirst, TokenSourceHeaderOnly, TokenSourceCookieOnly:
+			config.TokenSource = tokenSource
+		default:
+			return fmt.Errorf("invalid %s: %q, must be one of %s, %s, %s or %s", EnvTokenSource, tokenSource,
+				TokenSourceHeaderFirst, TokenSourceCookieFirst, TokenSourceHeaderOnly, TokenSourceCookieOnly)
+		}
+	}
+
 	return nil
 }
 
@@ -471,6 +1509,36 @@ func applyOidcConfig(config *Config) error {
 		config.OIDCClientID = oidcClientID
 	}
 
+	if jwksURI := strings.TrimSpace(os.Getenv(EnvOIDCPinnedJWKSURI)); jwksURI != "" {
+		config.OIDCPinnedJWKSURI = jwksURI
+	}
+
+	if thumbprints := os.Getenv(EnvOIDCPinnedKeyThumbprints); thumbprints != "" {
+		config.OIDCPinnedKeyThumbprints = nil
+		for _, thumbprint := range strings.Split(thumbprints, ",") {
+			thumbprint = strings.TrimSpace(thumbprint)
+			if thumbprint == "" || slices.Contains(config.OIDCPinnedKeyThumbprints, thumbprint) {
+				continue
+			}
+			if sum, err := base64.RawURLEncoding.DecodeString(thumbprint); err != nil || len(sum) != sha256.Size {
+				return fmt.Errorf("invalid %s: %q is not a base64url SHA-256 thumbprint", EnvOIDCPinnedKeyThumbprints, thumbprint)
+			}
+			config.OIDCPinnedKeyThumbprints = append(config.OIDCPinnedKeyThumbprints, thumbprint)
+		}
+	}
+
+	if usernameClaim := strings.TrimSpace(os.Getenv(EnvOidcUsernameClaim)); usernameClaim != "" {
+		config.OidcUsernameClaim = usernameClaim
+	}
+
+	if groupsClaim := strings.TrimSpace(os.Getenv(EnvOidcGroupsClaim)); groupsClaim != "" {
+		config.OidcGroupsClaim = groupsClaim
+	}
+
+	if uidClaim := strings.TrimSpace(os.Getenv(EnvOidcUIDClaim)); uidClaim != "" {
+		config.OidcUIDClaim = uidClaim
+	}
+
 	if oidcInitTimeoutSecs := os.Getenv(EnvOIDCInitTimeoutSecs); oidcInitTimeoutSecs != "" {
 		timeoutSecs, err := strconv.Atoi(oidcInitTimeoutSecs)
 		if err != nil {
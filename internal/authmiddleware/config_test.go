@@ -8,6 +8,7 @@ package authmiddleware
 import (
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -588,6 +589,1036 @@ func TestJwtNewKeyUseDelayConfig(t *testing.T) {
 	}
 }
 
+// TestJwtAlgorithmConfig tests that JWT_ALGORITHM defaults by signing type and
+// only accepts algorithms matching it
+func TestJwtAlgorithmConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		signingType   string
+		envValue      string
+		expectedValue string
+		expectError   bool
+	}{
+		{name: "Standard default", envValue: "", expectedValue: DefaultJwtAlgorithm},
+		{name: "Standard HS256", envValue: "HS256", expectedValue: "HS256"},
+		{name: "Standard HS512", envValue: "HS512", expectedValue: "HS512"},
+		{name: "Standard rejects RS256", envValue: "RS256", expectError: true},
+		{name: "Standard rejects none", envValue: "none", expectError: true},
+		{name: "Asymmetric default", signingType: JWTSigningTypeAsymmetric, expectedValue: DefaultJwtAsymmetricAlgorithm},
+		{name: "Asymmetric ES256", signingType: JWTSigningTypeAsymmetric, envValue: "ES256", expectedValue: "ES256"},
+		{name: "Asymmetric rejects HS384", signingType: JWTSigningTypeAsymmetric, envValue: "HS384", expectError: true},
+		{name: "Lowercase rejected", signingType: JWTSigningTypeAsymmetric, envValue: "rs256", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.signingType != "" {
+				t.Setenv(EnvJwtSigningType, tc.signingType)
+			}
+			if tc.envValue != "" {
+				t.Setenv(EnvJwtAlgorithm, tc.envValue)
+			}
+
+			config, err := NewConfig()
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewConfig() error = %v", err)
+			}
+			if config.JWTAlgorithm != tc.expectedValue {
+				t.Errorf("Expected JWTAlgorithm to be %q, got %q", tc.expectedValue, config.JWTAlgorithm)
+			}
+		})
+	}
+}
+
+func TestJwtIssuerMigrationConfig(t *testing.T) {
+	testCases := []struct {
+		name           string
+		env            map[string]string
+		expectedWindow time.Duration
+		expectError    bool
+	}{
+		{name: "Disabled by default", env: map[string]string{}},
+		{
+			name: "Window defaults to JWT expiration",
+			env: map[string]string{
+				EnvJwtPreviousIssuer:       "old-issuer",
+				EnvJwtIssuerMigrationStart: "2026-01-01T00:00:00Z",
+			},
+			expectedWindow: DefaultJwtExpiration,
+		},
+		{
+			name: "Explicit window",
+			env: map[string]string{
+				EnvJwtPreviousAudience:      "old-audience",
+				EnvJwtIssuerMigrationStart:  "2026-01-01T00:00:00Z",
+				EnvJwtIssuerMigrationWindow: "48h",
+			},
+			expectedWindow: 48 * time.Hour,
+		},
+		{
+			name:        "Previous issuer without start",
+			env:         map[string]string{EnvJwtPreviousIssuer: "old-issuer"},
+			expectError: true,
+		},
+		{
+			name:        "Start without previous values",
+			env:         map[string]string{EnvJwtIssuerMigrationStart: "2026-01-01T00:00:00Z"},
+			expectError: true,
+		},
+		{
+			name: "Invalid start",
+			env: map[string]string{
+				EnvJwtPreviousIssuer:       "old-issuer",
+				EnvJwtIssuerMigrationStart: "yesterday",
+			},
+			expectError: true,
+		},
+		{
+			name: "Non-positive window",
+			env: map[string]string{
+				EnvJwtPreviousIssuer:        "old-issuer",
+				EnvJwtIssuerMigrationStart:  "2026-01-01T00:00:00Z",
+				EnvJwtIssuerMigrationWindow: "0s",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			config, err := NewConfig()
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewConfig() error = %v", err)
+			}
+			if config.JWTIssuerMigrationWindow != tc.expectedWindow {
+				t.Errorf("Expected JWTIssuerMigrationWindow to be %s, got %s",
+					tc.expectedWindow, config.JWTIssuerMigrationWindow)
+			}
+		})
+	}
+}
+
+func TestJwtClaimFallbackConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTFallbackIssuer != "" || config.JWTFallbackAudience != "" {
+		t.Errorf("Expected no claim fallback by default, got issuer %q audience %q",
+			config.JWTFallbackIssuer, config.JWTFallbackAudience)
+	}
+
+	t.Setenv(EnvJwtFallbackAudience, "workspace-users")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTFallbackAudience != "workspace-users" {
+		t.Errorf("Expected JWTFallbackAudience to be set, got %q", config.JWTFallbackAudience)
+	}
+}
+
+func TestJwtSubjectMatchConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTSubjectMatch != JWTSubjectMatchOff {
+		t.Errorf("Expected JWTSubjectMatch %q by default, got %q", JWTSubjectMatchOff, config.JWTSubjectMatch)
+	}
+
+	t.Setenv(EnvJwtSubjectMatch, JWTSubjectMatchRequired)
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTSubjectMatch != JWTSubjectMatchRequired {
+		t.Errorf("Expected JWTSubjectMatch %q, got %q", JWTSubjectMatchRequired, config.JWTSubjectMatch)
+	}
+
+	t.Setenv(EnvJwtSubjectMatch, "always")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for invalid JWT_SUBJECT_MATCH")
+	}
+}
+
+func TestJwtKeyRetentionConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTKeyRotationInterval != 0 || config.JWTKeyRetentionCount != 0 {
+		t.Errorf("Expected key retention check disabled by default, got interval %v count %d",
+			config.JWTKeyRotationInterval, config.JWTKeyRetentionCount)
+	}
+
+	t.Setenv(EnvJwtKeyRotationInterval, "24h")
+	t.Setenv(EnvJwtKeyRetentionCount, "3")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTKeyRotationInterval != 24*time.Hour || config.JWTKeyRetentionCount != 3 {
+		t.Errorf("Expected interval 24h and count 3, got %v and %d",
+			config.JWTKeyRotationInterval, config.JWTKeyRetentionCount)
+	}
+
+	t.Setenv(EnvJwtKeyRetentionCount, "-1")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for negative JWT_KEY_RETENTION_COUNT")
+	}
+}
+
+func TestKeyUsageReportConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.EnableKeyUsageReport {
+		t.Error("Expected EnableKeyUsageReport to be disabled by default")
+	}
+
+	t.Setenv(EnvEnableKeyUsageReport, "true")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if !config.EnableKeyUsageReport {
+		t.Error("Expected EnableKeyUsageReport to be enabled")
+	}
+
+	t.Setenv(EnvEnableKeyUsageReport, "maybe")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for invalid ENABLE_KEY_USAGE_REPORT")
+	}
+}
+
+func TestJwtAudienceListConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if len(config.JWTAudiences) != 0 {
+		t.Errorf("Expected no JWTAudiences by default, got %v", config.JWTAudiences)
+	}
+
+	t.Setenv(EnvJwtAudience, "notebooks, kernels,,files")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTAudience != "notebooks" {
+		t.Errorf("Expected JWTAudience to be notebooks, got %s", config.JWTAudience)
+	}
+	if !slices.Equal(config.JWTAudiences, []string{"notebooks", "kernels", "files"}) {
+		t.Errorf("Expected JWTAudiences to be [notebooks kernels files], got %v", config.JWTAudiences)
+	}
+
+	t.Setenv(EnvJwtAudience, " , ")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for JWT_AUDIENCE without an audience")
+	}
+}
+
+func TestJwtClockSkewLeewayConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTClockSkewLeeway != DefaultJwtClockSkewLeeway {
+		t.Errorf("Expected JWTClockSkewLeeway to be %v, got %v", DefaultJwtClockSkewLeeway, config.JWTClockSkewLeeway)
+	}
+
+	t.Setenv(EnvJwtClockSkewLeeway, "30s")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTClockSkewLeeway != 30*time.Second {
+		t.Errorf("Expected JWTClockSkewLeeway to be 30s, got %v", config.JWTClockSkewLeeway)
+	}
+
+	for _, invalid := range []string{"soon", "0s", "-5s"} {
+		t.Setenv(EnvJwtClockSkewLeeway, invalid)
+		if _, err := NewConfig(); err == nil {
+			t.Errorf("Expected error for JWT_CLOCK_SKEW_LEEWAY=%s", invalid)
+		}
+	}
+}
+
+func TestJwtJwksConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTJWKSRefreshInterval != DefaultJwtJwksRefreshInterval {
+		t.Errorf("Expected JWTJWKSRefreshInterval to be %v, got %v", DefaultJwtJwksRefreshInterval, config.JWTJWKSRefreshInterval)
+	}
+
+	t.Setenv(EnvJwtSigningType, JWTSigningTypeJWKS)
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for jwks signing without JWT_JWKS_URL")
+	}
+
+	t.Setenv(EnvJwtJwksURL, "https://idp.example.com/.well-known/jwks.json")
+	t.Setenv(EnvJwtJwksRefreshInterval, "1m")
+	// External tokens are not bound to a workspace, so group rules are required
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for jwks signing without AUTHZ_GROUP_RULES")
+	}
+
+	t.Setenv(EnvAuthzGroupRules, "*.example.com=data-science")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTJWKSURL != "https://idp.example.com/.well-known/jwks.json" {
+		t.Errorf("Expected JWTJWKSURL to be set, got %q", config.JWTJWKSURL)
+	}
+	if config.JWTJWKSRefreshInterval != time.Minute {
+		t.Errorf("Expected JWTJWKSRefreshInterval to be 1m, got %v", config.JWTJWKSRefreshInterval)
+	}
+
+	for _, invalid := range []string{"soon", "0s", "-1m"} {
+		t.Setenv(EnvJwtJwksRefreshInterval, invalid)
+		if _, err := NewConfig(); err == nil {
+			t.Errorf("Expected error for JWT_JWKS_REFRESH_INTERVAL=%s", invalid)
+		}
+	}
+	t.Setenv(EnvJwtJwksRefreshInterval, "1m")
+
+	for _, invalid := range []string{"idp.example.com/jwks.json", "ftp://idp.example.com/jwks.json", "http://idp.example.com/jwks.json"} {
+		t.Setenv(EnvJwtJwksURL, invalid)
+		if _, err := NewConfig(); err == nil {
+			t.Errorf("Expected error for JWT_JWKS_URL=%s", invalid)
+		}
+	}
+
+	t.Setenv(EnvJwtJwksAllowInsecure, "true")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTJWKSURL != "http://idp.example.com/jwks.json" {
+		t.Errorf("Expected http JWTJWKSURL with JWT_JWKS_ALLOW_INSECURE_HTTP, got %q", config.JWTJWKSURL)
+	}
+}
+
+func TestTokenIntrospectionConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.EnableTokenIntrospection {
+		t.Error("Expected EnableTokenIntrospection to be disabled by default")
+	}
+
+	// The endpoint must not be served without caller authentication
+	t.Setenv(EnvEnableTokenIntrospection, "true")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for ENABLE_TOKEN_INTROSPECTION without INTROSPECTION_CLIENT_TOKEN")
+	}
+
+	t.Setenv(EnvIntrospectionClientToken, "client-token")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if !config.EnableTokenIntrospection || config.IntrospectionClientToken != "client-token" {
+		t.Error("Expected EnableTokenIntrospection to be enabled with the client token")
+	}
+
+	t.Setenv(EnvEnableTokenIntrospection, "maybe")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for invalid ENABLE_TOKEN_INTROSPECTION")
+	}
+}
+
+func TestJwtSecretWatchSyncTimeoutConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JwtSecretWatchSyncTimeout != DefaultJwtSecretWatchSyncTimeout {
+		t.Errorf("Expected default sync timeout %s, got %s", DefaultJwtSecretWatchSyncTimeout, config.JwtSecretWatchSyncTimeout)
+	}
+
+	t.Setenv(EnvJwtSecretWatchSyncTimeout, "45s")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JwtSecretWatchSyncTimeout != 45*time.Second {
+		t.Errorf("Expected sync timeout 45s, got %s", config.JwtSecretWatchSyncTimeout)
+	}
+
+	for _, value := range []string{"0s", "-1m", "soon"} {
+		t.Setenv(EnvJwtSecretWatchSyncTimeout, value)
+		if _, err := NewConfig(); err == nil {
+			t.Errorf("Expected error for JWT_SECRET_WATCH_SYNC_TIMEOUT=%q", value)
+		}
+	}
+}
+
+func TestInitialSecretLoadConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.InitialSecretLoadTimeout != DefaultInitialSecretLoadTimeout ||
+		config.InitialSecretLoadMaxAttempts != DefaultInitialSecretLoadMaxAttempts {
+		t.Errorf("Expected default initial secret load settings, got %s and %d",
+			config.InitialSecretLoadTimeout, config.InitialSecretLoadMaxAttempts)
+	}
+
+	t.Setenv(EnvInitialSecretLoadTimeout, "5m")
+	t.Setenv(EnvInitialSecretLoadMaxAttempts, "10")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.InitialSecretLoadTimeout != 5*time.Minute || config.InitialSecretLoadMaxAttempts != 10 {
+		t.Errorf("Expected 5m and 10 attempts, got %s and %d",
+			config.InitialSecretLoadTimeout, config.InitialSecretLoadMaxAttempts)
+	}
+
+	t.Setenv(EnvInitialSecretLoadMaxAttempts, "-1")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for negative INITIAL_SECRET_LOAD_MAX_ATTEMPTS")
+	}
+	t.Setenv(EnvInitialSecretLoadMaxAttempts, "0")
+	t.Setenv(EnvInitialSecretLoadTimeout, "0s")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for zero INITIAL_SECRET_LOAD_TIMEOUT")
+	}
+}
+
+func TestAuthzGroupRulesConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if len(config.AuthzGroupRules) != 0 {
+		t.Errorf("Expected no group rules by default, got %v", config.AuthzGroupRules)
+	}
+
+	t.Setenv(EnvAuthzGroupRules, "admin.example.com=admins;*.example.com/lab=users")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if len(config.AuthzGroupRules) != 2 || config.AuthzGroupRules[1].PathPrefix != "/lab" {
+		t.Errorf("Expected two parsed rules, got %v", config.AuthzGroupRules)
+	}
+
+	t.Setenv(EnvAuthzGroupRules, "admin.example.com")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for a rule without groups")
+	}
+}
+
+func TestForwardedHeaderNamesConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.ForwardedHostHeader != HeaderForwardedHost || config.ForwardedURIHeader != HeaderForwardedURI {
+		t.Errorf("Expected default forwarded headers, got %q and %q", config.ForwardedHostHeader, config.ForwardedURIHeader)
+	}
+
+	t.Setenv(EnvForwardedHostHeader, "X-Original-Host")
+	t.Setenv(EnvForwardedURIHeader, "X-Original-Uri")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.ForwardedHostHeader != "X-Original-Host" || config.ForwardedURIHeader != "X-Original-Uri" {
+		t.Errorf("Expected custom forwarded headers, got %q and %q", config.ForwardedHostHeader, config.ForwardedURIHeader)
+	}
+
+	t.Setenv(EnvForwardedProtoHeader, "X-Original-Proto")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.ForwardedProtoHeader != "X-Original-Proto" {
+		t.Errorf("Expected custom forwarded proto header, got %q", config.ForwardedProtoHeader)
+	}
+
+	t.Setenv(EnvForwardedURIHeader, "X Original Uri")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for an invalid header name")
+	}
+}
+
+func TestCookieSecureAutoConfig(t *testing.T) {
+	t.Setenv(EnvCookieSecure, "auto")
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if !config.CookieSecure || !config.CookieSecureAuto {
+		t.Errorf("Expected CookieSecure and CookieSecureAuto, got %v and %v", config.CookieSecure, config.CookieSecureAuto)
+	}
+
+	t.Setenv(EnvCookieSecure, "false")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.CookieSecure || config.CookieSecureAuto {
+		t.Errorf("Expected insecure cookies, got %v and %v", config.CookieSecure, config.CookieSecureAuto)
+	}
+}
+
+func TestTokenSourceConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.TokenSource != TokenSourceCookieOnly {
+		t.Errorf("Expected default token source %q, got %q", TokenSourceCookieOnly, config.TokenSource)
+	}
+
+	t.Setenv(EnvTokenSource, TokenSourceHeaderFirst)
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.TokenSource != TokenSourceHeaderFirst {
+		t.Errorf("Expected token source %q, got %q", TokenSourceHeaderFirst, config.TokenSource)
+	}
+
+	t.Setenv(EnvTokenSource, "query")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for an unknown token source")
+	}
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.RateLimitRPS != 0 || config.RateLimitBurst != DefaultRateLimitBurst {
+		t.Errorf("Expected rate limiting off by default, got rps=%v burst=%d", config.RateLimitRPS, config.RateLimitBurst)
+	}
+
+	t.Setenv(EnvRateLimitRPS, "2.5")
+	t.Setenv(EnvRateLimitBurst, "5")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.RateLimitRPS != 2.5 || config.RateLimitBurst != 5 {
+		t.Errorf("Expected rps=2.5 burst=5, got rps=%v burst=%d", config.RateLimitRPS, config.RateLimitBurst)
+	}
+
+	for env, value := range map[string]string{
+		EnvRateLimitRPS:   "-1",
+		EnvRateLimitBurst: "0",
+		EnvTrustedProxies: "proxy.local",
+	} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			if _, err := NewConfig(); err == nil {
+				t.Errorf("Expected error for %s=%s", env, value)
+			}
+		})
+	}
+}
+
+func TestCORSConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if len(config.CORSAllowedOrigins) != 0 {
+		t.Errorf("Expected CORS disabled by default, got origins %v", config.CORSAllowedOrigins)
+	}
+
+	t.Setenv(EnvCORSAllowedOrigins, "https://a.example.com, https://b.example.com:8443")
+	t.Setenv(EnvCORSAllowedMethods, "post")
+	t.Setenv(EnvCORSAllowedHeaders, "Content-Type")
+	t.Setenv(EnvCORSAllowCredentials, "true")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if len(config.CORSAllowedOrigins) != 2 || config.CORSAllowedOrigins[1] != "https://b.example.com:8443" {
+		t.Errorf("Expected two origins, got %v", config.CORSAllowedOrigins)
+	}
+	if len(config.CORSAllowedMethods) != 1 || config.CORSAllowedMethods[0] != "POST" {
+		t.Errorf("Expected [POST], got %v", config.CORSAllowedMethods)
+	}
+	if !config.CORSAllowCredentials {
+		t.Error("Expected credentials to be allowed")
+	}
+
+	for name, origins := range map[string]string{
+		"wildcard with credentials": "*",
+		"origin with path":          "https://a.example.com/app",
+		"origin without scheme":     "a.example.com",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(EnvCORSAllowedOrigins, origins)
+			if _, err := NewConfig(); err == nil {
+				t.Errorf("Expected error for %s=%s", EnvCORSAllowedOrigins, origins)
+			}
+		})
+	}
+}
+
+func TestJwtClaimLimitsConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTMaxGroups != 0 || config.JWTMaxClaimBytes != 0 || config.JWTClaimLimitTruncate {
+		t.Errorf("Expected no claim limits by default, got %d/%d/%v",
+			config.JWTMaxGroups, config.JWTMaxClaimBytes, config.JWTClaimLimitTruncate)
+	}
+
+	t.Setenv(EnvJwtMaxGroups, "200")
+	t.Setenv(EnvJwtMaxClaimBytes, "8192")
+	t.Setenv(EnvJwtClaimLimitTruncate, "true")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTMaxGroups != 200 || config.JWTMaxClaimBytes != 8192 || !config.JWTClaimLimitTruncate {
+		t.Errorf("Expected limits 200/8192 with truncation, got %d/%d/%v",
+			config.JWTMaxGroups, config.JWTMaxClaimBytes, config.JWTClaimLimitTruncate)
+	}
+
+	t.Setenv(EnvJwtMaxGroups, "-1")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for a negative max groups")
+	}
+}
+
+func TestIdentityHeaderConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.IdentityUserHeader != HeaderAuthUser || config.IdentityGroupsHeader != HeaderAuthGroups ||
+		config.IdentityUIDHeader != HeaderAuthUID || len(config.IdentityExtraHeaders) != 0 {
+		t.Errorf("Expected default identity headers, got %q %q %q %v", config.IdentityUserHeader,
+			config.IdentityGroupsHeader, config.IdentityUIDHeader, config.IdentityExtraHeaders)
+	}
+
+	t.Setenv(EnvIdentityUserHeader, "X-Auth-Request-User")
+	t.Setenv(EnvIdentityExtraHeaders, "department=X-Auth-Department, team = X-Auth-Team")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.IdentityUserHeader != "X-Auth-Request-User" {
+		t.Errorf("Expected custom user header, got %q", config.IdentityUserHeader)
+	}
+	if config.IdentityExtraHeaders["department"] != "X-Auth-Department" || config.IdentityExtraHeaders["team"] != "X-Auth-Team" {
+		t.Errorf("Expected two extra headers, got %v", config.IdentityExtraHeaders)
+	}
+
+	t.Setenv(EnvIdentityExtraHeaders, "department")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for an extra header without a name")
+	}
+}
+
+func TestOidcClaimMappingConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.OidcUsernameClaim != "preferred_username" || config.OidcGroupsClaim != "groups" ||
+		config.OidcUIDClaim != "sub" {
+		t.Errorf("Expected standard claim names, got %q %q %q",
+			config.OidcUsernameClaim, config.OidcGroupsClaim, config.OidcUIDClaim)
+	}
+
+	t.Setenv(EnvOidcUsernameClaim, "email")
+	t.Setenv(EnvOidcGroupsClaim, " realm_access.roles ")
+	t.Setenv(EnvOidcUIDClaim, "oid")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.OidcUsernameClaim != "email" || config.OidcGroupsClaim != "realm_access.roles" ||
+		config.OidcUIDClaim != "oid" {
+		t.Errorf("Expected mapped claim names, got %q %q %q",
+			config.OidcUsernameClaim, config.OidcGroupsClaim, config.OidcUIDClaim)
+	}
+}
+
+func TestSigningKeyHealthGracePeriodConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.SigningKeyHealthGracePeriod != DefaultSigningKeyHealthGracePeriod {
+		t.Errorf("Expected default grace period %s, got %s",
+			DefaultSigningKeyHealthGracePeriod, config.SigningKeyHealthGracePeriod)
+	}
+
+	t.Setenv(EnvSigningKeyHealthGracePeriod, "10m")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.SigningKeyHealthGracePeriod != 10*time.Minute {
+		t.Errorf("Expected grace period 10m, got %s", config.SigningKeyHealthGracePeriod)
+	}
+
+	t.Setenv(EnvSigningKeyHealthGracePeriod, "0s")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for a zero grace period")
+	}
+}
+
+func TestJwtValidationCacheConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTValidationCacheEnable {
+		t.Error("Expected the validation cache to be disabled by default")
+	}
+	if config.JWTValidationCacheSize != DefaultJwtValidationCacheSize {
+		t.Errorf("Expected default cache size %d, got %d", DefaultJwtValidationCacheSize, config.JWTValidationCacheSize)
+	}
+
+	t.Setenv(EnvJwtValidationCacheEnable, "true")
+	t.Setenv(EnvJwtValidationCacheSize, "500")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if !config.JWTValidationCacheEnable || config.JWTValidationCacheSize != 500 {
+		t.Errorf("Expected cache enabled with 500 entries, got %v and %d",
+			config.JWTValidationCacheEnable, config.JWTValidationCacheSize)
+	}
+
+	t.Setenv(EnvJwtValidationCacheSize, "0")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for zero JWT_VALIDATION_CACHE_SIZE")
+	}
+}
+
+func TestAccessLogConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if !config.AccessLog {
+		t.Error("Expected AccessLog to be enabled by default")
+	}
+
+	t.Setenv(EnvAccessLog, "false")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.AccessLog {
+		t.Error("Expected AccessLog to be disabled")
+	}
+
+	t.Setenv(EnvAccessLog, "sometimes")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for invalid ACCESS_LOG")
+	}
+}
+
+func TestScopeConfig(t *testing.T) {
+	t.Setenv(EnvJwtScope, "  workspace:read   workspace:connect ")
+	t.Setenv(EnvVerifyRequiredScopes, "workspace:connect workspace:read")
+
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTScope != "workspace:read workspace:connect" {
+		t.Errorf("Expected JWTScope to be normalized, got %q", config.JWTScope)
+	}
+	if !slices.Equal(config.VerifyRequiredScopes, []string{"workspace:connect", "workspace:read"}) {
+		t.Errorf("Unexpected VerifyRequiredScopes %v", config.VerifyRequiredScopes)
+	}
+}
+
+func TestJwtKeyPrefixesConfig(t *testing.T) {
+	t.Setenv(EnvJwtKeyPrefixes, "jwt-key-v2-, jwt-signing-key-")
+
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if !slices.Equal(config.JWTKeyPrefixes, []string{"jwt-key-v2-", "jwt-signing-key-"}) {
+		t.Errorf("Unexpected JWTKeyPrefixes %v", config.JWTKeyPrefixes)
+	}
+}
+
+func TestJwtRejectWeakKeysConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTRejectWeakKeys {
+		t.Error("Expected JWTRejectWeakKeys to default to false")
+	}
+
+	t.Setenv(EnvJwtRejectWeakKeys, "true")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if !config.JWTRejectWeakKeys {
+		t.Error("Expected JWTRejectWeakKeys to be true")
+	}
+
+	t.Setenv(EnvJwtRejectWeakKeys, "sometimes")
+	if _, err := NewConfig(); err == nil {
+		t.Errorf("Expected error for invalid %s", EnvJwtRejectWeakKeys)
+	}
+}
+
+func TestForceSigningKidConfig(t *testing.T) {
+	t.Setenv(EnvForceSigningKid, " 1767225600 ")
+
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.ForceSigningKid != "1767225600" {
+		t.Errorf("Expected ForceSigningKid 1767225600, got %q", config.ForceSigningKid)
+	}
+
+	for _, signingType := range []string{JWTSigningTypeAsymmetric, JWTSigningTypeJWKS} {
+		t.Setenv(EnvJwtSigningType, signingType)
+		t.Setenv(EnvJwtJwksURL, "https://idp.example.com/jwks.json")
+		if _, err := NewConfig(); err == nil {
+			t.Errorf("Expected error for FORCE_SIGNING_KID with %s signing", signingType)
+		}
+	}
+}
+
+func TestStandardSigningOnlyConfig(t *testing.T) {
+	settings := map[string]string{
+		EnvJwtAdditionalSecretNames: "archived-keys",
+		EnvJwtKeyEncoding:           "base64",
+		EnvJwtRejectWeakKeys:        "true",
+		EnvJwtMaxKeyRetention:       "168h",
+	}
+	for env, value := range settings {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			if _, err := NewConfig(); err != nil {
+				t.Fatalf("NewConfig() error = %v", err)
+			}
+
+			for _, signingType := range []string{JWTSigningTypeAsymmetric, JWTSigningTypeJWKS} {
+				t.Setenv(EnvJwtSigningType, signingType)
+				t.Setenv(EnvJwtJwksURL, "https://idp.example.com/jwks.json")
+				if _, err := NewConfig(); err == nil {
+					t.Errorf("Expected error for %s with %s signing", env, signingType)
+				}
+			}
+		})
+	}
+}
+
+func TestJwtAdditionalSecretNamesConfig(t *testing.T) {
+	t.Setenv(EnvJwtAdditionalSecretNames, "archived-keys, older-keys,,archived-keys")
+
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if !slices.Equal(config.JWTAdditionalSecretNames, []string{"archived-keys", "older-keys"}) {
+		t.Errorf("Unexpected JWTAdditionalSecretNames %v", config.JWTAdditionalSecretNames)
+	}
+
+	t.Setenv(EnvJwtAdditionalSecretNames, "archived-keys,"+DefaultJwtSecretName)
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error when an additional secret is the primary secret")
+	}
+}
+
+func TestJwtKeyEncodingConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTKeyEncoding != DefaultJwtKeyEncoding {
+		t.Errorf("Expected default JWTKeyEncoding %q, got %q", DefaultJwtKeyEncoding, config.JWTKeyEncoding)
+	}
+
+	t.Setenv(EnvJwtKeyEncoding, "Base64")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTKeyEncoding != "base64" {
+		t.Errorf("Expected JWTKeyEncoding base64, got %q", config.JWTKeyEncoding)
+	}
+
+	t.Setenv(EnvJwtKeyEncoding, "base32")
+	if _, err := NewConfig(); err == nil {
+		t.Errorf("Expected error for invalid %s", EnvJwtKeyEncoding)
+	}
+}
+
+func TestJwtMaxKeyRetentionConfig(t *testing.T) {
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTMaxKeyRetention != 0 {
+		t.Errorf("Expected JWTMaxKeyRetention to be disabled by default, got %v", config.JWTMaxKeyRetention)
+	}
+
+	t.Setenv(EnvJwtMaxKeyRetention, "168h")
+	config, err = NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.JWTMaxKeyRetention != 168*time.Hour {
+		t.Errorf("Expected JWTMaxKeyRetention 168h, got %v", config.JWTMaxKeyRetention)
+	}
+
+	// Shorter than the token lifetime would drop keys still backing live tokens
+	t.Setenv(EnvJwtExpiration, "2h")
+	t.Setenv(EnvJwtMaxKeyRetention, "1h")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for JWT_MAX_KEY_RETENTION shorter than JWT_EXPIRATION")
+	}
+
+	t.Setenv(EnvJwtMaxKeyRetention, "soon")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for JWT_MAX_KEY_RETENTION=soon")
+	}
+}
+
+func TestOIDCPinnedKeyThumbprintsConfig(t *testing.T) {
+	thumbprint := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+	t.Setenv(EnvOIDCPinnedJWKSURI, " https://issuer.example.com/keys ")
+	t.Setenv(EnvOIDCPinnedKeyThumbprints, thumbprint+", "+thumbprint+",")
+
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.OIDCPinnedJWKSURI != "https://issuer.example.com/keys" {
+		t.Errorf("Expected OIDCPinnedJWKSURI to be trimmed, got %q", config.OIDCPinnedJWKSURI)
+	}
+	if len(config.OIDCPinnedKeyThumbprints) != 1 || config.OIDCPinnedKeyThumbprints[0] != thumbprint {
+		t.Errorf("Expected one deduplicated thumbprint, got %v", config.OIDCPinnedKeyThumbprints)
+	}
+
+	// A hex SHA-256 is not a base64url thumbprint
+	t.Setenv(EnvOIDCPinnedKeyThumbprints, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+	if _, err := NewConfig(); err == nil {
+		t.Error("Expected error for a hex-encoded OIDC_PINNED_KEY_THUMBPRINTS value")
+	}
+}
+
+func TestBaseDomainConfig(t *testing.T) {
+	t.Setenv(EnvBaseDomain, ".Example.com.")
+
+	config, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.BaseDomain != "example.com" {
+		t.Errorf("Expected BaseDomain to be normalized, got %q", config.BaseDomain)
+	}
+}
+
+func TestValidationOnlyKeyRefreshConfig(t *testing.T) {
+	t.Run("reauthenticate by default", func(t *testing.T) {
+		config, err := NewConfig()
+		if err != nil {
+			t.Fatalf("NewConfig() error = %v", err)
+		}
+		if config.ValidationOnlyKeyRefresh != ValidationOnlyKeyRefreshReauthenticate {
+			t.Errorf("Expected %q, got %q", ValidationOnlyKeyRefreshReauthenticate, config.ValidationOnlyKeyRefresh)
+		}
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		t.Setenv(EnvValidationOnlyKeyRefresh, ValidationOnlyKeyRefreshExpire)
+		config, err := NewConfig()
+		if err != nil {
+			t.Fatalf("NewConfig() error = %v", err)
+		}
+		if config.ValidationOnlyKeyRefresh != ValidationOnlyKeyRefreshExpire {
+			t.Errorf("Expected %q, got %q", ValidationOnlyKeyRefreshExpire, config.ValidationOnlyKeyRefresh)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv(EnvValidationOnlyKeyRefresh, "ignore")
+		if _, err := NewConfig(); err == nil {
+			t.Errorf("Expected error for invalid %s", EnvValidationOnlyKeyRefresh)
+		}
+	})
+}
+
+func TestSigningStatusIntervalConfig(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		config, err := NewConfig()
+		if err != nil {
+			t.Fatalf("NewConfig() error = %v", err)
+		}
+		if config.SigningStatusInterval != 0 {
+			t.Errorf("Expected SigningStatusInterval to be 0, got %s", config.SigningStatusInterval)
+		}
+	})
+
+	t.Run("requires pod name", func(t *testing.T) {
+		t.Setenv(EnvSigningStatusInterval, "1m")
+		if _, err := NewConfig(); err == nil {
+			t.Errorf("Expected error when %s is not set", EnvPodName)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		t.Setenv(EnvSigningStatusInterval, "1m")
+		t.Setenv(EnvPodName, "authmiddleware-abc")
+		t.Setenv(EnvPodUID, "pod-uid")
+		config, err := NewConfig()
+		if err != nil {
+			t.Fatalf("NewConfig() error = %v", err)
+		}
+		if config.SigningStatusInterval != time.Minute {
+			t.Errorf("Expected SigningStatusInterval to be 1m, got %s", config.SigningStatusInterval)
+		}
+		if config.PodName != "authmiddleware-abc" || config.PodUID != "pod-uid" {
+			t.Errorf("Expected pod identity to be read, got name %q uid %q", config.PodName, config.PodUID)
+		}
+	})
+}
+
 // TestOIDCVerifierInitConfig tests that the NewOIDCVerifier function properly validates config
 func TestOIDCVerifierInitConfig(t *testing.T) {
 	testCases := []struct {
@@ -18,7 +18,19 @@ const (
 	HeaderForwardedHost  = "X-Forwarded-Host"
 	HeaderForwardedProto = "X-Forwarded-Proto"
 
-	// No headers set by middleware yet
+	// Schemes reported in the forwarded proto header
+	ProtoHTTP  = "http"
+	ProtoHTTPS = "https"
+
+	// Headers set by middleware on successful /verify responses, for the proxy to
+	// forward upstream
+	HeaderAuthUser   = "X-Auth-User"
+	HeaderAuthGroups = "X-Auth-Groups"
+	HeaderAuthUID    = "X-Auth-Uid"
+
+	// WebSocket handshake headers
+	HeaderConnection = "Connection"
+	HeaderUpgrade    = "Upgrade"
 
 	// Special groups
 	SystemAuthenticatedGroup = "system:authenticated"
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,12 +21,21 @@ const (
 	SameSiteLax    = "lax"
 )
 
+// CookieSecureAuto is the COOKIE_SECURE value deriving Secure from the forwarded proto
+const CookieSecureAuto = "auto"
+
 // Common errors
 var (
 	ErrNoCookie      = errors.New("cookie not found")
 	ErrInvalidCookie = errors.New("invalid cookie")
 )
 
+// maxCookieSize is the serialized cookie size beyond which browsers commonly reject a cookie
+const maxCookieSize = 4096
+
+// maxCookieChunks bounds how many chunk cookies an oversized token may be split into
+const maxCookieChunks = 4
+
 // CookieHandler exposes CookieManager interface to facilitate unit-testing
 type CookieHandler interface {
 	SetCookie(w http.ResponseWriter, token string, path string, domain string)
@@ -37,6 +47,7 @@ type CookieHandler interface {
 type CookieManager struct {
 	cookieName         string
 	cookieSecure       bool
+	secureFromProto    bool // set Secure per request from the forwarded proto
 	cookieDomain       string
 	cookiePath         string
 	cookieMaxAge       time.Duration
@@ -53,6 +64,10 @@ func NewCookieManager(cfg *Config) (*CookieManager, error) {
 	case SameSiteStrict:
 		sameSiteHttp = http.SameSiteStrictMode
 	case SameSiteNone:
+		// Browsers reject SameSite=None cookies that are not also Secure
+		if !cfg.CookieSecure || cfg.CookieSecureAuto {
+			return nil, fmt.Errorf("same site value %s requires secure cookies", cfg.CookieSameSite)
+		}
 		sameSiteHttp = http.SameSiteNoneMode
 	case SameSiteLax:
 		sameSiteHttp = http.SameSiteLaxMode
@@ -60,95 +75,229 @@ func NewCookieManager(cfg *Config) (*CookieManager, error) {
 		return nil, fmt.Errorf("invalid same site value: %s", cfg.CookieSameSite)
 	}
 
+	maxAge, err := resolveCookieMaxAge(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &CookieManager{
 		cookieName:         cfg.CookieName,
 		cookieSecure:       cfg.CookieSecure,
+		secureFromProto:    cfg.CookieSecureAuto,
 		cookieDomain:       cfg.CookieDomain,
 		cookiePath:         cfg.CookiePath,
-		cookieMaxAge:       cfg.CookieMaxAge,
+		cookieMaxAge:       maxAge,
 		cookieHTTPOnly:     cfg.CookieHTTPOnly,
 		cookieSameSiteHttp: sameSiteHttp,
 		pathRegexPattern:   cfg.PathRegexPattern,
 	}, nil
 }
 
-// SetCookie sets an auth cookie with the given token
-func (m *CookieManager) SetCookie(w http.ResponseWriter, token string, path string, domain string) {
-	cookieName := m.cookieName
-	cookiePath := m.cookiePath
-
-	// Extract the app path for cookie path only
-	if path != "" {
-		// Extract the app path using the regex pattern
-		appPath := path
-		if m.pathRegexPattern != "" {
-			appPath = ExtractAppPath(path, m.pathRegexPattern)
+// resolveCookieMaxAge picks the cookie Max-Age: SessionCookieMaxAge when set, then
+// CookieMaxAge, then the token expiration. SessionCookieMaxAge may not exceed the
+// token expiration, since the cookie would outlive the token it carries.
+func resolveCookieMaxAge(cfg *Config) (time.Duration, error) {
+	if cfg.SessionCookieMaxAge < 0 {
+		return 0, fmt.Errorf("session cookie max age must not be negative, got %v", cfg.SessionCookieMaxAge)
+	}
+	if cfg.SessionCookieMaxAge > 0 {
+		if cfg.JWTExpiration > 0 && cfg.SessionCookieMaxAge > cfg.JWTExpiration {
+			return 0, fmt.Errorf("session cookie max age (%v) exceeds token expiration (%v)",
+				cfg.SessionCookieMaxAge, cfg.JWTExpiration)
 		}
+		return cfg.SessionCookieMaxAge, nil
+	}
+	if cfg.CookieMaxAge > 0 {
+		return cfg.CookieMaxAge, nil
+	}
+	return cfg.JWTExpiration, nil
+}
 
-		// Use the extracted app path for cookie path if it's not empty
-		if appPath != "" && appPath != "/" {
-			cookiePath = appPath
-		}
+// SetCookie sets an auth cookie with the given token. A token too large for a single
+// cookie is split across numbered chunk cookies (name-0, name-1, ...).
+func (m *CookieManager) SetCookie(w http.ResponseWriter, token string, path string, domain string) {
+	cookiePath := m.resolveCookiePath(path)
+	cookie := m.newCookie(m.cookieName, token, cookiePath, domain, int(m.cookieMaxAge.Seconds()))
+
+	if cookie.Valid() != nil {
+		// http.SetCookie silently drops invalid cookies
+		cookieOperationsTotal.WithLabelValues(cookieOpSetRejected).Inc()
+		http.SetCookie(w, cookie)
+		return
 	}
 
-	cookie := &http.Cookie{
-		Name:     cookieName,
-		Value:    token,
+	if len(cookie.String()) <= maxCookieSize {
+		cookieOperationsTotal.WithLabelValues(cookieOpSet).Inc()
+		http.SetCookie(w, cookie)
+		// Expire chunks left over from a previously oversized token, so they do not
+		// keep inflating the Cookie header until their Max-Age
+		m.clearChunks(w, 0, cookiePath, domain)
+		return
+	}
+
+	chunks := m.splitIntoChunks(token, cookiePath, domain)
+	if len(chunks) > maxCookieChunks {
+		// Browsers drop oversized cookies, so writing a truncated chunk set would only
+		// leave the client with a token that cannot be reassembled
+		cookieOperationsTotal.WithLabelValues(cookieOpSetRejected).Inc()
+		http.SetCookie(w, cookie)
+		return
+	}
+
+	cookieOperationsTotal.WithLabelValues(cookieOpSet).Inc()
+	// The unchunked cookie takes precedence on read, so expire any previous one
+	http.SetCookie(w, m.newCookie(m.cookieName, "", cookiePath, domain, -1))
+	for i, chunk := range chunks {
+		http.SetCookie(w, m.newCookie(chunkCookieName(m.cookieName, i), chunk, cookiePath, domain,
+			int(m.cookieMaxAge.Seconds())))
+	}
+	m.clearChunks(w, len(chunks), cookiePath, domain)
+}
+
+// splitIntoChunks splits value into pieces that each fit in a chunk cookie
+func (m *CookieManager) splitIntoChunks(value string, cookiePath string, domain string) []string {
+	// Size the chunks for the longest chunk name, so every chunk cookie fits
+	overhead := len(m.newCookie(chunkCookieName(m.cookieName, maxCookieChunks), "", cookiePath, domain,
+		int(m.cookieMaxAge.Seconds())).String())
+	chunkSize := maxCookieSize - overhead
+	if chunkSize <= 0 {
+		return nil
+	}
+
+	var chunks []string
+	for len(value) > 0 {
+		n := min(chunkSize, len(value))
+		chunks = append(chunks, value[:n])
+		value = value[n:]
+	}
+	return chunks
+}
+
+// clearChunks expires the chunk cookies from index from onwards. The previous chunk
+// count is not known when writing, so every index up to maxCookieChunks is expired.
+func (m *CookieManager) clearChunks(w http.ResponseWriter, from int, cookiePath string, domain string) {
+	for i := from; i < maxCookieChunks; i++ {
+		http.SetCookie(w, m.newCookie(chunkCookieName(m.cookieName, i), "", cookiePath, domain, -1))
+	}
+}
+
+// chunkCookieName returns the name of the i-th chunk of cookie name
+func chunkCookieName(name string, i int) string {
+	return name + "-" + strconv.Itoa(i)
+}
+
+// resolveCookiePath returns the cookie path for a request path: the app path
+// extracted with the path regex when there is one, otherwise the configured path
+func (m *CookieManager) resolveCookiePath(path string) string {
+	if path == "" {
+		return m.cookiePath
+	}
+
+	appPath := path
+	if m.pathRegexPattern != "" {
+		appPath = ExtractAppPath(path, m.pathRegexPattern)
+	}
+
+	// Use the extracted app path for cookie path if it's not empty
+	if appPath != "" && appPath != "/" {
+		return appPath
+	}
+	return m.cookiePath
+}
+
+// withSecure returns a copy of the manager setting Secure to secure on its cookies
+func (m *CookieManager) withSecure(secure bool) *CookieManager {
+	scoped := *m
+	scoped.cookieSecure = secure
+	return &scoped
+}
+
+// cookiesFor returns the cookie handler for r. With COOKIE_SECURE=auto, cookies are
+// only marked Secure when the original request was HTTPS, since browsers drop Secure
+// cookies set over plain HTTP.
+func (s *Server) cookiesFor(r *http.Request) CookieHandler {
+	if m, ok := s.cookieManager.(*CookieManager); ok && m.secureFromProto {
+		return m.withSecure(GetForwardedProto(s.config, r) == ProtoHTTPS)
+	}
+	return s.cookieManager
+}
+
+// newCookie builds an auth cookie carrying the manager's security attributes
+func (m *CookieManager) newCookie(name string, value string, cookiePath string, domain string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
 		Path:     cookiePath,
 		Domain:   domain,
-		MaxAge:   int(m.cookieMaxAge.Seconds()),
+		MaxAge:   maxAge,
 		HttpOnly: m.cookieHTTPOnly,
 		Secure:   m.cookieSecure,
 		SameSite: m.cookieSameSiteHttp,
 	}
-
-	http.SetCookie(w, cookie)
 }
 
-// GetCookie retrieves the auth token from the cookie
+// GetCookie retrieves the auth token from the cookie, reassembling it from chunk
+// cookies when it was too large to store in one
 func (m *CookieManager) GetCookie(r *http.Request, path string) (string, error) {
 	cookieName := m.cookieName
 
 	cookie, err := r.Cookie(cookieName)
 	if err != nil {
 		if err == http.ErrNoCookie {
-			return "", ErrNoCookie
+			// net/http skips cookies with malformed values, so check the raw header
+			if !hasRawCookie(r, cookieName) {
+				return m.getChunkedCookie(r)
+			}
+			err = errors.New("malformed cookie value")
 		}
+		cookieOperationsTotal.WithLabelValues(cookieOpParseFailure).Inc()
 		return "", fmt.Errorf("%w: %v", ErrInvalidCookie, err)
 	}
 
 	return cookie.Value, nil
 }
 
-// ClearCookie removes the auth cookie
-func (m *CookieManager) ClearCookie(w http.ResponseWriter, path string, domain string) {
-	cookieName := m.cookieName
-	cookiePath := m.cookiePath
-
-	// Extract the app path for cookie path only
-	if path != "" {
-		// Extract the app path using the regex pattern
-		appPath := path
-		if m.pathRegexPattern != "" {
-			appPath = ExtractAppPath(path, m.pathRegexPattern)
+// getChunkedCookie joins the chunk cookies name-0, name-1, ... up to the first missing index
+func (m *CookieManager) getChunkedCookie(r *http.Request) (string, error) {
+	var value strings.Builder
+	for i := 0; i < maxCookieChunks; i++ {
+		name := chunkCookieName(m.cookieName, i)
+		chunk, err := r.Cookie(name)
+		if err == nil {
+			value.WriteString(chunk.Value)
+			continue
 		}
-
-		// Use the extracted app path for cookie path if it's not empty
-		if appPath != "" && appPath != "/" {
-			cookiePath = appPath
+		if hasRawCookie(r, name) {
+			cookieOperationsTotal.WithLabelValues(cookieOpParseFailure).Inc()
+			return "", fmt.Errorf("%w: malformed value in chunk %d", ErrInvalidCookie, i)
 		}
+		break
 	}
 
-	cookie := &http.Cookie{
-		Name:     cookieName,
-		Value:    "",
-		Path:     cookiePath,
-		Domain:   domain,
-		MaxAge:   -1,
-		HttpOnly: m.cookieHTTPOnly,
-		Secure:   m.cookieSecure,
-		SameSite: m.cookieSameSiteHttp,
+	if value.Len() == 0 {
+		return "", ErrNoCookie
 	}
+	return value.String(), nil
+}
+
+// hasRawCookie reports whether the request's Cookie headers contain an entry named name
+func hasRawCookie(r *http.Request, name string) bool {
+	for _, line := range r.Header.Values("Cookie") {
+		for _, part := range strings.Split(line, ";") {
+			key, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if key == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClearCookie removes the auth cookie and any chunk cookies
+func (m *CookieManager) ClearCookie(w http.ResponseWriter, path string, domain string) {
+	cookiePath := m.resolveCookiePath(path)
 
-	http.SetCookie(w, cookie)
+	cookieOperationsTotal.WithLabelValues(cookieOpClear).Inc()
+	http.SetCookie(w, m.newCookie(m.cookieName, "", cookiePath, domain, -1))
+	m.clearChunks(w, 0, cookiePath, domain)
 }
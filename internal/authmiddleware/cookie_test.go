@@ -6,19 +6,25 @@ Distributed under the terms of the MIT license
 package authmiddleware
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // TestNewCookieManagerSameSite verifies that NewCookieManager configures SameSite correctly
 func TestNewCookieManagerSameSite(t *testing.T) {
 	testCases := []struct {
-		name      string
-		sameSite  string
-		expectErr bool
+		name       string
+		sameSite   string
+		insecure   bool
+		secureAuto bool
+		expectErr  bool
 	}{
 		{
 			name:      "Strict SameSite",
@@ -40,17 +46,36 @@ func TestNewCookieManagerSameSite(t *testing.T) {
 			sameSite:  "invalid",
 			expectErr: true,
 		},
+		{
+			name:      "Lax SameSite without Secure",
+			sameSite:  SameSiteLax,
+			insecure:  true,
+			expectErr: false,
+		},
+		{
+			name:      "None SameSite without Secure",
+			sameSite:  SameSiteNone,
+			insecure:  true,
+			expectErr: true,
+		},
+		{
+			name:       "None SameSite with Secure from forwarded proto",
+			sameSite:   SameSiteNone,
+			secureAuto: true,
+			expectErr:  true,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			config := &Config{
-				CookieName:     "test_auth",
-				CookieSecure:   true,
-				CookiePath:     "/",
-				CookieMaxAge:   1 * time.Hour,
-				CookieHTTPOnly: true,
-				CookieSameSite: tc.sameSite,
+				CookieName:       "test_auth",
+				CookieSecure:     !tc.insecure,
+				CookieSecureAuto: tc.secureAuto,
+				CookiePath:       "/",
+				CookieMaxAge:     1 * time.Hour,
+				CookieHTTPOnly:   true,
+				CookieSameSite:   tc.sameSite,
 			}
 
 			manager, err := NewCookieManager(config)
@@ -541,3 +566,349 @@ func TestClearCookie(t *testing.T) {
 		})
 	}
 }
+
+// TestCookieOperationMetrics verifies that cookie operations increment the matching counters
+func TestCookieOperationMetrics(t *testing.T) {
+	config := &Config{
+		CookieName:     "test_auth",
+		CookiePath:     "/",
+		CookieMaxAge:   1 * time.Hour,
+		CookieSameSite: SameSiteLax,
+	}
+	manager, err := NewCookieManager(config)
+	if err != nil {
+		t.Fatalf("Failed to create cookie manager: %v", err)
+	}
+
+	counter := func(op string) float64 {
+		return testutil.ToFloat64(cookieOperationsTotal.WithLabelValues(op))
+	}
+
+	t.Run("set", func(t *testing.T) {
+		before := counter(cookieOpSet)
+		manager.SetCookie(httptest.NewRecorder(), "token", "/", "")
+		if got := counter(cookieOpSet) - before; got != 1 {
+			t.Errorf("Expected set counter to increase by 1, got %v", got)
+		}
+	})
+
+	t.Run("set rejected when oversized", func(t *testing.T) {
+		before := counter(cookieOpSetRejected)
+		manager.SetCookie(httptest.NewRecorder(), strings.Repeat("a", maxCookieSize*maxCookieChunks), "/", "")
+		if got := counter(cookieOpSetRejected) - before; got != 1 {
+			t.Errorf("Expected set_rejected counter to increase by 1, got %v", got)
+		}
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		before := counter(cookieOpClear)
+		manager.ClearCookie(httptest.NewRecorder(), "/", "")
+		if got := counter(cookieOpClear) - before; got != 1 {
+			t.Errorf("Expected clear counter to increase by 1, got %v", got)
+		}
+	})
+
+	t.Run("parse failure on malformed value", func(t *testing.T) {
+		before := counter(cookieOpParseFailure)
+		req := httptest.NewRequest("GET", "http://example.com/", nil)
+		req.Header.Set("Cookie", `test_auth=bad\value`)
+
+		_, err := manager.GetCookie(req, "/")
+		if !errors.Is(err, ErrInvalidCookie) {
+			t.Errorf("Expected ErrInvalidCookie, got %v", err)
+		}
+		if got := counter(cookieOpParseFailure) - before; got != 1 {
+			t.Errorf("Expected parse_failure counter to increase by 1, got %v", got)
+		}
+	})
+
+	t.Run("missing cookie is not a parse failure", func(t *testing.T) {
+		before := counter(cookieOpParseFailure)
+		req := httptest.NewRequest("GET", "http://example.com/", nil)
+
+		_, err := manager.GetCookie(req, "/")
+		if !errors.Is(err, ErrNoCookie) {
+			t.Errorf("Expected ErrNoCookie, got %v", err)
+		}
+		if got := counter(cookieOpParseFailure) - before; got != 0 {
+			t.Errorf("Expected parse_failure counter unchanged, got delta %v", got)
+		}
+	})
+}
+
+// TestChunkedCookie verifies that a token too large for one cookie is split into
+// numbered chunks on write and reassembled on read
+func TestChunkedCookie(t *testing.T) {
+	config := &Config{
+		CookieName:       "test_auth",
+		CookieSecure:     true,
+		CookiePath:       "/",
+		CookieMaxAge:     1 * time.Hour,
+		CookieHTTPOnly:   true,
+		CookieSameSite:   SameSiteLax,
+		PathRegexPattern: `^(/workspaces/[^/]+/[^/]+)(?:/.*)?$`,
+	}
+	manager, err := NewCookieManager(config)
+	if err != nil {
+		t.Fatalf("Failed to create cookie manager: %v", err)
+	}
+
+	// Live cookies are the ones a browser would keep: set with a positive max age
+	liveCookies := func(w *httptest.ResponseRecorder) map[string]*http.Cookie {
+		live := map[string]*http.Cookie{}
+		for _, c := range w.Result().Cookies() {
+			if c.MaxAge > 0 {
+				live[c.Name] = c
+			}
+		}
+		return live
+	}
+	expiredCookies := func(w *httptest.ResponseRecorder) map[string]bool {
+		expired := map[string]bool{}
+		for _, c := range w.Result().Cookies() {
+			if c.MaxAge < 0 {
+				expired[c.Name] = true
+			}
+		}
+		return expired
+	}
+	requestWith := func(cookies map[string]*http.Cookie) *http.Request {
+		req := httptest.NewRequest("GET", "http://example.com/workspaces/ns1/app1", nil)
+		for _, c := range cookies {
+			req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+		return req
+	}
+
+	token := strings.Repeat("x", maxCookieSize+500)
+
+	t.Run("oversized token is split into two chunks", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		manager.SetCookie(w, token, "/workspaces/ns1/app1/lab", "")
+
+		live := liveCookies(w)
+		if len(live) != 2 {
+			t.Fatalf("Expected 2 chunk cookies, got %d", len(live))
+		}
+		for _, name := range []string{"test_auth-0", "test_auth-1"} {
+			c, ok := live[name]
+			if !ok {
+				t.Fatalf("Expected chunk cookie %s", name)
+			}
+			if c.Path != "/workspaces/ns1/app1" {
+				t.Errorf("Expected chunk %s path /workspaces/ns1/app1, got %q", name, c.Path)
+			}
+			if !c.HttpOnly || !c.Secure {
+				t.Errorf("Expected chunk %s to keep HttpOnly and Secure", name)
+			}
+			if size := len(c.String()); size > maxCookieSize {
+				t.Errorf("Chunk %s is %d bytes, exceeds %d", name, size, maxCookieSize)
+			}
+		}
+		if !expiredCookies(w)["test_auth"] {
+			t.Error("Expected the unchunked cookie to be expired")
+		}
+
+		got, err := manager.GetCookie(requestWith(live), "/workspaces/ns1/app1")
+		if err != nil {
+			t.Fatalf("Failed to get chunked cookie: %v", err)
+		}
+		if got != token {
+			t.Errorf("Reassembled token differs: got %d bytes, want %d", len(got), len(token))
+		}
+	})
+
+	t.Run("stale chunks are expired when the chunk count shrinks", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		manager.SetCookie(w, strings.Repeat("y", 3*maxCookieSize-500), "/", "")
+		if live := liveCookies(w); len(live) != 3 {
+			t.Fatalf("Expected 3 chunk cookies, got %d", len(live))
+		}
+
+		w = httptest.NewRecorder()
+		manager.SetCookie(w, token, "/", "")
+		live := liveCookies(w)
+		if len(live) != 2 || live["test_auth-0"] == nil || live["test_auth-1"] == nil {
+			t.Fatalf("Expected chunks 0 and 1 to be live, got %v", live)
+		}
+		if !expiredCookies(w)["test_auth-2"] {
+			t.Error("Expected stale chunk test_auth-2 to be expired")
+		}
+	})
+
+	t.Run("chunks are expired when the token fits a single cookie again", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		manager.SetCookie(w, token, "/", "")
+		if live := liveCookies(w); len(live) != 2 {
+			t.Fatalf("Expected 2 chunk cookies, got %d", len(live))
+		}
+
+		w = httptest.NewRecorder()
+		manager.SetCookie(w, "small-token", "/", "")
+		live := liveCookies(w)
+		if len(live) != 1 || live["test_auth"] == nil || live["test_auth"].Value != "small-token" {
+			t.Fatalf("Expected only the unchunked cookie to be live, got %v", live)
+		}
+		expired := expiredCookies(w)
+		for i := range maxCookieChunks {
+			if name := chunkCookieName("test_auth", i); !expired[name] {
+				t.Errorf("Expected stale chunk %s to be expired", name)
+			}
+		}
+	})
+
+	t.Run("unchunked cookie takes precedence over chunks", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://example.com/", nil)
+		req.AddCookie(&http.Cookie{Name: "test_auth", Value: "current"})
+		req.AddCookie(&http.Cookie{Name: "test_auth-0", Value: "stale"})
+
+		got, err := manager.GetCookie(req, "/")
+		if err != nil {
+			t.Fatalf("Failed to get cookie: %v", err)
+		}
+		if got != "current" {
+			t.Errorf("Expected unchunked value, got %q", got)
+		}
+	})
+
+	t.Run("clear expires chunks", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		manager.ClearCookie(w, "/", "")
+
+		expired := expiredCookies(w)
+		for _, name := range []string{"test_auth", "test_auth-0", "test_auth-1"} {
+			if !expired[name] {
+				t.Errorf("Expected %s to be expired", name)
+			}
+		}
+	})
+}
+
+// TestSessionCookieMaxAge verifies how the cookie Max-Age is resolved and validated
+func TestSessionCookieMaxAge(t *testing.T) {
+	testCases := []struct {
+		name           string
+		sessionMaxAge  time.Duration
+		cookieMaxAge   time.Duration
+		jwtExpiration  time.Duration
+		expectedMaxAge int
+		expectErr      bool
+	}{
+		{
+			name:           "Session max age overrides cookie max age",
+			sessionMaxAge:  30 * time.Minute,
+			cookieMaxAge:   24 * time.Hour,
+			jwtExpiration:  1 * time.Hour,
+			expectedMaxAge: 1800,
+		},
+		{
+			name:           "Session max age equal to token expiration",
+			sessionMaxAge:  1 * time.Hour,
+			jwtExpiration:  1 * time.Hour,
+			expectedMaxAge: 3600,
+		},
+		{
+			name:           "Falls back to token expiration",
+			jwtExpiration:  1 * time.Hour,
+			expectedMaxAge: 3600,
+		},
+		{
+			name:          "Session max age exceeding token expiration is rejected",
+			sessionMaxAge: 2 * time.Hour,
+			jwtExpiration: 1 * time.Hour,
+			expectErr:     true,
+		},
+		{
+			name:          "Negative session max age is rejected",
+			sessionMaxAge: -1 * time.Minute,
+			jwtExpiration: 1 * time.Hour,
+			expectErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &Config{
+				CookieName:          "test_auth",
+				CookiePath:          "/",
+				CookieMaxAge:        tc.cookieMaxAge,
+				CookieSameSite:      SameSiteLax,
+				JWTExpiration:       tc.jwtExpiration,
+				SessionCookieMaxAge: tc.sessionMaxAge,
+			}
+
+			manager, err := NewCookieManager(config)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to create cookie manager: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			manager.SetCookie(w, "token", "/", "")
+			cookies := w.Result().Cookies()
+			if len(cookies) == 0 || cookies[0].Name != "test_auth" {
+				t.Fatalf("Expected the test_auth cookie first, got %v", cookies)
+			}
+			if cookies[0].MaxAge != tc.expectedMaxAge {
+				t.Errorf("Expected cookie max age %d but got %d", tc.expectedMaxAge, cookies[0].MaxAge)
+			}
+		})
+	}
+}
+
+// TestCookiesForForwardedProto verifies that COOKIE_SECURE=auto marks cookies Secure
+// only when the original request was HTTPS
+func TestCookiesForForwardedProto(t *testing.T) {
+	testCases := []struct {
+		name           string
+		secureAuto     bool
+		proto          string
+		expectedSecure bool
+	}{
+		{name: "auto over https", secureAuto: true, proto: "https", expectedSecure: true},
+		{name: "auto over http", secureAuto: true, proto: "http", expectedSecure: false},
+		{name: "auto without header", secureAuto: true, expectedSecure: true},
+		{name: "static secure over http", proto: "http", expectedSecure: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &Config{
+				CookieName:       "test_auth",
+				CookieSecure:     true,
+				CookieSecureAuto: tc.secureAuto,
+				CookiePath:       "/",
+				CookieMaxAge:     1 * time.Hour,
+				CookieSameSite:   SameSiteLax,
+			}
+			manager, err := NewCookieManager(config)
+			if err != nil {
+				t.Fatalf("Failed to create cookie manager: %v", err)
+			}
+			server := &Server{config: config, cookieManager: manager}
+
+			req := httptest.NewRequest(http.MethodGet, "/auth", nil)
+			if tc.proto != "" {
+				req.Header.Set(HeaderForwardedProto, tc.proto)
+			}
+			w := httptest.NewRecorder()
+			server.cookiesFor(req).SetCookie(w, "token", "/", "")
+
+			cookies := w.Result().Cookies()
+			if len(cookies) == 0 || cookies[0].Name != "test_auth" {
+				t.Fatalf("Expected the test_auth cookie first, got %v", cookies)
+			}
+			if cookies[0].Secure != tc.expectedSecure {
+				t.Errorf("Expected Secure=%v but got %v", tc.expectedSecure, cookies[0].Secure)
+			}
+			if !manager.cookieSecure {
+				t.Error("Expected the shared cookie manager to stay unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// corsAnyOrigin allows every origin in CORSAllowedOrigins
+const corsAnyOrigin = "*"
+
+// validateCORSOrigin checks that origin is "*" or a bare scheme://host[:port] origin
+func validateCORSOrigin(origin string) error {
+	if origin == corsAnyOrigin {
+		return nil
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("origin %q: %w", origin, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+		(parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("origin %q must be an http or https scheme and host", origin)
+	}
+	return nil
+}
+
+// corsOriginAllowed reports whether origin is in the configured allowlist
+func (s *Server) corsOriginAllowed(origin string) bool {
+	origin = strings.TrimSuffix(origin, "/")
+	return slices.ContainsFunc(s.config.CORSAllowedOrigins, func(allowed string) bool {
+		return allowed == corsAnyOrigin || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+	})
+}
+
+// withCORS answers CORS preflight requests and adds CORS headers to responses for
+// allowed origins. Preflights from other origins get a 403; their actual requests are
+// served without CORS headers, so browsers withhold the response. It returns next
+// unchanged when no origins are configured.
+func (s *Server) withCORS(next http.HandlerFunc) http.HandlerFunc {
+	if len(s.config.CORSAllowedOrigins) == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := s.corsOriginAllowed(origin)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !allowed {
+				s.logger.Info("Rejected CORS preflight from disallowed origin", "origin", origin, "path", r.URL.Path)
+				http.Error(w, "Origin not allowed", http.StatusForbidden)
+				return
+			}
+			s.setCORSOriginHeaders(w, origin)
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(s.config.CORSAllowedMethods, ", "))
+			if len(s.config.CORSAllowedHeaders) > 0 {
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(s.config.CORSAllowedHeaders, ", "))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			s.setCORSOriginHeaders(w, origin)
+		}
+		next(w, r)
+	}
+}
+
+// setCORSOriginHeaders allows origin to read the response
+func (s *Server) setCORSOriginHeaders(w http.ResponseWriter, origin string) {
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if s.config.CORSAllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
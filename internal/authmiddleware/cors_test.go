@@ -0,0 +1,120 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAllowedOrigin    = "https://notebooks.example.com"
+	testDisallowedOrigin = "https://evil.example.org"
+)
+
+// newCORSTestHandler wraps a handler answering 200 with CORS for testAllowedOrigin
+func newCORSTestHandler(t *testing.T, allowCredentials bool) (http.HandlerFunc, *bool) {
+	t.Helper()
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+	server.config.CORSAllowedOrigins = []string{testAllowedOrigin}
+	server.config.CORSAllowedMethods = []string{http.MethodGet, http.MethodPost}
+	server.config.CORSAllowedHeaders = []string{HeaderAuthorization, "Content-Type"}
+	server.config.CORSAllowCredentials = allowCredentials
+
+	called := false
+	return server.withCORS(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}), &called
+}
+
+func newPreflightRequest(origin string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, routeIntrospect, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	return req
+}
+
+func TestWithCORS_PreflightAllowedOrigin(t *testing.T) {
+	handler, called := newCORSTestHandler(t, true)
+
+	w := httptest.NewRecorder()
+	handler(w, newPreflightRequest(testAllowedOrigin))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.False(t, *called, "preflight must not reach the handler")
+	assert.Equal(t, testAllowedOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+}
+
+func TestWithCORS_PreflightDisallowedOrigin(t *testing.T) {
+	handler, called := newCORSTestHandler(t, true)
+
+	w := httptest.NewRecorder()
+	handler(w, newPreflightRequest(testDisallowedOrigin))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.False(t, *called)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestWithCORS_ActualRequests(t *testing.T) {
+	tests := []struct {
+		name            string
+		origin          string
+		credentials     bool
+		expectedOrigin  string
+		expectedCredHdr string
+	}{
+		{name: "allowed origin", origin: testAllowedOrigin, expectedOrigin: testAllowedOrigin},
+		{name: "allowed origin with credentials", origin: testAllowedOrigin, credentials: true,
+			expectedOrigin: testAllowedOrigin, expectedCredHdr: "true"},
+		{name: "allowed origin in other case", origin: "https://Notebooks.Example.com", expectedOrigin: "https://Notebooks.Example.com"},
+		{name: "disallowed origin", origin: testDisallowedOrigin, credentials: true},
+		{name: "same-origin request", origin: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, called := newCORSTestHandler(t, tt.credentials)
+
+			req := httptest.NewRequest(http.MethodPost, routeIntrospect, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.True(t, *called)
+			assert.Equal(t, tt.expectedOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.expectedCredHdr, w.Header().Get("Access-Control-Allow-Credentials"))
+		})
+	}
+}
+
+func TestWithCORS_Disabled(t *testing.T) {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+	called := false
+	handler := server.withCORS(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, newPreflightRequest(testAllowedOrigin))
+
+	assert.True(t, called, "preflight reaches the handler when CORS is disabled")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,39 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// padDenyResponse delays a denied response until at least Config.DenyResponseFloor has
+// elapsed since start, plus a random delay of up to Config.DenyResponseJitter, so that
+// failure modes (unknown kid, bad signature, expired token) are not distinguishable by
+// latency. It is a no-op when the floor is zero and returns early if ctx is done.
+func (s *Server) padDenyResponse(ctx context.Context, start time.Time) {
+	if s.config.DenyResponseFloor <= 0 {
+		return
+	}
+
+	target := s.config.DenyResponseFloor
+	if s.config.DenyResponseJitter > 0 {
+		target += rand.N(s.config.DenyResponseJitter)
+	}
+
+	wait := target - time.Since(start)
+	if wait <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyDenyLatency runs /verify with a validator that fails after the given work time
+func verifyDenyLatency(t *testing.T, cfg *Config, work time.Duration, failure error) time.Duration {
+	t.Helper()
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{
+		ValidateTokenFunc: func(string) (*jwt.Claims, error) {
+			time.Sleep(work)
+			return nil, failure
+		},
+	})
+	server.config.DenyResponseFloor = cfg.DenyResponseFloor
+	server.config.DenyResponseJitter = cfg.DenyResponseJitter
+
+	req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+	req.Header.Set(HeaderForwardedURI, testAppPath)
+	req.Header.Set(HeaderForwardedHost, "example.com")
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	server.handleVerify(w, req)
+	elapsed := time.Since(start)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	return elapsed
+}
+
+func TestHandleVerify_DenyResponseFloorNormalizesLatency(t *testing.T) {
+	floor := 80 * time.Millisecond
+	jitter := 10 * time.Millisecond
+	cfg := &Config{DenyResponseFloor: floor, DenyResponseJitter: jitter}
+
+	failureModes := []struct {
+		name string
+		work time.Duration
+		err  error
+	}{
+		{name: "unknown kid", work: 0, err: jwt.ErrInvalidToken},
+		{name: "bad signature", work: 10 * time.Millisecond, err: jwt.ErrInvalidSignature},
+		{name: "expired", work: 30 * time.Millisecond, err: jwt.ErrTokenExpired},
+	}
+
+	for _, mode := range failureModes {
+		t.Run(mode.name, func(t *testing.T) {
+			elapsed := verifyDenyLatency(t, cfg, mode.work, mode.err)
+			assert.GreaterOrEqual(t, elapsed, floor)
+			// Allow scheduling slack on top of floor + jitter
+			assert.Less(t, elapsed, floor+jitter+40*time.Millisecond)
+		})
+	}
+}
+
+func TestHandleVerify_DenyResponseFloorDisabledByDefault(t *testing.T) {
+	elapsed := verifyDenyLatency(t, &Config{}, 0, jwt.ErrInvalidToken)
+	assert.Less(t, elapsed, 50*time.Millisecond)
+}
+
+func TestPadDenyResponse_ReturnsWhenContextDone(t *testing.T) {
+	server := &Server{config: &Config{DenyResponseFloor: time.Hour}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	server.padDenyResponse(ctx, start)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -8,30 +8,99 @@ package authmiddleware
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 )
 
-// GetForwardedHost extracts the X-Forwarded-Host header from the request
-func GetForwardedHost(r *http.Request) (string, error) {
-	host := r.Header.Get(HeaderForwardedHost)
+// GetForwardedHost extracts the forwarded host from the request, read from the header
+// named by cfg (X-Forwarded-Host by default)
+func GetForwardedHost(cfg *Config, r *http.Request) (string, error) {
+	header := cfg.forwardedHostHeader()
+	host := r.Header.Get(header)
 	if host == "" {
-		return "", fmt.Errorf("missing %s header", HeaderForwardedHost)
+		return "", fmt.Errorf("missing %s header", header)
 	}
 	return host, nil
 }
 
-// GetForwardedURI extracts the X-Forwarded-URI header from the request
-func GetForwardedURI(r *http.Request) (string, error) {
-	uri := r.Header.Get(HeaderForwardedURI)
+// GetForwardedURI extracts the forwarded URI from the request, read from the header
+// named by cfg (X-Forwarded-Uri by default)
+func GetForwardedURI(cfg *Config, r *http.Request) (string, error) {
+	header := cfg.forwardedURIHeader()
+	uri := r.Header.Get(header)
 	if uri == "" {
-		return "", fmt.Errorf("missing %s header", HeaderForwardedURI)
+		return "", fmt.Errorf("missing %s header", header)
 	}
 	return uri, nil
 }
 
-// ExtractSubdomain extracts the subdomain part from a host (before first dot)
+// GetForwardedProto returns the scheme of the original request, "http" or "https",
+// read from the header named by cfg (X-Forwarded-Proto by default). A missing or
+// unrecognised value yields "https", since the middleware runs behind a
+// TLS-terminating proxy.
+func GetForwardedProto(cfg *Config, r *http.Request) string {
+	// Chained proxies may append their own scheme; the first one is the client's
+	proto, _, _ := strings.Cut(r.Header.Get(cfg.forwardedProtoHeader()), ",")
+	if strings.EqualFold(strings.TrimSpace(proto), ProtoHTTP) {
+		return ProtoHTTP
+	}
+	return ProtoHTTPS
+}
+
+// forwardedHostHeader returns the configured forwarded host header name, or
+// HeaderForwardedHost when cfg is nil or leaves it unset
+func (c *Config) forwardedHostHeader() string {
+	if c == nil {
+		return HeaderForwardedHost
+	}
+	return headerOrDefault(c.ForwardedHostHeader, HeaderForwardedHost)
+}
+
+// forwardedURIHeader returns the configured forwarded URI header name, or
+// HeaderForwardedURI when cfg is nil or leaves it unset
+func (c *Config) forwardedURIHeader() string {
+	if c == nil {
+		return HeaderForwardedURI
+	}
+	return headerOrDefault(c.ForwardedURIHeader, HeaderForwardedURI)
+}
+
+// forwardedProtoHeader returns the configured forwarded proto header name, or
+// HeaderForwardedProto when cfg is nil or leaves it unset
+func (c *Config) forwardedProtoHeader() string {
+	if c == nil {
+		return HeaderForwardedProto
+	}
+	return headerOrDefault(c.ForwardedProtoHeader, HeaderForwardedProto)
+}
+
+// headerOrDefault returns name, or fallback when name is empty
+func headerOrDefault(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// validHeaderName reports whether name is a valid HTTP header field name (RFC 9110 token)
+func validHeaderName(name string) bool {
+	return name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		return r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+	})
+}
+
+// ExtractSubdomain extracts the subdomain part from a host (before first dot).
+// Any port is ignored, and IP addresses have no subdomain so yield an empty string.
 func ExtractSubdomain(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+
 	parts := strings.Split(host, ".")
 	if len(parts) > 0 {
 		return parts[0]
@@ -39,6 +108,26 @@ func ExtractSubdomain(host string) string {
 	return host
 }
 
+// ErrUntrustedHost is returned when a host is not a subdomain of the trusted base domain
+var ErrUntrustedHost = errors.New("host is not under the trusted base domain")
+
+// ExtractTrustedSubdomain returns the subdomain of host when host is exactly one label
+// under baseDomain, and ErrUntrustedHost otherwise. Any port is ignored, and matching
+// is case-insensitive.
+func ExtractTrustedSubdomain(host string, baseDomain string) (string, error) {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+
+	subdomain, found := strings.CutSuffix(host, suffix)
+	if !found || subdomain == "" || strings.Contains(subdomain, ".") {
+		return "", fmt.Errorf("%w: %q", ErrUntrustedHost, host)
+	}
+	return subdomain, nil
+}
+
 // ExtractBearerToken extracts a bearer token from an Authorization header
 func ExtractBearerToken(authHeader string) (string, error) {
 	if authHeader == "" {
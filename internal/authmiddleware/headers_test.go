@@ -105,7 +105,7 @@ func TestGetForwardedHost(t *testing.T) {
 				req.Header.Set(HeaderForwardedHost, tt.headerValue)
 			}
 
-			host, err := GetForwardedHost(req)
+			host, err := GetForwardedHost(nil, req)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -156,7 +156,7 @@ func TestGetForwardedURI(t *testing.T) {
 				req.Header.Set(HeaderForwardedURI, tt.headerValue)
 			}
 
-			uri, err := GetForwardedURI(req)
+			uri, err := GetForwardedURI(nil, req)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -170,6 +170,95 @@ func TestGetForwardedURI(t *testing.T) {
 	}
 }
 
+func TestGetForwardedHeaders_CustomNames(t *testing.T) {
+	cfg := &Config{ForwardedHostHeader: "X-Original-Host", ForwardedURIHeader: "X-Original-Uri"}
+	req, err := http.NewRequest("GET", "http://example.com", nil)
+	require.NoError(t, err)
+	req.Header.Set(HeaderForwardedHost, "ignored.example.com")
+	req.Header.Set(HeaderForwardedURI, "/ignored")
+
+	_, err = GetForwardedHost(cfg, req)
+	assert.ErrorContains(t, err, "missing X-Original-Host header")
+	_, err = GetForwardedURI(cfg, req)
+	assert.ErrorContains(t, err, "missing X-Original-Uri header")
+
+	req.Header.Set("X-Original-Host", "workspace.example.com")
+	req.Header.Set("X-Original-Uri", "/workspaces/ns/app/lab")
+	host, err := GetForwardedHost(cfg, req)
+	require.NoError(t, err)
+	assert.Equal(t, "workspace.example.com", host)
+	uri, err := GetForwardedURI(cfg, req)
+	require.NoError(t, err)
+	assert.Equal(t, "/workspaces/ns/app/lab", uri)
+}
+
+func TestGetForwardedProto(t *testing.T) {
+	tests := []struct {
+		name        string
+		headerValue string
+		expected    string
+	}{
+		{name: "https", headerValue: "https", expected: ProtoHTTPS},
+		{name: "http", headerValue: "http", expected: ProtoHTTP},
+		{name: "uppercase", headerValue: "HTTP", expected: ProtoHTTP},
+		{name: "proxy chain uses the first scheme", headerValue: "http, https", expected: ProtoHTTP},
+		{name: "missing header defaults to https", headerValue: "", expected: ProtoHTTPS},
+		{name: "unknown scheme defaults to https", headerValue: "ws", expected: ProtoHTTPS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "http://example.com", nil)
+			require.NoError(t, err)
+			if tt.headerValue != "" {
+				req.Header.Set(HeaderForwardedProto, tt.headerValue)
+			}
+			assert.Equal(t, tt.expected, GetForwardedProto(nil, req))
+		})
+	}
+
+	t.Run("custom header name", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "http://example.com", nil)
+		require.NoError(t, err)
+		req.Header.Set(HeaderForwardedProto, "https")
+		req.Header.Set("X-Original-Proto", "http")
+		assert.Equal(t, ProtoHTTP, GetForwardedProto(&Config{ForwardedProtoHeader: "X-Original-Proto"}, req))
+	})
+}
+
+func TestExtractTrustedSubdomain(t *testing.T) {
+	tests := []struct {
+		name       string
+		host       string
+		baseDomain string
+		expected   string
+		expectErr  bool
+	}{
+		{name: "Subdomain of base domain", host: "ws1.example.com", baseDomain: "example.com", expected: "ws1"},
+		{name: "Port is ignored", host: "ws1.example.com:8443", baseDomain: "example.com", expected: "ws1"},
+		{name: "Case-insensitive", host: "WS1.Example.COM", baseDomain: "example.com", expected: "ws1"},
+		{name: "Trailing dots", host: "ws1.example.com.", baseDomain: ".example.com.", expected: "ws1"},
+		{name: "Untrusted parent domain", host: "attacker.evil.com", baseDomain: "example.com", expectErr: true},
+		{name: "Suffix without label boundary", host: "ws1.notexample.com", baseDomain: "example.com", expectErr: true},
+		{name: "Base domain itself", host: "example.com", baseDomain: "example.com", expectErr: true},
+		{name: "Nested subdomain", host: "ws1.evil.example.com", baseDomain: "example.com", expectErr: true},
+		{name: "Base domain used as subdomain", host: "example.com.evil.com", baseDomain: "example.com", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ExtractTrustedSubdomain(tt.host, tt.baseDomain)
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrUntrustedHost)
+				assert.Empty(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 // TestExtractSubdomain tests the ExtractSubdomain function
 func TestExtractSubdomain(t *testing.T) {
 	tests := []struct {
@@ -202,6 +291,31 @@ func TestExtractSubdomain(t *testing.T) {
 			host:     "",
 			expected: "",
 		},
+		{
+			name:     "Host with port",
+			host:     "ws.example.com:443",
+			expected: "ws",
+		},
+		{
+			name:     "IPv4 literal",
+			host:     "1.2.3.4",
+			expected: "",
+		},
+		{
+			name:     "IPv4 literal with port",
+			host:     "1.2.3.4:8080",
+			expected: "",
+		},
+		{
+			name:     "IPv6 literal with port",
+			host:     "[::1]:8080",
+			expected: "",
+		},
+		{
+			name:     "Bracketed IPv6 literal",
+			host:     "[2001:db8::1]",
+			expected: "",
+		},
 	}
 
 	for _, tt := range tests {
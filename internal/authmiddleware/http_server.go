@@ -2,9 +2,14 @@ package authmiddleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
@@ -19,37 +24,105 @@ import (
 // 3. We need to load initial JWT signing keys before starting the HTTP server
 //
 // The adapter handles:
-// - Initial secret loading (for StandardSigner only, deferred until Start to avoid network calls during construction)
+// - Initial secret loading (deferred until Start to avoid network calls during construction)
 // - Starting the HTTP server in a goroutine
 // - Graceful shutdown when context is cancelled
 // - Propagating server errors back to the manager
 type HTTPServerRunnable struct {
-	server         *Server
-	logger         logr.Logger
-	runtimeClient  client.Client
-	standardSigner *jwt.StandardSigner
-	secretName     string
-	namespace      string
+	server        *Server
+	logger        logr.Logger
+	runtimeClient client.Client
+	signer        jwt.SecretBackedSigner
+	secretName    string
+	namespace     string
+	// secretLoadBudget bounds the total time spent loading the initial keys,
+	// secretAttemptTimeout each read, and secretMaxAttempts the number of reads (0 for
+	// no limit). The wait between reads starts at secretRetryInterval and doubles up
+	// to secretMaxRetryInterval.
+	secretLoadBudget       time.Duration
+	secretAttemptTimeout   time.Duration
+	secretMaxAttempts      int
+	secretRetryInterval    time.Duration
+	secretMaxRetryInterval time.Duration
+	// keysLoaded is set once the initial signing keys have loaded
+	keysLoaded atomic.Bool
+	// signingKeyGracePeriod is how long the signer may have no key past its cooloff
+	// before SigningKeyHealthzCheck fails; signingKeyMissingSince holds the unix nanos
+	// at which the gap was first seen, zero while a key is usable
+	signingKeyGracePeriod  time.Duration
+	signingKeyMissingSince atomic.Int64
+	now                    func() time.Time
+}
+
+// Initial key loading retries reads that time out, which usually means API server
+// slowness, and reads of a secret that does not exist yet, e.g. before the rotator's
+// first run on a new cluster, until the budget is spent. Other errors such as
+// Forbidden fail immediately.
+const (
+	DefaultInitialSecretLoadBudget       = time.Minute
+	DefaultInitialSecretAttemptTimeout   = 10 * time.Second
+	DefaultInitialSecretMaxAttempts      = 0
+	DefaultInitialSecretRetryInterval    = 2 * time.Second
+	DefaultInitialSecretMaxRetryInterval = 15 * time.Second
+)
+
+// DefaultSigningKeyHealthGracePeriod is how long the signer may be unable to sign before
+// the liveness check fails. It is well above the new key cooloff, so the window after a
+// fresh secret's first key is loaded does not restart the pod.
+const DefaultSigningKeyHealthGracePeriod = 5 * time.Minute
+
+// HTTPServerRunnableOption configures optional HTTPServerRunnable behavior
+type HTTPServerRunnableOption func(*HTTPServerRunnable)
+
+// WithInitialSecretRetry bounds initial key loading to budget and at most maxAttempts
+// reads, where 0 means no limit other than the budget. Defaults to
+// DefaultInitialSecretLoadBudget and DefaultInitialSecretMaxAttempts.
+func WithInitialSecretRetry(budget time.Duration, maxAttempts int) HTTPServerRunnableOption {
+	return func(h *HTTPServerRunnable) {
+		h.secretLoadBudget = budget
+		h.secretMaxAttempts = maxAttempts
+	}
+}
+
+// WithSigningKeyHealthGracePeriod sets how long the signer may have no usable signing key
+// before SigningKeyHealthzCheck fails. Defaults to DefaultSigningKeyHealthGracePeriod.
+func WithSigningKeyHealthGracePeriod(gracePeriod time.Duration) HTTPServerRunnableOption {
+	return func(h *HTTPServerRunnable) {
+		h.signingKeyGracePeriod = gracePeriod
+	}
 }
 
 // NewHTTPServerRunnable creates a new HTTPServerRunnable.
-// If standardSigner is not nil, it will load the initial JWT signing keys before starting the server.
+// If signer is not nil, it will load the initial JWT signing keys before starting the server.
 func NewHTTPServerRunnable(
 	server *Server,
 	logger logr.Logger,
 	runtimeClient client.Client,
-	standardSigner *jwt.StandardSigner,
+	signer jwt.SecretBackedSigner,
 	secretName string,
 	namespace string,
+	opts ...HTTPServerRunnableOption,
 ) *HTTPServerRunnable {
-	return &HTTPServerRunnable{
-		server:         server,
-		logger:         logger,
-		runtimeClient:  runtimeClient,
-		standardSigner: standardSigner,
-		secretName:     secretName,
-		namespace:      namespace,
+	h := &HTTPServerRunnable{
+		server:        server,
+		logger:        logger,
+		runtimeClient: runtimeClient,
+		signer:        signer,
+		secretName:    secretName,
+		namespace:     namespace,
+
+		secretLoadBudget:       DefaultInitialSecretLoadBudget,
+		secretAttemptTimeout:   DefaultInitialSecretAttemptTimeout,
+		secretMaxAttempts:      DefaultInitialSecretMaxAttempts,
+		secretRetryInterval:    DefaultInitialSecretRetryInterval,
+		secretMaxRetryInterval: DefaultInitialSecretMaxRetryInterval,
+		signingKeyGracePeriod:  DefaultSigningKeyHealthGracePeriod,
+		now:                    time.Now,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // Start implements the Runnable interface. It starts the HTTP server
@@ -57,22 +130,18 @@ func NewHTTPServerRunnable(
 func (h *HTTPServerRunnable) Start(ctx context.Context) error {
 	h.logger.Info("Starting HTTP server runnable")
 
-	// Load initial JWT signing keys if using standard signing
-	if h.standardSigner != nil {
+	// Load initial JWT signing keys
+	if h.signer != nil {
 		h.logger.Info("Loading initial JWT signing keys from secret",
 			"secret", h.secretName,
 			"namespace", h.namespace)
 
 		// Retrieve initial secret and load keys
-		if err := h.standardSigner.RetrieveInitialSecret(
-			ctx,
-			h.runtimeClient,
-			h.secretName,
-			h.namespace,
-		); err != nil {
+		if err := h.loadInitialSecret(ctx); err != nil {
 			return fmt.Errorf("failed to retrieve initial secret: %w", err)
 		}
 
+		h.keysLoaded.Store(true)
 		h.logger.Info("Successfully loaded initial JWT signing keys")
 	}
 
@@ -99,6 +168,96 @@ func (h *HTTPServerRunnable) Start(ctx context.Context) error {
 	}
 }
 
+// loadInitialSecret reads the signing secret into the signer, retrying reads that time
+// out or find no secret with exponential backoff until secretLoadBudget elapses or
+// secretMaxAttempts reads have failed. Any other error is returned without retrying.
+func (h *HTTPServerRunnable) loadInitialSecret(ctx context.Context) error {
+	budgetCtx, cancel := context.WithTimeout(ctx, h.secretLoadBudget)
+	defer cancel()
+
+	interval := h.secretRetryInterval
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancelAttempt := context.WithTimeout(budgetCtx, h.secretAttemptTimeout)
+		err := h.signer.RetrieveInitialSecret(attemptCtx, h.runtimeClient, h.secretName, h.namespace)
+		cancelAttempt()
+		if err == nil {
+			return nil
+		}
+		if !isRetryableSecretError(err) || budgetCtx.Err() != nil {
+			return err
+		}
+		if h.secretMaxAttempts > 0 && attempt >= h.secretMaxAttempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+
+		h.logger.Info("Failed to read JWT signing secret, retrying",
+			"secret", h.secretName,
+			"namespace", h.namespace,
+			"attempt", attempt,
+			"retryIn", interval.String(),
+			"error", err.Error())
+
+		select {
+		case <-budgetCtx.Done():
+			return fmt.Errorf("gave up after %d attempts within %s: %w", attempt, h.secretLoadBudget, err)
+		case <-time.After(interval):
+		}
+		interval = min(2*interval, h.secretMaxRetryInterval)
+	}
+}
+
+// isRetryableSecretError reports whether a failed secret read timed out or found no
+// secret, as opposed to being rejected (e.g. Forbidden), which retrying would not fix
+func isRetryableSecretError(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsNotFound(err)
+}
+
+// ReadyzCheck is a healthz.Checker that fails until the initial signing keys have
+// loaded, and whenever the signer holds no keys, so the pod is not sent requests it
+// would fail. It always passes when there is no signer to load.
+func (h *HTTPServerRunnable) ReadyzCheck(_ *http.Request) error {
+	if h.signer == nil {
+		return nil
+	}
+	if !h.keysLoaded.Load() {
+		return errors.New("JWT signing keys not loaded yet")
+	}
+	if provider, ok := h.signer.(jwt.SnapshotProvider); ok && len(provider.Snapshot().Kids) == 0 {
+		return errors.New("no JWT signing keys loaded")
+	}
+	return nil
+}
+
+// SigningKeyHealthzCheck is a healthz.Checker that fails once the signer has had no key
+// past its cooloff for longer than the grace period, so a pod that can no longer issue
+// tokens is restarted. Shorter gaps pass, and so does a signer that cannot report its
+// keys or has not loaded them yet, which ReadyzCheck covers.
+func (h *HTTPServerRunnable) SigningKeyHealthzCheck(_ *http.Request) error {
+	checker, ok := h.signer.(jwt.SigningKeyChecker)
+	if !ok || !h.keysLoaded.Load() {
+		return nil
+	}
+	if checker.HasUsableSigningKey() {
+		h.signingKeyMissingSince.Store(0)
+		return nil
+	}
+
+	now := h.now()
+	if h.signingKeyMissingSince.CompareAndSwap(0, now.UnixNano()) {
+		h.logger.Info("No JWT signing key past its cooloff, tokens cannot be issued",
+			"gracePeriod", h.signingKeyGracePeriod.String())
+		return nil
+	}
+	missingFor := now.Sub(time.Unix(0, h.signingKeyMissingSince.Load()))
+	if missingFor > h.signingKeyGracePeriod {
+		return fmt.Errorf("no usable JWT signing key for %s", missingFor.Round(time.Second))
+	}
+	return nil
+}
+
 // NeedLeaderElection implements the Runnable interface.
 // Returns false because the HTTP server should run on all replicas.
 func (h *HTTPServerRunnable) NeedLeaderElection() bool {
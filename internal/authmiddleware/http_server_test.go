@@ -94,8 +94,8 @@ func TestNewHTTPServerRunnable(t *testing.T) {
 	if runnable.server != server {
 		t.Error("Server not set correctly")
 	}
-	if runnable.standardSigner != signer {
-		t.Error("StandardSigner not set correctly")
+	if runnable.signer != signer {
+		t.Error("Signer not set correctly")
 	}
 	if runnable.secretName != "test-secret" {
 		t.Error("Secret name not set correctly")
@@ -235,7 +235,9 @@ func TestStart_WithStandardSigner_MissingSecret(t *testing.T) {
 		signer,
 		"missing-secret",
 		"test-namespace",
+		WithInitialSecretRetry(200*time.Millisecond, 0),
 	)
+	runnable.secretRetryInterval = 10 * time.Millisecond
 
 	ctx := context.Background()
 	err := runnable.Start(ctx)
@@ -244,14 +246,9 @@ func TestStart_WithStandardSigner_MissingSecret(t *testing.T) {
 		t.Fatal("Expected error for missing secret, got nil")
 	}
 
-	// Check that it's a NotFound error
+	// The secret is waited for until the budget is spent, then NotFound is returned
 	if !apierrors.IsNotFound(err) {
-		t.Logf("Error message: %v", err)
-		// The error might be wrapped, so also check the error message
-		errMsg := err.Error()
-		if errMsg == "" {
-			t.Error("Expected error message about missing secret")
-		}
+		t.Errorf("Expected NotFound error, got: %v", err)
 	}
 }
 
@@ -468,3 +465,264 @@ func TestStart_ShutdownError(t *testing.T) {
 		t.Fatal("Start() did not return after context cancellation")
 	}
 }
+
+// flakySecretClient fails the first failures secret reads with err, then delegates
+type flakySecretClient struct {
+	client.Client
+	err      error
+	failures int
+	calls    int
+}
+
+func (f *flakySecretClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if _, ok := obj.(*corev1.Secret); ok {
+		f.calls++
+		if f.calls <= f.failures {
+			return f.err
+		}
+	}
+	return f.Client.Get(ctx, key, obj, opts...)
+}
+
+// newSecretLoadTestRunnable creates a runnable reading a valid signing secret through
+// a flakySecretClient, with retry timings shortened for tests
+func newSecretLoadTestRunnable(err error, failures int) (*HTTPServerRunnable, *flakySecretClient) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+		Data: map[string][]byte{
+			"jwt-signing-key-1700000000": []byte("abcdefghijklmnopqrstuvwxyz1234567890ABCDEFGHIJKLM"),
+		},
+	}
+	flaky := &flakySecretClient{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		err:      err,
+		failures: failures,
+	}
+
+	signer := jwt.NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	runnable := NewHTTPServerRunnable(createTestHTTPServer(), logr.Discard(), flaky, signer, "test-secret", "test-namespace")
+	runnable.secretLoadBudget = 500 * time.Millisecond
+	runnable.secretRetryInterval = 10 * time.Millisecond
+	runnable.secretMaxRetryInterval = 40 * time.Millisecond
+	return runnable, flaky
+}
+
+// TestLoadInitialSecret_RetriesDeadlineThenSucceeds tests that timed out reads are retried
+func TestLoadInitialSecret_RetriesDeadlineThenSucceeds(t *testing.T) {
+	for name, timeoutErr := range map[string]error{
+		"context deadline": fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+		"server timeout":   apierrors.NewServerTimeout(corev1.Resource("secrets"), "get", 1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			runnable, flaky := newSecretLoadTestRunnable(timeoutErr, 2)
+
+			if err := runnable.loadInitialSecret(context.Background()); err != nil {
+				t.Fatalf("Expected keys to load after retries, got: %v", err)
+			}
+			if flaky.calls != 3 {
+				t.Errorf("Expected 3 secret reads, got %d", flaky.calls)
+			}
+		})
+	}
+}
+
+// TestLoadInitialSecret_ForbiddenFailsImmediately tests that rejected reads are not retried
+func TestLoadInitialSecret_ForbiddenFailsImmediately(t *testing.T) {
+	forbidden := apierrors.NewForbidden(corev1.Resource("secrets"), "test-secret", errors.New("access denied"))
+	runnable, flaky := newSecretLoadTestRunnable(forbidden, 1)
+
+	err := runnable.loadInitialSecret(context.Background())
+	if !apierrors.IsForbidden(err) {
+		t.Fatalf("Expected Forbidden error, got: %v", err)
+	}
+	if flaky.calls != 1 {
+		t.Errorf("Expected a single secret read, got %d", flaky.calls)
+	}
+}
+
+// TestLoadInitialSecret_GivesUpAfterBudget tests that timeouts stop being retried once the budget is spent
+func TestLoadInitialSecret_GivesUpAfterBudget(t *testing.T) {
+	runnable, flaky := newSecretLoadTestRunnable(context.DeadlineExceeded, 1000)
+	runnable.secretLoadBudget = 100 * time.Millisecond
+
+	start := time.Now()
+	err := runnable.loadInitialSecret(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected to give up within the budget, took %s", elapsed)
+	}
+	if flaky.calls < 2 {
+		t.Errorf("Expected retries before giving up, got %d reads", flaky.calls)
+	}
+}
+
+// TestLoadInitialSecret_WaitsForMissingSecret tests that a secret not created yet is retried
+func TestLoadInitialSecret_WaitsForMissingSecret(t *testing.T) {
+	notFound := apierrors.NewNotFound(corev1.Resource("secrets"), "test-secret")
+	runnable, flaky := newSecretLoadTestRunnable(fmt.Errorf("failed to get JWT signing secret: %w", notFound), 3)
+
+	if err := runnable.loadInitialSecret(context.Background()); err != nil {
+		t.Fatalf("Expected keys to load once the secret exists, got: %v", err)
+	}
+	if flaky.calls != 4 {
+		t.Errorf("Expected 4 secret reads, got %d", flaky.calls)
+	}
+}
+
+// TestLoadInitialSecret_StopsAfterMaxAttempts tests that retries stop at the attempt limit
+func TestLoadInitialSecret_StopsAfterMaxAttempts(t *testing.T) {
+	runnable, flaky := newSecretLoadTestRunnable(context.DeadlineExceeded, 1000)
+	WithInitialSecretRetry(time.Minute, 3)(runnable)
+
+	err := runnable.loadInitialSecret(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline error, got: %v", err)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("Expected 3 secret reads, got %d", flaky.calls)
+	}
+}
+
+// TestLoadInitialSecret_BacksOffExponentially tests that the wait between reads doubles up to the cap
+func TestLoadInitialSecret_BacksOffExponentially(t *testing.T) {
+	runnable, _ := newSecretLoadTestRunnable(context.DeadlineExceeded, 4)
+	runnable.secretRetryInterval = 20 * time.Millisecond
+	runnable.secretMaxRetryInterval = 50 * time.Millisecond
+
+	// Waits of 20ms, 40ms, 50ms and 50ms
+	start := time.Now()
+	if err := runnable.loadInitialSecret(context.Background()); err != nil {
+		t.Fatalf("Expected keys to load after retries, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 160*time.Millisecond {
+		t.Errorf("Expected at least 160ms of backoff, took %s", elapsed)
+	}
+}
+
+// TestLoadInitialSecret_StopsOnCancel tests that retries stop when the manager shuts down
+func TestLoadInitialSecret_StopsOnCancel(t *testing.T) {
+	runnable, flaky := newSecretLoadTestRunnable(context.DeadlineExceeded, 1000)
+	runnable.secretLoadBudget = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := runnable.loadInitialSecret(ctx); err == nil {
+		t.Fatal("Expected an error after cancellation")
+	}
+	if flaky.calls == 0 {
+		t.Error("Expected at least one secret read")
+	}
+}
+
+// TestReadyzCheck_WaitsForKeys tests that readiness follows initial key loading
+func TestReadyzCheck_WaitsForKeys(t *testing.T) {
+	runnable, _ := newSecretLoadTestRunnable(nil, 0)
+	if err := runnable.ReadyzCheck(nil); err == nil {
+		t.Fatal("Expected not ready before keys load")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- runnable.Start(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-errChan
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runnable.ReadyzCheck(nil) != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected ready after keys load, got: %v", runnable.ReadyzCheck(nil))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestReadyzCheck_NoKeys tests that a signer without keys is not ready
+func TestReadyzCheck_NoKeys(t *testing.T) {
+	signer := jwt.NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	runnable := NewHTTPServerRunnable(createTestHTTPServer(), logr.Discard(), nil, signer, "test-secret", "test-namespace")
+	runnable.keysLoaded.Store(true)
+
+	if err := runnable.ReadyzCheck(nil); err == nil {
+		t.Error("Expected not ready while the signer holds no keys")
+	}
+}
+
+// TestSigningKeyHealthzCheck_GracePeriod tests that liveness fails only once the signer
+// has had no key past its cooloff for longer than the grace period
+func TestSigningKeyHealthzCheck_GracePeriod(t *testing.T) {
+	signer := jwt.NewStandardSigner("test-issuer", "test-audience", time.Hour, time.Hour)
+	if err := signer.UpdateKeys(map[string][]byte{"1000": []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long")}, "1000"); err != nil {
+		t.Fatalf("Failed to load keys: %v", err)
+	}
+	now := time.Now()
+	runnable := NewHTTPServerRunnable(createTestHTTPServer(), logr.Discard(), nil, signer, "test-secret", "test-namespace",
+		WithSigningKeyHealthGracePeriod(time.Minute))
+	runnable.now = func() time.Time { return now }
+
+	if err := runnable.SigningKeyHealthzCheck(nil); err != nil {
+		t.Errorf("Expected healthy before the initial keys load, got: %v", err)
+	}
+
+	runnable.keysLoaded.Store(true)
+	if err := runnable.SigningKeyHealthzCheck(nil); err != nil {
+		t.Errorf("Expected healthy when the gap is first seen, got: %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if err := runnable.SigningKeyHealthzCheck(nil); err != nil {
+		t.Errorf("Expected healthy within the grace period, got: %v", err)
+	}
+	now = now.Add(time.Minute)
+	if err := runnable.SigningKeyHealthzCheck(nil); err == nil {
+		t.Error("Expected unhealthy once the gap outlasts the grace period")
+	}
+}
+
+// TestSigningKeyHealthzCheck_Recovers tests that a usable key resets the gap
+func TestSigningKeyHealthzCheck_Recovers(t *testing.T) {
+	signer := jwt.NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	runnable := NewHTTPServerRunnable(createTestHTTPServer(), logr.Discard(), nil, signer, "test-secret", "test-namespace",
+		WithSigningKeyHealthGracePeriod(time.Minute))
+	runnable.keysLoaded.Store(true)
+	runnable.signingKeyMissingSince.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	if err := runnable.SigningKeyHealthzCheck(nil); err == nil {
+		t.Fatal("Expected unhealthy while the signer holds no keys")
+	}
+
+	if err := signer.UpdateKeys(map[string][]byte{"1000": []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long")}, "1000"); err != nil {
+		t.Fatalf("Failed to load keys: %v", err)
+	}
+	if err := runnable.SigningKeyHealthzCheck(nil); err != nil {
+		t.Errorf("Expected healthy once a key is usable, got: %v", err)
+	}
+	if since := runnable.signingKeyMissingSince.Load(); since != 0 {
+		t.Errorf("Expected the gap to be reset, got %d", since)
+	}
+}
+
+// TestSigningKeyHealthzCheck_NoSigner tests that liveness does not depend on keys without a signer
+func TestSigningKeyHealthzCheck_NoSigner(t *testing.T) {
+	runnable := NewHTTPServerRunnable(createTestHTTPServer(), logr.Discard(), nil, nil, "", "")
+	runnable.keysLoaded.Store(true)
+
+	if err := runnable.SigningKeyHealthzCheck(nil); err != nil {
+		t.Errorf("Expected healthy without a signer, got: %v", err)
+	}
+}
+
+// TestReadyzCheck_NoSigner tests that readiness does not wait when there are no keys to load
+func TestReadyzCheck_NoSigner(t *testing.T) {
+	runnable := NewHTTPServerRunnable(createTestHTTPServer(), logr.Discard(), nil, nil, "", "")
+
+	if err := runnable.ReadyzCheck(nil); err != nil {
+		t.Errorf("Expected ready without a signer, got: %v", err)
+	}
+}
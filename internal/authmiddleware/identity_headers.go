@@ -0,0 +1,53 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+// setIdentityHeaders adds the authenticated identity to a successful /verify response,
+// so the proxy can forward it upstream, including on WebSocket upgrades where the
+// workspace cannot read it from anywhere else. Header names come from the config;
+// empty claims are omitted.
+func (s *Server) setIdentityHeaders(w http.ResponseWriter, claims *jwt.Claims) {
+	setIdentityHeader(w, headerOrDefault(s.config.IdentityUserHeader, HeaderAuthUser), claims.User)
+	if len(claims.Groups) > 0 {
+		setIdentityHeader(w, headerOrDefault(s.config.IdentityGroupsHeader, HeaderAuthGroups), joinGroups(claims.Groups))
+	}
+	setIdentityHeader(w, headerOrDefault(s.config.IdentityUIDHeader, HeaderAuthUID), claims.UID)
+	for key, header := range s.config.IdentityExtraHeaders {
+		if values := claims.Extra[key]; len(values) > 0 {
+			setIdentityHeader(w, header, strings.Join(values, ","))
+		}
+	}
+}
+
+// setIdentityHeader sets header to value with control characters stripped, so a
+// crafted claim cannot inject further headers. Empty values are not set.
+func setIdentityHeader(w http.ResponseWriter, header string, value string) {
+	value = stripControlChars(value)
+	if value == "" {
+		return
+	}
+	w.Header().Set(header, value)
+}
+
+// joinGroups joins groups with commas, quoting names that contain one so that
+// splitGroups reads them back unchanged
+func joinGroups(groups []string) string {
+	quoted := make([]string, len(groups))
+	for i, group := range groups {
+		if strings.Contains(group, ",") {
+			group = `"` + group + `"`
+		}
+		quoted[i] = group
+	}
+	return strings.Join(quoted, ",")
+}
@@ -0,0 +1,74 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetIdentityHeaders(t *testing.T) {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+	server.config.IdentityExtraHeaders = map[string]string{"department": "X-Auth-Department"}
+
+	w := httptest.NewRecorder()
+	server.setIdentityHeaders(w, &jwt.Claims{
+		User:   "alice",
+		Groups: []string{"data-science", "admins", "ml"},
+		UID:    "uid-1",
+		Extra:  map[string][]string{"department": {"research", "eng"}, "cost-center": {"42"}},
+	})
+
+	assert.Equal(t, "alice", w.Header().Get(HeaderAuthUser))
+	assert.Equal(t, "data-science,admins,ml", w.Header().Get(HeaderAuthGroups))
+	assert.Equal(t, "uid-1", w.Header().Get(HeaderAuthUID))
+	assert.Equal(t, "research,eng", w.Header().Get("X-Auth-Department"))
+	assert.Len(t, w.Header(), 4, "unselected extra claims are not returned")
+}
+
+func TestSetIdentityHeaders_EmptyClaims(t *testing.T) {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+	server.config.IdentityExtraHeaders = map[string]string{"department": "X-Auth-Department"}
+
+	w := httptest.NewRecorder()
+	server.setIdentityHeaders(w, &jwt.Claims{User: "alice"})
+
+	assert.Equal(t, "alice", w.Header().Get(HeaderAuthUser))
+	for _, header := range []string{HeaderAuthGroups, HeaderAuthUID, "X-Auth-Department"} {
+		_, ok := w.Header()[http.CanonicalHeaderKey(header)]
+		assert.False(t, ok, "%s should not be set", header)
+	}
+}
+
+func TestSetIdentityHeaders_CustomNames(t *testing.T) {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+	server.config.IdentityUserHeader = "X-Auth-Request-User"
+	server.config.IdentityGroupsHeader = "X-Auth-Request-Groups"
+	server.config.IdentityUIDHeader = "X-Remote-Uid"
+
+	w := httptest.NewRecorder()
+	server.setIdentityHeaders(w, &jwt.Claims{User: "alice", Groups: []string{"g1", "g2"}, UID: "uid-1"})
+
+	assert.Equal(t, "alice", w.Header().Get("X-Auth-Request-User"))
+	assert.Equal(t, "g1,g2", w.Header().Get("X-Auth-Request-Groups"))
+	assert.Equal(t, "uid-1", w.Header().Get("X-Remote-Uid"))
+	assert.Empty(t, w.Header().Get(HeaderAuthUser))
+}
+
+func TestSetIdentityHeaders_StripsControlChars(t *testing.T) {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+
+	w := httptest.NewRecorder()
+	server.setIdentityHeaders(w, &jwt.Claims{User: "alice\r\nX-Admin: true", Groups: []string{"g1\n", "g2\x00\t"}})
+
+	assert.Equal(t, "aliceX-Admin: true", w.Header().Get(HeaderAuthUser))
+	assert.Equal(t, "g1,g2", w.Header().Get(HeaderAuthGroups))
+	assert.Empty(t, w.Header().Get("X-Admin"))
+}
@@ -6,7 +6,9 @@ Distributed under the terms of the MIT license
 package authmiddleware
 
 import (
+	"cmp"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 
@@ -14,28 +16,234 @@ import (
 )
 
 // NewJWTHandler creates a jwt.Handler based on the configured signing type
-// For standard signing, returns a StandardSigner that will be populated with keys on server start
-// Returns the handler and the StandardSigner (nil for KMS)
-func NewJWTHandler(cfg *Config, logger logr.Logger) (jwt.Handler, *jwt.StandardSigner, error) {
+// The returned signer loads its keys from the JWT secret; they are populated on server start
+// If revocations is not nil, the signer rejects tokens whose jti it holds
+// Returns the handler and the secret-backed signer
+func NewJWTHandler(
+	cfg *Config,
+	logger logr.Logger,
+	revocations jwt.RevocationStore,
+) (jwt.Handler, jwt.SecretBackedSigner, error) {
 	if cfg == nil {
 		return nil, nil, fmt.Errorf("config cannot be nil")
 	}
 
-	var signer jwt.Signer
-	var standardSigner *jwt.StandardSigner
+	var signer jwt.SecretBackedSigner
+	migration := issuerMigration(cfg)
+	fallback := claimFallback(cfg)
+	subjectMatch := subjectMatch(cfg)
+	retention := keyRetention(cfg)
+	audiences := jwtAudiences(cfg)
+	leeway := cmp.Or(cfg.JWTClockSkewLeeway, DefaultJwtClockSkewLeeway)
 
 	switch cfg.JWTSigningType {
 	case JWTSigningTypeStandard, "":
+		algorithm := cmp.Or(cfg.JWTAlgorithm, DefaultJwtAlgorithm)
+		if err := jwt.ValidateHMACAlgorithm(algorithm); err != nil {
+			return nil, nil, fmt.Errorf("failed to create standard signer: %w", err)
+		}
+
+		opts := []jwt.StandardSignerOption{
+			jwt.WithLogger(logger),
+			jwt.WithAlgorithm(algorithm),
+			jwt.WithRevocationStore(revocations),
+			jwt.WithScope(cfg.JWTScope),
+			jwt.WithKeyPrefixes(cfg.JWTKeyPrefixes...),
+			jwt.WithRejectWeakKeys(cfg.JWTRejectWeakKeys),
+			jwt.WithForcedSigningKid(cfg.ForceSigningKid),
+			jwt.WithAdditionalSecrets(cfg.JWTAdditionalSecretNames...),
+			jwt.WithKeyEncoding(cmp.Or(cfg.JWTKeyEncoding, DefaultJwtKeyEncoding)),
+			jwt.WithMaxKeyRetention(cfg.JWTMaxKeyRetention),
+			jwt.WithAdditionalAudiences(audiences[1:]...),
+			jwt.WithLeeway(leeway),
+		}
+		if migration != nil {
+			opts = append(opts, jwt.WithIssuerMigration(*migration))
+		}
+		if fallback != nil {
+			opts = append(opts, jwt.WithClaimFallback(*fallback))
+		}
+		if subjectMatch != nil {
+			opts = append(opts, jwt.WithSubjectMatch(*subjectMatch))
+		}
+		if retention != nil {
+			opts = append(opts, jwt.WithKeyRetention(*retention))
+		}
+
 		// Create StandardSigner without initial keys
 		// Keys will be loaded when the HTTP server starts
-		standardSigner = jwt.NewStandardSigner(cfg.JWTIssuer, cfg.JWTAudience, cfg.JWTExpiration, cfg.JwtNewKeyUseDelay)
-		signer = standardSigner
+		signer = jwt.NewStandardSigner(
+			cfg.JWTIssuer,
+			audiences[0],
+			cfg.JWTExpiration,
+			cfg.JwtNewKeyUseDelay,
+			opts...,
+		)
+		warnOnZeroCooloff(cfg, logger)
+
+		logger.Info("Created StandardSigner for JWT signing",
+			"secretName", cfg.JwtSecretName,
+			"algorithm", algorithm)
+
+	case JWTSigningTypeAsymmetric:
+		opts := []jwt.AsymmetricSignerOption{
+			jwt.WithAsymmetricLogger(logger),
+			jwt.WithAsymmetricRevocationStore(revocations),
+			jwt.WithAsymmetricScope(cfg.JWTScope),
+			jwt.WithAsymmetricKeyPrefixes(cfg.JWTKeyPrefixes...),
+			jwt.WithAsymmetricAdditionalAudiences(audiences[1:]...),
+			jwt.WithAsymmetricLeeway(leeway),
+		}
+		if migration != nil {
+			opts = append(opts, jwt.WithAsymmetricIssuerMigration(*migration))
+		}
+		if fallback != nil {
+			opts = append(opts, jwt.WithAsymmetricClaimFallback(*fallback))
+		}
+		if subjectMatch != nil {
+			opts = append(opts, jwt.WithAsymmetricSubjectMatch(*subjectMatch))
+		}
+		if retention != nil {
+			opts = append(opts, jwt.WithAsymmetricKeyRetention(*retention))
+		}
+
+		asymmetricSigner, err := jwt.NewAsymmetricSigner(
+			cmp.Or(cfg.JWTAlgorithm, DefaultJwtAsymmetricAlgorithm),
+			cfg.JWTIssuer,
+			audiences[0],
+			cfg.JWTExpiration,
+			cfg.JwtNewKeyUseDelay,
+			opts...,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create asymmetric signer: %w", err)
+		}
+		signer = asymmetricSigner
 
-		logger.Info("Created StandardSigner for JWT signing", "secretName", cfg.JwtSecretName)
+		logger.Info("Created AsymmetricSigner for JWT signing",
+			"secretName", cfg.JwtSecretName,
+			"algorithm", asymmetricSigner.Algorithm())
+
+	case JWTSigningTypeJWKS:
+		// Verify-only: tokens come from an external issuer, so the signing options do not apply
+		signer = jwt.NewJWKSVerifier(
+			cfg.JWTJWKSURL,
+			cfg.JWTIssuer,
+			audiences[0],
+			jwt.WithJWKSLogger(logger),
+			jwt.WithJWKSAdditionalAudiences(audiences[1:]...),
+			jwt.WithJWKSLeeway(leeway),
+			jwt.WithJWKSRefreshInterval(cfg.JWTJWKSRefreshInterval),
+		)
+
+		logger.Info("Created JWKSVerifier for verify-only JWT validation",
+			"jwksURL", cfg.JWTJWKSURL,
+			"refreshInterval", cfg.JWTJWKSRefreshInterval.String())
 
 	default:
 		return nil, nil, fmt.Errorf("unsupported JWT signing type %q", cfg.JWTSigningType)
 	}
 
-	return jwt.NewManager(signer, cfg.JWTRefreshEnable, cfg.JWTRefreshWindow, cfg.JWTRefreshHorizon), standardSigner, nil
+	if migration != nil {
+		logger.Info("Accepting previous JWT issuer and audience during migration",
+			"previousIssuer", migration.PreviousIssuer,
+			"previousAudience", migration.PreviousAudience,
+			"until", migration.End().UTC().Format(time.RFC3339))
+	}
+
+	if fallback != nil {
+		logger.Info("Accepting JWTs without an issuer or audience using fallback values",
+			"fallbackIssuer", fallback.Issuer,
+			"fallbackAudience", fallback.Audience)
+	}
+
+	var managerOpts []jwt.ManagerOption
+	if cfg.JWTValidationCacheEnable {
+		managerOpts = append(managerOpts, jwt.WithValidationCache(cfg.JWTValidationCacheSize, revocations))
+		logger.Info("Caching validated JWTs", "maxEntries", cfg.JWTValidationCacheSize)
+	}
+	if cfg.JWTMaxGroups > 0 || cfg.JWTMaxClaimBytes > 0 {
+		managerOpts = append(managerOpts, jwt.WithClaimLimits(jwt.ClaimLimits{
+			MaxGroups:     cfg.JWTMaxGroups,
+			MaxClaimBytes: cfg.JWTMaxClaimBytes,
+			Truncate:      cfg.JWTClaimLimitTruncate,
+		}, logger))
+		logger.Info("Limiting JWT claims",
+			"maxGroups", cfg.JWTMaxGroups,
+			"maxClaimBytes", cfg.JWTMaxClaimBytes,
+			"truncate", cfg.JWTClaimLimitTruncate)
+	}
+
+	return jwt.NewManager(signer, cfg.JWTRefreshEnable, cfg.JWTRefreshWindow, cfg.JWTRefreshHorizon, managerOpts...),
+		signer, nil
+}
+
+// jwtAudiences returns the configured audiences, falling back to the single JWTAudience
+func jwtAudiences(cfg *Config) []string {
+	if len(cfg.JWTAudiences) > 0 {
+		return cfg.JWTAudiences
+	}
+	return []string{cfg.JWTAudience}
+}
+
+// issuerMigration returns the configured issuer/audience migration, or nil when none is set
+func issuerMigration(cfg *Config) *jwt.IssuerMigration {
+	if cfg.JWTPreviousIssuer == "" && cfg.JWTPreviousAudience == "" {
+		return nil
+	}
+	return &jwt.IssuerMigration{
+		PreviousIssuer:   cfg.JWTPreviousIssuer,
+		PreviousAudience: cfg.JWTPreviousAudience,
+		Start:            cfg.JWTIssuerMigrationStart,
+		Window:           cfg.JWTIssuerMigrationWindow,
+	}
+}
+
+// claimFallback returns the configured fallback issuer/audience, or nil when none is set
+func claimFallback(cfg *Config) *jwt.ClaimFallback {
+	if cfg.JWTFallbackIssuer == "" && cfg.JWTFallbackAudience == "" {
+		return nil
+	}
+	return &jwt.ClaimFallback{
+		Issuer:   cfg.JWTFallbackIssuer,
+		Audience: cfg.JWTFallbackAudience,
+	}
+}
+
+// subjectMatch returns the configured subject/user enforcement, or nil when it is off
+func subjectMatch(cfg *Config) *jwt.SubjectMatch {
+	switch cfg.JWTSubjectMatch {
+	case JWTSubjectMatchWhenPresent:
+		return &jwt.SubjectMatch{}
+	case JWTSubjectMatchRequired:
+		return &jwt.SubjectMatch{RequireSubject: true}
+	}
+	return nil
+}
+
+// keyRetention returns the configured key retention, or nil when the check is disabled
+func keyRetention(cfg *Config) *jwt.KeyRetention {
+	if cfg.JWTKeyRotationInterval <= 0 || cfg.JWTKeyRetentionCount <= 0 {
+		return nil
+	}
+	return &jwt.KeyRetention{
+		RotationInterval: cfg.JWTKeyRotationInterval,
+		NumberOfKeys:     cfg.JWTKeyRetentionCount,
+	}
+}
+
+// warnOnZeroCooloff flags a zero new key use delay with standard signing.
+// With several replicas, a freshly rotated key may be used for signing before
+// every pod has loaded it, so validation fails transiently on the others.
+// Single-replica setups may use zero on purpose, so this only warns.
+func warnOnZeroCooloff(cfg *Config, logger logr.Logger) {
+	if cfg.JwtNewKeyUseDelay > 0 {
+		zeroCooloffGauge.Set(0)
+		return
+	}
+	zeroCooloffGauge.Set(1)
+	logger.Error(fmt.Errorf("%s is zero", EnvJwtNewKeyUseDelay),
+		"WARNING: new signing keys will be used immediately after rotation; "+
+			"multi-replica deployments may fail to validate tokens until every pod loads the new key",
+		"newKeyUseDelay", cfg.JwtNewKeyUseDelay.String())
 }
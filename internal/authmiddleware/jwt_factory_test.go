@@ -6,11 +6,16 @@ Distributed under the terms of the MIT license
 package authmiddleware
 
 import (
+	"strings"
 	"time"
 
+	"github.com/go-logr/logr/funcr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
 )
 
 var _ = Describe("NewJWTHandler", func() {
@@ -36,7 +41,7 @@ var _ = Describe("NewJWTHandler", func() {
 
 	Context("Standard Signing Type", func() {
 		It("Should create JWT handler and StandardSigner", func() {
-			handler, standardSigner, err := NewJWTHandler(cfg, logger)
+			handler, standardSigner, err := NewJWTHandler(cfg, logger, nil)
 
 			Expect(err).NotTo(HaveOccurred())
 			Expect(handler).NotTo(BeNil())
@@ -45,9 +50,152 @@ var _ = Describe("NewJWTHandler", func() {
 
 	})
 
+	Context("HMAC Algorithm", func() {
+		It("Should use the configured HMAC algorithm", func() {
+			cfg.JWTAlgorithm = jwt.AlgorithmHS512
+
+			_, signer, err := NewJWTHandler(cfg, logger, nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signer.(*jwt.StandardSigner).Algorithm()).To(Equal(jwt.AlgorithmHS512))
+		})
+
+		It("Should default to HS384", func() {
+			_, signer, err := NewJWTHandler(cfg, logger, nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signer.(*jwt.StandardSigner).Algorithm()).To(Equal(jwt.AlgorithmHS384))
+		})
+
+		It("Should return error for a non-HMAC algorithm", func() {
+			cfg.JWTAlgorithm = jwt.AlgorithmRS256
+
+			handler, signer, err := NewJWTHandler(cfg, logger, nil)
+
+			Expect(err).To(HaveOccurred())
+			Expect(handler).To(BeNil())
+			Expect(signer).To(BeNil())
+		})
+	})
+
+	Context("Asymmetric Signing Type", func() {
+		It("Should create an AsymmetricSigner that publishes its keys", func() {
+			cfg.JWTSigningType = JWTSigningTypeAsymmetric
+			cfg.JWTAlgorithm = jwt.AlgorithmES256
+
+			handler, signer, err := NewJWTHandler(cfg, logger, nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(handler).NotTo(BeNil())
+			Expect(signer).To(BeAssignableToTypeOf(&jwt.AsymmetricSigner{}))
+			Expect(signer).To(Satisfy(func(s jwt.SecretBackedSigner) bool {
+				_, ok := s.(jwt.KeySetPublisher)
+				return ok
+			}))
+		})
+
+		It("Should return error for an unsupported algorithm", func() {
+			cfg.JWTSigningType = JWTSigningTypeAsymmetric
+			cfg.JWTAlgorithm = "HS384"
+
+			handler, signer, err := NewJWTHandler(cfg, logger, nil)
+
+			Expect(err).To(HaveOccurred())
+			Expect(handler).To(BeNil())
+			Expect(signer).To(BeNil())
+		})
+	})
+
+	Context("JWKS Signing Type", func() {
+		It("Should create a verify-only JWKSVerifier", func() {
+			cfg.JWTSigningType = JWTSigningTypeJWKS
+			cfg.JWTJWKSURL = "https://idp.example.com/.well-known/jwks.json"
+
+			handler, signer, err := NewJWTHandler(cfg, logger, nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signer).To(BeAssignableToTypeOf(&jwt.JWKSVerifier{}))
+			_, err = handler.GenerateToken("user", nil, "", nil, "/path", "", jwt.TokenTypeSession)
+			Expect(err).To(MatchError(jwt.ErrSigningNotSupported))
+		})
+	})
+
+	Context("Audiences", func() {
+		const audienceTestKey = "test-signing-key-48-bytes-or-more-for-hs384-signing-long"
+
+		It("Should set every configured audience on issued tokens", func() {
+			cfg.JWTAudiences = []string{"notebooks", "kernels"}
+			cfg.JwtNewKeyUseDelay = 0
+
+			_, signer, err := NewJWTHandler(cfg, logger, nil)
+			Expect(err).NotTo(HaveOccurred())
+			standardSigner := signer.(*jwt.StandardSigner)
+			Expect(standardSigner.UpdateKeys(map[string][]byte{"1000": []byte(audienceTestKey)}, "1000")).To(Succeed())
+
+			token, err := standardSigner.GenerateToken("user", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+			Expect(err).NotTo(HaveOccurred())
+			claims, err := standardSigner.ValidateToken(token)
+			Expect(err).NotTo(HaveOccurred())
+			Expect([]string(claims.Audience)).To(Equal([]string{"notebooks", "kernels"}))
+		})
+
+		It("Should fall back to the single audience", func() {
+			Expect(jwtAudiences(cfg)).To(Equal([]string{"test-audience"}))
+		})
+	})
+
+	Context("Zero New Key Use Delay", func() {
+		var logs []string
+
+		newCapturingHandler := func() error {
+			logs = nil
+			l := funcr.New(func(prefix, args string) {
+				logs = append(logs, args)
+			}, funcr.Options{})
+			_, _, err := NewJWTHandler(cfg, l, nil)
+			return err
+		}
+
+		warned := func() bool {
+			for _, line := range logs {
+				if strings.Contains(line, "new signing keys will be used immediately") {
+					return true
+				}
+			}
+			return false
+		}
+
+		It("Should warn and set the gauge when the delay is zero with standard signing", func() {
+			cfg.JwtNewKeyUseDelay = 0
+
+			Expect(newCapturingHandler()).To(Succeed())
+			Expect(warned()).To(BeTrue())
+			Expect(testutil.ToFloat64(zeroCooloffGauge)).To(Equal(1.0))
+		})
+
+		It("Should not warn and should clear the gauge when the delay is positive", func() {
+			cfg.JwtNewKeyUseDelay = 0
+			Expect(newCapturingHandler()).To(Succeed())
+			Expect(testutil.ToFloat64(zeroCooloffGauge)).To(Equal(1.0))
+
+			cfg.JwtNewKeyUseDelay = 5 * time.Second
+			Expect(newCapturingHandler()).To(Succeed())
+			Expect(warned()).To(BeFalse())
+			Expect(testutil.ToFloat64(zeroCooloffGauge)).To(Equal(0.0))
+		})
+
+		It("Should not warn for unsupported signing types", func() {
+			cfg.JwtNewKeyUseDelay = 0
+			cfg.JWTSigningType = "unknown-type"
+
+			Expect(newCapturingHandler()).NotTo(Succeed())
+			Expect(warned()).To(BeFalse())
+		})
+	})
+
 	Context("Invalid Configuration", func() {
 		It("Should return error if config is nil", func() {
-			handler, standardSigner, err := NewJWTHandler(nil, logger)
+			handler, standardSigner, err := NewJWTHandler(nil, logger, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(Equal("config cannot be nil"))
@@ -58,7 +206,7 @@ var _ = Describe("NewJWTHandler", func() {
 		It("Should return error for unknown JWT signing type", func() {
 			cfg.JWTSigningType = "unknown-type"
 
-			handler, standardSigner, err := NewJWTHandler(cfg, logger)
+			handler, standardSigner, err := NewJWTHandler(cfg, logger, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("unsupported JWT signing type"))
@@ -69,7 +217,7 @@ var _ = Describe("NewJWTHandler", func() {
 		It("Should return error for kms JWT signing type with migration guidance", func() {
 			cfg.JWTSigningType = "kms"
 
-			handler, standardSigner, err := NewJWTHandler(cfg, logger)
+			handler, standardSigner, err := NewJWTHandler(cfg, logger, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("unsupported JWT signing type"))
@@ -0,0 +1,57 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+// keyRetentionSweepInterval is how often loaded keys are checked against the max key retention
+const keyRetentionSweepInterval = time.Minute
+
+// KeyRetentionSweeper periodically drops loaded keys past the signer's max key retention,
+// so a replica whose secret watch has stopped delivering events still retires old keys.
+type KeyRetentionSweeper struct {
+	signer   jwt.ExpiredKeyRemover
+	interval time.Duration
+	logger   logr.Logger
+}
+
+// NewKeyRetentionSweeper creates a KeyRetentionSweeper checking signer every keyRetentionSweepInterval
+func NewKeyRetentionSweeper(signer jwt.ExpiredKeyRemover, logger logr.Logger) *KeyRetentionSweeper {
+	return &KeyRetentionSweeper{
+		signer:   signer,
+		interval: keyRetentionSweepInterval,
+		logger:   logger,
+	}
+}
+
+// Start implements the Runnable interface. It sweeps every interval until the context
+// is cancelled; the signer logs the keys it removes.
+func (k *KeyRetentionSweeper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			k.signer.RemoveExpiredKeys()
+		}
+	}
+}
+
+// NeedLeaderElection implements the Runnable interface.
+// Returns false because every replica holds its own keys.
+func (k *KeyRetentionSweeper) NeedLeaderElection() bool {
+	return false
+}
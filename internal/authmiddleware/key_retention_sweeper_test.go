@@ -0,0 +1,52 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+func TestKeyRetentionSweeper_RemovesExpiredKeys(t *testing.T) {
+	var now atomic.Int64
+	now.Store(time.Unix(1000, 0).Unix())
+	signer := jwt.NewStandardSigner("issuer", "audience", time.Hour, 0,
+		jwt.WithMaxKeyRetention(time.Hour),
+		jwt.WithClock(func() time.Time { return time.Unix(now.Load(), 0) }))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("initial-key-48-bytes-or-more-for-hs384-signing-long"),
+	}, "1000"))
+	now.Add(int64(30 * time.Minute / time.Second))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("initial-key-48-bytes-or-more-for-hs384-signing-long"),
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
+	}, "2000"))
+	now.Add(int64(time.Hour / time.Second))
+
+	sweeper := NewKeyRetentionSweeper(signer, logr.Discard())
+	sweeper.interval = 10 * time.Millisecond
+	assert.False(t, sweeper.NeedLeaderElection())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- sweeper.Start(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		return len(signer.Snapshot().Kids) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"2000"}, signer.Snapshot().Kids)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
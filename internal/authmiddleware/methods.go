@@ -0,0 +1,59 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// knownHTTPMethods lists the methods accepted in method allowlists
+var knownHTTPMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions,
+}
+
+// allowMethods checks r.Method against allowed. When the method is not allowed it
+// writes a 405 response with an Allow header and returns false.
+func allowMethods(w http.ResponseWriter, r *http.Request, allowed ...string) bool {
+	if slices.Contains(allowed, r.Method) {
+		return true
+	}
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+// verifyAllowedMethods returns the configured /verify methods, defaulting to GET and HEAD
+func (s *Server) verifyAllowedMethods() []string {
+	if len(s.config.VerifyAllowedMethods) == 0 {
+		return []string{http.MethodGet, http.MethodHead}
+	}
+	return s.config.VerifyAllowedMethods
+}
+
+// parseHTTPMethods parses a comma-separated list of HTTP methods, normalized to upper case
+func parseHTTPMethods(value string) ([]string, error) {
+	methods := []string{}
+	for _, m := range splitAndTrim(value, ",") {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m == "" {
+			continue
+		}
+		if !slices.Contains(knownHTTPMethods, m) {
+			return nil, fmt.Errorf("unsupported HTTP method %q", m)
+		}
+		if !slices.Contains(methods, m) {
+			methods = append(methods, m)
+		}
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("at least one HTTP method is required")
+	}
+	return methods, nil
+}
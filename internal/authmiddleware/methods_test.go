@@ -0,0 +1,94 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoints_DisallowedMethodsReturn405WithAllow(t *testing.T) {
+	testCases := []struct {
+		name          string
+		method        string
+		verifyMethods []string
+		handler       func(*Server) http.HandlerFunc
+		expectedAllow string
+	}{
+		{
+			name:          "verify POST with default methods",
+			method:        http.MethodPost,
+			handler:       func(s *Server) http.HandlerFunc { return s.handleVerify },
+			expectedAllow: "GET, HEAD",
+		},
+		{
+			name:          "verify DELETE with configured methods",
+			method:        http.MethodDelete,
+			verifyMethods: []string{http.MethodGet, http.MethodPost},
+			handler:       func(s *Server) http.HandlerFunc { return s.handleVerify },
+			expectedAllow: "GET, POST",
+		},
+		{
+			name:          "auth PUT",
+			method:        http.MethodPut,
+			handler:       func(s *Server) http.HandlerFunc { return s.handleAuth },
+			expectedAllow: "GET",
+		},
+		{
+			name:          "bearer-auth POST",
+			method:        http.MethodPost,
+			handler:       func(s *Server) http.HandlerFunc { return s.handleBearerAuth },
+			expectedAllow: "GET",
+		},
+		{
+			name:          "health POST",
+			method:        http.MethodPost,
+			handler:       func(s *Server) http.HandlerFunc { return s.handleHealth },
+			expectedAllow: "GET, HEAD",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := createTestServer(nil)
+			server.config.VerifyAllowedMethods = tc.verifyMethods
+
+			w := httptest.NewRecorder()
+			tc.handler(server)(w, httptest.NewRequest(tc.method, "/", nil))
+
+			assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+			assert.Equal(t, tc.expectedAllow, w.Header().Get("Allow"))
+		})
+	}
+}
+
+func TestHandleVerify_ConfiguredMethodAllowed(t *testing.T) {
+	server := createTestServer(nil)
+	server.config.VerifyAllowedMethods = []string{http.MethodGet, http.MethodPost}
+
+	w := httptest.NewRecorder()
+	server.handleVerify(w, httptest.NewRequest(http.MethodPost, "/verify", nil))
+
+	// Passes the method check and fails on the missing forwarded headers instead
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Header().Get("Allow"))
+}
+
+func TestParseHTTPMethods(t *testing.T) {
+	methods, err := parseHTTPMethods(" get, post ,GET")
+	require.NoError(t, err)
+	assert.Equal(t, []string{http.MethodGet, http.MethodPost}, methods)
+
+	_, err = parseHTTPMethods("GET,FETCH")
+	assert.Error(t, err)
+
+	_, err = parseHTTPMethods(" , ")
+	assert.Error(t, err)
+}
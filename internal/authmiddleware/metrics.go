@@ -0,0 +1,47 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Cookie operation label values
+const (
+	cookieOpSet          = "set"
+	cookieOpClear        = "clear"
+	cookieOpParseFailure = "parse_failure"
+	cookieOpSetRejected  = "set_rejected"
+)
+
+var (
+	// cookieOperationsTotal counts cookie operations performed by CookieManager
+	cookieOperationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "authmiddleware_cookie_operations_total",
+		Help: "Number of auth cookie operations, labeled by operation",
+	}, []string{"operation"})
+
+	// rateLimitedTotal counts requests rejected by the per-client rate limiter
+	rateLimitedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "authmiddleware_rate_limited_requests_total",
+		Help: "Number of auth requests rejected with 429 by the per-client rate limiter, labeled by route",
+	}, []string{"route"})
+
+	// zeroCooloffGauge is 1 when standard signing runs with a zero new-key-use delay
+	zeroCooloffGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jwt_zero_cooloff",
+		Help: "Set to 1 when standard JWT signing is configured with a zero new key use delay",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		cookieOperationsTotal,
+		rateLimitedTotal,
+		zeroCooloffGauge,
+	)
+}
@@ -54,6 +54,19 @@ func (m *MockJWTHandler) GenerateToken(
 	return "mock-token", nil
 }
 
+// GenerateTokenContext calls the GenerateToken mock implementation
+func (m *MockJWTHandler) GenerateTokenContext(
+	ctx context.Context,
+	user string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string) (string, error) {
+	return m.GenerateToken(user, groups, uid, extra, path, domain, tokenType)
+}
+
 // ValidateToken calls the mock implementation
 func (m *MockJWTHandler) ValidateToken(tokenString string) (*jwt.Claims, error) {
 	if m.ValidateTokenFunc != nil {
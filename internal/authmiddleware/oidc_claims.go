@@ -0,0 +1,133 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Standard OIDC claim names, used unless the operator maps identity to other claims
+const (
+	OIDCClaimPreferredUsername = "preferred_username"
+	OIDCClaimGroups            = "groups"
+	OIDCClaimSubject           = "sub"
+	OIDCClaimEmail             = "email"
+)
+
+// OIDCClaimMapping names the ID token claims that carry the user, groups and UID
+type OIDCClaimMapping struct {
+	UsernameClaim string
+	GroupsClaim   string
+	UIDClaim      string
+}
+
+// oidcClaimMappingFromConfig returns the claim mapping of config, falling back to the
+// standard claim names for unset fields
+func oidcClaimMappingFromConfig(config *Config) OIDCClaimMapping {
+	mapping := OIDCClaimMapping{
+		UsernameClaim: config.OidcUsernameClaim,
+		GroupsClaim:   config.OidcGroupsClaim,
+		UIDClaim:      config.OidcUIDClaim,
+	}
+	if mapping.UsernameClaim == "" {
+		mapping.UsernameClaim = OIDCClaimPreferredUsername
+	}
+	if mapping.GroupsClaim == "" {
+		mapping.GroupsClaim = OIDCClaimGroups
+	}
+	if mapping.UIDClaim == "" {
+		mapping.UIDClaim = OIDCClaimSubject
+	}
+	return mapping
+}
+
+// mapClaims builds OIDCClaims from the raw claims of a verified ID token. A missing
+// claim leaves its field empty; a claim of the wrong type is an error.
+func (m OIDCClaimMapping) mapClaims(raw map[string]any) (*OIDCClaims, error) {
+	username, err := stringClaim(raw, m.UsernameClaim)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := stringClaim(raw, m.UIDClaim)
+	if err != nil {
+		return nil, err
+	}
+	email, err := stringClaim(raw, OIDCClaimEmail)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := stringListClaim(raw, m.GroupsClaim)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCClaims{
+		Username: username,
+		Email:    email,
+		Groups:   groups,
+		Subject:  uid,
+	}, nil
+}
+
+// lookupClaim returns the claim called name. A name not found as a top-level claim is
+// read as a dot-separated path into nested objects, e.g. realm_access.roles.
+func lookupClaim(raw map[string]any, name string) (any, bool) {
+	if value, ok := raw[name]; ok {
+		return value, true
+	}
+	if !strings.Contains(name, ".") {
+		return nil, false
+	}
+	var current any = raw
+	for _, part := range strings.Split(name, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// stringClaim returns the string claim called name, or "" when it is absent
+func stringClaim(raw map[string]any, name string) (string, error) {
+	value, ok := lookupClaim(raw, name)
+	if !ok || value == nil {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("claim %q must be a string, got %T", name, value)
+	}
+	return s, nil
+}
+
+// stringListClaim returns the claim called name as a list of strings. A single string
+// is read as a one-element list, as some providers do for users in one group.
+func stringListClaim(raw map[string]any, name string) ([]string, error) {
+	value, ok := lookupClaim(raw, name)
+	if !ok || value == nil {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("claim %q must contain only strings, got %T", name, item)
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("claim %q must be a string or a list of strings, got %T", name, value)
+	}
+}
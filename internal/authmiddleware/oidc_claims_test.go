@@ -0,0 +1,139 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"log/slog"
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCClaimMapping_MapClaims(t *testing.T) {
+	raw := map[string]any{
+		"sub":                        "user-1",
+		"oid":                        "object-1",
+		"preferred_username":         "alice",
+		"email":                      "alice@example.com",
+		"upn":                        "alice@corp.example.com",
+		"groups":                     []any{"g1"},
+		"roles":                      []any{"admin", "dev"},
+		"realm_access":               map[string]any{"roles": []any{"realm-admin"}},
+		"https://example.com/groups": []any{"namespaced"},
+		"team":                       "single",
+	}
+
+	tests := []struct {
+		name     string
+		mapping  OIDCClaimMapping
+		username string
+		uid      string
+		groups   []string
+	}{
+		{
+			name:     "standard claims",
+			mapping:  oidcClaimMappingFromConfig(&Config{}),
+			username: "alice", uid: "user-1", groups: []string{"g1"},
+		},
+		{
+			name:     "non-standard claims",
+			mapping:  OIDCClaimMapping{UsernameClaim: "upn", GroupsClaim: "roles", UIDClaim: "oid"},
+			username: "alice@corp.example.com", uid: "object-1", groups: []string{"admin", "dev"},
+		},
+		{
+			name:     "nested groups claim",
+			mapping:  OIDCClaimMapping{UsernameClaim: "email", GroupsClaim: "realm_access.roles", UIDClaim: "sub"},
+			username: "alice@example.com", uid: "user-1", groups: []string{"realm-admin"},
+		},
+		{
+			name:     "claim name containing dots",
+			mapping:  OIDCClaimMapping{UsernameClaim: "preferred_username", GroupsClaim: "https://example.com/groups", UIDClaim: "sub"},
+			username: "alice", uid: "user-1", groups: []string{"namespaced"},
+		},
+		{
+			name:     "single string group",
+			mapping:  OIDCClaimMapping{UsernameClaim: "preferred_username", GroupsClaim: "team", UIDClaim: "sub"},
+			username: "alice", uid: "user-1", groups: []string{"single"},
+		},
+		{
+			name:     "missing claims",
+			mapping:  OIDCClaimMapping{UsernameClaim: "nickname", GroupsClaim: "realm_access.missing", UIDClaim: "sub"},
+			username: "", uid: "user-1", groups: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.mapping.mapClaims(raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.username, claims.Username)
+			assert.Equal(t, tt.uid, claims.Subject)
+			assert.Equal(t, tt.groups, claims.Groups)
+			assert.Equal(t, "alice@example.com", claims.Email)
+		})
+	}
+}
+
+func TestOIDCClaimMapping_WrongClaimType(t *testing.T) {
+	mapping := oidcClaimMappingFromConfig(&Config{})
+
+	_, err := mapping.mapClaims(map[string]any{"sub": "user-1", "preferred_username": 42.0})
+	assert.Error(t, err)
+
+	_, err = mapping.mapClaims(map[string]any{"sub": "user-1", "groups": []any{"g1", 42.0}})
+	assert.Error(t, err)
+
+	_, err = mapping.mapClaims(map[string]any{"sub": "user-1", "groups": map[string]any{"g1": true}})
+	assert.Error(t, err)
+}
+
+// TestVerifyToken_NonStandardGroupsClaim tests that a provider putting groups under a
+// nested claim is mapped when the token is verified
+func TestVerifyToken_NonStandardGroupsClaim(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server, _ := newPinnedIssuerServer(t, key)
+
+	verifier, err := NewOIDCVerifier(&Config{
+		OIDCIssuerURL:       server.URL,
+		OIDCClientID:        "test-client",
+		OIDCInitTimeoutSecs: 5,
+		OidcUsernameClaim:   "email",
+		OidcGroupsClaim:     "realm_access.roles",
+	}, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, verifier.Start(context.Background()))
+
+	token := jwt5.NewWithClaims(jwt5.SigningMethodRS256, jwt5.MapClaims{
+		"iss":          server.URL,
+		"aud":          "test-client",
+		"sub":          "user-1",
+		"email":        "alice@example.com",
+		"realm_access": map[string]any{"roles": []string{"data-science", "admins"}},
+		"exp":          time.Now().Add(time.Hour).Unix(),
+		"iat":          time.Now().Unix(),
+	})
+	token.Header["kid"] = "test-kid"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	claims, isFault, err := verifier.VerifyToken(context.Background(), signed, slog.Default())
+	require.NoError(t, err)
+	assert.False(t, isFault)
+	assert.Equal(t, "alice@example.com", claims.Username)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, []string{"data-science", "admins"}, claims.Groups)
+
+	config := &Config{OidcUsernamePrefix: "oidc:", OidcGroupsPrefix: "oidc:"}
+	assert.Equal(t, "oidc:alice@example.com", GetOIDCUsernameFromToken(config, claims))
+	assert.Equal(t, []string{"oidc:data-science", "oidc:admins"}, GetOIDCGroupsFromToken(config, claims))
+}
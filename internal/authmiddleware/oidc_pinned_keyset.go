@@ -0,0 +1,169 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
+)
+
+const (
+	// maxKeySetBytes bounds the size of a fetched OIDC key set
+	maxKeySetBytes = 1 << 20
+
+	// pinnedKeySetMinRefetchInterval limits how often an unknown kid triggers a key set fetch
+	pinnedKeySetMinRefetchInterval = 30 * time.Second
+)
+
+// ErrUnpinnedSigningKey is returned when no pinned key verifies an OIDC token signature
+var ErrUnpinnedSigningKey = errors.New("OIDC token is not signed by a pinned key")
+
+// pinnedKeyAlgorithms lists the signature algorithms accepted when parsing OIDC tokens;
+// the verifier further restricts them to the algorithms advertised by the issuer
+var pinnedKeyAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// pinnedKeySet is an oidc.KeySet that only trusts the keys of the issuer key set whose
+// RFC 7638 SHA-256 thumbprint is pinned. Keys are fetched on first use and fetched again,
+// at most once per pinnedKeySetMinRefetchInterval, when a token names an unknown kid.
+type pinnedKeySet struct {
+	jwksURL     string
+	thumbprints map[string]bool
+	httpClient  *http.Client
+	logger      *slog.Logger
+	now         func() time.Time
+
+	mu        sync.Mutex
+	keys      []jose.JSONWebKey
+	lastFetch time.Time
+}
+
+// newPinnedKeySet creates a key set trusting only the keys with the given thumbprints
+func newPinnedKeySet(jwksURL string, thumbprints []string, logger *slog.Logger) *pinnedKeySet {
+	pinned := make(map[string]bool, len(thumbprints))
+	for _, thumbprint := range thumbprints {
+		pinned[thumbprint] = true
+	}
+	return &pinnedKeySet{
+		jwksURL:     jwksURL,
+		thumbprints: pinned,
+		httpClient:  http.DefaultClient,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// VerifySignature verifies the token signature with a pinned key and returns its payload
+func (k *pinnedKeySet) VerifySignature(ctx context.Context, token string) ([]byte, error) {
+	jws, err := jose.ParseSigned(token, pinnedKeyAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt: %w", err)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, fmt.Errorf("malformed jwt: expected one signature, got %d", len(jws.Signatures))
+	}
+	kid := jws.Signatures[0].Header.KeyID
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if payload, found := verifyWithKeys(jws, kid, k.keys); found {
+		return payload, nil
+	}
+	if !k.lastFetch.IsZero() && k.now().Sub(k.lastFetch) < pinnedKeySetMinRefetchInterval {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnpinnedSigningKey, kid)
+	}
+	if err := k.fetchLocked(ctx); err != nil {
+		return nil, err
+	}
+	if payload, found := verifyWithKeys(jws, kid, k.keys); found {
+		return payload, nil
+	}
+	return nil, fmt.Errorf("%w: kid %q", ErrUnpinnedSigningKey, kid)
+}
+
+// verifyWithKeys tries each key matching kid, or every key when the token has no kid
+func verifyWithKeys(jws *jose.JSONWebSignature, kid string, keys []jose.JSONWebKey) ([]byte, bool) {
+	for i := range keys {
+		if kid != "" && keys[i].KeyID != kid {
+			continue
+		}
+		if payload, err := jws.Verify(&keys[i]); err == nil {
+			return payload, true
+		}
+	}
+	return nil, false
+}
+
+// fetchLocked fetches the issuer key set and keeps the pinned public keys.
+// Caller must hold k.mu.
+func (k *pinnedKeySet) fetchLocked(ctx context.Context) error {
+	k.lastFetch = k.now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.jwksURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create key set request: %w", err)
+	}
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch key set: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch key set: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxKeySetBytes))
+	if err != nil {
+		return fmt.Errorf("unable to read key set: %w", err)
+	}
+
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal(body, &keySet); err != nil {
+		return fmt.Errorf("failed to decode key set: %w", err)
+	}
+
+	keys := make([]jose.JSONWebKey, 0, len(keySet.Keys))
+	for _, key := range keySet.Keys {
+		public := key.Public()
+		if !public.Valid() {
+			continue
+		}
+		sum, err := public.Thumbprint(crypto.SHA256)
+		if err != nil {
+			continue
+		}
+		thumbprint := base64.RawURLEncoding.EncodeToString(sum)
+		if !k.thumbprints[thumbprint] {
+			if k.logger != nil {
+				k.logger.Warn("Security: ignoring unpinned key from OIDC key set",
+					"event", "oidc_unpinned_key",
+					"jwks_uri", k.jwksURL,
+					"kid", key.KeyID,
+					"thumbprint", thumbprint)
+			}
+			continue
+		}
+		keys = append(keys, public)
+	}
+	k.keys = keys
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPinnedKeySet_RefetchIsThrottled tests that unknown kids refetch the key set at most
+// once per interval, and that a pinned key published later is picked up
+func TestPinnedKeySet_RefetchIsThrottled(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches, published atomic.Int32
+	published.Store(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		keys := []*rsa.PrivateKey{oldKey, newKey}
+		_, _ = w.Write(pinnedTestJWKS(keys[:published.Load()]...))
+	}))
+	t.Cleanup(server.Close)
+
+	now := time.Now()
+	keySet := newPinnedKeySet(server.URL, []string{thumbprintOf(t, oldKey), thumbprintOf(t, newKey)}, nil)
+	keySet.now = func() time.Time { return now }
+
+	_, err = keySet.VerifySignature(context.Background(), signPinnedTestToken(t, "issuer", "test-kid", oldKey))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// The new key is published, but an unknown kid within the interval does not refetch
+	published.Store(2)
+	newToken := signPinnedTestToken(t, "issuer", pinnedTestKid(1), newKey)
+	_, err = keySet.VerifySignature(context.Background(), newToken)
+	assert.ErrorIs(t, err, ErrUnpinnedSigningKey)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	now = now.Add(pinnedKeySetMinRefetchInterval)
+	_, err = keySet.VerifySignature(context.Background(), newToken)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
@@ -16,7 +16,9 @@ import (
 	"github.com/coreos/go-oidc/v3/oidc"
 )
 
-// No constants needed here
+// ErrDiscoveryPinMismatch is returned when the issuer discovery document
+// does not match the pinned values
+var ErrDiscoveryPinMismatch = errors.New("OIDC discovery document does not match pinned values")
 
 // OIDCVerifierInterface defines the interface for OIDC token verification
 type OIDCVerifierInterface interface {
@@ -38,6 +40,10 @@ type OIDCVerifier struct {
 	logger         *slog.Logger
 	timeoutSeconds int // Timeout for OIDC provider initialization
 	oidcConfig     *oidc.Config
+	claimMapping   OIDCClaimMapping
+
+	pinnedJWKSURI     string   // Expected discovery jwks_uri, empty when not pinned
+	pinnedThumbprints []string // Trusted key thumbprints, empty when keys are not pinned
 }
 
 // OIDCClaims represents the claims we extract from an OIDC ID token
@@ -86,6 +92,10 @@ func NewOIDCVerifier(config *Config, logger *slog.Logger) (*OIDCVerifier, error)
 		logger:         logger,
 		timeoutSeconds: config.OIDCInitTimeoutSecs,
 		oidcConfig:     oidcConfig,
+		claimMapping:   oidcClaimMappingFromConfig(config),
+
+		pinnedJWKSURI:     config.OIDCPinnedJWKSURI,
+		pinnedThumbprints: config.OIDCPinnedKeyThumbprints,
 	}, nil
 }
 
@@ -114,6 +124,10 @@ func (v *OIDCVerifier) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize OIDC provider: %w", err)
 	}
+	verifier, err := v.newPinnedVerifier(provider)
+	if err != nil {
+		return fmt.Errorf("failed to initialize OIDC provider: %w", err)
+	}
 	v.provider = provider
 	if v.logger != nil {
 		v.logger.Info("OIDC provider is ready")
@@ -124,13 +138,48 @@ func (v *OIDCVerifier) Start(ctx context.Context) error {
 			"issuer URL", v.issuerURL,
 			"client ID", v.clientID)
 	}
-	v.verifier = provider.Verifier(v.oidcConfig)
+	v.verifier = verifier
 	if v.logger != nil {
 		v.logger.Info("Token verifier is ready")
 	}
 	return nil
 }
 
+// newPinnedVerifier checks the discovered keys endpoint against the pinned one and, when key
+// thumbprints are pinned, returns a verifier trusting only those keys, so a compromised or
+// misdirected issuer cannot introduce signing keys.
+func (v *OIDCVerifier) newPinnedVerifier(provider *oidc.Provider) (*oidc.IDTokenVerifier, error) {
+	var discovery struct {
+		JWKSURL    string   `json:"jwks_uri"`
+		Algorithms []string `json:"id_token_signing_alg_values_supported"`
+	}
+	if err := provider.Claims(&discovery); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery document: %w", err)
+	}
+
+	if v.pinnedJWKSURI != "" && discovery.JWKSURL != v.pinnedJWKSURI {
+		if v.logger != nil {
+			v.logger.Error("Security: OIDC discovery document does not match pinned jwks_uri",
+				"event", "oidc_discovery_pin_mismatch",
+				"issuer", v.issuerURL,
+				"expected", v.pinnedJWKSURI,
+				"actual", discovery.JWKSURL)
+		}
+		return nil, fmt.Errorf("%w: jwks_uri expected %q, got %q",
+			ErrDiscoveryPinMismatch, v.pinnedJWKSURI, discovery.JWKSURL)
+	}
+
+	if len(v.pinnedThumbprints) == 0 {
+		return provider.Verifier(v.oidcConfig), nil
+	}
+	oidcConfig := *v.oidcConfig
+	if len(oidcConfig.SupportedSigningAlgs) == 0 {
+		oidcConfig.SupportedSigningAlgs = discovery.Algorithms
+	}
+	keySet := newPinnedKeySet(discovery.JWKSURL, v.pinnedThumbprints, v.logger)
+	return oidc.NewVerifier(v.issuerURL, keySet, &oidcConfig), nil
+}
+
 // VerifyToken verifies an OIDC token and returns Claims, isFault, error.
 // It may call the provider to refresh the public keySet if not cached
 func (v *OIDCVerifier) VerifyToken(ctx context.Context, tokenString string, logger *slog.Logger) (*OIDCClaims, bool, error) {
@@ -154,7 +203,7 @@ func (v *OIDCVerifier) VerifyToken(ctx context.Context, tokenString string, logg
 	}
 
 	// Extract claims from the token
-	var claims OIDCClaims
+	var rawClaims map[string]any
 
 	// Log the response from Dex for debugging
 	logger.Info("Received verified token from Dex",
@@ -164,9 +213,13 @@ func (v *OIDCVerifier) VerifyToken(ctx context.Context, tokenString string, logg
 		"expiration", idToken.Expiry,
 		"issued_at", idToken.IssuedAt)
 
-	if err := idToken.Claims(&claims); err != nil {
+	if err := idToken.Claims(&rawClaims); err != nil {
 		return nil, false, fmt.Errorf("failed to parse claims: %w", err)
 	}
+	claims, err := v.claimMapping.mapClaims(rawClaims)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to map claims: %w", err)
+	}
 
 	// Log detailed claims information to help verify correct parsing in production
 	// This is especially useful when integrating with Dex using GitHub connector
@@ -202,7 +255,7 @@ func (v *OIDCVerifier) VerifyToken(ctx context.Context, tokenString string, logg
 		}
 	}
 
-	return &claims, false, nil
+	return claims, false, nil
 }
 
 // GetOIDCGroupsFromToken extracts and formats group names from OIDC claims
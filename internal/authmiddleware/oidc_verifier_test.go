@@ -7,11 +7,21 @@ package authmiddleware
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	jose "github.com/go-jose/go-jose/v4"
+	jwt5 "github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -368,3 +378,180 @@ func TestStart_ContextCanceled(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to initialize OIDC provider")
 }
+
+// newPinnedIssuerServer starts a fake OIDC issuer serving a discovery document and JWKS
+// for the given RSA keys, and returns the server with the discovery document bytes.
+// The first key has kid "test-kid", the following ones "test-kid-<index>".
+func newPinnedIssuerServer(t *testing.T, keys ...*rsa.PrivateKey) (*httptest.Server, *[]byte) {
+	t.Helper()
+	discovery := new([]byte)
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	*discovery, _ = json.Marshal(map[string]any{
+		"issuer":                                server.URL,
+		"jwks_uri":                              server.URL + "/keys",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(*discovery)
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(pinnedTestJWKS(keys...))
+	})
+	return server, discovery
+}
+
+// pinnedTestJWKS encodes the public keys as a JWKS, with kids from pinnedTestKid
+func pinnedTestJWKS(keys ...*rsa.PrivateKey) []byte {
+	jwks := make([]map[string]string, 0, len(keys))
+	for i, key := range keys {
+		jwks = append(jwks, map[string]string{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": pinnedTestKid(i),
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	encoded, _ := json.Marshal(map[string]any{"keys": jwks})
+	return encoded
+}
+
+func pinnedTestKid(index int) string {
+	if index == 0 {
+		return "test-kid"
+	}
+	return fmt.Sprintf("test-kid-%d", index)
+}
+
+func thumbprintOf(t *testing.T, key *rsa.PrivateKey) string {
+	t.Helper()
+	sum, err := (&jose.JSONWebKey{Key: &key.PublicKey}).Thumbprint(crypto.SHA256)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(sum)
+}
+
+func signPinnedTestToken(t *testing.T, issuer, kid string, key *rsa.PrivateKey) string {
+	t.Helper()
+	token := jwt5.NewWithClaims(jwt5.SigningMethodRS256, jwt5.MapClaims{
+		"iss":                issuer,
+		"aud":                "test-client",
+		"sub":                "user-1",
+		"preferred_username": "alice",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// TestStart_PinnedJWKSURIAndKeyMatch tests that a matching jwks_uri and key thumbprint allow token verification
+func TestStart_PinnedJWKSURIAndKeyMatch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server, _ := newPinnedIssuerServer(t, key)
+
+	verifier, err := NewOIDCVerifier(&Config{
+		OIDCIssuerURL:            server.URL,
+		OIDCClientID:             "test-client",
+		OIDCInitTimeoutSecs:      5,
+		OIDCPinnedJWKSURI:        server.URL + "/keys",
+		OIDCPinnedKeyThumbprints: []string{thumbprintOf(t, key)},
+	}, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, verifier.Start(context.Background()))
+
+	claims, isFault, err := verifier.VerifyToken(context.Background(),
+		signPinnedTestToken(t, server.URL, "test-kid", key), slog.Default())
+	require.NoError(t, err)
+	assert.False(t, isFault)
+	assert.Equal(t, "alice", claims.Username)
+}
+
+// TestStart_PinnedJWKSURIMismatch tests that a discovery document pointing at another keys endpoint is rejected
+func TestStart_PinnedJWKSURIMismatch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server, discovery := newPinnedIssuerServer(t, key)
+
+	// The discovery endpoint now points at a different keys endpoint
+	*discovery, _ = json.Marshal(map[string]any{
+		"issuer":   server.URL,
+		"jwks_uri": "https://attacker.example.com/keys",
+	})
+
+	verifier, err := NewOIDCVerifier(&Config{
+		OIDCIssuerURL:       server.URL,
+		OIDCClientID:        "test-client",
+		OIDCInitTimeoutSecs: 5,
+		OIDCPinnedJWKSURI:   server.URL + "/keys",
+	}, slog.Default())
+	require.NoError(t, err)
+
+	err = verifier.Start(context.Background())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrDiscoveryPinMismatch)
+
+	_, isFault, err := verifier.VerifyToken(context.Background(), "fake.jwt.token", slog.Default())
+	assert.Error(t, err)
+	assert.True(t, isFault)
+}
+
+// TestStart_PinnedDiscoveryFieldsIgnoreOtherChanges tests that unrelated discovery fields
+// may change without breaking a pinned verifier
+func TestStart_PinnedDiscoveryFieldsIgnoreOtherChanges(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server, discovery := newPinnedIssuerServer(t, key)
+
+	*discovery, _ = json.Marshal(map[string]any{
+		"issuer":                                server.URL,
+		"jwks_uri":                              server.URL + "/keys",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      []string{"openid", "email"},
+	})
+
+	verifier, err := NewOIDCVerifier(&Config{
+		OIDCIssuerURL:       server.URL,
+		OIDCClientID:        "test-client",
+		OIDCInitTimeoutSecs: 5,
+		OIDCPinnedJWKSURI:   server.URL + "/keys",
+	}, slog.Default())
+	require.NoError(t, err)
+	assert.NoError(t, verifier.Start(context.Background()))
+}
+
+// TestVerifyToken_UnpinnedKeyRejected tests that a key published by the issuer but not
+// pinned cannot sign accepted tokens, while the pinned key still can
+func TestVerifyToken_UnpinnedKeyRejected(t *testing.T) {
+	pinnedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rogueKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server, _ := newPinnedIssuerServer(t, pinnedKey, rogueKey)
+
+	verifier, err := NewOIDCVerifier(&Config{
+		OIDCIssuerURL:            server.URL,
+		OIDCClientID:             "test-client",
+		OIDCInitTimeoutSecs:      5,
+		OIDCPinnedKeyThumbprints: []string{thumbprintOf(t, pinnedKey)},
+	}, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, verifier.Start(context.Background()))
+
+	_, isFault, err := verifier.VerifyToken(context.Background(),
+		signPinnedTestToken(t, server.URL, pinnedTestKid(1), rogueKey), slog.Default())
+	require.Error(t, err)
+	assert.False(t, isFault)
+	assert.Contains(t, err.Error(), ErrUnpinnedSigningKey.Error())
+
+	claims, _, err := verifier.VerifyToken(context.Background(),
+		signPinnedTestToken(t, server.URL, "test-kid", pinnedKey), slog.Default())
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Username)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// headerForwardedFor carries the client address chain appended by each proxy
+const headerForwardedFor = "X-Forwarded-For"
+
+// rateLimiterSweepInterval is how often idle client buckets are dropped
+const rateLimiterSweepInterval = time.Minute
+
+// clientRateLimiter keeps a token bucket per client IP
+type clientRateLimiter struct {
+	limit rate.Limit
+	burst int
+	// idle is how long a bucket takes to refill; idle buckets are indistinguishable
+	// from new ones and can be dropped
+	idle time.Duration
+	// trusted are the proxies whose X-Forwarded-For entries are believed
+	trusted []netip.Prefix
+	now     func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*clientBucket
+	lastSweep time.Time
+}
+
+// clientBucket is the token bucket of one client IP
+type clientBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newClientRateLimiter allows each client rps requests per second with bursts of up to
+// burst, identifying clients behind trustedProxies by X-Forwarded-For
+func newClientRateLimiter(rps float64, burst int, trustedProxies []netip.Prefix, now func() time.Time) *clientRateLimiter {
+	return &clientRateLimiter{
+		limit:     rate.Limit(rps),
+		burst:     burst,
+		idle:      time.Duration(float64(burst) / rps * float64(time.Second)),
+		trusted:   trustedProxies,
+		now:       now,
+		clients:   make(map[string]*clientBucket),
+		lastSweep: now(),
+	}
+}
+
+// allow reports whether client may make a request now, taking a token if so
+func (l *clientRateLimiter) allow(client string) bool {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= rateLimiterSweepInterval {
+		for key, bucket := range l.clients {
+			if now.Sub(bucket.lastSeen) >= l.idle {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.clients[client]
+	if !ok {
+		bucket = &clientBucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[client] = bucket
+	}
+	bucket.lastSeen = now
+	return bucket.limiter.AllowN(now, 1)
+}
+
+// withRateLimit rejects requests with 429 once the client IP exhausts its token bucket.
+// It returns next unchanged when rate limiting is disabled.
+func (s *Server) withRateLimit(route string, next http.HandlerFunc) http.HandlerFunc {
+	if s.rateLimiter == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		client := clientIP(r, s.rateLimiter.trusted)
+		if !s.rateLimiter.allow(client) {
+			rateLimitedTotal.WithLabelValues(route).Inc()
+			s.logger.Info("Rate limited client", "route", route, "client", client)
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clientIP returns the address of the client behind r. X-Forwarded-For is only
+// believed when the direct peer is a trusted proxy; the chain is then walked from the
+// right, skipping trusted proxies, so a client cannot pick its own address by
+// prepending entries.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	if !isTrustedProxy(peer, trusted) {
+		return peer
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values(headerForwardedFor), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !isTrustedProxy(hop, trusted) {
+			return hop
+		}
+		peer = hop
+	}
+	return peer
+}
+
+// parseTrustedProxies parses a list of IPs and CIDRs, ignoring surrounding spaces
+func parseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR", entry)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// isTrustedProxy reports whether ip falls within one of the trusted prefixes
+func isTrustedProxy(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
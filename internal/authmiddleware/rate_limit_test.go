@@ -0,0 +1,126 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRateLimiter_BurstThenThrottle(t *testing.T) {
+	now := time.Now()
+	limiter := newClientRateLimiter(1, 3, nil, func() time.Time { return now })
+
+	for i := range 3 {
+		assert.True(t, limiter.allow("10.0.0.1"), "request %d within burst", i)
+	}
+	assert.False(t, limiter.allow("10.0.0.1"))
+	// Other clients have their own bucket
+	assert.True(t, limiter.allow("10.0.0.2"))
+
+	// One token is back after a second, the whole burst after three
+	now = now.Add(time.Second)
+	assert.True(t, limiter.allow("10.0.0.1"))
+	assert.False(t, limiter.allow("10.0.0.1"))
+
+	now = now.Add(3 * time.Second)
+	for range 3 {
+		assert.True(t, limiter.allow("10.0.0.1"))
+	}
+	assert.False(t, limiter.allow("10.0.0.1"))
+}
+
+func TestClientRateLimiter_DropsIdleClients(t *testing.T) {
+	now := time.Now()
+	limiter := newClientRateLimiter(1, 3, nil, func() time.Time { return now })
+	limiter.allow("10.0.0.1")
+	limiter.allow("10.0.0.2")
+
+	now = now.Add(rateLimiterSweepInterval)
+	limiter.allow("10.0.0.2")
+	assert.Len(t, limiter.clients, 1)
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := parseTrustedProxies([]string{"127.0.0.1", "10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		expected   string
+	}{
+		{name: "direct client", remoteAddr: "192.0.2.1:1234", expected: "192.0.2.1"},
+		{name: "untrusted peer cannot forward", remoteAddr: "192.0.2.1:1234", forwarded: "198.51.100.7", expected: "192.0.2.1"},
+		{name: "trusted proxy", remoteAddr: "127.0.0.1:1234", forwarded: "198.51.100.7", expected: "198.51.100.7"},
+		{name: "proxy chain", remoteAddr: "127.0.0.1:1234", forwarded: "198.51.100.7, 10.1.2.3", expected: "198.51.100.7"},
+		{name: "spoofed prefix is ignored", remoteAddr: "127.0.0.1:1234", forwarded: "203.0.113.9, 198.51.100.7", expected: "198.51.100.7"},
+		{name: "only trusted hops", remoteAddr: "127.0.0.1:1234", forwarded: "10.1.2.3", expected: "10.1.2.3"},
+		{name: "trusted proxy without header", remoteAddr: "127.0.0.1:1234", expected: "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, routeVerify, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set(headerForwardedFor, tt.forwarded)
+			}
+			assert.Equal(t, tt.expected, clientIP(req, trusted))
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := parseTrustedProxies([]string{"127.0.0.1", " ::1", "10.0.0.0/8 "})
+	require.NoError(t, err)
+	assert.Len(t, prefixes, 3)
+
+	_, err = parseTrustedProxies([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
+
+func TestWithRateLimit(t *testing.T) {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+	now := time.Now()
+	server.rateLimiter = newClientRateLimiter(1, 2, nil, func() time.Time { return now })
+	handler := server.withRateLimit(routeVerify, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, routeVerify, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, call().Code)
+	assert.Equal(t, http.StatusOK, call().Code)
+	w := call()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, call().Code)
+}
+
+func TestWithRateLimit_Disabled(t *testing.T) {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+	called := 0
+	handler := server.withRateLimit(routeVerify, func(w http.ResponseWriter, r *http.Request) { called++ })
+	for range 100 {
+		handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, routeVerify, nil))
+	}
+	assert.Equal(t, 100, called)
+}
@@ -0,0 +1,35 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// routeVerify is the path of the forward-auth verification route
+const routeVerify = "/verify"
+
+// requiredScopesFor returns the scopes a token must carry on route. Scopes set with
+// WithRequiredScopes take precedence; /verify otherwise uses Config.VerifyRequiredScopes.
+func (s *Server) requiredScopesFor(route string) []string {
+	if scopes, ok := s.requiredScopes[route]; ok {
+		return scopes
+	}
+	if route == routeVerify {
+		return s.config.VerifyRequiredScopes
+	}
+	return nil
+}
+
+// writeInsufficientScope writes a 403 response with an RFC 6750 insufficient_scope
+// challenge naming the scopes the route requires
+func writeInsufficientScope(w http.ResponseWriter, required []string) {
+	w.Header().Set("WWW-Authenticate",
+		fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(required, " ")))
+	http.Error(w, "Insufficient scope", http.StatusForbidden)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// runVerifyWithScope calls /verify with a valid session token carrying scope
+func runVerifyWithScope(t *testing.T, scope string, configure func(*Server)) *httptest.ResponseRecorder {
+	t.Helper()
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{
+		ValidateTokenFunc: func(string) (*jwt.Claims, error) {
+			return &jwt.Claims{
+				User:      "alice",
+				Path:      testAppPath,
+				Domain:    "example.com",
+				TokenType: jwt.TokenTypeSession,
+				Scope:     scope,
+			}, nil
+		},
+	})
+	configure(server)
+
+	req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+	req.Header.Set(HeaderForwardedURI, testAppPath+"/lab")
+	req.Header.Set(HeaderForwardedHost, "example.com")
+	w := httptest.NewRecorder()
+	server.handleVerify(w, req)
+	return w
+}
+
+func TestHandleVerify_SufficientScopeAllows(t *testing.T) {
+	w := runVerifyWithScope(t, "workspace:read workspace:connect", func(s *Server) {
+		s.config.VerifyRequiredScopes = []string{"workspace:connect"}
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandleVerify_InsufficientScopeDenies(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope string
+	}{
+		{name: "missing scope", scope: "workspace:read"},
+		{name: "no scope claim", scope: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := runVerifyWithScope(t, tt.scope, func(s *Server) {
+				s.config.VerifyRequiredScopes = []string{"workspace:read", "workspace:connect"}
+			})
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Contains(t, w.Body.String(), "Insufficient scope")
+			assert.Equal(t, `Bearer error="insufficient_scope", scope="workspace:read workspace:connect"`,
+				w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestHandleVerify_NoScopeRequirementAllows(t *testing.T) {
+	w := runVerifyWithScope(t, "", func(*Server) {})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestWithRequiredScopes_OverridesConfig(t *testing.T) {
+	configure := func(s *Server) {
+		s.config.VerifyRequiredScopes = []string{"workspace:admin"}
+		WithRequiredScopes(routeVerify, "workspace:connect")(s)
+	}
+
+	w := runVerifyWithScope(t, "workspace:connect", configure)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = runVerifyWithScope(t, "workspace:read", configure)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Routes without a requirement accept any scope
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+	configure(server)
+	assert.Empty(t, server.requiredScopesFor("/bearer-auth"))
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
 	"k8s.io/client-go/kubernetes"
@@ -25,10 +26,28 @@ type Server struct {
 	httpServer    *http.Server
 	restClient    rest.Interface
 	oidcVerifier  OIDCVerifierInterface
+	// keySetPublisher serves /jwks.json when tokens are signed with asymmetric keys
+	keySetPublisher jwt.KeySetPublisher
+	// keyUsageReporter serves /debug/keys when the key usage report is enabled
+	keyUsageReporter jwt.KeyUsageReporter
+	// authorizer makes the final decision on /verify after authentication succeeds
+	authorizer Authorizer
+	// revocations receives token IDs revoked through /revoke
+	revocations jwt.RevocationStore
+	// requiredScopes overrides the scopes a token must carry, keyed by route
+	requiredScopes map[string][]string
+	// rateLimiter throttles each client IP on the auth endpoints; nil when disabled
+	rateLimiter *clientRateLimiter
 }
 
 // NewServer creates a new server instance
-func NewServer(config *Config, jwtManager jwt.Handler, cookieManager CookieHandler, logger *slog.Logger) *Server {
+func NewServer(
+	config *Config,
+	jwtManager jwt.Handler,
+	cookieManager CookieHandler,
+	logger *slog.Logger,
+	opts ...ServerOption,
+) *Server {
 	// Initialize Kubernetes client for in-cluster use
 	k8sConfig, err := rest.InClusterConfig()
 	var restClient rest.Interface
@@ -61,14 +80,30 @@ func NewServer(config *Config, jwtManager jwt.Handler, cookieManager CookieHandl
 		}
 	}
 
-	return &Server{
+	// Rate limit clients on the auth endpoints when configured
+	var rateLimiter *clientRateLimiter
+	if config.RateLimitRPS > 0 {
+		trustedProxies, err := parseTrustedProxies(config.TrustedProxies)
+		if err != nil {
+			logger.Error("Ignoring invalid trusted proxies for rate limiting", "error", err)
+		}
+		rateLimiter = newClientRateLimiter(config.RateLimitRPS, config.RateLimitBurst, trustedProxies, time.Now)
+	}
+
+	s := &Server{
 		config:        config,
 		jwtManager:    jwtManager,
 		cookieManager: cookieManager,
 		logger:        logger,
 		restClient:    restClient,
 		oidcVerifier:  oidcVerifier,
+		authorizer:    AllowAllAuthorizer{},
+		rateLimiter:   rateLimiter,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Start initializes and starts the HTTP server
@@ -91,20 +126,36 @@ func (s *Server) Start() error {
 
 	// Register routes
 	if s.config.EnableOAuth {
-		router.HandleFunc("/auth", s.handleAuth)
+		router.HandleFunc("/auth", s.withRateLimit("/auth", s.withAccessLog("/auth", s.handleAuth)))
 	}
 	if s.config.EnableBearerAuth {
-		router.HandleFunc("/bearer-auth", s.handleBearerAuth)
+		router.HandleFunc("/bearer-auth", s.withRateLimit("/bearer-auth", s.withAccessLog("/bearer-auth", s.handleBearerAuth)))
+	}
+	router.HandleFunc(routeVerify, s.withRateLimit(routeVerify, s.withAccessLog(routeVerify, s.handleVerify)))
+	if s.config.JWTRefreshEnable {
+		router.HandleFunc(routeRefresh, s.withRateLimit(routeRefresh, s.withCORS(s.withAccessLog(routeRefresh, s.handleRefresh))))
+	}
+	if s.config.EnableTokenIntrospection {
+		router.HandleFunc(routeIntrospect, s.withRateLimit(routeIntrospect, s.withCORS(s.handleIntrospect)))
 	}
-	router.HandleFunc("/verify", s.handleVerify)
 	router.HandleFunc("/health", s.handleHealth)
+	if s.keySetPublisher != nil {
+		router.HandleFunc("/jwks.json", s.handleJWKS)
+	}
+	if s.revocations != nil && s.config.RevocationAdminToken != "" {
+		router.HandleFunc("/revoke", s.handleRevoke)
+	}
+	if s.keyUsageReporter != nil {
+		router.HandleFunc("/debug/keys", s.handleKeyUsage)
+	}
 
 	// Configure HTTP server
 	s.httpServer = &http.Server{
-		Addr:         fmt.Sprintf(":%d", s.config.Port),
-		Handler:      router,
-		ReadTimeout:  s.config.ReadTimeout,
-		WriteTimeout: s.config.WriteTimeout,
+		Addr:           fmt.Sprintf(":%d", s.config.Port),
+		Handler:        router,
+		ReadTimeout:    s.config.ReadTimeout,
+		WriteTimeout:   s.config.WriteTimeout,
+		MaxHeaderBytes: s.config.MaxHeaderBytes,
 	}
 
 	// Start server (blocks until error or shutdown)
@@ -0,0 +1,42 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+// ServerOption configures optional Server behavior
+type ServerOption func(*Server)
+
+// WithAuthorizer sets the Authorizer consulted by /verify. Defaults to AllowAllAuthorizer.
+func WithAuthorizer(authorizer Authorizer) ServerOption {
+	return func(s *Server) {
+		if authorizer != nil {
+			s.authorizer = authorizer
+		}
+	}
+}
+
+// WithRevocationStore sets the store that /revoke adds token IDs to. The same store
+// must be given to the signer for revocations to take effect.
+func WithRevocationStore(store jwt.RevocationStore) ServerOption {
+	return func(s *Server) {
+		s.revocations = store
+	}
+}
+
+// WithRequiredScopes requires tokens presented on route to carry every one of scopes,
+// replacing any requirement from the config. Routes without a requirement accept
+// tokens regardless of scope. Only /verify currently checks scopes.
+func WithRequiredScopes(route string, scopes ...string) ServerOption {
+	return func(s *Server) {
+		if s.requiredScopes == nil {
+			s.requiredScopes = make(map[string][]string)
+		}
+		s.requiredScopes[route] = scopes
+	}
+}
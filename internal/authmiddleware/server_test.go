@@ -173,6 +173,61 @@ func TestServerStartsAndBindsToTheCorrectPort(t *testing.T) {
 	}
 }
 
+func TestServerEnforcesMaxHeaderBytes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping test in short mode")
+	}
+
+	testPort := 54322
+	maxHeaderBytes := 8 << 10
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	config := &Config{
+		Port:             testPort,
+		ReadTimeout:      1 * time.Second,
+		WriteTimeout:     1 * time.Second,
+		ShutdownTimeout:  1 * time.Second,
+		MaxHeaderBytes:   maxHeaderBytes,
+		PathRegexPattern: DefaultPathRegexPattern,
+	}
+	server := NewServer(config, &MockJWTHandler{}, &MockCookieHandler{}, logger)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Start()
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			t.Logf("Error shutting down server: %v", err)
+		}
+		select {
+		case <-errCh:
+		case <-time.After(2 * time.Second):
+			t.Log("Warning: Server did not shut down within expected time")
+		}
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	client := &http.Client{Timeout: 500 * time.Millisecond}
+	get := func(headerSize int) int {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d/health", testPort), nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Forwarded-Groups", strings.Repeat("g", headerSize))
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Headers just under the configured limit are accepted
+	require.Equal(t, http.StatusOK, get(maxHeaderBytes-512))
+
+	// net/http allows 4096 bytes of slack over MaxHeaderBytes before rejecting
+	require.Equal(t, http.StatusRequestHeaderFieldsTooLarge, get(maxHeaderBytes+8192))
+}
+
 func TestServerStarts_RetrievesKeySetFromOIDCProvider_WhenOauthIsEnabled(t *testing.T) {
 	t.Run("successful OIDC initialization", func(t *testing.T) {
 		// Create a test logger that captures logs
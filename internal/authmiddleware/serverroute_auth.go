@@ -14,14 +14,14 @@ import (
 
 // handleAuth handles authentication requests
 func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodGet) {
 		return
 	}
 
 	// Get headers from request
-	fullPath := r.Header.Get(HeaderForwardedURI)
-	host := r.Header.Get(HeaderForwardedHost)
+	uriHeader, hostHeader := s.config.forwardedURIHeader(), s.config.forwardedHostHeader()
+	fullPath := r.Header.Get(uriHeader)
+	host := r.Header.Get(hostHeader)
 	authHeader := r.Header.Get(HeaderAuthorization)
 
 	// Get headers for verification with OIDC claims
@@ -35,12 +35,12 @@ func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
 
 	// Validate required headers
 	if fullPath == "" {
-		http.Error(w, "Missing "+HeaderForwardedURI+" header", http.StatusBadRequest)
+		http.Error(w, "Missing "+uriHeader+" header", http.StatusBadRequest)
 		return
 	}
 
 	if host == "" {
-		http.Error(w, "Missing "+HeaderForwardedHost+" header", http.StatusBadRequest)
+		http.Error(w, "Missing "+hostHeader+" header", http.StatusBadRequest)
 		return
 	}
 
@@ -86,6 +86,13 @@ func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
 	k8sUID := oidcClaims.Subject
 	k8sUsername := GetOIDCUsernameFromToken(s.config, oidcClaims)
 	k8sGroups := GetOIDCGroupsFromToken(s.config, oidcClaims)
+	setAccessLogIdentity(r.Context(), k8sUsername, k8sGroups)
+
+	if err := validateIdentityClaims(k8sUsername, k8sUID, k8sGroups, nil); err != nil {
+		s.logger.Error("Rejected OIDC identity with unsafe claims", "error", err)
+		http.Error(w, "Invalid identity claims", http.StatusBadRequest)
+		return
+	}
 
 	// Verify preferred username in header if available
 	if headerPreferredUsername != "" && k8sUsername != headerPreferredUsername {
@@ -172,7 +179,7 @@ func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
 	)
 
 	// Generate JWT token with app path and domain for authorization scope
-	jwtToken, err := s.jwtManager.GenerateToken(k8sUsername, k8sGroups, k8sUID, nil, appPath, host, jwt.TokenTypeSession)
+	jwtToken, err := s.jwtManager.GenerateTokenContext(r.Context(), k8sUsername, k8sGroups, k8sUID, nil, appPath, host, jwt.TokenTypeSession)
 	if err != nil {
 		s.logger.Error("Failed to generate token", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -180,7 +187,7 @@ func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set cookie using appPath and same domain as JWT token
-	s.cookieManager.SetCookie(w, jwtToken, appPath, host)
+	s.cookiesFor(r).SetCookie(w, jwtToken, appPath, host)
 
 	// Create empty response
 	response := map[string]string{}
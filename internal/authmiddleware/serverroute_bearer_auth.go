@@ -15,16 +15,16 @@ import (
 // handleBearerAuth handles bearer token authentication requests
 // Takes short lived JWT tokens from URL parameter and exchanges them for 6-hour session cookies
 func (s *Server) handleBearerAuth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodGet) {
 		return
 	}
 
 	// Get the original forwarded URI which contains the token
-	forwardedURI := r.Header.Get(HeaderForwardedURI)
+	uriHeader := s.config.forwardedURIHeader()
+	forwardedURI := r.Header.Get(uriHeader)
 	if forwardedURI == "" {
 		s.logger.Error("Missing forwarded URI header")
-		http.Error(w, "Missing "+HeaderForwardedURI+" header", http.StatusBadRequest)
+		http.Error(w, "Missing "+uriHeader+" header", http.StatusBadRequest)
 		return
 	}
 
@@ -48,12 +48,13 @@ func (s *Server) handleBearerAuth(w http.ResponseWriter, r *http.Request) {
 
 	// Get headers for path/host extraction
 	fullPath := forwardedURI
-	host := r.Header.Get(HeaderForwardedHost)
+	hostHeader := s.config.forwardedHostHeader()
+	host := r.Header.Get(hostHeader)
 
 	// Validate required headers
 	if host == "" {
 		s.logger.Error("Missing forwarded host header")
-		http.Error(w, "Missing "+HeaderForwardedHost+" header", http.StatusBadRequest)
+		http.Error(w, "Missing "+hostHeader+" header", http.StatusBadRequest)
 		return
 	}
 
@@ -81,6 +82,14 @@ func (s *Server) handleBearerAuth(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid token", http.StatusUnauthorized)
 		return
 	}
+	setAccessLogIdentity(r.Context(), reviewStatus.User.Username, reviewStatus.User.Groups)
+
+	if err := validateIdentityClaims(reviewStatus.User.Username, reviewStatus.User.UID,
+		reviewStatus.User.Groups, reviewStatus.User.Extra); err != nil {
+		s.logger.Error("Rejected bearer token identity with unsafe claims", "error", err)
+		http.Error(w, "Invalid identity claims", http.StatusBadRequest)
+		return
+	}
 
 	if reviewStatus.Path != appPath {
 		s.logger.Error("Token path mismatch", "token_path", reviewStatus.Path, "request_path", appPath)
@@ -94,7 +103,7 @@ func (s *Server) handleBearerAuth(w http.ResponseWriter, r *http.Request) {
 	extra := reviewStatus.User.Extra
 
 	// Generate new long-term session token
-	sessionToken, err := s.jwtManager.GenerateToken(
+	sessionToken, err := s.jwtManager.GenerateTokenContext(r.Context(),
 		user, groups, uid, extra, appPath, host, jwt.TokenTypeSession)
 	if err != nil {
 		s.logger.Error("Failed to generate session token", "error", err, "user", user)
@@ -103,7 +112,7 @@ func (s *Server) handleBearerAuth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set session cookie using appPath and same domain as JWT token
-	s.cookieManager.SetCookie(w, sessionToken, appPath, host)
+	s.cookiesFor(r).SetCookie(w, sessionToken, appPath, host)
 
 	// Log successful token exchange
 	s.logger.Info("Token exchange successful",
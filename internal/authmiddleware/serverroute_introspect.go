@@ -0,0 +1,102 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// routeIntrospect is the path of the token introspection route
+const routeIntrospect = "/introspect"
+
+// maxIntrospectRequestBytes bounds the size of an /introspect request body
+const maxIntrospectRequestBytes = 16384
+
+// introspectionResponse is the body returned by /introspect (RFC 7662 section 2.2).
+// Only Active is set for a token that failed validation.
+type introspectionResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	JTI       string   `json:"jti,omitempty"`
+	User      string   `json:"user,omitempty"`
+	Groups    []string `json:"groups,omitempty"`
+	UID       string   `json:"uid,omitempty"`
+}
+
+// handleIntrospect lets services validate a token over HTTP instead of holding the
+// signing keys. It reads the token form field and answers with the claims of a valid
+// token, or {"active": false} for an expired, revoked or malformed one. Callers
+// authenticate with the configured introspection client token.
+func (s *Server) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	if !hasBearerToken(r, s.config.IntrospectionClientToken) {
+		s.logger.Warn("Rejected unauthenticated introspection request", "remote_addr", r.RemoteAddr)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxIntrospectRequestBytes)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		http.Error(w, "Missing token", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := s.jwtManager.ValidateToken(token)
+	if err != nil {
+		s.logger.Debug("Introspected token is not active", "error", err)
+		s.writeIntrospectionResponse(w, introspectionResponse{Active: false})
+		return
+	}
+
+	resp := introspectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		TokenType: claims.TokenType,
+		Subject:   claims.Subject,
+		Audience:  claims.Audience,
+		Issuer:    claims.Issuer,
+		JTI:       claims.ID,
+		User:      claims.User,
+		Groups:    claims.Groups,
+		UID:       claims.UID,
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		resp.NotBefore = claims.NotBefore.Unix()
+	}
+	s.writeIntrospectionResponse(w, resp)
+}
+
+// writeIntrospectionResponse writes an introspection result; tokens must not be cached
+func (s *Server) writeIntrospectionResponse(w http.ResponseWriter, resp introspectionResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Error("Failed to encode introspection response", "error", err)
+	}
+}
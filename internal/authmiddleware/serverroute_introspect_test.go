@@ -0,0 +1,164 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// introspectTestClientToken is the client token configured by newIntrospectTestServer
+const introspectTestClientToken = "introspect-client-token"
+
+// newIntrospectTestServer creates a test server that accepts introspectTestClientToken
+func newIntrospectTestServer(jwtHandler *MockJWTHandler) *Server {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, jwtHandler)
+	server.config.IntrospectionClientToken = introspectTestClientToken
+	return server
+}
+
+// newIntrospectRequest builds an authenticated form-encoded POST /introspect request
+func newIntrospectRequest(token string) *http.Request {
+	form := url.Values{}
+	if token != "" {
+		form.Set("token", token)
+	}
+	req := httptest.NewRequest(http.MethodPost, routeIntrospect, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+introspectTestClientToken)
+	return req
+}
+
+// decodeIntrospection decodes the raw JSON object so absent fields can be asserted
+func decodeIntrospection(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var resp map[string]any
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp
+}
+
+func TestHandleIntrospect_ActiveToken(t *testing.T) {
+	issuedAt := time.Unix(1700000000, 0)
+	claims := &jwt.Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			Subject:   "user",
+			Issuer:    "workspaces-auth",
+			Audience:  jwt5.ClaimStrings{"workspace-users"},
+			IssuedAt:  jwt5.NewNumericDate(issuedAt),
+			ExpiresAt: jwt5.NewNumericDate(issuedAt.Add(time.Hour)),
+			ID:        "jti-1",
+		},
+		User:      "user",
+		Groups:    []string{"g1", "g2"},
+		UID:       "uid",
+		TokenType: jwt.TokenTypeSession,
+		Scope:     "workspace:connect",
+	}
+	var validated string
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc: func(token string) (*jwt.Claims, error) {
+			validated = token
+			return claims, nil
+		},
+	}
+	server := newIntrospectTestServer(jwtHandler)
+
+	w := httptest.NewRecorder()
+	server.handleIntrospect(w, newIntrospectRequest("opaque-token"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "opaque-token", validated)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	resp := decodeIntrospection(t, w)
+	assert.Equal(t, true, resp["active"])
+	assert.Equal(t, "user", resp["sub"])
+	assert.Equal(t, "workspaces-auth", resp["iss"])
+	assert.Equal(t, []any{"workspace-users"}, resp["aud"])
+	assert.Equal(t, float64(issuedAt.Unix()), resp["iat"])
+	assert.Equal(t, float64(issuedAt.Add(time.Hour).Unix()), resp["exp"])
+	assert.Equal(t, "jti-1", resp["jti"])
+	assert.Equal(t, "user", resp["user"])
+	assert.Equal(t, []any{"g1", "g2"}, resp["groups"])
+	assert.Equal(t, "uid", resp["uid"])
+	assert.Equal(t, "workspace:connect", resp["scope"])
+	assert.Equal(t, jwt.TokenTypeSession, resp["token_type"])
+}
+
+func TestHandleIntrospect_InactiveToken(t *testing.T) {
+	for _, validationErr := range []error{jwt.ErrTokenExpired, jwt.ErrTokenRevoked, jwt.ErrInvalidToken} {
+		t.Run(validationErr.Error(), func(t *testing.T) {
+			jwtHandler := &MockJWTHandler{
+				ValidateTokenFunc: func(string) (*jwt.Claims, error) { return nil, validationErr },
+			}
+			server := newIntrospectTestServer(jwtHandler)
+
+			w := httptest.NewRecorder()
+			server.handleIntrospect(w, newIntrospectRequest("bad-token"))
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, map[string]any{"active": false}, decodeIntrospection(t, w))
+		})
+	}
+}
+
+func TestHandleIntrospect_MissingToken(t *testing.T) {
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc: func(string) (*jwt.Claims, error) {
+			t.Error("ValidateToken should not be called without a token")
+			return nil, nil
+		},
+	}
+	server := newIntrospectTestServer(jwtHandler)
+
+	w := httptest.NewRecorder()
+	server.handleIntrospect(w, newIntrospectRequest(""))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleIntrospect_MethodNotAllowed(t *testing.T) {
+	server := newIntrospectTestServer(&MockJWTHandler{})
+
+	w := httptest.NewRecorder()
+	server.handleIntrospect(w, httptest.NewRequest(http.MethodGet, routeIntrospect, nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHandleIntrospect_Unauthorized(t *testing.T) {
+	for name, authorization := range map[string]string{
+		"missing":    "",
+		"wrong":      "Bearer other-token",
+		"not bearer": "Basic " + introspectTestClientToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			jwtHandler := &MockJWTHandler{
+				ValidateTokenFunc: func(string) (*jwt.Claims, error) {
+					t.Error("ValidateToken should not be called for an unauthenticated caller")
+					return nil, nil
+				},
+			}
+			server := newIntrospectTestServer(jwtHandler)
+
+			req := newIntrospectRequest("opaque-token")
+			req.Header.Set("Authorization", authorization)
+			w := httptest.NewRecorder()
+			server.handleIntrospect(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// handleJWKS serves the public signing keys so other services can verify tokens offline
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+
+	keySet, err := s.keySetPublisher.JWKS()
+	if err != nil {
+		s.logger.Error("Failed to build JWKS", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", s.jwksCacheControl())
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(keySet); err != nil {
+		s.logger.Error("Failed to encode JWKS response", "error", err)
+	}
+}
+
+// jwksCacheControl limits caching to the new key use delay, so verifiers refetch
+// the key set before a newly published key starts signing tokens
+func (s *Server) jwksCacheControl() string {
+	maxAge := int(s.config.JwtNewKeyUseDelay.Seconds())
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+	return fmt.Sprintf("public, max-age=%d", maxAge)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+type failingKeySetPublisher struct{}
+
+func (failingKeySetPublisher) JWKS() (jwt.JSONWebKeySet, error) {
+	return jwt.JSONWebKeySet{}, errors.New("boom")
+}
+
+func createJWKSTestServer(t *testing.T, newKeyUseDelay time.Duration) (*Server, *jwt.AsymmetricSigner) {
+	t.Helper()
+	signer, err := jwt.NewAsymmetricSigner(jwt.AlgorithmES256, "test-issuer", "test-audience", time.Hour, newKeyUseDelay)
+	require.NoError(t, err)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+
+	return &Server{
+		config:          &Config{JwtNewKeyUseDelay: newKeyUseDelay},
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		keySetPublisher: signer,
+	}, signer
+}
+
+func TestHandleJWKS_ServesPublicKeys(t *testing.T) {
+	server, _ := createJWKSTestServer(t, 30*time.Second)
+
+	req := httptest.NewRequest(http.MethodGet, "/jwks.json", nil)
+	w := httptest.NewRecorder()
+	server.handleJWKS(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=30", w.Header().Get("Cache-Control"))
+
+	var keySet jwt.JSONWebKeySet
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&keySet))
+	require.Len(t, keySet.Keys, 1)
+	assert.Equal(t, "1000", keySet.Keys[0].Kid)
+	assert.Equal(t, "EC", keySet.Keys[0].Kty)
+	assert.Equal(t, jwt.AlgorithmES256, keySet.Keys[0].Alg)
+	assert.NotContains(t, w.Body.String(), `"d"`, "private key material must not be served")
+}
+
+func TestHandleJWKS_NoCacheWithZeroDelay(t *testing.T) {
+	server, _ := createJWKSTestServer(t, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/jwks.json", nil)
+	w := httptest.NewRecorder()
+	server.handleJWKS(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+}
+
+func TestHandleJWKS_RejectsOtherMethods(t *testing.T) {
+	server, _ := createJWKSTestServer(t, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/jwks.json", nil)
+	w := httptest.NewRecorder()
+	server.handleJWKS(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+}
+
+func TestHandleJWKS_PublisherError(t *testing.T) {
+	server := &Server{
+		config:          &Config{},
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		keySetPublisher: failingKeySetPublisher{},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/jwks.json", nil)
+	w := httptest.NewRecorder()
+	server.handleJWKS(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
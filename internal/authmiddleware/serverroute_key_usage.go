@@ -0,0 +1,78 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+// keyUsageEntry reports the state and usage of one loaded signing key
+type keyUsageEntry struct {
+	Kid        string  `json:"kid"`
+	AgeSeconds float64 `json:"ageSeconds"`
+	// Fingerprint identifies the key bytes, so replicas can be compared after a rotation
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// CoolingOff is true while the key is within the new key use delay and not yet signing
+	CoolingOff bool `json:"coolingOff"`
+	// Signing is true for the key new tokens are signed with
+	Signing             bool   `json:"signing"`
+	ValidationOnly      bool   `json:"validationOnly"`
+	Signed              uint64 `json:"signed"`
+	ValidationSuccesses uint64 `json:"validationSuccesses"`
+	ValidationFailures  uint64 `json:"validationFailures"`
+}
+
+// keyUsageResponse is the body returned by /debug/keys
+type keyUsageResponse struct {
+	LatestKid string          `json:"latestKid"`
+	Keys      []keyUsageEntry `json:"keys"`
+}
+
+// handleKeyUsage reports, for each loaded kid, how many tokens it signed and validated
+// since this replica loaded it, so operators can tell when a key is safe to prune.
+// Counts are per replica and reset on restart. Key fingerprints let operators confirm
+// every replica loaded the same keys; key material itself is never included.
+func (s *Server) handleKeyUsage(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+
+	snapshot := s.keyUsageReporter.Snapshot()
+	usage := s.keyUsageReporter.KeyUsage()
+	fingerprints := make(map[string]string)
+	if provider, ok := s.keyUsageReporter.(jwt.KeyInfoProvider); ok {
+		for _, key := range provider.KeyInfo().Keys {
+			fingerprints[key.Kid] = key.Fingerprint
+		}
+	}
+
+	response := keyUsageResponse{LatestKid: snapshot.LatestKid, Keys: []keyUsageEntry{}}
+	for _, kid := range snapshot.Kids {
+		counts := usage[kid]
+		response.Keys = append(response.Keys, keyUsageEntry{
+			Kid:                 kid,
+			AgeSeconds:          snapshot.KeyAges[kid].Seconds(),
+			Fingerprint:         fingerprints[kid],
+			CoolingOff:          !slices.Contains(snapshot.UsableKids, kid),
+			Signing:             kid == snapshot.SigningKid,
+			ValidationOnly:      slices.Contains(snapshot.ValidationOnlyKids, kid),
+			Signed:              counts.Signed,
+			ValidationSuccesses: counts.ValidationSuccesses,
+			ValidationFailures:  counts.ValidationFailures,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.logger.Error("Failed to encode key usage response", "error", err)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+func TestHandleKeyUsage_ReflectsCounters(t *testing.T) {
+	const (
+		oldKey = "old-signing-key-48-bytes-or-more-for-hs384-signing-long"
+		newKey = "new-signing-key-48-bytes-or-more-for-hs384-signing-long"
+	)
+	now := time.Now()
+	signer := jwt.NewStandardSigner("test-issuer", "test-audience", time.Hour, time.Minute,
+		jwt.WithClock(func() time.Time { return now }))
+
+	generate := func() string {
+		token, err := signer.GenerateToken("user", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+		return token
+	}
+
+	// Two tokens signed with the old key
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(oldKey)}, "1000"))
+	now = now.Add(2 * time.Minute)
+	oldToken := generate()
+	generate()
+
+	// Rotation: the new key cools off, then signs one token
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(oldKey), "2000": []byte(newKey)}, "2000"))
+	now = now.Add(2 * time.Minute)
+	newToken := generate()
+
+	for _, token := range []string{oldToken, oldToken, newToken} {
+		_, err := signer.ValidateToken(token)
+		require.NoError(t, err)
+	}
+	// A tampered signature counts as a failure for the kid it claims
+	_, err := signer.ValidateToken(oldToken[:len(oldToken)-4] + "AAAA")
+	require.Error(t, err)
+
+	server := &Server{
+		logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		keyUsageReporter: signer,
+	}
+	w := httptest.NewRecorder()
+	server.handleKeyUsage(w, httptest.NewRequest(http.MethodGet, "/debug/keys", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var resp keyUsageResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "2000", resp.LatestKid)
+	require.Len(t, resp.Keys, 2)
+
+	old, current := resp.Keys[0], resp.Keys[1]
+	assert.Equal(t, "1000", old.Kid)
+	assert.Equal(t, uint64(2), old.Signed)
+	assert.Equal(t, uint64(2), old.ValidationSuccesses)
+	assert.Equal(t, uint64(1), old.ValidationFailures)
+	assert.False(t, old.Signing)
+	assert.InDelta(t, 240, old.AgeSeconds, 1)
+	assert.Equal(t, jwt.KeyFingerprint([]byte(oldKey)), old.Fingerprint)
+
+	assert.Equal(t, "2000", current.Kid)
+	assert.Equal(t, uint64(1), current.Signed)
+	assert.Equal(t, uint64(1), current.ValidationSuccesses)
+	assert.Equal(t, uint64(0), current.ValidationFailures)
+	assert.True(t, current.Signing)
+	assert.False(t, current.CoolingOff)
+
+	// Pruning a key drops it from the report
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"2000": []byte(newKey)}, "2000"))
+	w = httptest.NewRecorder()
+	server.handleKeyUsage(w, httptest.NewRequest(http.MethodGet, "/debug/keys", nil))
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Keys, 1)
+	assert.Equal(t, "2000", resp.Keys[0].Kid)
+	assert.Equal(t, uint64(1), resp.Keys[0].Signed)
+}
+
+func TestHandleKeyUsage_CoolingOff(t *testing.T) {
+	signer := jwt.NewStandardSigner("test-issuer", "test-audience", time.Hour, time.Hour)
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("new-signing-key-48-bytes-or-more-for-hs384-signing-long"),
+	}, "1000"))
+
+	server := &Server{
+		logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		keyUsageReporter: signer,
+	}
+	w := httptest.NewRecorder()
+	server.handleKeyUsage(w, httptest.NewRequest(http.MethodGet, "/debug/keys", nil))
+
+	var resp keyUsageResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Keys, 1)
+	assert.True(t, resp.Keys[0].CoolingOff)
+	assert.False(t, resp.Keys[0].Signing)
+}
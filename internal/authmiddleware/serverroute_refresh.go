@@ -0,0 +1,119 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+// routeRefresh is the path of the explicit token refresh route
+const routeRefresh = "/refresh"
+
+// refreshResponse is the body returned by /refresh
+type refreshResponse struct {
+	// Refreshed is true when a new token was issued and set in the cookie
+	Refreshed bool `json:"refreshed"`
+}
+
+// handleRefresh lets a client renew its session token before it expires, rather than
+// waiting for /verify to refresh it. A new token is only issued when the current one
+// is within the refresh window and not marked skip-refresh; its lifetime is still
+// bounded by the refresh horizon. Expired or revoked tokens get a 401.
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	start := time.Now()
+
+	requestPath := r.Header.Get(s.config.forwardedURIHeader())
+	token, err := s.sessionToken(r, requestPath)
+	if err != nil {
+		s.logger.Info("No session token found for refresh", "error", err, "path", requestPath)
+		s.padDenyResponse(r.Context(), start)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := s.jwtManager.ValidateToken(token)
+	if err != nil {
+		s.logger.Info("Invalid token for refresh", "error", err)
+		s.padDenyResponse(r.Context(), start)
+		writeTokenValidationError(w, err)
+		return
+	}
+
+	setAccessLogIdentity(r.Context(), claims.User, claims.Groups)
+
+	if err := validateIdentityClaims(claims.User, claims.UID, claims.Groups, claims.Extra); err != nil {
+		s.logger.Warn("Rejected token with unsafe claims for refresh", "error", err)
+		s.padDenyResponse(r.Context(), start)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if claims.TokenType != jwt.TokenTypeSession {
+		s.logger.Info("Invalid token type for refresh", "expected", jwt.TokenTypeSession, "actual", claims.TokenType)
+		s.padDenyResponse(r.Context(), start)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !s.jwtManager.ShouldRefreshToken(claims) {
+		s.writeRefreshResponse(w, false)
+		return
+	}
+
+	// A refresh extends access, so re-check it as /verify does
+	accessReviewResult, workspaceInfo, err := s.VerifyWorkspaceAccessFromJwt(r.Context(), r, claims)
+	if err != nil {
+		s.logger.Warn("Failed to retrieve the accessReview for token refresh", "error", err)
+		http.Error(w, "Unable to verify workspace access", http.StatusServiceUnavailable)
+		return
+	}
+	if !accessReviewResult.Allowed {
+		s.logger.Info("JWT refresh denied: ConnectionAccessReview.Allowed is false",
+			"workspace", workspaceInfo.Name,
+			"workspaceNamespace", workspaceInfo.Namespace,
+			"reason", accessReviewResult.Reason)
+		s.cookiesFor(r).ClearCookie(w, claims.Path, claims.Domain)
+		http.Error(w, "Access denied: you are no longer authorized to access this workspace", http.StatusForbidden)
+		return
+	}
+
+	newToken, err := s.jwtManager.RefreshToken(claims)
+	if errors.Is(err, jwt.ErrValidationOnlyKey) {
+		if s.refuseValidationOnlyRefresh(w, r, start, claims) {
+			return
+		}
+		s.writeRefreshResponse(w, false)
+		return
+	}
+	if err != nil {
+		// Beyond the refresh horizon: the current token stays valid until it expires
+		s.logger.Info("Not refreshing token", "user", claims.User, "error", err)
+		s.writeRefreshResponse(w, false)
+		return
+	}
+
+	s.cookiesFor(r).SetCookie(w, newToken, claims.Path, claims.Domain)
+	s.logger.Info("Token refreshed on request", "user", claims.User, "path", claims.Path)
+	s.writeRefreshResponse(w, true)
+}
+
+// writeRefreshResponse reports whether /refresh issued a new token
+func (s *Server) writeRefreshResponse(w http.ResponseWriter, refreshed bool) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(refreshResponse{Refreshed: refreshed}); err != nil {
+		s.logger.Error("Failed to encode refresh response", "error", err)
+	}
+}
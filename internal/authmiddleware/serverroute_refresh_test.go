@@ -0,0 +1,173 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshTestClaims returns session claims for the workspace at testAppPath2
+func refreshTestClaims() *jwt.Claims {
+	return &jwt.Claims{
+		User:      "user",
+		Groups:    []string{"g1"},
+		UID:       "uid",
+		Path:      testAppPath2,
+		Domain:    "example.com",
+		TokenType: jwt.TokenTypeSession,
+	}
+}
+
+// newRefreshRequest builds a POST /refresh request as forwarded by the proxy
+func newRefreshRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodPost, routeRefresh, nil)
+	req.Header.Set(HeaderForwardedURI, testAppPath2+"/lab")
+	req.Header.Set(HeaderForwardedHost, "example.com")
+	return req
+}
+
+// withAccessReview points the server at a mock API server answering the access review
+func withAccessReview(t *testing.T, server *Server, claims *jwt.Claims, allowed bool) {
+	t.Helper()
+	mockServer := NewMockK8sServer(t)
+	t.Cleanup(mockServer.Close)
+	mockServer.SetupServer200OK(CreateConnectionAccessReviewResponse(
+		"ns2", "app2", claims.User, claims.Groups, claims.UID, allowed, false, "reason"))
+	restClient, err := mockServer.CreateRESTClient()
+	require.NoError(t, err)
+	server.restClient = restClient
+}
+
+func decodeRefreshResponse(t *testing.T, w *httptest.ResponseRecorder) refreshResponse {
+	t.Helper()
+	var resp refreshResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp
+}
+
+func TestHandleRefresh_WithinWindow(t *testing.T) {
+	claims := refreshTestClaims()
+	var setToken string
+	cookieHandler := &MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return testCookieToken, nil
+		},
+		SetCookieFunc: func(w http.ResponseWriter, token string, path string, domain string) {
+			setToken = token
+			assert.Equal(t, testAppPath2, path)
+			assert.Equal(t, "example.com", domain)
+		},
+	}
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc:      func(string) (*jwt.Claims, error) { return claims, nil },
+		ShouldRefreshTokenFunc: func(*jwt.Claims) bool { return true },
+		RefreshTokenFunc:       func(*jwt.Claims) (string, error) { return "refreshed-token", nil },
+	}
+	server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+	withAccessReview(t, server, claims, true)
+
+	w := httptest.NewRecorder()
+	server.handleRefresh(w, newRefreshRequest())
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, decodeRefreshResponse(t, w).Refreshed)
+	assert.Equal(t, "refreshed-token", setToken)
+}
+
+func TestHandleRefresh_NotDue(t *testing.T) {
+	claims := refreshTestClaims()
+	cookieHandler := &MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return testCookieToken, nil
+		},
+		SetCookieFunc: func(w http.ResponseWriter, token string, path string, domain string) {
+			t.Error("SetCookie should not be called outside the refresh window")
+		},
+	}
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc:      func(string) (*jwt.Claims, error) { return claims, nil },
+		ShouldRefreshTokenFunc: func(*jwt.Claims) bool { return false },
+		RefreshTokenFunc: func(*jwt.Claims) (string, error) {
+			t.Error("RefreshToken should not be called outside the refresh window")
+			return "", nil
+		},
+	}
+	server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+
+	w := httptest.NewRecorder()
+	server.handleRefresh(w, newRefreshRequest())
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, decodeRefreshResponse(t, w).Refreshed)
+}
+
+func TestHandleRefresh_RejectsInvalidTokens(t *testing.T) {
+	for _, validationErr := range []error{jwt.ErrTokenExpired, jwt.ErrTokenRevoked} {
+		t.Run(validationErr.Error(), func(t *testing.T) {
+			cookieHandler := &MockCookieHandler{
+				GetCookieFunc: func(r *http.Request, path string) (string, error) {
+					return testCookieToken, nil
+				},
+				SetCookieFunc: func(w http.ResponseWriter, token string, path string, domain string) {
+					t.Error("SetCookie should not be called for an invalid token")
+				},
+			}
+			jwtHandler := &MockJWTHandler{
+				ValidateTokenFunc: func(string) (*jwt.Claims, error) { return nil, validationErr },
+			}
+			server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+
+			w := httptest.NewRecorder()
+			server.handleRefresh(w, newRefreshRequest())
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
+func TestHandleRefresh_AccessRevoked(t *testing.T) {
+	claims := refreshTestClaims()
+	cleared := false
+	cookieHandler := &MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return testCookieToken, nil
+		},
+		SetCookieFunc: func(w http.ResponseWriter, token string, path string, domain string) {
+			t.Error("SetCookie should not be called when access is revoked")
+		},
+		ClearCookieFunc: func(w http.ResponseWriter, path string, domain string) {
+			cleared = true
+		},
+	}
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc:      func(string) (*jwt.Claims, error) { return claims, nil },
+		ShouldRefreshTokenFunc: func(*jwt.Claims) bool { return true },
+	}
+	server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+	withAccessReview(t, server, claims, false)
+
+	w := httptest.NewRecorder()
+	server.handleRefresh(w, newRefreshRequest())
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.True(t, cleared)
+}
+
+func TestHandleRefresh_MethodNotAllowed(t *testing.T) {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{}, &MockJWTHandler{})
+
+	w := httptest.NewRecorder()
+	server.handleRefresh(w, httptest.NewRequest(http.MethodGet, routeRefresh, nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxRevokeRequestBytes bounds the size of a /revoke request body
+const maxRevokeRequestBytes = 4096
+
+// revokeRequest is the body accepted by /revoke
+type revokeRequest struct {
+	// JTI is the ID of the token to revoke
+	JTI string `json:"jti"`
+	// ExpiresAt is the token's expiration, if known. The entry is kept until then,
+	// or for one JWT lifetime when omitted.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// hasBearerToken reports whether r carries expected as its bearer token. An empty
+// expected token never matches.
+func hasBearerToken(r *http.Request, expected string) bool {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return found && expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// handleRevoke adds a token ID to the revocation list so the token is rejected
+// before it expires. Callers authenticate with the configured admin bearer token.
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	if !hasBearerToken(r, s.config.RevocationAdminToken) {
+		s.logger.Warn("Rejected unauthenticated revocation request", "remote_addr", r.RemoteAddr)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req revokeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRevokeRequestBytes)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.JTI == "" {
+		http.Error(w, "Missing jti", http.StatusBadRequest)
+		return
+	}
+
+	// No token outlives one JWT lifetime from now, so never hold an entry longer
+	expiresAt := time.Now().Add(s.config.JWTExpiration)
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(expiresAt) {
+		expiresAt = *req.ExpiresAt
+	}
+
+	s.revocations.Revoke(req.JTI, expiresAt)
+	s.logger.Info("Security audit: token revoked",
+		"event", "jwt_revoked",
+		"jti", req.JTI,
+		"expires_at", expiresAt.UTC().Format(time.RFC3339))
+
+	w.WriteHeader(http.StatusNoContent)
+}
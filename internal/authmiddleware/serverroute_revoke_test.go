@@ -0,0 +1,107 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+const testRevocationAdminToken = "test-admin-token"
+
+func createRevokeTestServer() (*Server, *jwt.MemoryRevocationStore) {
+	store := jwt.NewMemoryRevocationStore()
+	server := &Server{
+		config: &Config{
+			RevocationAdminToken: testRevocationAdminToken,
+			JWTExpiration:        time.Hour,
+		},
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		revocations: store,
+	}
+	return server, store
+}
+
+func revokeRequestWithBody(body string, adminToken string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/revoke", strings.NewReader(body))
+	if adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+	}
+	return req
+}
+
+func TestHandleRevoke_AddsJTI(t *testing.T) {
+	server, store := createRevokeTestServer()
+
+	w := httptest.NewRecorder()
+	server.handleRevoke(w, revokeRequestWithBody(`{"jti":"abc-123"}`, testRevocationAdminToken))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.True(t, store.IsRevoked("abc-123"))
+}
+
+func TestHandleRevoke_RequiresAdminToken(t *testing.T) {
+	for name, token := range map[string]string{"missing": "", "wrong": "not-the-admin-token"} {
+		t.Run(name, func(t *testing.T) {
+			server, store := createRevokeTestServer()
+
+			w := httptest.NewRecorder()
+			server.handleRevoke(w, revokeRequestWithBody(`{"jti":"abc-123"}`, token))
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.False(t, store.IsRevoked("abc-123"))
+		})
+	}
+}
+
+func TestHandleRevoke_InvalidBody(t *testing.T) {
+	for name, body := range map[string]string{
+		"malformed":   `{"jti":`,
+		"missing jti": `{}`,
+		"too large":   `{"jti":"` + strings.Repeat("a", maxRevokeRequestBytes) + `"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			server, store := createRevokeTestServer()
+
+			w := httptest.NewRecorder()
+			server.handleRevoke(w, revokeRequestWithBody(body, testRevocationAdminToken))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, 0, store.Len())
+		})
+	}
+}
+
+func TestHandleRevoke_PastExpirationIsNotStored(t *testing.T) {
+	server, store := createRevokeTestServer()
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+
+	w := httptest.NewRecorder()
+	server.handleRevoke(w, revokeRequestWithBody(`{"jti":"abc-123","expiresAt":"`+past+`"}`, testRevocationAdminToken))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, 0, store.Len(), "an already expired token needs no revocation entry")
+}
+
+func TestHandleRevoke_RejectsOtherMethods(t *testing.T) {
+	server, _ := createRevokeTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/revoke", nil)
+	w := httptest.NewRecorder()
+	server.handleRevoke(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST", w.Header().Get("Allow"))
+}
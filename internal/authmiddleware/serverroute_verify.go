@@ -6,35 +6,44 @@ Distributed under the terms of the MIT license
 package authmiddleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
 )
 
 // handleVerify handles token verification requests
 func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, s.verifyAllowedMethods()...) {
+		return
+	}
+	start := time.Now()
+
 	// Get requested path from header
-	requestPath := r.Header.Get(HeaderForwardedURI)
-	requestDomain := r.Header.Get(HeaderForwardedHost)
+	uriHeader, hostHeader := s.config.forwardedURIHeader(), s.config.forwardedHostHeader()
+	requestPath := r.Header.Get(uriHeader)
+	requestDomain := r.Header.Get(hostHeader)
 
 	// Validate required headers
 	if requestPath == "" {
-		s.logger.Info("Missing " + HeaderForwardedURI + " header")
-		http.Error(w, "Missing "+HeaderForwardedURI+" header", http.StatusBadRequest)
+		s.logger.Info("Missing " + uriHeader + " header")
+		http.Error(w, "Missing "+uriHeader+" header", http.StatusBadRequest)
 		return
 	}
 
 	if requestDomain == "" {
-		s.logger.Info("Missing " + HeaderForwardedHost + " header")
-		http.Error(w, "Missing "+HeaderForwardedHost+" header", http.StatusBadRequest)
+		s.logger.Info("Missing " + hostHeader + " header")
+		http.Error(w, "Missing "+hostHeader+" header", http.StatusBadRequest)
 		return
 	}
 
-	// Get path-specific cookie by hashing full path, retrieve embedded JWT
-	token, err := s.cookieManager.GetCookie(r, requestPath)
+	// Get the session JWT from the path-specific cookie or Authorization header, per TokenSource
+	token, err := s.sessionToken(r, requestPath)
 	if err != nil {
-		s.logger.Info("No auth cookie found", "error", err, "path", requestPath)
+		s.logger.Info("No session token found", "error", err, "path", requestPath)
+		s.padDenyResponse(r.Context(), start)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -43,13 +52,28 @@ func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
 	claims, err := s.jwtManager.ValidateToken(token)
 	if err != nil {
 		s.logger.Info("Invalid token", "error", err)
+		s.padDenyResponse(r.Context(), start)
+		writeTokenValidationError(w, err)
+		return
+	}
+
+	setAccessLogIdentity(r.Context(), claims.User, claims.Groups)
+
+	if err := validateIdentityClaims(claims.User, claims.UID, claims.Groups, claims.Extra); err != nil {
+		s.logger.Warn("Rejected token with unsafe claims for verify", "error", err)
+		s.padDenyResponse(r.Context(), start)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
+	// Tokens from an external issuer with jwks signing carry neither a token type nor a
+	// domain, and cannot be re-signed, so those checks and refresh only apply to our own
+	verifyOnly := s.config.JWTSigningType == JWTSigningTypeJWKS
+
 	// Validate token type - verify should only accept session tokens
-	if claims.TokenType != jwt.TokenTypeSession {
+	if !verifyOnly && claims.TokenType != jwt.TokenTypeSession {
 		s.logger.Info("Invalid token type for verify", "expected", jwt.TokenTypeSession, "actual", claims.TokenType)
+		s.padDenyResponse(r.Context(), start)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -58,20 +82,39 @@ func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
 	if claims.Path != "" && requestPath != "" {
 		if !strings.HasPrefix(requestPath, claims.Path) {
 			s.logger.Warn("Path mismatch", "token_path", claims.Path, "request_path", requestPath)
+			s.padDenyResponse(r.Context(), start)
 			http.Error(w, "Path not authorized", http.StatusForbidden)
 			return
 		}
 	}
 
 	// Verify token domain matches request domain
-	if claims.Domain != requestDomain {
+	if !verifyOnly && claims.Domain != requestDomain {
 		s.logger.Warn("Domain mismatch", "error", err, "token_domain", claims.Domain, "request_domain", requestDomain)
+		s.padDenyResponse(r.Context(), start)
 		http.Error(w, "Domain not authorized", http.StatusForbidden)
 		return
 	}
 
+	// Verify token carries the scopes required on this route
+	requiredScopes := s.requiredScopesFor(routeVerify)
+	if missing := claims.MissingScopes(requiredScopes); len(missing) > 0 {
+		s.logger.Info("Insufficient token scope", "user", claims.User, "path", requestPath, "missing_scopes", missing)
+		s.padDenyResponse(r.Context(), start)
+		writeInsufficientScope(w, requiredScopes)
+		return
+	}
+
+	// Let the configured authorizer make the final decision
+	if allowed, reason := s.getAuthorizer().Authorize(r.Context(), claims, r); !allowed {
+		s.logger.Info("Request denied by authorizer", "user", claims.User, "path", requestPath, "reason", reason)
+		s.padDenyResponse(r.Context(), start)
+		http.Error(w, "Access denied: "+reason, http.StatusForbidden)
+		return
+	}
+
 	// Check if token needs to be refreshed
-	if s.jwtManager.ShouldRefreshToken(claims) {
+	if !verifyOnly && s.jwtManager.ShouldRefreshToken(claims) {
 		s.logger.Debug("Refreshing token", "user", claims.User, "path", claims.Path)
 
 		// Verify that the user still has access to the specific Workspace
@@ -81,11 +124,15 @@ func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
 		if accessErr != nil {
 			s.logger.Warn("Failed to retrieve the accessReview for cookie refresh", "error", accessErr)
 			newToken, err := s.jwtManager.UpdateSkipRefreshToken(claims)
-			if err != nil {
+			if errors.Is(err, jwt.ErrValidationOnlyKey) {
+				if s.refuseValidationOnlyRefresh(w, r, start, claims) {
+					return
+				}
+			} else if err != nil {
 				s.logger.Warn("Failed to update token to skip", "error", err)
 			} else {
 				// Set refreshed cookie with the same path as the original token
-				s.cookieManager.SetCookie(w, newToken, claims.Path, claims.Domain)
+				s.cookiesFor(r).SetCookie(w, newToken, claims.Path, claims.Domain)
 				s.logger.Info("Token refreshed successfully", "user", claims.User, "path", claims.Path)
 			}
 			// UNHAPPY CASE 2: user is no longer allowed, return 403
@@ -98,23 +145,52 @@ func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
 				workspaceInfo.Namespace,
 				"reason",
 				accessReviewResult.Reason)
-			s.cookieManager.ClearCookie(w, claims.Path, claims.Domain)
+			s.cookiesFor(r).ClearCookie(w, claims.Path, claims.Domain)
 			http.Error(w, "Access denied: you are no longer authorized to access this workspace", http.StatusForbidden)
 			return
 			// HAPPY CASE: user is allowed, refresh their cookie
 		} else {
 			// Refresh token
 			newToken, err := s.jwtManager.RefreshToken(claims)
-			if err != nil {
+			if errors.Is(err, jwt.ErrValidationOnlyKey) {
+				if s.refuseValidationOnlyRefresh(w, r, start, claims) {
+					return
+				}
+			} else if err != nil {
 				// Log but don't fail the request - just continue with the existing token
 				s.logger.Warn("Failed to refresh token", "error", err)
 			} else {
 				// Set refreshed cookie with the same path as the original token
-				s.cookieManager.SetCookie(w, newToken, claims.Path, claims.Domain)
+				s.cookiesFor(r).SetCookie(w, newToken, claims.Path, claims.Domain)
 				s.logger.Info("Token refreshed successfully", "user", claims.User, "path", claims.Path)
 			}
 		}
 	}
 
+	if isWebSocketUpgrade(r) {
+		s.logger.Debug("Verified WebSocket upgrade", "user", claims.User, "path", requestPath)
+	}
+	s.setIdentityHeaders(w, claims)
 	w.WriteHeader(http.StatusOK)
 }
+
+// refuseValidationOnlyRefresh handles a token due for refresh that was signed by a
+// validation-only key, which is never used to re-sign. It reports whether a response
+// was written: in reauthenticate mode the cookie is cleared and the user is told to
+// sign in again; in expire mode the request proceeds and the token runs out unrefreshed.
+func (s *Server) refuseValidationOnlyRefresh(w http.ResponseWriter, r *http.Request, start time.Time, claims *jwt.Claims) bool {
+	if s.config.ValidationOnlyKeyRefresh == ValidationOnlyKeyRefreshExpire {
+		s.logger.Info("Not refreshing token signed by a validation-only key",
+			"user", claims.User, "path", claims.Path, "kid", claims.KeyID)
+		return false
+	}
+
+	s.logger.Info("Token signed by a validation-only key cannot be refreshed, requiring re-authentication",
+		"user", claims.User, "path", claims.Path, "kid", claims.KeyID)
+	s.cookiesFor(r).ClearCookie(w, claims.Path, claims.Domain)
+	s.padDenyResponse(r.Context(), start)
+	w.Header().Set("WWW-Authenticate",
+		`Bearer error="invalid_token", error_description="signing key retired, please sign in again"`)
+	http.Error(w, "Session signed by a retired key: please sign in again", http.StatusUnauthorized)
+	return true
+}
@@ -6,6 +6,12 @@ Distributed under the terms of the MIT license
 package authmiddleware
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -14,7 +20,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	jwt5 "github.com/golang-jwt/jwt/v5"
 	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -916,3 +924,233 @@ func TestHandleVerifyWithRefresh_UpdateSkipRefreshTokenError_StillReturns200(t *
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestHandleVerifyWithRefresh_ValidationOnlyKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		expectedCode  int
+		expectCleared bool
+	}{
+		{name: "reauthenticate by default", mode: "", expectedCode: http.StatusUnauthorized, expectCleared: true},
+		{name: "reauthenticate", mode: ValidationOnlyKeyRefreshReauthenticate, expectedCode: http.StatusUnauthorized, expectCleared: true},
+		{name: "expire", mode: ValidationOnlyKeyRefreshExpire, expectedCode: http.StatusOK, expectCleared: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &jwt.Claims{
+				User:      "user",
+				Groups:    []string{"g1"},
+				UID:       "uid",
+				Path:      testAppPath2,
+				Domain:    "example.com",
+				TokenType: jwt.TokenTypeSession,
+				KeyID:     "2000",
+			}
+
+			cleared := false
+			cookieHandler := &MockCookieHandler{
+				GetCookieFunc: func(r *http.Request, path string) (string, error) {
+					return testCookieToken, nil
+				},
+				SetCookieFunc: func(w http.ResponseWriter, token string, path string, domain string) {
+					t.Error("SetCookie should not be called for a validation-only key")
+				},
+				ClearCookieFunc: func(w http.ResponseWriter, path string, domain string) {
+					cleared = true
+				},
+			}
+
+			jwtHandler := &MockJWTHandler{
+				ValidateTokenFunc: func(tokenString string) (*jwt.Claims, error) {
+					return claims, nil
+				},
+				ShouldRefreshTokenFunc: func(c *jwt.Claims) bool { return true },
+				RefreshTokenFunc: func(c *jwt.Claims) (string, error) {
+					return "", fmt.Errorf("%w: kid %s", jwt.ErrValidationOnlyKey, c.KeyID)
+				},
+			}
+
+			server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+			server.config.ValidationOnlyKeyRefresh = tt.mode
+
+			mockServer := NewMockK8sServer(t)
+			defer mockServer.Close()
+			mockedResponse := CreateConnectionAccessReviewResponse("ns2", "app2", claims.User, claims.Groups, claims.UID, true, false, "allowed")
+			mockServer.SetupServer200OK(mockedResponse)
+			restClient, err := mockServer.CreateRESTClient()
+			require.NoError(t, err)
+			server.restClient = restClient
+
+			req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+			req.Header.Set(HeaderForwardedURI, testAppPath2+"/lab")
+			req.Header.Set(HeaderForwardedHost, "example.com")
+			w := httptest.NewRecorder()
+
+			server.handleVerify(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectCleared, cleared)
+			if tt.expectCleared {
+				assert.Contains(t, w.Body.String(), "please sign in again")
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+			}
+		})
+	}
+}
+
+func TestHandleVerify_CustomForwardedHeaders(t *testing.T) {
+	cookieHandler := &MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return testCookieToken, nil
+		},
+	}
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc: func(string) (*jwt.Claims, error) {
+			return &jwt.Claims{
+				User:      "user",
+				Path:      testAppPath2,
+				Domain:    "example.com",
+				TokenType: jwt.TokenTypeSession,
+			}, nil
+		},
+		ShouldRefreshTokenFunc: func(*jwt.Claims) bool { return false },
+	}
+	server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+	server.config.ForwardedHostHeader = "X-Original-Host"
+	server.config.ForwardedURIHeader = "X-Original-Uri"
+
+	req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+	req.Header.Set(HeaderForwardedURI, testAppPath2+"/lab")
+	req.Header.Set(HeaderForwardedHost, "example.com")
+	w := httptest.NewRecorder()
+	server.handleVerify(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Missing X-Original-Uri header")
+
+	req = httptest.NewRequest(http.MethodGet, "/verify", nil)
+	req.Header.Set("X-Original-Uri", testAppPath2+"/lab")
+	req.Header.Set("X-Original-Host", "example.com")
+	w = httptest.NewRecorder()
+	server.handleVerify(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestHandleVerify_JWKSExternalToken tests that with jwks signing, a token shaped like an
+// external identity provider's (sub, no token type or domain) is accepted and never refreshed
+// newJWKSVerifyTestServer starts a fake identity provider publishing an ES256 key and
+// returns a jwks mode server trusting it, with a function signing IdP tokens for groups
+func newJWKSVerifyTestServer(t *testing.T) (*Server, func(groups ...string) string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecdhKey, err := key.PublicKey.ECDH()
+	require.NoError(t, err)
+	point := ecdhKey.Bytes() // 0x04 || X || Y
+	keySet, err := json.Marshal(jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{{
+		Kty: "EC",
+		Kid: "idp-key-1",
+		Use: "sig",
+		Alg: jwt.AlgorithmES256,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(point[1:33]),
+		Y:   base64.RawURLEncoding.EncodeToString(point[33:]),
+	}}})
+	require.NoError(t, err)
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(keySet)
+	}))
+	t.Cleanup(idp.Close)
+
+	verifier := jwt.NewJWKSVerifier(idp.URL, "https://idp.example.com", "workspace-users")
+	require.NoError(t, verifier.Refresh(context.Background()))
+	// Expires within the refresh window, so a refresh would be attempted for our own tokens
+	manager := jwt.NewManager(verifier, true, 15*time.Minute, 12*time.Hour)
+
+	signToken := func(groups ...string) string {
+		now := time.Now()
+		idpToken := jwt5.NewWithClaims(jwt5.SigningMethodES256, &jwt.Claims{
+			RegisteredClaims: jwt5.RegisteredClaims{
+				Issuer:    "https://idp.example.com",
+				Subject:   "alice@example.com",
+				Audience:  jwt5.ClaimStrings{"workspace-users"},
+				IssuedAt:  jwt5.NewNumericDate(now),
+				ExpiresAt: jwt5.NewNumericDate(now.Add(5 * time.Minute)),
+			},
+			Groups: groups,
+		})
+		idpToken.Header["kid"] = "idp-key-1"
+		token, err := idpToken.SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+
+	server := &Server{
+		config: &Config{
+			PathRegexPattern: DefaultPathRegexPattern,
+			JWTSigningType:   JWTSigningTypeJWKS,
+			JWTRefreshEnable: true,
+			TokenSource:      TokenSourceHeaderOnly,
+		},
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		jwtManager: manager,
+	}
+	return server, signToken
+}
+
+func TestHandleVerify_JWKSExternalToken(t *testing.T) {
+	server, signToken := newJWKSVerifyTestServer(t)
+	token := signToken()
+
+	req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+	req.Header.Set(HeaderForwardedURI, testAppPath2+"/lab")
+	req.Header.Set(HeaderForwardedHost, "example.com")
+	req.Header.Set(HeaderAuthorization, "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	server.handleVerify(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "alice@example.com", w.Header().Get(HeaderAuthUser))
+	assert.Empty(t, w.Result().Cookies(), "external tokens must not be refreshed")
+
+	// The same token is still rejected when the middleware issues its own tokens
+	server.config.JWTSigningType = JWTSigningTypeStandard
+	w = httptest.NewRecorder()
+	server.handleVerify(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestHandleVerify_JWKSDeniedForOtherWorkspace tests that an external token, which is
+// not bound to a workspace domain, only reaches the hosts its groups are authorized for
+func TestHandleVerify_JWKSDeniedForOtherWorkspace(t *testing.T) {
+	server, signToken := newJWKSVerifyTestServer(t)
+	server.authorizer = NewRuleAuthorizer(
+		GroupRule{Host: "team-a.example.com", Groups: []string{"team-a"}},
+		GroupRule{Host: "team-b.example.com", Groups: []string{"team-b"}},
+	).WithDenyUnmatched()
+	token := signToken("team-a")
+
+	tests := []struct {
+		host string
+		code int
+	}{
+		{host: "team-a.example.com", code: http.StatusOK},
+		{host: "team-b.example.com", code: http.StatusForbidden},
+		{host: "team-c.example.com", code: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+			req.Header.Set(HeaderForwardedURI, testAppPath2+"/lab")
+			req.Header.Set(HeaderForwardedHost, tt.host)
+			req.Header.Set(HeaderAuthorization, "Bearer "+token)
+			w := httptest.NewRecorder()
+
+			server.handleVerify(w, req)
+
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+		})
+	}
+}
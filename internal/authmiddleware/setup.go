@@ -6,16 +6,22 @@ Distributed under the terms of the MIT license
 package authmiddleware
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
 )
 
 // SetupAuthMiddlewareWithManager sets up the authentication middleware server
-// and adds it to the manager as a Runnable.
-func SetupAuthMiddlewareWithManager(mgr ctrl.Manager, cfg *Config) error {
+// and adds it to the manager as a Runnable. Options are passed to NewServer.
+// ctx should be the context the manager is started with, so setup stops on shutdown.
+func SetupAuthMiddlewareWithManager(ctx context.Context, mgr ctrl.Manager, cfg *Config, opts ...ServerOption) error {
 	if cfg == nil {
 		return fmt.Errorf("config cannot be nil")
 	}
@@ -31,28 +37,88 @@ func SetupAuthMiddlewareWithManager(mgr ctrl.Manager, cfg *Config) error {
 	// Get controller-runtime client from manager (for testability)
 	runtimeClient := mgr.GetClient()
 
+	// Enforce group rules ahead of the caller's options, so an explicit WithAuthorizer wins.
+	// External tokens are not bound to a workspace domain, so in jwks mode a host without
+	// a rule is denied rather than open to every user of the issuer.
+	if len(cfg.AuthzGroupRules) > 0 {
+		authorizer := NewRuleAuthorizer(cfg.AuthzGroupRules...).
+			WithForwardedHeaders(cfg.ForwardedHostHeader, cfg.ForwardedURIHeader)
+		if cfg.JWTSigningType == JWTSigningTypeJWKS {
+			authorizer = authorizer.WithDenyUnmatched()
+		}
+		opts = append([]ServerOption{WithAuthorizer(authorizer)}, opts...)
+	}
+
+	// Keep a revocation list when the /revoke endpoint is enabled
+	var revocations jwt.RevocationStore
+	if cfg.RevocationAdminToken != "" {
+		revocations = jwt.NewMemoryRevocationStore(
+			jwt.WithRevocationLeeway(cmp.Or(cfg.JWTClockSkewLeeway, DefaultJwtClockSkewLeeway)))
+		opts = append(opts, WithRevocationStore(revocations))
+	}
+
 	// Create JWT handler
-	jwtHandler, standardSigner, err := NewJWTHandler(cfg, logrLogger.WithName("jwt"))
+	jwtHandler, signer, err := NewJWTHandler(cfg, logrLogger.WithName("jwt"), revocations)
 	if err != nil {
 		return fmt.Errorf("failed to create JWT handler: %w", err)
 	}
 
-	// Register secret watching event handlers if using standard signing
-	if standardSigner != nil {
+	// Register secret watching event handlers so rotated keys are picked up
+	if signer != nil {
 		logrLogger.Info("Registering secret watch event handlers",
 			"secret", cfg.JwtSecretName,
 			"namespace", cfg.Namespace)
 
-		if err := standardSigner.RegisterSecretWatch(
+		if err := signer.RegisterSecretWatch(
+			ctx,
 			mgr,
 			cfg.JwtSecretName,
 			cfg.Namespace,
+			cfg.JwtSecretWatchSyncTimeout,
 			logrLogger.WithName("secret-watch"),
 		); err != nil {
 			return fmt.Errorf("failed to register secret watch handlers: %w", err)
 		}
 	}
 
+	// Export the age distribution of the loaded keys
+	if provider, ok := signer.(jwt.SnapshotProvider); ok {
+		if err := metrics.Registry.Register(jwt.NewKeyAgeCollector(provider)); err != nil {
+			return fmt.Errorf("failed to register key age metrics: %w", err)
+		}
+	}
+
+	// Export per-kid sign and validation counts, to catch replicas stuck on a retired key
+	if reporter, ok := signer.(jwt.KeyUsageReporter); ok {
+		if err := metrics.Registry.Register(jwt.NewKeyUsageCollector(reporter)); err != nil {
+			return fmt.Errorf("failed to register key usage metrics: %w", err)
+		}
+	}
+
+	// Publish the loaded signing keys to a SigningKeySet when enabled
+	if cfg.SigningStatusInterval > 0 {
+		provider, ok := signer.(jwt.SnapshotProvider)
+		if !ok {
+			return fmt.Errorf("signing type %q does not support signing key status reporting", cfg.JWTSigningType)
+		}
+		reporter := NewSigningStatusReporter(runtimeClient, provider, cfg, logrLogger.WithName("signing-status"))
+		if err := mgr.Add(reporter); err != nil {
+			return fmt.Errorf("failed to add signing status reporter to manager: %w", err)
+		}
+	}
+
+	// Drop keys past the max retention even if the secret watch stops delivering updates
+	if cfg.JWTMaxKeyRetention > 0 {
+		remover, ok := signer.(jwt.ExpiredKeyRemover)
+		if !ok {
+			return fmt.Errorf("signing type %q does not support a max key retention", cfg.JWTSigningType)
+		}
+		sweeper := NewKeyRetentionSweeper(remover, logrLogger.WithName("key-retention"))
+		if err := mgr.Add(sweeper); err != nil {
+			return fmt.Errorf("failed to add key retention sweeper to manager: %w", err)
+		}
+	}
+
 	// Create cookie manager
 	cookieManager, err := NewCookieManager(cfg)
 	if err != nil {
@@ -60,17 +126,29 @@ func SetupAuthMiddlewareWithManager(mgr ctrl.Manager, cfg *Config) error {
 	}
 
 	// Create HTTP server
-	server := NewServer(cfg, jwtHandler, cookieManager, slogLogger)
+	server := NewServer(cfg, jwtHandler, cookieManager, slogLogger, opts...)
+	if publisher, ok := signer.(jwt.KeySetPublisher); ok {
+		server.keySetPublisher = publisher
+	}
+	if cfg.EnableKeyUsageReport {
+		reporter, ok := signer.(jwt.KeyUsageReporter)
+		if !ok {
+			return fmt.Errorf("signing type %q does not support key usage reporting", cfg.JWTSigningType)
+		}
+		server.keyUsageReporter = reporter
+	}
 
 	// Wrap server in HTTPServerRunnable
-	// Pass standardSigner and secret info for initial key loading on start
+	// Pass signer and secret info for initial key loading on start
 	httpServerRunnable := NewHTTPServerRunnable(
 		server,
 		logrLogger.WithName("http-server"),
 		runtimeClient,
-		standardSigner,
+		signer,
 		cfg.JwtSecretName,
 		cfg.Namespace,
+		WithInitialSecretRetry(cfg.InitialSecretLoadTimeout, cfg.InitialSecretLoadMaxAttempts),
+		WithSigningKeyHealthGracePeriod(cfg.SigningKeyHealthGracePeriod),
 	)
 
 	logrLogger.Info("Adding HTTP server to manager")
@@ -78,6 +156,17 @@ func SetupAuthMiddlewareWithManager(mgr ctrl.Manager, cfg *Config) error {
 		return fmt.Errorf("failed to add HTTP server to manager: %w", err)
 	}
 
+	// Keep the pod out of the Service endpoints until it can validate tokens
+	if err := mgr.AddReadyzCheck("signing-keys", httpServerRunnable.ReadyzCheck); err != nil {
+		return fmt.Errorf("failed to add signing keys readiness check: %w", err)
+	}
+
+	// Restart the pod if it stays unable to issue tokens; the Ping check in main is kept
+	// separate so a brief key gap alone does not fail liveness
+	if err := mgr.AddHealthzCheck("signing-key", httpServerRunnable.SigningKeyHealthzCheck); err != nil {
+		return fmt.Errorf("failed to add signing key health check: %w", err)
+	}
+
 	logrLogger.Info("Authentication middleware setup complete")
 	return nil
 }
@@ -0,0 +1,137 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workspacev1alpha1 "github.com/jupyter-infra/jupyter-k8s/api/v1alpha1"
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+// SigningStatusReporter periodically publishes the signing keys this replica has loaded
+// to a SigningKeySet named after its pod, so `kubectl get signingkeysets` shows the
+// key state of every replica.
+type SigningStatusReporter struct {
+	runtimeClient client.Client
+	signer        jwt.SnapshotProvider
+	name          string
+	namespace     string
+	podUID        string
+	secretName    string
+	interval      time.Duration
+	logger        logr.Logger
+	now           func() time.Time
+}
+
+// NewSigningStatusReporter creates a SigningStatusReporter for the replica described by cfg
+func NewSigningStatusReporter(
+	runtimeClient client.Client,
+	signer jwt.SnapshotProvider,
+	cfg *Config,
+	logger logr.Logger,
+) *SigningStatusReporter {
+	return &SigningStatusReporter{
+		runtimeClient: runtimeClient,
+		signer:        signer,
+		name:          cfg.PodName,
+		namespace:     cfg.Namespace,
+		podUID:        cfg.PodUID,
+		secretName:    cfg.JwtSecretName,
+		interval:      cfg.SigningStatusInterval,
+		logger:        logger,
+		now:           time.Now,
+	}
+}
+
+// Start implements the Runnable interface. It reports once immediately, then every
+// interval until the context is cancelled. Failures are logged and retried on the next tick.
+func (r *SigningStatusReporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Report(ctx); err != nil {
+			r.logger.Error(err, "Failed to publish signing key status", "name", r.name)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements the Runnable interface.
+// Returns false because every replica reports its own key set.
+func (r *SigningStatusReporter) NeedLeaderElection() bool {
+	return false
+}
+
+// Report publishes the signer's current key state, creating the SigningKeySet if needed
+func (r *SigningStatusReporter) Report(ctx context.Context) error {
+	snapshot := r.signer.Snapshot()
+
+	keySet := &workspacev1alpha1.SigningKeySet{}
+	err := r.runtimeClient.Get(ctx, types.NamespacedName{Name: r.name, Namespace: r.namespace}, keySet)
+	if apierrors.IsNotFound(err) {
+		keySet = r.newSigningKeySet()
+		if err := r.runtimeClient.Create(ctx, keySet); err != nil {
+			return fmt.Errorf("failed to create SigningKeySet %s: %w", r.name, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get SigningKeySet %s: %w", r.name, err)
+	}
+
+	reportTime := metav1.NewTime(r.now())
+	keySet.Status = workspacev1alpha1.SigningKeySetStatus{
+		Kids:               snapshot.Kids,
+		KeyCount:           len(snapshot.Kids),
+		LatestKid:          snapshot.LatestKid,
+		SigningKid:         snapshot.SigningKid,
+		ValidationOnlyKids: snapshot.ValidationOnlyKids,
+		LastReportTime:     &reportTime,
+	}
+	if err := r.runtimeClient.Status().Update(ctx, keySet); err != nil {
+		return fmt.Errorf("failed to update SigningKeySet %s status: %w", r.name, err)
+	}
+	return nil
+}
+
+// newSigningKeySet builds the SigningKeySet for this replica, owned by its pod when the UID is known
+func (r *SigningStatusReporter) newSigningKeySet() *workspacev1alpha1.SigningKeySet {
+	keySet := &workspacev1alpha1.SigningKeySet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.name,
+			Namespace: r.namespace,
+			Labels: map[string]string{
+				"app": "authmiddleware",
+			},
+		},
+		Spec: workspacev1alpha1.SigningKeySetSpec{
+			SecretName: r.secretName,
+		},
+	}
+	if r.podUID != "" {
+		keySet.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Pod",
+			Name:       r.name,
+			UID:        types.UID(r.podUID),
+		}}
+	}
+	return keySet
+}
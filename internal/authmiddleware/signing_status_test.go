@@ -0,0 +1,104 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	workspacev1alpha1 "github.com/jupyter-infra/jupyter-k8s/api/v1alpha1"
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+func newSigningStatusTestClient(t *testing.T) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, workspacev1alpha1.AddToScheme(scheme))
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&workspacev1alpha1.SigningKeySet{}).
+		Build()
+}
+
+func signingStatusTestConfig() *Config {
+	return &Config{
+		Namespace:             "auth",
+		PodName:               "authmiddleware-abc",
+		PodUID:                "pod-uid",
+		JwtSecretName:         "authmiddleware-secrets",
+		SigningStatusInterval: time.Minute,
+	}
+}
+
+func getSigningKeySet(t *testing.T, c client.Client) *workspacev1alpha1.SigningKeySet {
+	t.Helper()
+	keySet := &workspacev1alpha1.SigningKeySet{}
+	require.NoError(t, c.Get(context.Background(),
+		types.NamespacedName{Name: "authmiddleware-abc", Namespace: "auth"}, keySet))
+	return keySet
+}
+
+func TestSigningStatusReporter_ReflectsUpdateKeys(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := newSigningStatusTestClient(t)
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	signer := jwt.NewStandardSigner("issuer", "audience", time.Hour, 5*time.Second, jwt.WithClock(clock))
+	key := []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long")
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key}, "1000"))
+
+	reporter := NewSigningStatusReporter(k8sClient, signer, signingStatusTestConfig(), logr.Discard())
+	reporter.now = clock
+
+	now = now.Add(10 * time.Second)
+	require.NoError(t, reporter.Report(ctx))
+
+	keySet := getSigningKeySet(t, k8sClient)
+	assert.Equal(t, "authmiddleware-secrets", keySet.Spec.SecretName)
+	assert.Equal(t, []string{"1000"}, keySet.Status.Kids)
+	assert.Equal(t, 1, keySet.Status.KeyCount)
+	assert.Equal(t, "1000", keySet.Status.LatestKid)
+	assert.Equal(t, "1000", keySet.Status.SigningKid)
+	require.Len(t, keySet.OwnerReferences, 1)
+	assert.Equal(t, "Pod", keySet.OwnerReferences[0].Kind)
+	assert.Equal(t, types.UID("pod-uid"), keySet.OwnerReferences[0].UID)
+
+	// A rotation adds a key that is loaded but still in cooloff
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key, "2000": key}, "2000"))
+	require.NoError(t, reporter.Report(ctx))
+
+	keySet = getSigningKeySet(t, k8sClient)
+	assert.Equal(t, []string{"1000", "2000"}, keySet.Status.Kids)
+	assert.Equal(t, 2, keySet.Status.KeyCount)
+	assert.Equal(t, "2000", keySet.Status.LatestKid)
+	assert.Equal(t, "1000", keySet.Status.SigningKid)
+	require.NotNil(t, keySet.Status.LastReportTime)
+	assert.Equal(t, now.Unix(), keySet.Status.LastReportTime.Unix())
+}
+
+func TestSigningStatusReporter_NoOwnerWithoutPodUID(t *testing.T) {
+	k8sClient := newSigningStatusTestClient(t)
+	signer := jwt.NewStandardSigner("issuer", "audience", time.Hour, 0)
+
+	cfg := signingStatusTestConfig()
+	cfg.PodUID = ""
+	reporter := NewSigningStatusReporter(k8sClient, signer, cfg, logr.Discard())
+	require.NoError(t, reporter.Report(context.Background()))
+
+	keySet := getSigningKeySet(t, k8sClient)
+	assert.Empty(t, keySet.OwnerReferences)
+	assert.Equal(t, 0, keySet.Status.KeyCount)
+}
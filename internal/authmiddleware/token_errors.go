@@ -0,0 +1,68 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+)
+
+// RFC 6750 error codes used in WWW-Authenticate challenges
+const (
+	bearerErrorInvalidRequest = "invalid_request"
+	bearerErrorInvalidToken   = "invalid_token"
+)
+
+// tokenErrorClass is how a token validation failure is answered
+type tokenErrorClass struct {
+	status      int
+	code        string
+	description string
+}
+
+// tokenErrorClasses maps jwt validation errors to responses, most specific first:
+// a malformed token is a bad request, a token for another issuer or audience is
+// forbidden, and any other failure, such as an expired or revoked token, is unauthorized
+var tokenErrorClasses = []struct {
+	err   error
+	class tokenErrorClass
+}{
+	{jwt5.ErrTokenMalformed, tokenErrorClass{http.StatusBadRequest, bearerErrorInvalidRequest, "malformed token"}},
+	{jwt.ErrMissingKid, tokenErrorClass{http.StatusBadRequest, bearerErrorInvalidRequest, "missing key ID"}},
+	{jwt.ErrWrongIssuer, tokenErrorClass{http.StatusForbidden, bearerErrorInvalidToken, "issuer not accepted"}},
+	{jwt.ErrWrongAudience, tokenErrorClass{http.StatusForbidden, bearerErrorInvalidToken, "audience not accepted"}},
+	{jwt.ErrTokenExpired, tokenErrorClass{http.StatusUnauthorized, bearerErrorInvalidToken, "token expired"}},
+	{jwt.ErrInvalidSignature, tokenErrorClass{http.StatusUnauthorized, bearerErrorInvalidToken, "invalid signature"}},
+	{jwt.ErrTokenRevoked, tokenErrorClass{http.StatusUnauthorized, bearerErrorInvalidToken, "token revoked"}},
+	{jwt.ErrUnknownKid, tokenErrorClass{http.StatusUnauthorized, bearerErrorInvalidToken, "unknown key ID"}},
+	{jwt.ErrAlgorithmNotAllowed, tokenErrorClass{http.StatusUnauthorized, bearerErrorInvalidToken, "signing algorithm not allowed"}},
+}
+
+// defaultTokenErrorClass answers validation failures no other class matches
+var defaultTokenErrorClass = tokenErrorClass{http.StatusUnauthorized, bearerErrorInvalidToken, "invalid token"}
+
+// classifyTokenError returns the response for a token validation error
+func classifyTokenError(err error) tokenErrorClass {
+	for _, c := range tokenErrorClasses {
+		if errors.Is(err, c.err) {
+			return c.class
+		}
+	}
+	return defaultTokenErrorClass
+}
+
+// writeTokenValidationError answers a failed token validation with the status of its
+// error class and an RFC 6750 challenge describing it
+func writeTokenValidationError(w http.ResponseWriter, err error) {
+	class := classifyTokenError(err)
+	w.Header().Set("WWW-Authenticate",
+		fmt.Sprintf(`Bearer error="%s", error_description="%s"`, class.code, class.description))
+	http.Error(w, http.StatusText(class.status), class.status)
+}
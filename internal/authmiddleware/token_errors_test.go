@@ -0,0 +1,103 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyTokenError(t *testing.T) {
+	signer := jwt.NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	_, malformedErr := signer.ValidateToken("not.a.jwt")
+	require.Error(t, malformedErr)
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "malformed", err: malformedErr, status: http.StatusBadRequest, code: bearerErrorInvalidRequest},
+		{name: "missing kid", err: fmt.Errorf("%w: %w", jwt.ErrInvalidToken, jwt.ErrMissingKid),
+			status: http.StatusBadRequest, code: bearerErrorInvalidRequest},
+		{name: "wrong issuer", err: fmt.Errorf("%w: %w", jwt.ErrInvalidToken, jwt.ErrWrongIssuer),
+			status: http.StatusForbidden, code: bearerErrorInvalidToken},
+		{name: "wrong audience", err: fmt.Errorf("%w: %w", jwt.ErrInvalidToken, jwt.ErrWrongAudience),
+			status: http.StatusForbidden, code: bearerErrorInvalidToken},
+		{name: "expired", err: jwt.ErrTokenExpired, status: http.StatusUnauthorized, code: bearerErrorInvalidToken},
+		{name: "invalid signature", err: jwt.ErrInvalidSignature, status: http.StatusUnauthorized, code: bearerErrorInvalidToken},
+		{name: "revoked", err: jwt.ErrTokenRevoked, status: http.StatusUnauthorized, code: bearerErrorInvalidToken},
+		{name: "unknown kid", err: fmt.Errorf("%w: %w: 9999", jwt.ErrInvalidToken, jwt.ErrUnknownKid),
+			status: http.StatusUnauthorized, code: bearerErrorInvalidToken},
+		{name: "algorithm not allowed", err: fmt.Errorf("%w: %q", jwt.ErrAlgorithmNotAllowed, "none"),
+			status: http.StatusUnauthorized, code: bearerErrorInvalidToken},
+		{name: "other", err: errors.New("boom"), status: http.StatusUnauthorized, code: bearerErrorInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeTokenValidationError(w, tt.err)
+
+			assert.Equal(t, tt.status, w.Code)
+			challenge := w.Header().Get("WWW-Authenticate")
+			assert.Contains(t, challenge, fmt.Sprintf(`Bearer error="%s"`, tt.code))
+			assert.Contains(t, challenge, fmt.Sprintf(`error_description="%s"`, classifyTokenError(tt.err).description))
+		})
+	}
+}
+
+func TestHandleVerify_WrongAudienceIsForbidden(t *testing.T) {
+	cookieHandler := &MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return testCookieToken, nil
+		},
+	}
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc: func(string) (*jwt.Claims, error) {
+			return nil, fmt.Errorf("%w: %w", jwt.ErrInvalidToken, jwt.ErrWrongAudience)
+		},
+	}
+	server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+
+	req := httptest.NewRequest(http.MethodGet, routeVerify, nil)
+	req.Header.Set(HeaderForwardedURI, testAppPath2+"/lab")
+	req.Header.Set(HeaderForwardedHost, "example.com")
+	w := httptest.NewRecorder()
+	server.handleVerify(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token", error_description="audience not accepted"`,
+		w.Header().Get("WWW-Authenticate"))
+}
+
+func TestHandleRefresh_ExpiredTokenChallenge(t *testing.T) {
+	cookieHandler := &MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return testCookieToken, nil
+		},
+	}
+	jwtHandler := &MockJWTHandler{
+		ValidateTokenFunc: func(string) (*jwt.Claims, error) { return nil, jwt.ErrTokenExpired },
+	}
+	server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+
+	w := httptest.NewRecorder()
+	server.handleRefresh(w, newRefreshRequest())
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token", error_description="token expired"`,
+		w.Header().Get("WWW-Authenticate"))
+}
@@ -0,0 +1,71 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Token sources for session tokens presented to /verify and /refresh
+const (
+	// TokenSourceHeaderFirst prefers a bearer token in the Authorization header over the cookie
+	TokenSourceHeaderFirst = "header-first"
+	// TokenSourceCookieFirst prefers the cookie over a bearer token in the Authorization header
+	TokenSourceCookieFirst = "cookie-first"
+	// TokenSourceHeaderOnly only accepts a bearer token in the Authorization header
+	TokenSourceHeaderOnly = "header-only"
+	// TokenSourceCookieOnly only accepts the cookie
+	TokenSourceCookieOnly = "cookie-only"
+)
+
+// ErrNoSessionToken is returned when no configured source carries a session token
+var ErrNoSessionToken = errors.New("no session token in request")
+
+// sessionToken returns the session token presented with r, reading the Authorization
+// header and the cookie for path in the order set by TokenSource. An Authorization
+// header that is not a bearer token, such as a Jupyter token, counts as absent.
+// WebSocket handshakes always fall back to the cookie, since browsers cannot set
+// Authorization on them.
+func (s *Server) sessionToken(r *http.Request, path string) (string, error) {
+	fromHeader := func() (string, error) {
+		token, err := ExtractBearerToken(r.Header.Get(HeaderAuthorization))
+		if err != nil {
+			return "", errors.Join(ErrNoSessionToken, err)
+		}
+		return token, nil
+	}
+	fromCookie := func() (string, error) {
+		return s.cookieManager.GetCookie(r, path)
+	}
+
+	switch s.config.TokenSource {
+	case TokenSourceHeaderOnly:
+		if isWebSocketUpgrade(r) {
+			if token, err := fromHeader(); err == nil {
+				return token, nil
+			}
+			return fromCookie()
+		}
+		return fromHeader()
+	case TokenSourceHeaderFirst:
+		if token, err := fromHeader(); err == nil {
+			return token, nil
+		}
+		return fromCookie()
+	case TokenSourceCookieFirst:
+		token, err := fromCookie()
+		if err == nil {
+			return token, nil
+		}
+		if headerToken, headerErr := fromHeader(); headerErr == nil {
+			return headerToken, nil
+		}
+		return "", err
+	default:
+		return fromCookie()
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		header      string
+		cookie      string
+		expected    string
+		expectError bool
+	}{
+		{name: "header-first, both present", source: TokenSourceHeaderFirst, header: "Bearer header-token", cookie: "cookie-token", expected: "header-token"},
+		{name: "header-first, cookie only", source: TokenSourceHeaderFirst, cookie: "cookie-token", expected: "cookie-token"},
+		{name: "header-first, non-bearer header", source: TokenSourceHeaderFirst, header: "token jupyter", cookie: "cookie-token", expected: "cookie-token"},
+		{name: "header-first, neither", source: TokenSourceHeaderFirst, expectError: true},
+		{name: "cookie-first, both present", source: TokenSourceCookieFirst, header: "Bearer header-token", cookie: "cookie-token", expected: "cookie-token"},
+		{name: "cookie-first, header only", source: TokenSourceCookieFirst, header: "Bearer header-token", expected: "header-token"},
+		{name: "cookie-first, neither", source: TokenSourceCookieFirst, expectError: true},
+		{name: "header-only, both present", source: TokenSourceHeaderOnly, header: "Bearer header-token", cookie: "cookie-token", expected: "header-token"},
+		{name: "header-only, cookie only", source: TokenSourceHeaderOnly, cookie: "cookie-token", expectError: true},
+		{name: "cookie-only, both present", source: TokenSourceCookieOnly, header: "Bearer header-token", cookie: "cookie-token", expected: "cookie-token"},
+		{name: "cookie-only, header only", source: TokenSourceCookieOnly, header: "Bearer header-token", expectError: true},
+		{name: "unset defaults to cookie", header: "Bearer header-token", cookie: "cookie-token", expected: "cookie-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := createVerifyRefreshTestServer(&MockCookieHandler{
+				GetCookieFunc: func(r *http.Request, path string) (string, error) {
+					if tt.cookie == "" {
+						return "", ErrNoCookie
+					}
+					return tt.cookie, nil
+				},
+			}, &MockJWTHandler{})
+			server.config.TokenSource = tt.source
+
+			req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderAuthorization, tt.header)
+			}
+
+			token, err := server.sessionToken(req, testAppPath2+"/lab")
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, token)
+		})
+	}
+}
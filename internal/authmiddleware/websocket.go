@@ -0,0 +1,28 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// isWebSocketUpgrade reports whether r is a WebSocket opening handshake (RFC 6455),
+// such as a Jupyter kernel channel. The proxy must pass the Connection and Upgrade
+// headers on to /verify for it to be recognized.
+func isWebSocketUpgrade(r *http.Request) bool {
+	if !strings.EqualFold(strings.TrimSpace(r.Header.Get(HeaderUpgrade)), "websocket") {
+		return false
+	}
+	for _, value := range r.Header.Values(HeaderConnection) {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package authmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// newWebSocketVerifyRequest builds the /verify subrequest for a kernel channel handshake
+func newWebSocketVerifyRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodGet, routeVerify, nil)
+	req.Header.Set(HeaderForwardedURI, testAppPath2+"/api/kernels/k1/channels")
+	req.Header.Set(HeaderForwardedHost, "example.com")
+	req.Header.Set(HeaderConnection, "keep-alive, Upgrade")
+	req.Header.Set(HeaderUpgrade, "websocket")
+	return req
+}
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	tests := []struct {
+		name       string
+		connection string
+		upgrade    string
+		expected   bool
+	}{
+		{name: "handshake", connection: "Upgrade", upgrade: "websocket", expected: true},
+		{name: "connection token list", connection: "keep-alive, upgrade", upgrade: "WebSocket", expected: true},
+		{name: "plain request", connection: "keep-alive", expected: false},
+		{name: "upgrade without connection token", connection: "keep-alive", upgrade: "websocket", expected: false},
+		{name: "other protocol", connection: "Upgrade", upgrade: "h2c", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, routeVerify, nil)
+			req.Header.Set(HeaderConnection, tt.connection)
+			if tt.upgrade != "" {
+				req.Header.Set(HeaderUpgrade, tt.upgrade)
+			}
+			assert.Equal(t, tt.expected, isWebSocketUpgrade(req))
+		})
+	}
+}
+
+func TestHandleVerify_WebSocketUpgradeWithCookie(t *testing.T) {
+	for _, source := range []string{TokenSourceCookieOnly, TokenSourceHeaderFirst, TokenSourceHeaderOnly} {
+		t.Run(source, func(t *testing.T) {
+			cookieHandler := &MockCookieHandler{
+				GetCookieFunc: func(r *http.Request, path string) (string, error) {
+					return testCookieToken, nil
+				},
+			}
+			jwtHandler := &MockJWTHandler{
+				ValidateTokenFunc: func(token string) (*jwt.Claims, error) {
+					assert.Equal(t, testCookieToken, token)
+					return &jwt.Claims{
+						User:      "alice",
+						Groups:    []string{"data-science", "team,a"},
+						Path:      testAppPath2,
+						Domain:    "example.com",
+						TokenType: jwt.TokenTypeSession,
+					}, nil
+				},
+				ShouldRefreshTokenFunc: func(*jwt.Claims) bool { return false },
+			}
+			server := createVerifyRefreshTestServer(cookieHandler, jwtHandler)
+			server.config.TokenSource = source
+
+			w := httptest.NewRecorder()
+			server.handleVerify(w, newWebSocketVerifyRequest())
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "alice", w.Header().Get(HeaderAuthUser))
+			assert.Equal(t, `data-science,"team,a"`, w.Header().Get(HeaderAuthGroups))
+			assert.Equal(t, []string{"data-science", "team,a"}, splitGroups(w.Header().Get(HeaderAuthGroups)))
+		})
+	}
+}
+
+func TestHandleVerify_WebSocketUpgradeWithoutCookie(t *testing.T) {
+	cookieHandler := &MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return "", ErrNoCookie
+		},
+	}
+	server := createVerifyRefreshTestServer(cookieHandler, &MockJWTHandler{})
+
+	w := httptest.NewRecorder()
+	server.handleVerify(w, newWebSocketVerifyRequest())
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderAuthUser))
+}
+
+func TestSessionToken_HeaderOnlyRequiresHeaderOutsideWebSockets(t *testing.T) {
+	server := createVerifyRefreshTestServer(&MockCookieHandler{
+		GetCookieFunc: func(r *http.Request, path string) (string, error) {
+			return testCookieToken, nil
+		},
+	}, &MockJWTHandler{})
+	server.config.TokenSource = TokenSourceHeaderOnly
+
+	_, err := server.sessionToken(httptest.NewRequest(http.MethodGet, routeVerify, nil), testAppPath2)
+	assert.ErrorIs(t, err, ErrNoSessionToken)
+}
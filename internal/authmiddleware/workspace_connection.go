@@ -40,7 +40,7 @@ func (s *Server) ExtractWorkspaceInfo(r *http.Request) (*WorkspaceInfo, error) {
 
 // extractWorkspaceInfoFromPath extracts workspace info from URL path
 func (s *Server) extractWorkspaceInfoFromPath(r *http.Request) (*WorkspaceInfo, error) {
-	path, err := GetForwardedURI(r)
+	path, err := GetForwardedURI(s.config, r)
 	if err != nil {
 		return nil, err
 	}
@@ -67,13 +67,18 @@ func (s *Server) extractWorkspaceInfoFromPath(r *http.Request) (*WorkspaceInfo,
 
 // extractWorkspaceInfoFromSubdomain extracts workspace info from subdomain
 func (s *Server) extractWorkspaceInfoFromSubdomain(r *http.Request) (*WorkspaceInfo, error) {
-	host, err := GetForwardedHost(r)
+	host, err := GetForwardedHost(s.config, r)
 	if err != nil {
 		return nil, err
 	}
 
-	// Extract subdomain part (before first dot)
+	// Extract subdomain part (before first dot), only under the trusted base domain if set
 	subdomain := ExtractSubdomain(host)
+	if s.config.BaseDomain != "" {
+		if subdomain, err = ExtractTrustedSubdomain(host, s.config.BaseDomain); err != nil {
+			return nil, err
+		}
+	}
 
 	// Extract workspace name using regex
 	nameRe := regexp.MustCompile(s.config.WorkspaceNameSubdomainRegex)
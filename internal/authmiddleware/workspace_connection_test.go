@@ -7,6 +7,7 @@ package authmiddleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -558,6 +559,31 @@ func TestExtractWorkspaceInfo_SubdomainMode(t *testing.T) {
 	}
 }
 
+func TestExtractWorkspaceInfo_SubdomainMode_BaseDomain(t *testing.T) {
+	config := &Config{
+		RoutingMode:                      RoutingModeSubdomain,
+		WorkspaceNameSubdomainRegex:      `^([^-]+)-.*$`,
+		WorkspaceNamespaceSubdomainRegex: `^[^-]+-(.*)$`,
+		BaseDomain:                       "example.com",
+	}
+	server := &Server{config: config}
+
+	req := httptest.NewRequest("GET", "/bearer-auth", nil)
+	req.Header.Set("X-Forwarded-Host", "myworkspace-mrswmylvnr2a.example.com")
+	workspaceInfo, err := server.ExtractWorkspaceInfo(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if workspaceInfo.Name != TestWorkspaceName || workspaceInfo.Namespace != TestDefaultNamespace {
+		t.Errorf("unexpected workspace info %+v", workspaceInfo)
+	}
+
+	req.Header.Set("X-Forwarded-Host", "myworkspace-mrswmylvnr2a.evil.com")
+	if _, err := server.ExtractWorkspaceInfo(req); !errors.Is(err, ErrUntrustedHost) {
+		t.Errorf("expected ErrUntrustedHost, got %v", err)
+	}
+}
+
 func TestExtractWorkspaceInfo_PathMode(t *testing.T) {
 	config := &Config{
 		RoutingMode:                 RoutingModePath,
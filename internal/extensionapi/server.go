@@ -336,9 +336,11 @@ func SetupExtensionAPIServerWithManager(mgr ctrl.Manager, config *ExtensionConfi
 			"namespace", namespace)
 
 		if err := stdFactory.Signer().RegisterSecretWatch(
+			context.Background(),
 			mgr,
 			config.JwtSecretName,
 			namespace,
+			jwt.DefaultSecretWatchSyncTimeout,
 			logger.WithName("jwt-secret-watch"),
 		); err != nil {
 			return fmt.Errorf("failed to register JWT secret watch handlers: %w", err)
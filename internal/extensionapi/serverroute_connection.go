@@ -7,6 +7,7 @@ Distributed under the terms of the MIT license
 package extensionapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -66,9 +67,9 @@ func resolveConnectionHandler(accessStrategy *workspacev1alpha1.WorkspaceAccessS
 
 // validateConnection validates that a workspace is ready for connection and resolves context.
 // Returns the access strategy, resolved connection context, or an HTTP error.
-func (s *ExtensionServer) validateConnection(ws *workspacev1alpha1.Workspace, logger logr.Logger) (*workspacev1alpha1.WorkspaceAccessStrategy, map[string]string, int, error) {
+func (s *ExtensionServer) validateConnection(ctx context.Context, ws *workspacev1alpha1.Workspace, logger logr.Logger) (*workspacev1alpha1.WorkspaceAccessStrategy, map[string]string, int, error) {
 	// AccessStrategy should exist because the controller prevents deletion while workspaces reference it
-	accessStrategy, err := s.getAccessStrategy(ws)
+	accessStrategy, err := s.getAccessStrategy(ctx, ws)
 	if err != nil {
 		logger.Error(err, "Failed to get access strategy", "workspaceName", ws.Name)
 
@@ -145,7 +146,7 @@ func (s *ExtensionServer) generateBearerTokenURL(r *http.Request, ws *workspacev
 	// Generate JWT token with domain and path using access strategy-specific signer.
 	// skipRefresh=true: bootstrap tokens are exchanged immediately for session tokens
 	// via /bearer-auth, so refresh is not applicable.
-	token, err := signer.GenerateToken(user, groups, user, extra, path, domain, jwt.TokenTypeBootstrap, true)
+	token, err := signer.GenerateTokenContext(r.Context(), user, groups, user, extra, path, domain, jwt.TokenTypeBootstrap, true)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate JWT token: %w", err)
 	}
@@ -248,7 +249,7 @@ func (s *ExtensionServer) HandleConnectionCreate(w http.ResponseWriter, r *http.
 	}
 
 	// Validate connection readiness and resolve context
-	accessStrategy, resolvedContext, statusCode, err := s.validateConnection(ws, logger)
+	accessStrategy, resolvedContext, statusCode, err := s.validateConnection(r.Context(), ws, logger)
 	if err != nil {
 		WriteKubernetesError(w, statusCode, err.Error())
 		return
@@ -7,6 +7,7 @@ package extensionapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -55,6 +56,10 @@ func (m *mockSigner) GenerateToken(username string, groups []string, uid string,
 	return m.token, nil
 }
 
+func (m *mockSigner) GenerateTokenContext(ctx context.Context, username string, groups []string, uid string, extra map[string][]string, path string, domain string, tokenType string, skipRefresh bool) (string, error) {
+	return m.GenerateToken(username, groups, uid, extra, path, domain, tokenType, skipRefresh)
+}
+
 func (m *mockSigner) GenerateRefreshToken(claims *jwt.Claims) (string, error) {
 	return m.token, nil
 }
@@ -63,6 +68,10 @@ func (m *mockSigner) ValidateToken(tokenString string) (*jwt.Claims, error) {
 	return nil, nil
 }
 
+func (m *mockSigner) IsKnownKid(kid string) bool {
+	return true
+}
+
 // mockTokenValidator for testing
 type mockTokenValidator struct {
 	claims *jwt.Claims
@@ -581,7 +590,7 @@ func TestValidateConnection(t *testing.T) {
 			}
 
 			logger := ctrl.Log.WithName("test")
-			_, resolvedCtx, statusCode, err := server.validateConnection(tt.workspace, logger)
+			_, resolvedCtx, statusCode, err := server.validateConnection(context.Background(), tt.workspace, logger)
 
 			if statusCode != tt.expectedStatusCode {
 				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, statusCode)
@@ -13,7 +13,7 @@ import (
 )
 
 // getAccessStrategy fetches the AccessStrategy for the workspace
-func (s *ExtensionServer) getAccessStrategy(workspace *workspacev1alpha1.Workspace) (*workspacev1alpha1.WorkspaceAccessStrategy, error) {
+func (s *ExtensionServer) getAccessStrategy(ctx context.Context, workspace *workspacev1alpha1.Workspace) (*workspacev1alpha1.WorkspaceAccessStrategy, error) {
 	if workspace.Spec.AccessStrategy == nil {
 		return nil, nil // No AccessStrategy configured
 	}
@@ -25,7 +25,7 @@ func (s *ExtensionServer) getAccessStrategy(workspace *workspacev1alpha1.Workspa
 	}
 
 	var accessStrategy workspacev1alpha1.WorkspaceAccessStrategy
-	err := s.k8sClient.Get(context.Background(),
+	err := s.k8sClient.Get(ctx,
 		client.ObjectKey{
 			Name:      workspace.Spec.AccessStrategy.Name,
 			Namespace: accessStrategyNamespace,
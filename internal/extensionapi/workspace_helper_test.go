@@ -0,0 +1,74 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package extensionapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	workspacev1alpha1 "github.com/jupyter-infra/jupyter-k8s/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestGetAccessStrategy_UsesCallerContext(t *testing.T) {
+	workspace := &workspacev1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-workspace", Namespace: "default"},
+		Spec: workspacev1alpha1.WorkspaceSpec{
+			AccessStrategy: &workspacev1alpha1.AccessStrategyRef{Name: "test-strategy"},
+		},
+	}
+	accessStrategy := &workspacev1alpha1.WorkspaceAccessStrategy{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-strategy", Namespace: "default"},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = workspacev1alpha1.AddToScheme(scheme)
+
+	// The fake client ignores the context, so abort the Get the way a real client would
+	getCalled := false
+	fakeClient := ctrlclient.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(accessStrategy).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				getCalled = true
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	server := &ExtensionServer{k8sClient: fakeClient}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := server.getAccessStrategy(ctx, workspace)
+	if !getCalled {
+		t.Fatal("expected Get to be called")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if result != nil {
+		t.Errorf("expected no access strategy, got %v", result)
+	}
+
+	result, err = server.getAccessStrategy(context.Background(), workspace)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || result.Name != "test-strategy" {
+		t.Errorf("expected test-strategy, got %v", result)
+	}
+}
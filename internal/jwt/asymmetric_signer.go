@@ -0,0 +1,579 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Asymmetric signing algorithms supported by AsymmetricSigner
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for RS256 signing keys
+const minRSAKeyBits = 2048
+
+// AsymmetricSigner handles JWT token creation and validation using RSA or ECDSA keys.
+// It supports multiple keys for rotation like StandardSigner, and publishes the public
+// half of every loaded key so other services can verify tokens without the private keys.
+type AsymmetricSigner struct {
+	signingKeys    map[string]crypto.Signer // map[kid]private key
+	keyAddedTimes  map[string]time.Time     // map[kid]timestamp when key was added
+	latestKid      string                   // newest key ID
+	newKeyUseDelay time.Duration            // cooloff period before using a new key
+	method         jwt5.SigningMethod
+	issuer         string
+	audiences      []string // first is the constructor audience; all are set on tokens, any is accepted
+	expiration     time.Duration
+	leeway         time.Duration    // clock skew tolerated on validation, overridable via WithAsymmetricLeeway
+	now            func() time.Time // time source, overridable via WithAsymmetricClock
+	logger         logr.Logger
+	revocations    RevocationStore  // consulted on validation when set via WithAsymmetricRevocationStore
+	migration      *IssuerMigration // previous issuer/audience accepted, set via WithAsymmetricIssuerMigration
+	claimFallback  *ClaimFallback   // issuer/audience assumed for tokens omitting them, set via WithAsymmetricClaimFallback
+	subjectMatch   *SubjectMatch    // sub/User agreement enforced on validation, set via WithAsymmetricSubjectMatch
+	scope          string           // scope claim stamped on new tokens, set via WithAsymmetricScope
+	keyPrefixes    []string         // secret key name prefixes read, set via WithAsymmetricKeyPrefixes
+	retention      *retentionWarner // flags tokens outliving their key, set via WithAsymmetricKeyRetention
+	signingLag     signingLagWarner // flags signing with an older kid than the latest past its cooloff
+	usage          keyUsageCounter  // per-kid sign and validation counts
+	keyGeneration  atomic.Uint64    // incremented by every UpdateKeys
+	mu             sync.RWMutex     // protect key map, keyAddedTimes, and latestKid
+}
+
+// AsymmetricSignerOption configures optional AsymmetricSigner behavior
+type AsymmetricSignerOption func(*AsymmetricSigner)
+
+// WithAsymmetricClock overrides the time source used for cooloff tracking, token timestamps
+// and validation. Intended for tests; defaults to time.Now.
+func WithAsymmetricClock(now func() time.Time) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		if now != nil {
+			s.now = now
+		}
+	}
+}
+
+// WithAsymmetricLogger sets the logger used for security audit events.
+// Defaults to a discarding logger.
+func WithAsymmetricLogger(logger logr.Logger) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		s.logger = logger
+	}
+}
+
+// WithAsymmetricIssuerMigration accepts the previous issuer and audience until the
+// migration window elapses. Defaults to accepting only the configured values.
+func WithAsymmetricIssuerMigration(migration IssuerMigration) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		s.migration = &migration
+	}
+}
+
+// WithAsymmetricAdditionalAudiences sets further audiences on generated tokens besides
+// the one passed to NewAsymmetricSigner, and accepts tokens carrying any of them.
+// Defaults to the single constructor audience.
+func WithAsymmetricAdditionalAudiences(audiences ...string) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		s.audiences = appendAudiences(s.audiences, audiences)
+	}
+}
+
+// WithAsymmetricClaimFallback assumes the given issuer and audience for tokens that
+// omit them. Only intended for trusted integrations; defaults to requiring both claims.
+func WithAsymmetricClaimFallback(fallback ClaimFallback) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		s.claimFallback = &fallback
+	}
+}
+
+// WithAsymmetricSubjectMatch rejects tokens whose sub claim differs from the User claim.
+// Defaults to no comparison.
+func WithAsymmetricSubjectMatch(match SubjectMatch) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		s.subjectMatch = &match
+	}
+}
+
+// WithAsymmetricKeyPrefixes reads signing keys stored under any of prefixes, so keys
+// written under a new prefix and keys under the old one both load while migrating.
+// Defaults to KeyPrefix.
+func WithAsymmetricKeyPrefixes(prefixes ...string) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		s.keyPrefixes = prefixes
+	}
+}
+
+// WithAsymmetricRevocationStore sets the store consulted by ValidateToken.
+// Defaults to no revocation checks.
+func WithAsymmetricRevocationStore(store RevocationStore) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		s.revocations = store
+	}
+}
+
+// WithAsymmetricKeyRetention warns and counts in jwt_tokens_outliving_signing_key_total
+// when a token is issued with an expiration after its signing key is expected to be
+// pruned. Defaults to no check.
+func WithAsymmetricKeyRetention(retention KeyRetention) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		s.retention = newRetentionWarner(retention)
+	}
+}
+
+// WithAsymmetricLeeway sets the clock skew tolerated on the exp and nbf claims, and
+// by which nbf is backdated on new tokens. Defaults to DefaultLeeway.
+func WithAsymmetricLeeway(leeway time.Duration) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		if leeway >= 0 {
+			s.leeway = leeway
+		}
+	}
+}
+
+// WithAsymmetricScope sets the space-delimited scope claim on generated tokens.
+// Refreshed tokens keep the scope of the token they replace. Defaults to no scope.
+func WithAsymmetricScope(scope string) AsymmetricSignerOption {
+	return func(s *AsymmetricSigner) {
+		s.scope = scope
+	}
+}
+
+// NewAsymmetricSigner creates a new AsymmetricSigner for RS256 or ES256 without initial keys.
+// Keys must be loaded by calling RetrieveInitialSecret() or UpdateKeys() before use.
+func NewAsymmetricSigner(
+	algorithm string,
+	issuer string,
+	audience string,
+	expiration time.Duration,
+	newKeyUseDelay time.Duration,
+	opts ...AsymmetricSignerOption,
+) (*AsymmetricSigner, error) {
+	var method jwt5.SigningMethod
+	switch algorithm {
+	case AlgorithmRS256:
+		method = jwt5.SigningMethodRS256
+	case AlgorithmES256:
+		method = jwt5.SigningMethodES256
+	default:
+		return nil, fmt.Errorf("unsupported asymmetric signing algorithm %q", algorithm)
+	}
+
+	s := &AsymmetricSigner{
+		signingKeys:    make(map[string]crypto.Signer),
+		keyAddedTimes:  make(map[string]time.Time),
+		newKeyUseDelay: newKeyUseDelay,
+		method:         method,
+		issuer:         issuer,
+		audiences:      []string{audience},
+		expiration:     expiration,
+		leeway:         DefaultLeeway,
+		now:            time.Now,
+		logger:         logr.Discard(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	newKeyUseDelaySeconds.Set(newKeyUseDelay.Seconds())
+	return s, nil
+}
+
+// Algorithm returns the JWS algorithm this signer uses
+func (s *AsymmetricSigner) Algorithm() string {
+	return s.method.Alg()
+}
+
+// getLatestKidAndKeyWithCoolOff returns the latest key ID and private key that have passed the cooloff period
+// Returns empty kid and nil key if no key is beyond the cooloff period
+func (s *AsymmetricSigner) getLatestKidAndKeyWithCoolOff() (string, crypto.Signer) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latestKidAndKeyWithCoolOffLocked(s.now())
+}
+
+// latestKidAndKeyWithCoolOffLocked is getLatestKidAndKeyWithCoolOff at the given time.
+// Caller must hold s.mu.
+func (s *AsymmetricSigner) latestKidAndKeyWithCoolOffLocked(now time.Time) (string, crypto.Signer) {
+	var usableKid string
+
+	for kid, addedTime := range s.keyAddedTimes {
+		if now.Sub(addedTime) >= s.newKeyUseDelay {
+			if usableKid == "" || KidIsNewer(kid, usableKid) {
+				usableKid = kid
+			}
+		}
+	}
+
+	if usableKid == "" {
+		return "", nil
+	}
+
+	return usableKid, s.signingKeys[usableKid]
+}
+
+// checkSigningLag compares signingKid with the latest loaded kid, see signingLagWarner
+func (s *AsymmetricSigner) checkSigningLag(signingKid string) {
+	s.mu.RLock()
+	latestKid, latestAddedAt := s.latestKid, s.keyAddedTimes[s.latestKid]
+	s.mu.RUnlock()
+	s.signingLag.check(s.logger, signingKid, latestKid, latestAddedAt, s.now(), s.newKeyUseDelay)
+}
+
+// HasUsableSigningKey reports whether a key has passed the cooloff period, so
+// GenerateToken can sign
+func (s *AsymmetricSigner) HasUsableSigningKey() bool {
+	kid, _ := s.getLatestKidAndKeyWithCoolOff()
+	return kid != ""
+}
+
+// GenerateToken creates a new JWT token for the given user and groups
+// Uses the latest key that has passed the cooloff period (newKeyUseDelay), so verifiers
+// have time to fetch the new public key before tokens signed with it appear
+func (s *AsymmetricSigner) GenerateToken(
+	username string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+	skipRefresh bool) (string, error) {
+	return s.GenerateTokenContext(context.Background(), username, groups, uid, extra, path, domain, tokenType, skipRefresh)
+}
+
+// GenerateTokenContext is GenerateToken, failing with ctx's error if it is done before signing
+func (s *AsymmetricSigner) GenerateTokenContext(
+	ctx context.Context,
+	username string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+	skipRefresh bool) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	now := s.now().UTC()
+	return s.generateTokenWithIssuedAt(
+		username, groups, uid, extra, path, domain, tokenType, skipRefresh, s.scope, now,
+	)
+}
+
+// GenerateRefreshToken creates a new JWT token preserving the original IssuedAt
+// from the provided claims.
+func (s *AsymmetricSigner) GenerateRefreshToken(claims *Claims) (string, error) {
+	if claims == nil {
+		return "", fmt.Errorf("claims cannot be nil")
+	}
+	if claims.IssuedAt == nil {
+		return "", fmt.Errorf("claims.IssuedAt cannot be nil")
+	}
+	return s.generateTokenWithIssuedAt(
+		claims.User, claims.Groups, claims.UID, claims.Extra,
+		claims.Path, claims.Domain, claims.TokenType, false, claims.Scope, claims.IssuedAt.Time,
+	)
+}
+
+// generateTokenWithIssuedAt is the internal token generation method
+func (s *AsymmetricSigner) generateTokenWithIssuedAt(
+	username string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+	skipRefresh bool,
+	scope string,
+	issuedAt time.Time) (string, error) {
+	usableKid, signingKey := s.getLatestKidAndKeyWithCoolOff()
+	if usableKid == "" || signingKey == nil {
+		cooloffBlockedTotal.Inc()
+		return "", fmt.Errorf("no signing key available beyond cooloff period (%v)", s.newKeyUseDelay)
+	}
+	s.checkSigningLag(usableKid)
+
+	claims := &Claims{
+		RegisteredClaims: registeredClaims(s.issuer, s.audiences, username, s.now().UTC(), issuedAt, s.expiration, s.leeway),
+		User:             username,
+		Groups:           groups,
+		UID:              uid,
+		Extra:            extra,
+		Path:             path,
+		Domain:           domain,
+		TokenType:        tokenType,
+		SkipRefresh:      skipRefresh,
+		Scope:            scope,
+	}
+
+	s.retention.check(s.logger, usableKid, claims.ExpiresAt.Time)
+
+	token := jwt5.NewWithClaims(s.method, claims)
+	token.Header["kid"] = usableKid
+	token.Header["typ"] = TypHeaderJWT
+
+	signed, err := token.SignedString(signingKey)
+	if err != nil {
+		return "", err
+	}
+	s.usage.recordSign(usableKid)
+	return signed, nil
+}
+
+// ValidateToken validates and parses the token
+// Requires kid header and verifies against the corresponding public key
+func (s *AsymmetricSigner) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := s.validateToken(tokenString)
+	var kid string
+	if claims != nil {
+		kid = claims.KeyID
+	} else {
+		kid = tokenKid(tokenString)
+	}
+	// Only count loaded kids, so forged kid headers cannot grow the counters
+	if s.IsKnownKid(kid) {
+		s.usage.recordValidation(kid, err)
+	}
+	return claims, err
+}
+
+// IsKnownKid reports whether kid is a loaded key, so callers caching decisions
+// about a token can drop them once its signing key is pruned
+func (s *AsymmetricSigner) IsKnownKid(kid string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.signingKeys[kid]
+	return ok
+}
+
+// KeyUsage returns the sign and validation counts of each loaded key
+func (s *AsymmetricSigner) KeyUsage() map[string]KeyUsage {
+	s.mu.RLock()
+	kids := make([]string, 0, len(s.signingKeys))
+	for kid := range s.signingKeys {
+		kids = append(kids, kid)
+	}
+	s.mu.RUnlock()
+	return s.usage.report(kids)
+}
+
+// validateToken parses and checks the token; ValidateToken adds usage accounting
+func (s *AsymmetricSigner) validateToken(tokenString string) (*Claims, error) {
+	migrating := s.migration.Active(s.now())
+	checkManually := migrating || s.claimFallback != nil || len(s.audiences) > 1
+	parserOpts := append([]jwt5.ParserOption{
+		jwt5.WithValidMethods([]string{s.method.Alg()}),
+		jwt5.WithLeeway(s.leeway),
+		jwt5.WithTimeFunc(s.now),
+	}, issuerAudienceOptions(s.issuer, s.audiences[0], checkManually)...)
+
+	token, err := jwt5.ParseWithClaims(
+		tokenString,
+		&Claims{},
+		func(t *jwt5.Token) (any, error) {
+			kid, ok := t.Header["kid"].(string)
+			if !ok || kid == "" {
+				return nil, ErrMissingKid
+			}
+
+			s.mu.RLock()
+			key := s.signingKeys[kid]
+			s.mu.RUnlock()
+
+			if key == nil {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownKid, kid)
+			}
+
+			return key.Public(), nil
+		},
+		parserOpts...,
+	)
+
+	if err != nil {
+		if token != nil {
+			if alg, ok := token.Header["alg"].(string); ok && alg != s.method.Alg() {
+				s.logger.Info("Security audit: rejected token with disallowed signing algorithm",
+					"event", "jwt_algorithm_not_allowed",
+					"presentedAlg", alg,
+					"expectedAlg", s.method.Alg(),
+					"kid", token.Header["kid"])
+				return nil, fmt.Errorf("%w: %q", ErrAlgorithmNotAllowed, alg)
+			}
+		}
+		return nil, mapParseError(err)
+	}
+
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if err := checkTypHeader(token, false); err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return nil, ErrInvalidClaims
+	}
+	claims.KeyID, _ = token.Header["kid"].(string)
+
+	if checkManually {
+		s.claimFallback.apply(claims)
+		migration := s.migration
+		if !migrating {
+			migration = nil
+		}
+		if err := migration.check(claims, s.issuer, s.audiences); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.subjectMatch.check(claims); err != nil {
+		return nil, err
+	}
+
+	if err := checkRevoked(s.revocations, claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// checkKey verifies a private key can be used with the configured algorithm
+func (s *AsymmetricSigner) checkKey(kid string, key crypto.Signer) error {
+	switch s.method.Alg() {
+	case AlgorithmRS256:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("key for kid %s is %T, %s requires an RSA key", kid, key, AlgorithmRS256)
+		}
+		if rsaKey.N.BitLen() < minRSAKeyBits {
+			return fmt.Errorf("key for kid %s is %d bits, %s requires at least %d",
+				kid, rsaKey.N.BitLen(), AlgorithmRS256, minRSAKeyBits)
+		}
+	case AlgorithmES256:
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return fmt.Errorf("key for kid %s is not a P-256 ECDSA key as required by %s", kid, AlgorithmES256)
+		}
+	}
+	return nil
+}
+
+// UpdateKeys atomically updates the signing keys
+// Keys that are no longer present are dropped from both validation and the published key set
+func (s *AsymmetricSigner) UpdateKeys(signingKeys map[string]crypto.Signer, latestKid string) error {
+	if len(signingKeys) == 0 {
+		return fmt.Errorf("signingKeys cannot be empty")
+	}
+	if _, ok := signingKeys[latestKid]; !ok {
+		return fmt.Errorf("latestKid %s not found in signingKeys", latestKid)
+	}
+	for kid, key := range signingKeys {
+		if err := s.checkKey(kid, key); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	newKeyAddedTimes := make(map[string]time.Time)
+	for kid := range signingKeys {
+		if oldTime, exists := s.keyAddedTimes[kid]; exists {
+			newKeyAddedTimes[kid] = oldTime
+		} else {
+			newKeyAddedTimes[kid] = now
+		}
+	}
+
+	s.signingKeys = signingKeys
+	s.keyAddedTimes = newKeyAddedTimes
+	s.usage.prune(func(kid string) bool { return signingKeys[kid] != nil })
+	s.latestKid = latestKid
+	s.keyGeneration.Add(1)
+
+	return nil
+}
+
+// KeyGeneration returns the number of times the keys have been updated
+func (s *AsymmetricSigner) KeyGeneration() uint64 {
+	return s.keyGeneration.Load()
+}
+
+// AcceptedUntil returns the end of the issuer migration window for claims accepted only
+// through the previous issuer or audience, or the zero time otherwise
+func (s *AsymmetricSigner) AcceptedUntil(claims *Claims) time.Time {
+	return s.migration.acceptedUntil(claims, s.issuer, s.audiences)
+}
+
+// JWKS returns the public keys of all loaded keys, newest first.
+// New keys are published as soon as they are loaded, before the cooloff period lets
+// them sign, and older keys stay published until they are removed from the secret,
+// so verifiers that refresh the set within the overlap window never miss a kid.
+func (s *AsymmetricSigner) JWKS() (JSONWebKeySet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	kids := make([]string, 0, len(s.signingKeys))
+	for kid := range s.signingKeys {
+		kids = append(kids, kid)
+	}
+	sort.Slice(kids, func(i, j int) bool { return KidIsNewer(kids[i], kids[j]) })
+
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(kids))}
+	for _, kid := range kids {
+		jwk, err := publicJWK(kid, s.method.Alg(), s.signingKeys[kid].Public())
+		if err != nil {
+			return JSONWebKeySet{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// RetrieveInitialSecret loads the initial private keys from the Kubernetes secret.
+// This is called when the HTTP server starts to ensure keys are loaded before accepting requests.
+func (s *AsymmetricSigner) RetrieveInitialSecret(
+	ctx context.Context,
+	runtimeClient client.Client,
+	secretName string,
+	namespace string,
+) error {
+	secret, err := getSigningSecret(ctx, runtimeClient, secretName, namespace)
+	if err != nil {
+		return err
+	}
+
+	signingKeys, latestKid, err := ParsePrivateKeysFromSecret(secret, s.keyPrefixes...)
+	if err != nil {
+		return fmt.Errorf("failed to parse signing keys from secret: %w", err)
+	}
+
+	if err := s.UpdateKeys(signingKeys, latestKid); err != nil {
+		return fmt.Errorf("failed to update signing keys: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,316 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func generateECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+// publicKeyFromJWK rebuilds a public key from its JWK, as an offline verifier would
+func publicKeyFromJWK(t *testing.T, jwk JSONWebKey) crypto.PublicKey {
+	t.Helper()
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+	switch jwk.Kty {
+	case "RSA":
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode(jwk.N)),
+			E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64()),
+		}
+	case "EC":
+		require.Equal(t, "P-256", jwk.Crv)
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(decode(jwk.X)),
+			Y:     new(big.Int).SetBytes(decode(jwk.Y)),
+		}
+	}
+	t.Fatalf("unexpected kty %q", jwk.Kty)
+	return nil
+}
+
+func TestAsymmetricSigner_GenerateValidateRoundtrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		key       crypto.Signer
+	}{
+		{name: "RS256", algorithm: AlgorithmRS256, key: generateRSAKey(t)},
+		{name: "ES256", algorithm: AlgorithmES256, key: generateECKey(t)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewAsymmetricSigner(tt.algorithm, "test-issuer", "test-audience", time.Hour, 0)
+			require.NoError(t, err)
+			require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": tt.key}, "1000"))
+
+			token, err := signer.GenerateToken(testUser, []string{"group1"}, "uid123", nil, "/path", "domain.com", TokenTypeSession, false)
+			require.NoError(t, err)
+
+			claims, err := signer.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, testUser, claims.User)
+			assert.Equal(t, "/path", claims.Path)
+
+			parsed, _, err := jwt5.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, parsed.Header["alg"])
+			assert.Equal(t, "1000", parsed.Header["kid"])
+		})
+	}
+}
+
+func TestNewAsymmetricSigner_UnsupportedAlgorithm(t *testing.T) {
+	_, err := NewAsymmetricSigner("HS384", "test-issuer", "test-audience", time.Hour, 0)
+	assert.Error(t, err)
+}
+
+func TestAsymmetricSigner_ValidateToken_RejectsOtherAlgorithms(t *testing.T) {
+	signer, err := NewAsymmetricSigner(AlgorithmRS256, "test-issuer", "test-audience", time.Hour, 0)
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": generateRSAKey(t)}, "1000"))
+
+	hmacSigner := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
+	hmacToken, err := hmacSigner.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	_, err = signer.ValidateToken(hmacToken)
+	assert.ErrorIs(t, err, ErrAlgorithmNotAllowed)
+	assert.ErrorIs(t, err, ErrWrongAlgorithm)
+}
+
+func TestAsymmetricSigner_ValidateToken_KidErrors(t *testing.T) {
+	key := generateRSAKey(t)
+	signer, err := NewAsymmetricSigner(AlgorithmRS256, "test-issuer", "test-audience", time.Hour, 0)
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+
+	claims := &Claims{RegisteredClaims: jwt5.RegisteredClaims{
+		Issuer:    "test-issuer",
+		Audience:  []string{"test-audience"},
+		ExpiresAt: jwt5.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+
+	noKid, err := jwt5.NewWithClaims(jwt5.SigningMethodRS256, claims).SignedString(key)
+	require.NoError(t, err)
+	_, err = signer.ValidateToken(noKid)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, err, ErrMissingKid)
+
+	unknown := jwt5.NewWithClaims(jwt5.SigningMethodRS256, claims)
+	unknown.Header["kid"] = "9999"
+	unknownKid, err := unknown.SignedString(key)
+	require.NoError(t, err)
+	_, err = signer.ValidateToken(unknownKid)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, err, ErrUnknownKid)
+	assert.NotErrorIs(t, err, ErrMissingKid)
+}
+
+func TestAsymmetricSigner_UpdateKeys_RejectsIncompatibleKeys(t *testing.T) {
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		algorithm string
+		key       crypto.Signer
+	}{
+		{name: "EC key for RS256", algorithm: AlgorithmRS256, key: generateECKey(t)},
+		{name: "RSA key below 2048 bits", algorithm: AlgorithmRS256, key: smallRSA},
+		{name: "RSA key for ES256", algorithm: AlgorithmES256, key: generateRSAKey(t)},
+		{name: "P-384 key for ES256", algorithm: AlgorithmES256, key: p384},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewAsymmetricSigner(tt.algorithm, "test-issuer", "test-audience", time.Hour, 0)
+			require.NoError(t, err)
+			assert.Error(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": tt.key}, "1000"))
+		})
+	}
+}
+
+func TestAsymmetricSigner_UpdateKeys_AppliesRemovalOfNewestKey(t *testing.T) {
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "test-issuer", "test-audience", time.Hour, 0)
+	require.NoError(t, err)
+	olderKey, newerKey := generateECKey(t), generateECKey(t)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": olderKey, "2000": newerKey}, "2000"))
+
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": olderKey}, "1000"))
+
+	jwks, err := signer.JWKS()
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "1000", jwks.Keys[0].Kid)
+}
+
+func TestAsymmetricSigner_JWKS_VerifiesTokensOffline(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmES256} {
+		t.Run(algorithm, func(t *testing.T) {
+			var key crypto.Signer
+			if algorithm == AlgorithmRS256 {
+				key = generateRSAKey(t)
+			} else {
+				key = generateECKey(t)
+			}
+			signer, err := NewAsymmetricSigner(algorithm, "test-issuer", "test-audience", time.Hour, 0)
+			require.NoError(t, err)
+			require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+
+			token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+			require.NoError(t, err)
+
+			keySet, err := signer.JWKS()
+			require.NoError(t, err)
+			require.Len(t, keySet.Keys, 1)
+			jwk := keySet.Keys[0]
+			assert.Equal(t, "1000", jwk.Kid)
+			assert.Equal(t, algorithm, jwk.Alg)
+			assert.Equal(t, "sig", jwk.Use)
+
+			// Verify with only the published public key
+			parsed, err := jwt5.ParseWithClaims(token, &Claims{}, func(*jwt5.Token) (any, error) {
+				return publicKeyFromJWK(t, jwk), nil
+			}, jwt5.WithValidMethods([]string{algorithm}))
+			require.NoError(t, err)
+			assert.True(t, parsed.Valid)
+		})
+	}
+}
+
+func TestAsymmetricSigner_RotationOverlap(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "test-issuer", "test-audience", time.Hour, time.Minute,
+		WithAsymmetricClock(clock))
+	require.NoError(t, err)
+
+	oldKey := generateECKey(t)
+	newKey := generateECKey(t)
+
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": oldKey}, "1000"))
+	now = now.Add(2 * time.Minute)
+	oldToken, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	// Rotation adds a new key: it is published immediately but not yet used for signing
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": oldKey, "2000": newKey}, "2000"))
+
+	keySet, err := signer.JWKS()
+	require.NoError(t, err)
+	require.Len(t, keySet.Keys, 2)
+	assert.Equal(t, "2000", keySet.Keys[0].Kid, "newest key should be listed first")
+	assert.Equal(t, "1000", keySet.Keys[1].Kid)
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	parsed, _, err := jwt5.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "1000", parsed.Header["kid"], "new key should not sign during cooloff")
+
+	// After the cooloff the new key signs, and old tokens still validate
+	now = now.Add(2 * time.Minute)
+	token, err = signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	parsed, _, err = jwt5.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2000", parsed.Header["kid"])
+
+	_, err = signer.ValidateToken(oldToken)
+	assert.NoError(t, err)
+
+	// Once the old key is pruned from the secret it is no longer published
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"2000": newKey}, "2000"))
+	keySet, err = signer.JWKS()
+	require.NoError(t, err)
+	require.Len(t, keySet.Keys, 1)
+	assert.Equal(t, "2000", keySet.Keys[0].Kid)
+}
+
+func TestParsePrivateKeysFromSecret(t *testing.T) {
+	rsaKey := generateRSAKey(t)
+	ecKey := generateECKey(t)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			"jwt-signing-key-2000": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+			"jwt-signing-key-3000": pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}),
+			"unrelated":            []byte("ignored"),
+		},
+	}
+
+	keys, latestKid, err := ParsePrivateKeysFromSecret(secret)
+	require.NoError(t, err)
+	assert.Equal(t, "3000", latestKid)
+	require.Len(t, keys, 3)
+	assert.IsType(t, &rsa.PrivateKey{}, keys["1000"])
+	assert.IsType(t, &ecdsa.PrivateKey{}, keys["2000"])
+	assert.IsType(t, &ecdsa.PrivateKey{}, keys["3000"])
+
+	secret.Data["jwt-signing-key-4000"] = []byte("not-a-pem-key")
+	_, _, err = ParsePrivateKeysFromSecret(secret)
+	assert.ErrorContains(t, err, "jwt-signing-key-4000")
+}
+
+func TestAsymmetricSigner_ValidateToken_Revoked(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "test-issuer", "test-audience", time.Hour, 0,
+		WithAsymmetricRevocationStore(store))
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": generateECKey(t)}, "1000"))
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, claims.ID)
+
+	store.Revoke(claims.ID, claims.ExpiresAt.Time)
+	_, err = signer.ValidateToken(token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
@@ -0,0 +1,20 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import "slices"
+
+// appendAudiences adds the non-empty audiences not already in current. Signers keep
+// their constructor audience first, so it alone is enforced by the parser when no
+// other audience is configured.
+func appendAudiences(current []string, audiences []string) []string {
+	for _, aud := range audiences {
+		if aud != "" && !slices.Contains(current, aud) {
+			current = append(current, aud)
+		}
+	}
+	return current
+}
@@ -0,0 +1,86 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardSigner_AdditionalAudiences(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	signer := NewStandardSigner("issuer", "notebooks", time.Hour, 0,
+		WithClock(clock),
+		WithAdditionalAudiences("", "notebooks", "kernels", "files"))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(testMigrationKey)}, "1000"))
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notebooks", "kernels", "files"}, []string(claims.Audience))
+
+	// A token for any one of the configured audiences is accepted
+	_, err = signer.ValidateToken(tokenFrom(t, "issuer", "kernels", clock))
+	assert.NoError(t, err)
+	_, err = signer.ValidateToken(tokenFrom(t, "issuer", "notebooks", clock))
+	assert.NoError(t, err)
+
+	_, err = signer.ValidateToken(tokenFrom(t, "issuer", "other-audience", clock))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = signer.ValidateToken(tokenFrom(t, "other-issuer", "kernels", clock))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestStandardSigner_SingleAudienceRejectsOthers(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	signer := NewStandardSigner("issuer", "notebooks", time.Hour, 0, WithClock(clock), WithAdditionalAudiences())
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(testMigrationKey)}, "1000"))
+
+	_, err := signer.ValidateToken(tokenFrom(t, "issuer", "notebooks", clock))
+	assert.NoError(t, err)
+	_, err = signer.ValidateToken(tokenFrom(t, "issuer", "kernels", clock))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAsymmetricSigner_AdditionalAudiences(t *testing.T) {
+	key := generateECKey(t)
+
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "issuer", "notebooks", time.Hour, 0,
+		WithAsymmetricAdditionalAudiences("kernels"))
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notebooks", "kernels"}, []string(claims.Audience))
+
+	kernelsSigner, err := NewAsymmetricSigner(AlgorithmES256, "issuer", "kernels", time.Hour, 0)
+	require.NoError(t, err)
+	require.NoError(t, kernelsSigner.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+	kernelsToken, err := kernelsSigner.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	_, err = signer.ValidateToken(kernelsToken)
+	assert.NoError(t, err)
+
+	otherSigner, err := NewAsymmetricSigner(AlgorithmES256, "issuer", "files", time.Hour, 0)
+	require.NoError(t, err)
+	require.NoError(t, otherSigner.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+	otherToken, err := otherSigner.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	_, err = signer.ValidateToken(otherToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,35 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"slices"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimFallback supplies the issuer or audience of tokens that omit the claim, for
+// trusted integrations whose tokens do not carry them. The fallback is only applied
+// to a missing claim: a token carrying a wrong issuer or audience is still rejected.
+// Either value may be empty to keep that claim required.
+type ClaimFallback struct {
+	Issuer   string
+	Audience string
+}
+
+// apply fills in the issuer and audience the token omits
+func (f *ClaimFallback) apply(claims *Claims) {
+	if f == nil {
+		return
+	}
+	if claims.Issuer == "" {
+		claims.Issuer = f.Issuer
+	}
+	missingAudience := !slices.ContainsFunc(claims.Audience, func(aud string) bool { return aud != "" })
+	if missingAudience && f.Audience != "" {
+		claims.Audience = jwt5.ClaimStrings{f.Audience}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// externalToken signs a session token as an external issuer would, omitting empty claims
+func externalToken(t *testing.T, method jwt5.SigningMethod, key any, issuer string, audience string) string {
+	t.Helper()
+	now := time.Now()
+	claims := &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			Issuer:    issuer,
+			ExpiresAt: jwt5.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt5.NewNumericDate(now),
+		},
+		User:      testUser,
+		TokenType: TokenTypeSession,
+	}
+	if audience != "" {
+		claims.Audience = jwt5.ClaimStrings{audience}
+	}
+	token := jwt5.NewWithClaims(method, claims)
+	token.Header["kid"] = "1000"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestStandardSigner_ClaimFallback(t *testing.T) {
+	key := []byte(testMigrationKey)
+	sign := func(issuer, audience string) string {
+		return externalToken(t, jwt5.SigningMethodHS384, key, issuer, audience)
+	}
+
+	strict := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	require.NoError(t, strict.UpdateKeys(map[string][]byte{"1000": key}, "1000"))
+	_, err := strict.ValidateToken(sign("test-issuer", ""))
+	assert.ErrorIs(t, err, ErrInvalidToken, "missing audience is rejected by default")
+
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+		WithClaimFallback(ClaimFallback{Audience: "test-audience"}))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key}, "1000"))
+
+	// A token missing the audience validates under the fallback
+	claims, err := signer.ValidateToken(sign("test-issuer", ""))
+	require.NoError(t, err)
+	assert.Equal(t, jwt5.ClaimStrings{"test-audience"}, claims.Audience)
+
+	// A token carrying a wrong audience still fails
+	_, err = signer.ValidateToken(sign("test-issuer", "other-audience"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// No issuer fallback is configured, so the issuer is still required and checked
+	_, err = signer.ValidateToken(sign("", "test-audience"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = signer.ValidateToken(sign("other-issuer", "test-audience"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = signer.ValidateToken(sign("test-issuer", "test-audience"))
+	assert.NoError(t, err)
+}
+
+func TestAsymmetricSigner_ClaimFallback(t *testing.T) {
+	key := generateECKey(t)
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "test-issuer", "test-audience", time.Hour, 0,
+		WithAsymmetricClaimFallback(ClaimFallback{Issuer: "test-issuer", Audience: "test-audience"}))
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+
+	claims, err := signer.ValidateToken(externalToken(t, jwt5.SigningMethodES256, key, "", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "test-issuer", claims.Issuer)
+
+	_, err = signer.ValidateToken(externalToken(t, jwt5.SigningMethodES256, key, "test-issuer", "other-audience"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = signer.ValidateToken(externalToken(t, jwt5.SigningMethodES256, key, "other-issuer", ""))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
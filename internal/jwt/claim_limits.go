@@ -0,0 +1,118 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/go-logr/logr"
+)
+
+// ErrClaimsTooLarge is returned when a token's groups or extra claims exceed the
+// configured ClaimLimits and truncation is disabled
+var ErrClaimsTooLarge = errors.New("token claims exceed configured limits")
+
+// ClaimLimits bounds the identity claims of generated tokens, which end up in cookies
+// and proxy headers with size limits of their own. Zero values disable a limit.
+type ClaimLimits struct {
+	// MaxGroups is the maximum number of groups in a token
+	MaxGroups int
+	// MaxClaimBytes is the maximum JSON-encoded size of the groups and extra claims
+	MaxClaimBytes int
+	// Truncate drops trailing groups beyond MaxGroups, then extra claims and further
+	// trailing groups to fit MaxClaimBytes, instead of failing with ErrClaimsTooLarge.
+	// Groups are kept in preference to extra claims since authorization relies on them.
+	Truncate bool
+}
+
+// WithClaimLimits bounds the groups and extra claims of tokens created with
+// GenerateToken and GenerateNonRefreshableToken. Truncations are logged to logger.
+// Defaults to no limits.
+func WithClaimLimits(limits ClaimLimits, logger logr.Logger) ManagerOption {
+	return func(m *Manager) {
+		if limits.MaxGroups > 0 || limits.MaxClaimBytes > 0 {
+			m.claimLimits = &limits
+			m.logger = logger
+		}
+	}
+}
+
+// identityClaims is the part of Claims counted against MaxClaimBytes
+type identityClaims struct {
+	Groups []string            `json:"Groups,omitempty"`
+	Extra  map[string][]string `json:"Extra,omitempty"`
+}
+
+// claimBytes returns the JSON-encoded size of groups and extra
+func claimBytes(groups []string, extra map[string][]string) int {
+	encoded, err := json.Marshal(identityClaims{Groups: groups, Extra: extra})
+	if err != nil {
+		return 0
+	}
+	return len(encoded)
+}
+
+// apply checks groups and extra against the limits, returning them truncated to fit
+// when Truncate is set. The inputs are not modified.
+func (l *ClaimLimits) apply(user string, groups []string, extra map[string][]string, logger logr.Logger) (
+	[]string, map[string][]string, error,
+) {
+	if l.MaxGroups > 0 && len(groups) > l.MaxGroups {
+		if !l.Truncate {
+			return nil, nil, fmt.Errorf("%w: %d groups, limit %d", ErrClaimsTooLarge, len(groups), l.MaxGroups)
+		}
+		logger.Info("Truncating token groups to the configured limit",
+			"user", redactUser(user), "groups", len(groups), "maxGroups", l.MaxGroups)
+		groups = groups[:l.MaxGroups]
+	}
+
+	if l.MaxClaimBytes <= 0 {
+		return groups, extra, nil
+	}
+	size := claimBytes(groups, extra)
+	if size <= l.MaxClaimBytes {
+		return groups, extra, nil
+	}
+	if !l.Truncate {
+		return nil, nil, fmt.Errorf("%w: claims are %d bytes, limit %d", ErrClaimsTooLarge, size, l.MaxClaimBytes)
+	}
+
+	// Keep as many leading groups as fit without any extra claims
+	kept := sort.Search(len(groups)+1, func(n int) bool {
+		return claimBytes(groups[:n], nil) > l.MaxClaimBytes
+	}) - 1
+	if kept < 0 {
+		return nil, nil, fmt.Errorf("%w: claims do not fit in %d bytes", ErrClaimsTooLarge, l.MaxClaimBytes)
+	}
+	truncatedGroups := groups[:kept]
+
+	// Then add back extra claims, in key order, while they fit
+	var truncatedExtra map[string][]string
+	if kept == len(groups) {
+		for _, key := range slices.Sorted(maps.Keys(extra)) {
+			candidate := maps.Clone(truncatedExtra)
+			if candidate == nil {
+				candidate = make(map[string][]string)
+			}
+			candidate[key] = extra[key]
+			if claimBytes(truncatedGroups, candidate) > l.MaxClaimBytes {
+				continue
+			}
+			truncatedExtra = candidate
+		}
+	}
+
+	logger.Info("Truncating token claims to the configured size limit",
+		"user", redactUser(user), "claimBytes", size, "maxClaimBytes", l.MaxClaimBytes,
+		"groups", len(groups), "keptGroups", len(truncatedGroups),
+		"extraKeys", len(extra), "keptExtraKeys", len(truncatedExtra))
+	return truncatedGroups, truncatedExtra, nil
+}
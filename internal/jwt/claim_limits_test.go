@@ -0,0 +1,113 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLimitedManager returns a Manager with limits whose signer records the claims it signs
+func newLimitedManager(limits ClaimLimits) (*Manager, *[]string, *map[string][]string) {
+	var signedGroups []string
+	var signedExtra map[string][]string
+	signer := &mockSigner{
+		generateFunc: func(user string, groups []string, uid string, extra map[string][]string, path string, domain string, tokenType string, skipRefresh bool) (string, error) {
+			signedGroups, signedExtra = groups, extra
+			return mockTokenValue, nil
+		},
+	}
+	return NewManager(signer, false, 0, 0, WithClaimLimits(limits, logr.Discard())), &signedGroups, &signedExtra
+}
+
+func testGroups(n int) []string {
+	groups := make([]string, n)
+	for i := range groups {
+		groups[i] = fmt.Sprintf("group-%03d", i)
+	}
+	return groups
+}
+
+func TestClaimLimits_MaxGroups(t *testing.T) {
+	manager, signedGroups, _ := newLimitedManager(ClaimLimits{MaxGroups: 3})
+
+	_, err := manager.GenerateToken(testUser, testGroups(3), "", nil, "/path", "", TokenTypeSession)
+	require.NoError(t, err)
+	assert.Len(t, *signedGroups, 3)
+
+	_, err = manager.GenerateToken(testUser, testGroups(4), "", nil, "/path", "", TokenTypeSession)
+	assert.ErrorIs(t, err, ErrClaimsTooLarge)
+	_, err = manager.GenerateNonRefreshableToken(testUser, testGroups(4), "", nil, "/path", "", TokenTypeSession)
+	assert.ErrorIs(t, err, ErrClaimsTooLarge)
+}
+
+func TestClaimLimits_MaxGroupsTruncate(t *testing.T) {
+	manager, signedGroups, _ := newLimitedManager(ClaimLimits{MaxGroups: 3, Truncate: true})
+	groups := testGroups(5)
+
+	_, err := manager.GenerateToken(testUser, groups, "", nil, "/path", "", TokenTypeSession)
+	require.NoError(t, err)
+	assert.Equal(t, groups[:3], *signedGroups)
+	assert.Len(t, groups, 5, "caller's groups must not be modified")
+}
+
+func TestClaimLimits_MaxClaimBytes(t *testing.T) {
+	groups := testGroups(10)
+	extra := map[string][]string{"a": {"1"}}
+	limit := claimBytes(groups, extra)
+
+	manager, _, _ := newLimitedManager(ClaimLimits{MaxClaimBytes: limit})
+	_, err := manager.GenerateToken(testUser, groups, "", extra, "/path", "", TokenTypeSession)
+	require.NoError(t, err, "claims exactly at the limit are accepted")
+
+	_, err = manager.GenerateToken(testUser, append(groups, "one-more"), "", extra, "/path", "", TokenTypeSession)
+	assert.ErrorIs(t, err, ErrClaimsTooLarge)
+}
+
+func TestClaimLimits_MaxClaimBytesTruncate(t *testing.T) {
+	groups := testGroups(10)
+	extra := map[string][]string{"a": {"1"}, "b": {"2"}}
+
+	t.Run("drops extra claims before groups", func(t *testing.T) {
+		limit := claimBytes(groups, map[string][]string{"a": {"1"}})
+		manager, signedGroups, signedExtra := newLimitedManager(ClaimLimits{MaxClaimBytes: limit, Truncate: true})
+
+		_, err := manager.GenerateToken(testUser, groups, "", extra, "/path", "", TokenTypeSession)
+		require.NoError(t, err)
+		assert.Equal(t, groups, *signedGroups)
+		assert.Equal(t, map[string][]string{"a": {"1"}}, *signedExtra)
+	})
+
+	t.Run("drops trailing groups", func(t *testing.T) {
+		limit := claimBytes(groups[:6], nil)
+		manager, signedGroups, signedExtra := newLimitedManager(ClaimLimits{MaxClaimBytes: limit, Truncate: true})
+
+		_, err := manager.GenerateToken(testUser, groups, "", extra, "/path", "", TokenTypeSession)
+		require.NoError(t, err)
+		assert.Equal(t, groups[:6], *signedGroups)
+		assert.Empty(t, *signedExtra)
+		assert.Len(t, extra, 2, "caller's extra claims must not be modified")
+	})
+
+	t.Run("fails when nothing fits", func(t *testing.T) {
+		manager, _, _ := newLimitedManager(ClaimLimits{MaxClaimBytes: 1, Truncate: true})
+		_, err := manager.GenerateToken(testUser, groups, "", extra, "/path", "", TokenTypeSession)
+		assert.ErrorIs(t, err, ErrClaimsTooLarge)
+	})
+}
+
+func TestClaimLimits_Disabled(t *testing.T) {
+	manager, signedGroups, _ := newLimitedManager(ClaimLimits{})
+	assert.Nil(t, manager.claimLimits)
+
+	_, err := manager.GenerateToken(testUser, testGroups(1000), "", nil, "/path", "", TokenTypeSession)
+	require.NoError(t, err)
+	assert.Len(t, *signedGroups, 1000)
+}
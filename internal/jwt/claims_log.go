@@ -0,0 +1,77 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// redactedUserPrefixLen is the number of leading characters of User kept in logs
+	redactedUserPrefixLen = 2
+	redactedMarker        = "[REDACTED]"
+)
+
+var _ fmt.Stringer = Claims{}
+var _ slog.LogValuer = Claims{}
+
+// String returns a log-safe representation of the claims: User is truncated and
+// Extra, UID and group names are replaced by counts or a redaction marker.
+func (c Claims) String() string {
+	return fmt.Sprintf("Claims{User:%s UID:%s Groups:%d Extra:%s Path:%s Domain:%s TokenType:%s SkipRefresh:%t ExpiresAt:%s}",
+		redactUser(c.User), redactNonEmpty(c.UID), len(c.Groups), redactExtra(c.Extra),
+		c.Path, c.Domain, c.TokenType, c.SkipRefresh, formatExpiry(c))
+}
+
+// LogValue implements slog.LogValuer with the same redaction as String
+func (c Claims) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("user", redactUser(c.User)),
+		slog.String("uid", redactNonEmpty(c.UID)),
+		slog.Int("groups", len(c.Groups)),
+		slog.String("extra", redactExtra(c.Extra)),
+		slog.String("path", c.Path),
+		slog.String("domain", c.Domain),
+		slog.String("tokenType", c.TokenType),
+		slog.Bool("skipRefresh", c.SkipRefresh),
+		slog.String("expiresAt", formatExpiry(c)),
+	)
+}
+
+// redactUser keeps a short prefix of the user name so log lines stay correlatable
+func redactUser(user string) string {
+	runes := []rune(user)
+	if len(runes) == 0 {
+		return ""
+	}
+	if len(runes) <= redactedUserPrefixLen {
+		return "***"
+	}
+	return string(runes[:redactedUserPrefixLen]) + "***"
+}
+
+func redactNonEmpty(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedMarker
+}
+
+func redactExtra(extra map[string][]string) string {
+	if len(extra) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s(%d keys)", redactedMarker, len(extra))
+}
+
+func formatExpiry(c Claims) string {
+	if c.ExpiresAt == nil {
+		return ""
+	}
+	return c.ExpiresAt.UTC().Format(time.RFC3339)
+}
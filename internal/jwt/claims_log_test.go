@@ -0,0 +1,68 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSensitiveClaims() *Claims {
+	return &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			ExpiresAt: jwt5.NewNumericDate(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+		},
+		User:      "alice@example.com",
+		UID:       "uid-12345",
+		Groups:    []string{"secret-team", "admins"},
+		Extra:     map[string][]string{"email": {"alice@example.com"}},
+		Path:      "/workspaces/ns/app",
+		Domain:    "example.com",
+		TokenType: TokenTypeSession,
+	}
+}
+
+func TestClaims_String_Redacts(t *testing.T) {
+	claims := newSensitiveClaims()
+
+	for _, out := range []string{claims.String(), fmt.Sprintf("%v", claims), fmt.Sprintf("%s", *claims)} {
+		assert.NotContains(t, out, "alice@example.com")
+		assert.NotContains(t, out, "uid-12345")
+		assert.NotContains(t, out, "secret-team")
+		assert.Contains(t, out, "al***")
+		assert.Contains(t, out, "TokenType:session")
+		assert.Contains(t, out, "ExpiresAt:2025-01-01T12:00:00Z")
+		assert.Contains(t, out, "Groups:2")
+	}
+}
+
+func TestClaims_LogValue_Redacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	logger.Info("validated", "claims", newSensitiveClaims())
+
+	out := buf.String()
+	assert.NotContains(t, out, "alice@example.com")
+	assert.NotContains(t, out, "uid-12345")
+	assert.NotContains(t, out, "secret-team")
+	assert.Contains(t, out, "claims.user=al***")
+	assert.Contains(t, out, "claims.tokenType=session")
+	assert.Contains(t, out, "claims.expiresAt=2025-01-01T12:00:00Z")
+}
+
+func TestRedactUser(t *testing.T) {
+	assert.Equal(t, "", redactUser(""))
+	assert.Equal(t, "***", redactUser("ab"))
+	assert.Equal(t, "bo***", redactUser("bob"))
+	assert.Equal(t, "jü***", redactUser("jürgen"))
+}
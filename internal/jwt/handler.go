@@ -7,14 +7,18 @@ Distributed under the terms of the MIT license
 package jwt
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/go-logr/logr"
 )
 
 // Handler combines signing and token lifecycle management
 type Handler interface {
 	GenerateToken(user string, groups []string, uid string, extra map[string][]string, path string, domain string, tokenType string) (string, error)
+	GenerateTokenContext(ctx context.Context, user string, groups []string, uid string, extra map[string][]string, path string, domain string, tokenType string) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
 	RefreshToken(claims *Claims) (string, error)
 	UpdateSkipRefreshToken(claims *Claims) (string, error)
@@ -27,19 +31,48 @@ type Manager struct {
 	enableRefresh  bool
 	refreshWindow  time.Duration
 	refreshHorizon time.Duration
+	cache          *validationCache // validated tokens, set via WithValidationCache
+	claimLimits    *ClaimLimits     // bounds on generated claims, set via WithClaimLimits
+	logger         logr.Logger
+}
+
+// ManagerOption configures optional Manager behavior
+type ManagerOption func(*Manager)
+
+// WithValidationCache caches up to maxEntries validated tokens so repeated requests
+// skip signature verification. Entries expire with their token and are dropped when
+// the signer's keys change. revocations, if not nil, is checked on every cache hit so
+// revoking a token takes effect immediately. Defaults to no cache.
+func WithValidationCache(maxEntries int, revocations RevocationStore) ManagerOption {
+	return func(m *Manager) {
+		if maxEntries > 0 {
+			m.cache = newValidationCache(maxEntries, revocations)
+		}
+	}
 }
 
 // NewManager creates a new Manager
-func NewManager(signer Signer, enableRefresh bool, refreshWindow time.Duration, refreshHorizon time.Duration) *Manager {
-	return &Manager{
+func NewManager(
+	signer Signer,
+	enableRefresh bool,
+	refreshWindow time.Duration,
+	refreshHorizon time.Duration,
+	opts ...ManagerOption,
+) *Manager {
+	m := &Manager{
 		signer:         signer,
 		enableRefresh:  enableRefresh,
 		refreshWindow:  refreshWindow,
 		refreshHorizon: refreshHorizon,
+		logger:         logr.Discard(),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
-// GenerateToken delegates to the signer
+// GenerateToken delegates to the signer after applying the claim limits
 func (m *Manager) GenerateToken(
 	user string,
 	groups []string,
@@ -49,20 +82,112 @@ func (m *Manager) GenerateToken(
 	domain string,
 	tokenType string,
 ) (string, error) {
-	return m.signer.GenerateToken(user, groups, uid, extra, path, domain, tokenType, false)
+	return m.generateToken(context.Background(), user, groups, uid, extra, path, domain, tokenType, false)
+}
+
+// GenerateTokenContext is GenerateToken bounded by ctx, which is passed to the signer
+// so a request's cancellation and tracing reach the signing step
+func (m *Manager) GenerateTokenContext(
+	ctx context.Context,
+	user string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+) (string, error) {
+	return m.generateToken(ctx, user, groups, uid, extra, path, domain, tokenType, false)
+}
+
+// GenerateNonRefreshableToken creates a token with SkipRefresh set, for callers such as
+// service accounts whose tokens must expire rather than be silently extended.
+// The token validates normally but ShouldRefreshToken and RefreshToken refuse it.
+func (m *Manager) GenerateNonRefreshableToken(
+	user string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+) (string, error) {
+	return m.generateToken(context.Background(), user, groups, uid, extra, path, domain, tokenType, true)
+}
+
+// generateToken applies the claim limits, if any, and signs the token
+func (m *Manager) generateToken(
+	ctx context.Context,
+	user string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+	skipRefresh bool,
+) (string, error) {
+	if m.claimLimits != nil {
+		var err error
+		groups, extra, err = m.claimLimits.apply(user, groups, extra, m.logger)
+		if err != nil {
+			return "", err
+		}
+	}
+	return m.signer.GenerateTokenContext(ctx, user, groups, uid, extra, path, domain, tokenType, skipRefresh)
 }
 
-// ValidateToken delegates to the signer
+// ValidateToken delegates to the signer, answering from the validation cache when enabled
 func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
-	return m.signer.ValidateToken(tokenString)
+	if m.cache == nil {
+		return m.signer.ValidateToken(tokenString)
+	}
+
+	generation := m.keyGeneration()
+	claims, err := m.cache.get(tokenString, generation, time.Now())
+	if claims != nil || err != nil {
+		return claims, err
+	}
+
+	claims, err = m.signer.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	m.cache.put(tokenString, claims, generation, m.acceptedUntil(claims))
+	return claims, nil
+}
+
+// acceptedUntil returns the signer's acceptance deadline for claims, or the zero time
+// if it does not report one
+func (m *Manager) acceptedUntil(claims *Claims) time.Time {
+	if provider, ok := m.signer.(AcceptanceDeadlineProvider); ok {
+		return provider.AcceptedUntil(claims)
+	}
+	return time.Time{}
+}
+
+// keyGeneration returns the signer's key generation, or zero if it does not track one
+func (m *Manager) keyGeneration() uint64 {
+	if provider, ok := m.signer.(KeyGenerationProvider); ok {
+		return provider.KeyGeneration()
+	}
+	return 0
 }
 
 // RefreshToken creates a new token preserving the original IssuedAt for horizon tracking.
-// Returns an error if the token is beyond the refresh horizon, forcing re-authentication.
+// Returns an error if the token is beyond the refresh horizon, forcing re-authentication,
+// ErrValidationOnlyKey if the token was signed by a key that must not be re-signed,
+// and ErrRefreshNotAllowed if the token is marked SkipRefresh.
 func (m *Manager) RefreshToken(claims *Claims) (string, error) {
 	if claims == nil {
 		return "", errors.New("claims cannot be nil")
 	}
+	if claims.SkipRefresh {
+		return "", ErrRefreshNotAllowed
+	}
+	if err := m.checkSigningKey(claims); err != nil {
+		return "", err
+	}
 
 	now := time.Now().UTC()
 	timeSinceOriginalIssuance := now.Sub(claims.IssuedAt.Time)
@@ -81,6 +206,9 @@ func (m *Manager) UpdateSkipRefreshToken(claims *Claims) (string, error) {
 	if claims == nil {
 		return "", errors.New("claims cannot be nil")
 	}
+	if err := m.checkSigningKey(claims); err != nil {
+		return "", err
+	}
 
 	return m.signer.GenerateToken(
 		claims.User, claims.Groups, claims.UID, claims.Extra,
@@ -88,6 +216,18 @@ func (m *Manager) UpdateSkipRefreshToken(claims *Claims) (string, error) {
 	)
 }
 
+// checkSigningKey refuses to re-sign claims whose token was signed by a validation-only key
+func (m *Manager) checkSigningKey(claims *Claims) error {
+	checker, ok := m.signer.(ValidationOnlyKeyChecker)
+	if !ok || claims.KeyID == "" {
+		return nil
+	}
+	if checker.IsValidationOnlyKey(claims.KeyID) {
+		return fmt.Errorf("%w: kid %s", ErrValidationOnlyKey, claims.KeyID)
+	}
+	return nil
+}
+
 // ShouldRefreshToken determines if a token should be refreshed.
 // Returns true when the token is within the refresh window (approaching expiry)
 // and not already marked as skip-refresh.
@@ -6,6 +6,8 @@ Distributed under the terms of the MIT license
 package jwt
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -18,6 +20,7 @@ const mockTokenValue = "mock-token"
 // mockSigner implements Signer for testing
 type mockSigner struct {
 	generateFunc     func(user string, groups []string, uid string, extra map[string][]string, path string, domain string, tokenType string, skipRefresh bool) (string, error)
+	generateCtxFunc  func(ctx context.Context) (string, error)
 	validateFunc     func(tokenString string) (*Claims, error)
 	refreshTokenFunc func(claims *Claims) (string, error)
 }
@@ -29,6 +32,13 @@ func (m *mockSigner) GenerateToken(user string, groups []string, uid string, ext
 	return mockTokenValue, nil
 }
 
+func (m *mockSigner) GenerateTokenContext(ctx context.Context, user string, groups []string, uid string, extra map[string][]string, path string, domain string, tokenType string, skipRefresh bool) (string, error) {
+	if m.generateCtxFunc != nil {
+		return m.generateCtxFunc(ctx)
+	}
+	return m.GenerateToken(user, groups, uid, extra, path, domain, tokenType, skipRefresh)
+}
+
 func (m *mockSigner) GenerateRefreshToken(claims *Claims) (string, error) {
 	if m.refreshTokenFunc != nil {
 		return m.refreshTokenFunc(claims)
@@ -36,6 +46,10 @@ func (m *mockSigner) GenerateRefreshToken(claims *Claims) (string, error) {
 	return mockTokenValue, nil
 }
 
+func (m *mockSigner) IsKnownKid(kid string) bool {
+	return true
+}
+
 func (m *mockSigner) ValidateToken(tokenString string) (*Claims, error) {
 	if m.validateFunc != nil {
 		return m.validateFunc(tokenString)
@@ -63,6 +77,24 @@ func TestManager_GenerateToken(t *testing.T) {
 	}
 }
 
+func TestManager_GenerateTokenContext_PassesContextToSigner(t *testing.T) {
+	// The signer stands in for one signing over the network, blocking until ctx is done
+	signer := &mockSigner{
+		generateCtxFunc: func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	manager := NewManager(signer, false, 0, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := manager.GenerateTokenContext(ctx, "user", nil, "uid", nil, "/path", "domain", TokenTypeSession)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
 func TestManager_ValidateToken(t *testing.T) {
 	signer := &mockSigner{}
 	manager := NewManager(signer, false, 0, 0)
@@ -371,3 +403,80 @@ func TestManager_UpdateSkipRefreshToken_SetsSkipRefreshTrue(t *testing.T) {
 		t.Fatal("Expected GenerateToken to be called with skipRefresh=true")
 	}
 }
+
+func TestManager_RefreshToken_ValidationOnlyKey(t *testing.T) {
+	validKey := []byte("test-signing-key-at-least-48-bytes-long-for-hs384!")
+	shortKey := []byte("short-manual-key-32-bytes-long!!")
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	if err := signer.UpdateKeys(map[string][]byte{"1000": validKey, "2000": shortKey}, "2000"); err != nil {
+		t.Fatalf("UpdateKeys failed: %v", err)
+	}
+	manager := NewManager(signer, true, time.Minute, time.Hour)
+
+	now := time.Now().UTC()
+	legacy := jwt5.NewWithClaims(jwt5.SigningMethodHS384, &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			ExpiresAt: jwt5.NewNumericDate(now.Add(time.Minute)),
+			IssuedAt:  jwt5.NewNumericDate(now),
+			Issuer:    "test-issuer",
+			Audience:  []string{"test-audience"},
+		},
+		User: "testuser",
+	})
+	legacy.Header["kid"] = "2000"
+	legacyString, err := legacy.SignedString(shortKey)
+	if err != nil {
+		t.Fatalf("failed to sign legacy token: %v", err)
+	}
+
+	claims, err := manager.ValidateToken(legacyString)
+	if err != nil {
+		t.Fatalf("Expected legacy token to validate, got: %v", err)
+	}
+
+	if _, err := manager.RefreshToken(claims); !errors.Is(err, ErrValidationOnlyKey) {
+		t.Fatalf("Expected ErrValidationOnlyKey from RefreshToken, got: %v", err)
+	}
+	if _, err := manager.UpdateSkipRefreshToken(claims); !errors.Is(err, ErrValidationOnlyKey) {
+		t.Fatalf("Expected ErrValidationOnlyKey from UpdateSkipRefreshToken, got: %v", err)
+	}
+
+	// Tokens signed by a regular key still refresh
+	token, err := manager.GenerateToken("testuser", nil, "", nil, "/path", "", TokenTypeSession)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	claims, err = manager.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if _, err := manager.RefreshToken(claims); err != nil {
+		t.Fatalf("Expected refresh to succeed, got: %v", err)
+	}
+}
+
+func TestManager_GenerateNonRefreshableToken(t *testing.T) {
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
+	// The whole token lifetime is inside the refresh window
+	manager := NewManager(signer, true, 2*time.Hour, 12*time.Hour)
+
+	token, err := manager.GenerateNonRefreshableToken("svc-account", nil, "uid", nil, "/path", "domain", TokenTypeSession)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	claims, err := manager.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Expected token to validate, got %v", err)
+	}
+	if !claims.SkipRefresh {
+		t.Error("Expected SkipRefresh to be set")
+	}
+
+	if manager.ShouldRefreshToken(claims) {
+		t.Error("Expected ShouldRefreshToken to return false for a non-refreshable token")
+	}
+	if _, err := manager.RefreshToken(claims); !errors.Is(err, ErrRefreshNotAllowed) {
+		t.Errorf("Expected ErrRefreshNotAllowed, got %v", err)
+	}
+}
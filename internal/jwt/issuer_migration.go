@@ -0,0 +1,75 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+)
+
+// IssuerMigration accepts tokens carrying a previous issuer or audience for a limited
+// time after a rename, so sessions issued under the old values stay valid until they
+// are refreshed or expire. Either previous value may be empty if only one was renamed.
+type IssuerMigration struct {
+	PreviousIssuer   string
+	PreviousAudience string
+	// Start is when the rename was rolled out. The previous values are accepted
+	// until Start+Window and rejected afterwards.
+	Start  time.Time
+	Window time.Duration
+}
+
+// End returns the time after which the previous values are no longer accepted
+func (m IssuerMigration) End() time.Time {
+	return m.Start.Add(m.Window)
+}
+
+// Active reports whether the previous values are still accepted at now
+func (m *IssuerMigration) Active(now time.Time) bool {
+	return m != nil && now.Before(m.End())
+}
+
+// check accepts the current or previous issuer, and any of the current audiences or
+// the previous one. A nil migration accepts only the current values.
+func (m *IssuerMigration) check(claims *Claims, issuer string, audiences []string) error {
+	if m == nil {
+		m = &IssuerMigration{}
+	}
+	if claims.Issuer != issuer && (m.PreviousIssuer == "" || claims.Issuer != m.PreviousIssuer) {
+		return fmt.Errorf("%w: %w", ErrInvalidToken, ErrWrongIssuer)
+	}
+	if !slices.ContainsFunc(claims.Audience, func(aud string) bool { return slices.Contains(audiences, aud) }) &&
+		(m.PreviousAudience == "" || !slices.Contains(claims.Audience, m.PreviousAudience)) {
+		return fmt.Errorf("%w: %w", ErrInvalidToken, ErrWrongAudience)
+	}
+	return nil
+}
+
+// acceptedUntil returns the end of the migration window for claims carrying only the
+// previous issuer or audience, or the zero time when claims carry the current values
+func (m *IssuerMigration) acceptedUntil(claims *Claims, issuer string, audiences []string) time.Time {
+	if m == nil {
+		return time.Time{}
+	}
+	if claims.Issuer == issuer &&
+		slices.ContainsFunc(claims.Audience, func(aud string) bool { return slices.Contains(audiences, aud) }) {
+		return time.Time{}
+	}
+	return m.End()
+}
+
+// issuerAudienceOptions returns the parser options enforcing issuer and audience.
+// When checkManually is set (while migrating, with a claim fallback, or with several
+// audiences) none are returned and the caller must run IssuerMigration.check instead.
+func issuerAudienceOptions(issuer string, audience string, checkManually bool) []jwt5.ParserOption {
+	if checkManually {
+		return nil
+	}
+	return []jwt5.ParserOption{jwt5.WithIssuer(issuer), jwt5.WithAudience(audience)}
+}
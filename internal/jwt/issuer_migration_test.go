@@ -0,0 +1,136 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMigrationKey = "test-signing-key-48-bytes-or-more-for-hs384-signing-long"
+
+// tokenFrom issues a session token with the given issuer and audience
+func tokenFrom(t *testing.T, issuer, audience string, now func() time.Time) string {
+	t.Helper()
+	signer := NewStandardSigner(issuer, audience, 2*time.Hour, 0, WithClock(now))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(testMigrationKey)}, "1000"))
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	return token
+}
+
+func TestStandardSigner_IssuerMigration(t *testing.T) {
+	start := time.Now()
+	now := start
+	clock := func() time.Time { return now }
+
+	signer := NewStandardSigner("new-issuer", "new-audience", 2*time.Hour, 0,
+		WithClock(clock),
+		WithIssuerMigration(IssuerMigration{
+			PreviousIssuer:   "old-issuer",
+			PreviousAudience: "old-audience",
+			Start:            start,
+			Window:           time.Hour,
+		}))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(testMigrationKey)}, "1000"))
+
+	oldToken := tokenFrom(t, "old-issuer", "old-audience", clock)
+	newToken := tokenFrom(t, "new-issuer", "new-audience", clock)
+	strangerToken := tokenFrom(t, "other-issuer", "new-audience", clock)
+	wrongAudienceToken := tokenFrom(t, "new-issuer", "other-audience", clock)
+
+	// During the window both old and new values are accepted
+	now = start.Add(30 * time.Minute)
+	_, err := signer.ValidateToken(oldToken)
+	assert.NoError(t, err)
+	_, err = signer.ValidateToken(newToken)
+	assert.NoError(t, err)
+	_, err = signer.ValidateToken(strangerToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, err, ErrWrongIssuer)
+	_, err = signer.ValidateToken(wrongAudienceToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, err, ErrWrongAudience)
+
+	// Once it elapses only the new values are, although the old token has not expired
+	now = start.Add(90 * time.Minute)
+	_, err = signer.ValidateToken(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = signer.ValidateToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestStandardSigner_AcceptedUntil(t *testing.T) {
+	start := time.Now()
+	migration := IssuerMigration{PreviousIssuer: "old-issuer", PreviousAudience: "old-audience", Start: start, Window: time.Hour}
+	signer := NewStandardSigner("new-issuer", "new-audience", 2*time.Hour, 0, WithIssuerMigration(migration))
+
+	current := &Claims{}
+	current.Issuer = "new-issuer"
+	current.Audience = []string{"new-audience"}
+	assert.True(t, signer.AcceptedUntil(current).IsZero())
+
+	previousIssuer := &Claims{}
+	previousIssuer.Issuer = "old-issuer"
+	previousIssuer.Audience = []string{"new-audience"}
+	assert.Equal(t, migration.End(), signer.AcceptedUntil(previousIssuer))
+
+	previousAudience := &Claims{}
+	previousAudience.Issuer = "new-issuer"
+	previousAudience.Audience = []string{"old-audience"}
+	assert.Equal(t, migration.End(), signer.AcceptedUntil(previousAudience))
+
+	// Without a migration only the expiry applies
+	assert.True(t, NewStandardSigner("new-issuer", "new-audience", time.Hour, 0).AcceptedUntil(previousIssuer).IsZero())
+}
+
+func TestStandardSigner_IssuerMigration_AudienceOnly(t *testing.T) {
+	start := time.Now()
+	clock := func() time.Time { return start }
+
+	signer := NewStandardSigner("issuer", "new-audience", time.Hour, 0,
+		WithClock(clock),
+		WithIssuerMigration(IssuerMigration{PreviousAudience: "old-audience", Start: start, Window: time.Hour}))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(testMigrationKey)}, "1000"))
+
+	_, err := signer.ValidateToken(tokenFrom(t, "issuer", "old-audience", clock))
+	assert.NoError(t, err)
+
+	// The issuer was not renamed, so no other issuer is accepted
+	_, err = signer.ValidateToken(tokenFrom(t, "old-issuer", "old-audience", clock))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAsymmetricSigner_IssuerMigration(t *testing.T) {
+	start := time.Now()
+	now := start
+	clock := func() time.Time { return now }
+	key := generateECKey(t)
+
+	oldSigner, err := NewAsymmetricSigner(AlgorithmES256, "old-issuer", "audience", 2*time.Hour, 0,
+		WithAsymmetricClock(clock))
+	require.NoError(t, err)
+	require.NoError(t, oldSigner.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+	oldToken, err := oldSigner.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "new-issuer", "audience", 2*time.Hour, 0,
+		WithAsymmetricClock(clock),
+		WithAsymmetricIssuerMigration(IssuerMigration{PreviousIssuer: "old-issuer", Start: start, Window: time.Hour}))
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+
+	_, err = signer.ValidateToken(oldToken)
+	assert.NoError(t, err)
+
+	now = start.Add(time.Hour)
+	_, err = signer.ValidateToken(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
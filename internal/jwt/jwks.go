@@ -0,0 +1,125 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// JSONWebKey is the public part of a signing key in RFC 7517 format
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA public key members
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC public key members
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a set of public keys as served from a jwks.json endpoint
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// publicJWK converts a public key to its JSON Web Key representation
+func publicJWK(kid string, alg string, pub crypto.PublicKey) (JSONWebKey, error) {
+	jwk := JSONWebKey{Kid: kid, Use: "sig", Alg: alg}
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return JSONWebKey{}, fmt.Errorf("unsupported curve %s for kid %s", key.Curve.Params().Name, kid)
+		}
+		ecdhKey, err := key.ECDH()
+		if err != nil {
+			return JSONWebKey{}, fmt.Errorf("failed to encode EC key for kid %s: %w", kid, err)
+		}
+		// Uncompressed point encoding: 0x04 || X || Y
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+	default:
+		return JSONWebKey{}, fmt.Errorf("unsupported public key type %T for kid %s", pub, kid)
+	}
+
+	return jwk, nil
+}
+
+// p256CoordinateBytes is the length of each coordinate of a P-256 point
+const p256CoordinateBytes = 32
+
+// parseJWK converts a JSON Web Key to the algorithm it verifies and its public key.
+// Only RSA keys of at least minRSAKeyBits (RS256) and P-256 EC keys (ES256) are accepted.
+func parseJWK(jwk JSONWebKey) (string, crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		if jwk.Alg != "" && jwk.Alg != AlgorithmRS256 {
+			return "", nil, fmt.Errorf("unsupported algorithm %q for RSA kid %s", jwk.Alg, jwk.Kid)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil || len(n) == 0 {
+			return "", nil, fmt.Errorf("invalid modulus for kid %s", jwk.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) == 0 {
+			return "", nil, fmt.Errorf("invalid exponent for kid %s", jwk.Kid)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > math.MaxInt32 {
+			return "", nil, fmt.Errorf("invalid exponent for kid %s", jwk.Kid)
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if key.N.BitLen() < minRSAKeyBits {
+			return "", nil, fmt.Errorf("key for kid %s is %d bits, %s requires at least %d",
+				jwk.Kid, key.N.BitLen(), AlgorithmRS256, minRSAKeyBits)
+		}
+		return AlgorithmRS256, key, nil
+	case "EC":
+		if jwk.Alg != "" && jwk.Alg != AlgorithmES256 {
+			return "", nil, fmt.Errorf("unsupported algorithm %q for EC kid %s", jwk.Alg, jwk.Kid)
+		}
+		if jwk.Crv != "P-256" {
+			return "", nil, fmt.Errorf("unsupported curve %q for kid %s", jwk.Crv, jwk.Kid)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if errX != nil || errY != nil || len(x) != p256CoordinateBytes || len(y) != p256CoordinateBytes {
+			return "", nil, fmt.Errorf("invalid coordinates for kid %s", jwk.Kid)
+		}
+		// Uncompressed point encoding: 0x04 || X || Y; ecdh rejects points off the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return "", nil, fmt.Errorf("invalid point for kid %s: %w", jwk.Kid, err)
+		}
+		return AlgorithmES256, &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported key type %q for kid %s", jwk.Kty, jwk.Kid)
+	}
+}
@@ -0,0 +1,442 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultJWKSRefreshInterval is how often a JWKSVerifier re-fetches its key set
+const DefaultJWKSRefreshInterval = 5 * time.Minute
+
+const (
+	// jwksFetchTimeout bounds a single fetch of the key set
+	jwksFetchTimeout = 10 * time.Second
+	// jwksMinFetchInterval bounds how often a token with an unknown kid may trigger a
+	// fetch, so forged kid headers cannot make us hammer the issuer
+	jwksMinFetchInterval = 30 * time.Second
+	// jwksMaxResponseBytes bounds the size of a fetched key set
+	jwksMaxResponseBytes = 1 << 20
+)
+
+// jwksAlgorithms are the signing algorithms a JWKSVerifier accepts
+var jwksAlgorithms = []string{AlgorithmRS256, AlgorithmES256}
+
+// jwksKey is a verification key from the key set and the algorithm it verifies
+type jwksKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// equal reports whether k and other are the same algorithm and public key
+func (k jwksKey) equal(other jwksKey) bool {
+	key, ok := k.key.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && k.alg == other.alg && key.Equal(other.key)
+}
+
+// JWKSVerifier validates RS256 and ES256 tokens issued by an external identity provider
+// against the key set published at a JWKS URL. It has no signing capability: it is the
+// signer of a verify-only Manager, and token generation returns ErrSigningNotSupported.
+// The key set is re-fetched periodically, and on demand when a token names an unknown
+// kid, so the issuer's key rollovers are picked up.
+type JWKSVerifier struct {
+	url             string
+	httpClient      *http.Client
+	refreshInterval time.Duration
+	issuer          string
+	audiences       []string         // any is accepted
+	leeway          time.Duration    // clock skew tolerated on validation, overridable via WithJWKSLeeway
+	now             func() time.Time // time source, overridable via WithJWKSClock
+	logger          logr.Logger
+	keys            map[string]jwksKey // map[kid]key
+	lastFetch       time.Time          // start of the last fetch attempt, successful or not
+	keyGeneration   atomic.Uint64      // incremented whenever the fetched keys change
+	mu              sync.RWMutex       // protect keys and lastFetch
+	fetchMu         sync.Mutex         // serializes fetches
+}
+
+// JWKSVerifierOption configures optional JWKSVerifier behavior
+type JWKSVerifierOption func(*JWKSVerifier)
+
+// WithJWKSHTTPClient sets the client used to fetch the key set.
+// Defaults to a client with a 10 second timeout.
+func WithJWKSHTTPClient(httpClient *http.Client) JWKSVerifierOption {
+	return func(v *JWKSVerifier) {
+		if httpClient != nil {
+			v.httpClient = httpClient
+		}
+	}
+}
+
+// WithJWKSRefreshInterval sets how often the key set is re-fetched.
+// Defaults to DefaultJWKSRefreshInterval.
+func WithJWKSRefreshInterval(interval time.Duration) JWKSVerifierOption {
+	return func(v *JWKSVerifier) {
+		if interval > 0 {
+			v.refreshInterval = interval
+		}
+	}
+}
+
+// WithJWKSClock overrides the time source used for validation and fetch throttling.
+// Intended for tests; defaults to time.Now.
+func WithJWKSClock(now func() time.Time) JWKSVerifierOption {
+	return func(v *JWKSVerifier) {
+		if now != nil {
+			v.now = now
+		}
+	}
+}
+
+// WithJWKSLogger sets the logger used for fetch failures and security audit events.
+// Defaults to a discarding logger.
+func WithJWKSLogger(logger logr.Logger) JWKSVerifierOption {
+	return func(v *JWKSVerifier) {
+		v.logger = logger
+	}
+}
+
+// WithJWKSLeeway sets the clock skew tolerated on the exp and nbf claims.
+// Defaults to DefaultLeeway.
+func WithJWKSLeeway(leeway time.Duration) JWKSVerifierOption {
+	return func(v *JWKSVerifier) {
+		if leeway >= 0 {
+			v.leeway = leeway
+		}
+	}
+}
+
+// WithJWKSAdditionalAudiences accepts tokens carrying any of audiences besides the one
+// passed to NewJWKSVerifier. Defaults to the single constructor audience.
+func WithJWKSAdditionalAudiences(audiences ...string) JWKSVerifierOption {
+	return func(v *JWKSVerifier) {
+		v.audiences = appendAudiences(v.audiences, audiences)
+	}
+}
+
+// NewJWKSVerifier creates a JWKSVerifier for tokens from issuer and audience, verified
+// against the key set at url. Keys must be loaded by calling Refresh() before use.
+func NewJWKSVerifier(url string, issuer string, audience string, opts ...JWKSVerifierOption) *JWKSVerifier {
+	v := &JWKSVerifier{
+		url:             url,
+		httpClient:      &http.Client{Timeout: jwksFetchTimeout},
+		refreshInterval: DefaultJWKSRefreshInterval,
+		issuer:          issuer,
+		audiences:       []string{audience},
+		leeway:          DefaultLeeway,
+		now:             time.Now,
+		logger:          logr.Discard(),
+		keys:            make(map[string]jwksKey),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// GenerateToken always fails: a JWKSVerifier holds no signing keys
+func (v *JWKSVerifier) GenerateToken(
+	user string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+	skipRefresh bool,
+) (string, error) {
+	return "", ErrSigningNotSupported
+}
+
+// GenerateTokenContext always fails: a JWKSVerifier holds no signing keys
+func (v *JWKSVerifier) GenerateTokenContext(
+	ctx context.Context,
+	user string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+	skipRefresh bool,
+) (string, error) {
+	return "", ErrSigningNotSupported
+}
+
+// GenerateRefreshToken always fails: a JWKSVerifier holds no signing keys
+func (v *JWKSVerifier) GenerateRefreshToken(claims *Claims) (string, error) {
+	return "", ErrSigningNotSupported
+}
+
+// IsKnownKid reports whether kid is in the last fetched key set
+func (v *JWKSVerifier) IsKnownKid(kid string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	_, ok := v.keys[kid]
+	return ok
+}
+
+// KeyGeneration returns the number of times the fetched keys have changed
+func (v *JWKSVerifier) KeyGeneration() uint64 {
+	return v.keyGeneration.Load()
+}
+
+// ValidateToken validates and parses the token.
+// Requires a kid header naming a key in the key set, and an algorithm matching that key.
+// External tokens name the user in sub, which is copied to User when the token has none;
+// a token naming no user is rejected.
+func (v *JWKSVerifier) ValidateToken(tokenString string) (*Claims, error) {
+	checkManually := len(v.audiences) > 1
+	parserOpts := append([]jwt5.ParserOption{
+		jwt5.WithValidMethods(jwksAlgorithms),
+		jwt5.WithLeeway(v.leeway),
+		jwt5.WithTimeFunc(v.now),
+	}, issuerAudienceOptions(v.issuer, v.audiences[0], checkManually)...)
+
+	token, err := jwt5.ParseWithClaims(
+		tokenString,
+		&Claims{},
+		func(t *jwt5.Token) (any, error) {
+			kid, ok := t.Header["kid"].(string)
+			if !ok || kid == "" {
+				return nil, ErrMissingKid
+			}
+
+			key, ok := v.lookupKey(kid)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownKid, kid)
+			}
+
+			// The key, not the token header, decides the algorithm
+			if t.Method.Alg() != key.alg {
+				return nil, fmt.Errorf("%w: %q for kid %s, expected %s", ErrAlgorithmNotAllowed, t.Method.Alg(), kid, key.alg)
+			}
+
+			return key.key, nil
+		},
+		parserOpts...,
+	)
+
+	if err != nil {
+		if token != nil {
+			if alg, ok := token.Header["alg"].(string); ok && !slices.Contains(jwksAlgorithms, alg) {
+				v.logger.Info("Security audit: rejected token with disallowed signing algorithm",
+					"event", "jwt_algorithm_not_allowed",
+					"presentedAlg", alg,
+					"kid", token.Header["kid"])
+				return nil, fmt.Errorf("%w: %q", ErrAlgorithmNotAllowed, alg)
+			}
+		}
+		if errors.Is(err, ErrAlgorithmNotAllowed) {
+			return nil, ErrAlgorithmNotAllowed
+		}
+		return nil, mapParseError(err)
+	}
+
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if err := checkTypHeader(token, false); err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return nil, ErrInvalidClaims
+	}
+	claims.KeyID, _ = token.Header["kid"].(string)
+	if claims.User == "" {
+		claims.User = claims.Subject
+	}
+	if claims.User == "" {
+		return nil, fmt.Errorf("%w: no sub or User claim", ErrInvalidClaims)
+	}
+
+	if checkManually {
+		if err := (*IssuerMigration)(nil).check(claims, v.issuer, v.audiences); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// lookupKey returns the key for kid. An unknown kid triggers a fetch, at most once per
+// jwksMinFetchInterval, in case the issuer has rolled over to a new key.
+func (v *JWKSVerifier) lookupKey(kid string) (jwksKey, bool) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	v.mu.RUnlock()
+	if ok {
+		return key, true
+	}
+
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+
+	v.mu.RLock()
+	key, ok = v.keys[kid]
+	recentlyFetched := v.now().Sub(v.lastFetch) < jwksMinFetchInterval
+	v.mu.RUnlock()
+	if ok || recentlyFetched {
+		return key, ok
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	if err := v.refresh(ctx); err != nil {
+		v.logger.Error(err, "Failed to fetch JWKS for unknown kid", "kid", kid, "url", v.url)
+		return jwksKey{}, false
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	key, ok = v.keys[kid]
+	return key, ok
+}
+
+// Refresh fetches the key set and replaces the loaded keys with it. Keys that cannot
+// be used are skipped; a key set without any usable key is an error and leaves the
+// loaded keys unchanged.
+func (v *JWKSVerifier) Refresh(ctx context.Context) error {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	return v.refresh(ctx)
+}
+
+// refresh implements Refresh. Callers hold fetchMu.
+func (v *JWKSVerifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	v.lastFetch = v.now()
+	v.mu.Unlock()
+
+	set, err := v.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]jwksKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		alg, pub, err := parseJWK(jwk)
+		if err != nil {
+			v.logger.Info("Skipping unusable key in JWKS", "kid", jwk.Kid, "url", v.url, "error", err.Error())
+			continue
+		}
+		keys[jwk.Kid] = jwksKey{alg: alg, key: pub}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("JWKS at %s has no usable %s or %s signing keys", v.url, AlgorithmRS256, AlgorithmES256)
+	}
+
+	v.mu.Lock()
+	changed := !maps.EqualFunc(v.keys, keys, jwksKey.equal)
+	v.keys = keys
+	v.mu.Unlock()
+
+	// Only a changed key set invalidates cached validations
+	if changed {
+		v.keyGeneration.Add(1)
+		v.logger.Info("Loaded JWKS", "url", v.url, "keys", len(keys))
+	}
+	return nil
+}
+
+// fetch downloads and decodes the key set
+func (v *JWKSVerifier) fetch(ctx context.Context) (*JSONWebKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build JWKS request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS from %s: %w", v.url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS from %s: unexpected status %d", v.url, resp.StatusCode)
+	}
+
+	var set JSONWebKeySet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, jwksMaxResponseBytes)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS from %s: %w", v.url, err)
+	}
+	return &set, nil
+}
+
+// Start re-fetches the key set every refresh interval until ctx is done. A failed
+// fetch is logged and the previously loaded keys stay in use.
+func (v *JWKSVerifier) Start(ctx context.Context) error {
+	ticker := time.NewTicker(v.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			fetchCtx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+			if err := v.Refresh(fetchCtx); err != nil {
+				v.logger.Error(err, "Failed to refresh JWKS, keeping the loaded keys", "url", v.url)
+			}
+			cancel()
+		}
+	}
+}
+
+// NeedLeaderElection returns false because every replica validates tokens
+func (v *JWKSVerifier) NeedLeaderElection() bool {
+	return false
+}
+
+// RetrieveInitialSecret fetches the initial key set, so a JWKSVerifier can stand in
+// for a secret-backed signer. There is no secret: the arguments naming it are ignored.
+func (v *JWKSVerifier) RetrieveInitialSecret(
+	ctx context.Context,
+	runtimeClient client.Client,
+	secretName string,
+	namespace string,
+) error {
+	return v.Refresh(ctx)
+}
+
+// RegisterSecretWatch adds the periodic key set refresh to the manager, in place of
+// watching a secret. The arguments naming the secret are ignored.
+func (v *JWKSVerifier) RegisterSecretWatch(
+	ctx context.Context,
+	mgr ctrl.Manager,
+	secretName string,
+	namespace string,
+	syncTimeout time.Duration,
+	logger logr.Logger,
+) error {
+	if err := mgr.Add(v); err != nil {
+		return fmt.Errorf("failed to add JWKS refresh to manager: %w", err)
+	}
+	return nil
+}
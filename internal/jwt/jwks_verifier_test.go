@@ -0,0 +1,248 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer serves the key set of an AsymmetricSigner standing in for an external issuer
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	set      JSONWebKeySet
+	status   int
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	s := &jwksServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.WriteHeader(s.status)
+		_ = json.NewEncoder(w).Encode(s.set)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// publish serves the public keys of issuer
+func (s *jwksServer) publish(t *testing.T, issuer *AsymmetricSigner) {
+	t.Helper()
+	set, err := issuer.JWKS()
+	require.NoError(t, err)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set = set
+}
+
+// newExternalIssuer returns an AsymmetricSigner signing with key under kid
+func newExternalIssuer(t *testing.T, algorithm string, kid string, key crypto.Signer) *AsymmetricSigner {
+	t.Helper()
+	issuer, err := NewAsymmetricSigner(algorithm, "external-issuer", "test-audience", time.Hour, 0)
+	require.NoError(t, err)
+	require.NoError(t, issuer.UpdateKeys(map[string]crypto.Signer{kid: key}, kid))
+	return issuer
+}
+
+func TestJWKSVerifier_ValidatesExternalTokens(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		key       crypto.Signer
+	}{
+		{name: "RS256", algorithm: AlgorithmRS256, key: generateRSAKey(t)},
+		{name: "ES256", algorithm: AlgorithmES256, key: generateECKey(t)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newExternalIssuer(t, tt.algorithm, "idp-key-1", tt.key)
+			server := newJWKSServer(t)
+			server.publish(t, issuer)
+
+			verifier := NewJWKSVerifier(server.URL, "external-issuer", "test-audience")
+			require.NoError(t, verifier.Refresh(context.Background()))
+
+			token, err := issuer.GenerateToken(testUser, []string{"g1"}, "uid", nil, "/path", "", TokenTypeSession, false)
+			require.NoError(t, err)
+			claims, err := verifier.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, testUser, claims.User)
+			assert.Equal(t, "idp-key-1", claims.KeyID)
+			assert.True(t, verifier.IsKnownKid("idp-key-1"))
+		})
+	}
+}
+
+func TestJWKSVerifier_SigningNotSupported(t *testing.T) {
+	verifier := NewJWKSVerifier("http://127.0.0.1:0/jwks.json", "external-issuer", "test-audience")
+
+	_, err := verifier.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	assert.ErrorIs(t, err, ErrSigningNotSupported)
+	_, err = verifier.GenerateRefreshToken(&Claims{})
+	assert.ErrorIs(t, err, ErrSigningNotSupported)
+
+	manager := NewManager(verifier, true, 15*time.Minute, 12*time.Hour)
+	_, err = manager.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession)
+	assert.ErrorIs(t, err, ErrSigningNotSupported)
+}
+
+func TestJWKSVerifier_KeyRollover(t *testing.T) {
+	now := time.Now()
+	server := newJWKSServer(t)
+	oldIssuer := newExternalIssuer(t, AlgorithmRS256, "idp-key-1", generateRSAKey(t))
+	server.publish(t, oldIssuer)
+
+	verifier := NewJWKSVerifier(server.URL, "external-issuer", "test-audience",
+		WithJWKSClock(func() time.Time { return now }))
+	require.NoError(t, verifier.Refresh(context.Background()))
+	generation := verifier.KeyGeneration()
+
+	newIssuer := newExternalIssuer(t, AlgorithmRS256, "idp-key-2", generateRSAKey(t))
+	server.publish(t, newIssuer)
+	token, err := newIssuer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	// Just after a fetch, an unknown kid does not trigger another one
+	_, err = verifier.ValidateToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, err, ErrUnknownKid)
+	assert.Equal(t, int32(1), server.requests.Load())
+
+	now = now.Add(jwksMinFetchInterval)
+	claims, err := verifier.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "idp-key-2", claims.KeyID)
+	assert.Equal(t, int32(2), server.requests.Load())
+	assert.False(t, verifier.IsKnownKid("idp-key-1"))
+	assert.Greater(t, verifier.KeyGeneration(), generation)
+}
+
+func TestJWKSVerifier_UnchangedKeySetKeepsGeneration(t *testing.T) {
+	server := newJWKSServer(t)
+	server.publish(t, newExternalIssuer(t, AlgorithmES256, "idp-key-1", generateECKey(t)))
+
+	verifier := NewJWKSVerifier(server.URL, "external-issuer", "test-audience")
+	require.NoError(t, verifier.Refresh(context.Background()))
+	generation := verifier.KeyGeneration()
+
+	require.NoError(t, verifier.Refresh(context.Background()))
+	assert.Equal(t, generation, verifier.KeyGeneration())
+}
+
+func TestJWKSVerifier_RejectsInvalidTokens(t *testing.T) {
+	issuer := newExternalIssuer(t, AlgorithmRS256, "idp-key-1", generateRSAKey(t))
+	server := newJWKSServer(t)
+	server.publish(t, issuer)
+
+	verifier := NewJWKSVerifier(server.URL, "external-issuer", "other-audience")
+	require.NoError(t, verifier.Refresh(context.Background()))
+
+	t.Run("wrong audience", func(t *testing.T) {
+		token, err := issuer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+		require.NoError(t, err)
+		_, err = verifier.ValidateToken(token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.ErrorIs(t, err, ErrWrongAudience)
+	})
+
+	t.Run("HMAC token", func(t *testing.T) {
+		token := jwt5.NewWithClaims(jwt5.SigningMethodHS256, &Claims{User: testUser})
+		token.Header["kid"] = "idp-key-1"
+		signed, err := token.SignedString([]byte("shared-secret"))
+		require.NoError(t, err)
+		_, err = verifier.ValidateToken(signed)
+		assert.ErrorIs(t, err, ErrAlgorithmNotAllowed)
+	})
+
+	t.Run("no user", func(t *testing.T) {
+		verifier := NewJWKSVerifier(server.URL, "external-issuer", "test-audience")
+		require.NoError(t, verifier.Refresh(context.Background()))
+		token, err := issuer.GenerateToken("", nil, "", nil, "/path", "", TokenTypeSession, false)
+		require.NoError(t, err)
+		_, err = verifier.ValidateToken(token)
+		assert.ErrorIs(t, err, ErrInvalidClaims)
+	})
+
+	t.Run("algorithm differs from key", func(t *testing.T) {
+		token := jwt5.NewWithClaims(jwt5.SigningMethodES256, &Claims{User: testUser})
+		token.Header["kid"] = "idp-key-1"
+		signed, err := token.SignedString(generateECKey(t))
+		require.NoError(t, err)
+		_, err = verifier.ValidateToken(signed)
+		assert.ErrorIs(t, err, ErrAlgorithmNotAllowed)
+	})
+}
+
+func TestJWKSVerifier_RefreshErrors(t *testing.T) {
+	server := newJWKSServer(t)
+	issuer := newExternalIssuer(t, AlgorithmRS256, "idp-key-1", generateRSAKey(t))
+	server.publish(t, issuer)
+
+	verifier := NewJWKSVerifier(server.URL, "external-issuer", "test-audience")
+	require.NoError(t, verifier.Refresh(context.Background()))
+
+	server.mu.Lock()
+	server.status = http.StatusInternalServerError
+	server.mu.Unlock()
+	assert.Error(t, verifier.Refresh(context.Background()))
+
+	server.mu.Lock()
+	server.status = http.StatusOK
+	server.set = JSONWebKeySet{Keys: []JSONWebKey{{Kty: "oct", Kid: "hmac"}}}
+	server.mu.Unlock()
+	assert.Error(t, verifier.Refresh(context.Background()))
+
+	// Failed refreshes keep the loaded keys
+	assert.True(t, verifier.IsKnownKid("idp-key-1"))
+}
+
+func TestParseJWK(t *testing.T) {
+	rsaKey := generateRSAKey(t)
+	rsaJWK, err := publicJWK("rsa", AlgorithmRS256, rsaKey.Public())
+	require.NoError(t, err)
+	alg, pub, err := parseJWK(rsaJWK)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmRS256, alg)
+	assert.True(t, rsaKey.PublicKey.Equal(pub))
+
+	ecKey := generateECKey(t)
+	ecJWK, err := publicJWK("ec", AlgorithmES256, ecKey.Public())
+	require.NoError(t, err)
+	alg, pub, err = parseJWK(ecJWK)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmES256, alg)
+	assert.True(t, ecKey.PublicKey.Equal(pub))
+
+	wrongAlg := rsaJWK
+	wrongAlg.Alg = "RS512"
+	_, _, err = parseJWK(wrongAlg)
+	assert.Error(t, err)
+
+	offCurve := ecJWK
+	offCurve.Y = offCurve.X
+	_, _, err = parseJWK(offCurve)
+	assert.Error(t, err)
+
+	_, _, err = parseJWK(JSONWebKey{Kty: "oct", Kid: "hmac"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,230 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+// Package jwttest provides a conformance suite that every jwt.Signer implementation
+// runs, so signers agree on claim handling and on the errors they return.
+package jwttest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Default values used by the conformance suite
+const (
+	DefaultIssuer     = "conformance-issuer"
+	DefaultAudience   = "conformance-audience"
+	DefaultExpiration = time.Hour
+)
+
+// SignerConfig is the configuration the suite asks a SignerFactory to apply
+type SignerConfig struct {
+	Issuer     string
+	Audience   string
+	Expiration time.Duration
+	// Now is the time source the signer must use for token timestamps and validation
+	Now func() time.Time
+}
+
+// SignerFactory returns a ready-to-use signer for cfg. Signers returned by one factory
+// must share key material, so a token from one is rejected by another only because of
+// the configured issuer, audience or clock.
+type SignerFactory func(t *testing.T, cfg SignerConfig) jwt.Signer
+
+// testClock is a settable time source shared by a signer under test
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+// RunSignerConformance runs the conformance suite against signers built by newSigner
+func RunSignerConformance(t *testing.T, newSigner SignerFactory) {
+	t.Helper()
+
+	newDefault := func(t *testing.T, clock *testClock) jwt.Signer {
+		return newSigner(t, SignerConfig{
+			Issuer:     DefaultIssuer,
+			Audience:   DefaultAudience,
+			Expiration: DefaultExpiration,
+			Now:        clock.Now,
+		})
+	}
+
+	t.Run("GenerateValidateRoundtrip", func(t *testing.T) {
+		clock := &testClock{now: time.Now()}
+		signer := newDefault(t, clock)
+		extra := map[string][]string{"team": {"data"}}
+
+		token, err := signer.GenerateToken("alice", []string{"g1", "g2"}, "uid-1", extra,
+			"/workspaces/ns/ws", "example.com", jwt.TokenTypeSession, true)
+		require.NoError(t, err)
+
+		claims, err := signer.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", claims.User)
+		assert.Equal(t, "alice", claims.Subject)
+		assert.Equal(t, []string{"g1", "g2"}, claims.Groups)
+		assert.Equal(t, "uid-1", claims.UID)
+		assert.Equal(t, extra, claims.Extra)
+		assert.Equal(t, "/workspaces/ns/ws", claims.Path)
+		assert.Equal(t, "example.com", claims.Domain)
+		assert.Equal(t, jwt.TokenTypeSession, claims.TokenType)
+		assert.True(t, claims.SkipRefresh)
+		assert.Equal(t, DefaultIssuer, claims.Issuer)
+		assert.Equal(t, []string{DefaultAudience}, []string(claims.Audience))
+		assert.NotEmpty(t, claims.ID)
+		require.NotNil(t, claims.ExpiresAt)
+		assert.WithinDuration(t, clock.now.Add(DefaultExpiration), claims.ExpiresAt.Time, time.Second)
+	})
+
+	t.Run("UniqueTokenIDs", func(t *testing.T) {
+		signer := newDefault(t, &testClock{now: time.Now()})
+		first, err := signer.GenerateToken("alice", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+		second, err := signer.GenerateToken("alice", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+
+		firstClaims, err := signer.ValidateToken(first)
+		require.NoError(t, err)
+		secondClaims, err := signer.ValidateToken(second)
+		require.NoError(t, err)
+		assert.NotEqual(t, firstClaims.ID, secondClaims.ID)
+	})
+
+	t.Run("RefreshPreservesIssuedAtAndClaims", func(t *testing.T) {
+		clock := &testClock{now: time.Now()}
+		signer := newDefault(t, clock)
+		token, err := signer.GenerateToken("alice", []string{"g1"}, "uid-1", nil,
+			"/path", "example.com", jwt.TokenTypeSession, true)
+		require.NoError(t, err)
+		original, err := signer.ValidateToken(token)
+		require.NoError(t, err)
+
+		clock.now = clock.now.Add(10 * time.Minute)
+		refreshed, err := signer.GenerateRefreshToken(original)
+		require.NoError(t, err)
+		claims, err := signer.ValidateToken(refreshed)
+		require.NoError(t, err)
+
+		assert.Equal(t, original.IssuedAt.Unix(), claims.IssuedAt.Unix())
+		assert.True(t, claims.ExpiresAt.After(original.ExpiresAt.Time))
+		assert.Equal(t, original.User, claims.User)
+		assert.Equal(t, original.Groups, claims.Groups)
+		assert.Equal(t, original.Path, claims.Path)
+		assert.Equal(t, original.Domain, claims.Domain)
+		assert.False(t, claims.SkipRefresh, "refreshed tokens clear SkipRefresh")
+	})
+
+	t.Run("KnowsSigningKid", func(t *testing.T) {
+		signer := newDefault(t, &testClock{now: time.Now()})
+		token, err := signer.GenerateToken("alice", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+		claims, err := signer.ValidateToken(token)
+		require.NoError(t, err)
+
+		assert.True(t, signer.IsKnownKid(claims.KeyID))
+		assert.False(t, signer.IsKnownKid("unknown-kid"))
+		assert.False(t, signer.IsKnownKid(""))
+	})
+
+	t.Run("RefreshRejectsNilClaims", func(t *testing.T) {
+		signer := newDefault(t, &testClock{now: time.Now()})
+		_, err := signer.GenerateRefreshToken(nil)
+		assert.Error(t, err)
+		_, err = signer.GenerateRefreshToken(&jwt.Claims{})
+		assert.Error(t, err)
+	})
+
+	t.Run("RejectsExpiredToken", func(t *testing.T) {
+		clock := &testClock{now: time.Now()}
+		signer := newDefault(t, clock)
+		token, err := signer.GenerateToken("alice", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+
+		clock.now = clock.now.Add(DefaultExpiration + time.Minute)
+		_, err = signer.ValidateToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	})
+
+	t.Run("RejectsWrongAudience", func(t *testing.T) {
+		clock := &testClock{now: time.Now()}
+		other := newSigner(t, SignerConfig{
+			Issuer:     DefaultIssuer,
+			Audience:   "other-audience",
+			Expiration: DefaultExpiration,
+			Now:        clock.Now,
+		})
+		token, err := other.GenerateToken("alice", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+
+		_, err = newDefault(t, clock).ValidateToken(token)
+		assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+	})
+
+	t.Run("RejectsWrongIssuer", func(t *testing.T) {
+		clock := &testClock{now: time.Now()}
+		other := newSigner(t, SignerConfig{
+			Issuer:     "other-issuer",
+			Audience:   DefaultAudience,
+			Expiration: DefaultExpiration,
+			Now:        clock.Now,
+		})
+		token, err := other.GenerateToken("alice", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+
+		_, err = newDefault(t, clock).ValidateToken(token)
+		assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+	})
+
+	t.Run("RejectsTamperedSignature", func(t *testing.T) {
+		signer := newDefault(t, &testClock{now: time.Now()})
+		token, err := signer.GenerateToken("alice", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+
+		_, err = signer.ValidateToken(tamperSignature(token))
+		assert.ErrorIs(t, err, jwt.ErrInvalidSignature)
+	})
+
+	t.Run("RejectsTamperedClaims", func(t *testing.T) {
+		signer := newDefault(t, &testClock{now: time.Now()})
+		token, err := signer.GenerateToken("alice", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+		other, err := signer.GenerateToken("mallory", nil, "", nil, "/path", "", jwt.TokenTypeSession, false)
+		require.NoError(t, err)
+
+		// Splice another token's payload under the original signature
+		parts := strings.Split(token, ".")
+		parts[1] = strings.Split(other, ".")[1]
+		_, err = signer.ValidateToken(strings.Join(parts, "."))
+		assert.ErrorIs(t, err, jwt.ErrInvalidSignature)
+	})
+
+	t.Run("RejectsMalformedToken", func(t *testing.T) {
+		signer := newDefault(t, &testClock{now: time.Now()})
+		for _, token := range []string{"", "not-a-jwt", "a.b.c"} {
+			_, err := signer.ValidateToken(token)
+			assert.ErrorIs(t, err, jwt.ErrInvalidToken, "token %q", token)
+		}
+	})
+}
+
+// tamperSignature flips a character in the signature segment of a token
+func tamperSignature(token string) string {
+	parts := strings.Split(token, ".")
+	sig := []byte(parts[2])
+	if sig[0] == 'A' {
+		sig[0] = 'B'
+	} else {
+		sig[0] = 'A'
+	}
+	parts[2] = string(sig)
+	return strings.Join(parts, ".")
+}
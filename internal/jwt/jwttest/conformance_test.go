@@ -0,0 +1,41 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwttest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardSignerConformance(t *testing.T) {
+	key := []byte(strings.Repeat("k", jwt.KeySizeBytes))
+
+	RunSignerConformance(t, func(t *testing.T, cfg SignerConfig) jwt.Signer {
+		signer := jwt.NewStandardSigner(cfg.Issuer, cfg.Audience, cfg.Expiration, 0, jwt.WithClock(cfg.Now))
+		require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key}, "1000"))
+		return signer
+	})
+}
+
+func TestAsymmetricSignerConformance(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	RunSignerConformance(t, func(t *testing.T, cfg SignerConfig) jwt.Signer {
+		signer, err := jwt.NewAsymmetricSigner(jwt.AlgorithmES256, cfg.Issuer, cfg.Audience, cfg.Expiration, 0,
+			jwt.WithAsymmetricClock(cfg.Now))
+		require.NoError(t, err)
+		require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+		return signer
+	})
+}
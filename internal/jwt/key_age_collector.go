@@ -0,0 +1,63 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// keyAgeBuckets spans an hour to 90 days, covering typical rotation schedules
+var keyAgeBuckets = []float64{
+	3600,    // 1h
+	21600,   // 6h
+	86400,   // 1d
+	259200,  // 3d
+	604800,  // 7d
+	1209600, // 14d
+	2592000, // 30d
+	7776000, // 90d
+}
+
+var keyAgeDesc = prometheus.NewDesc(
+	"jwt_key_age_seconds",
+	"Age of each loaded signing key since the signer first saw it",
+	nil, nil,
+)
+
+// KeyAgeCollector exports the age distribution of a signer's loaded keys as a
+// histogram. Ages are computed from the signer's snapshot on every scrape.
+type KeyAgeCollector struct {
+	signer SnapshotProvider
+}
+
+// NewKeyAgeCollector creates a collector reporting the key ages of signer
+func NewKeyAgeCollector(signer SnapshotProvider) *KeyAgeCollector {
+	return &KeyAgeCollector{signer: signer}
+}
+
+// Describe implements prometheus.Collector
+func (c *KeyAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- keyAgeDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *KeyAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.signer.Snapshot()
+
+	buckets := make(map[float64]uint64, len(keyAgeBuckets))
+	var sum float64
+	for _, age := range snapshot.KeyAges {
+		seconds := age.Seconds()
+		sum += seconds
+		for _, upperBound := range keyAgeBuckets {
+			if seconds <= upperBound {
+				buckets[upperBound]++
+			}
+		}
+	}
+
+	ch <- prometheus.MustNewConstHistogram(keyAgeDesc, uint64(len(snapshot.KeyAges)), sum, buckets)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyAgeCollector(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+		WithClock(func() time.Time { return now }))
+	key := func(c byte) []byte { return []byte(strings.Repeat(string(c), 48)) }
+
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key('a')}, "1000"))
+	now = now.Add(4 * 24 * time.Hour)
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key('a'), "2000": key('b')}, "2000"))
+	now = now.Add(90 * time.Minute)
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key('a'), "2000": key('b'), "3000": key('c')}, "3000"))
+	now = now.Add(30 * time.Minute)
+
+	// Ages are now 4d2h, 2h and 30m
+	expected := `
+# HELP jwt_key_age_seconds Age of each loaded signing key since the signer first saw it
+# TYPE jwt_key_age_seconds histogram
+jwt_key_age_seconds_bucket{le="3600"} 1
+jwt_key_age_seconds_bucket{le="21600"} 2
+jwt_key_age_seconds_bucket{le="86400"} 2
+jwt_key_age_seconds_bucket{le="259200"} 2
+jwt_key_age_seconds_bucket{le="604800"} 3
+jwt_key_age_seconds_bucket{le="1.2096e+06"} 3
+jwt_key_age_seconds_bucket{le="2.592e+06"} 3
+jwt_key_age_seconds_bucket{le="7.776e+06"} 3
+jwt_key_age_seconds_bucket{le="+Inf"} 3
+jwt_key_age_seconds_sum 361800
+jwt_key_age_seconds_count 3
+`
+	require.NoError(t, testutil.CollectAndCompare(NewKeyAgeCollector(signer), strings.NewReader(expected)))
+
+	// Pruning the oldest key drops it from the distribution on the next scrape
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"2000": key('b'), "3000": key('c')}, "3000"))
+	expected = `
+# HELP jwt_key_age_seconds Age of each loaded signing key since the signer first saw it
+# TYPE jwt_key_age_seconds histogram
+jwt_key_age_seconds_bucket{le="3600"} 1
+jwt_key_age_seconds_bucket{le="21600"} 2
+jwt_key_age_seconds_bucket{le="86400"} 2
+jwt_key_age_seconds_bucket{le="259200"} 2
+jwt_key_age_seconds_bucket{le="604800"} 2
+jwt_key_age_seconds_bucket{le="1.2096e+06"} 2
+jwt_key_age_seconds_bucket{le="2.592e+06"} 2
+jwt_key_age_seconds_bucket{le="7.776e+06"} 2
+jwt_key_age_seconds_bucket{le="+Inf"} 2
+jwt_key_age_seconds_sum 9000
+jwt_key_age_seconds_count 2
+`
+	require.NoError(t, testutil.CollectAndCompare(NewKeyAgeCollector(signer), strings.NewReader(expected)))
+}
@@ -0,0 +1,51 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrWeakKeyMaterial is returned for a signing key that is too short or an obvious
+// placeholder, e.g. all zeros or a repeated short pattern
+var ErrWeakKeyMaterial = errors.New("weak signing key material")
+
+// maxWeakKeyPeriod is the longest pattern whose repetition marks a key as weak
+const maxWeakKeyPeriod = 8
+
+// validateKeyMaterial reports whether key is shorter than minBytes or made of one short
+// pattern repeated, as left by a placeholder or a broken generator. The error names
+// the kid and the reason, never the key bytes.
+func validateKeyMaterial(kid string, key []byte, minBytes int) error {
+	if len(key) < minBytes {
+		return fmt.Errorf("%w: kid %s is %d bytes, at least %d are required",
+			ErrWeakKeyMaterial, kid, len(key), minBytes)
+	}
+	if len(key) > 0 && bytes.Count(key, key[:1]) == len(key) {
+		if key[0] == 0 {
+			return fmt.Errorf("%w: kid %s is all zero bytes", ErrWeakKeyMaterial, kid)
+		}
+		return fmt.Errorf("%w: kid %s repeats a single byte", ErrWeakKeyMaterial, kid)
+	}
+	for period := 2; period <= maxWeakKeyPeriod && period*2 <= len(key); period++ {
+		if repeatsPattern(key, period) {
+			return fmt.Errorf("%w: kid %s repeats a %d-byte pattern", ErrWeakKeyMaterial, kid, period)
+		}
+	}
+	return nil
+}
+
+// repeatsPattern reports whether key is its first period bytes repeated
+func repeatsPattern(key []byte, period int) bool {
+	for i := period; i < len(key); i++ {
+		if key[i] != key[i-period] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func randomKey(t *testing.T, n int) []byte {
+	t.Helper()
+	key := make([]byte, n)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func TestValidateKeyMaterial(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     []byte
+		wantErr string
+	}{
+		{name: "random", key: randomKey(t, 48)},
+		{name: "all zeros", key: make([]byte, 48), wantErr: "is all zero bytes"},
+		{name: "single repeated byte", key: bytes.Repeat([]byte("a"), 48), wantErr: "repeats a single byte"},
+		{name: "repeated pattern", key: bytes.Repeat([]byte("abcd"), 12), wantErr: "repeats a 4-byte pattern"},
+		{name: "too short", key: randomKey(t, 32), wantErr: "is 32 bytes, at least 48"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKeyMaterial("1000", tt.key, 48)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrWeakKeyMaterial)
+			assert.ErrorContains(t, err, "kid 1000 "+tt.wantErr)
+		})
+	}
+}
+
+func TestStandardSigner_UpdateKeys_WeakKeyMaterial(t *testing.T) {
+	zeroKey := make([]byte, 48)
+
+	t.Run("logged by default", func(t *testing.T) {
+		var logs []string
+		logger := funcr.New(func(prefix, args string) {
+			logs = append(logs, args)
+		}, funcr.Options{})
+		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithLogger(logger))
+
+		require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": zeroKey}, "1000"))
+		require.Len(t, logs, 1)
+		assert.Contains(t, logs[0], "all zero bytes")
+		assert.Contains(t, logs[0], `"kid"="1000"`)
+
+		// A kid already loaded is not reported again
+		require.NoError(t, signer.UpdateKeys(map[string][]byte{
+			"1000": zeroKey,
+			"2000": randomKey(t, 48),
+		}, "2000"))
+		assert.Len(t, logs, 1)
+	})
+
+	t.Run("rejected when configured", func(t *testing.T) {
+		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithRejectWeakKeys(true))
+
+		err := signer.UpdateKeys(map[string][]byte{"1000": zeroKey}, "1000")
+		assert.ErrorIs(t, err, ErrWeakKeyMaterial)
+		assert.False(t, signer.HasUsableSigningKey())
+
+		require.NoError(t, signer.UpdateKeys(map[string][]byte{"2000": randomKey(t, 48)}, "2000"))
+		assert.True(t, signer.HasUsableSigningKey())
+	})
+}
+
+func TestStandardSigner_RetrieveInitialSecret_RejectsWeakKey(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jwt-secret",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": make([]byte, 48),
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	signer := NewStandardSigner("issuer", "audience", time.Hour, 0, WithRejectWeakKeys(true))
+	err := signer.RetrieveInitialSecret(context.Background(), fakeClient, "jwt-secret", "default")
+	assert.ErrorIs(t, err, ErrWeakKeyMaterial)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// KeyRetention describes how long the rotator keeps a signing key in the secret. A key
+// is pruned once NumberOfKeys newer keys exist, about RotationInterval*NumberOfKeys
+// after it was created. Tokens signed with it stop validating from then on.
+type KeyRetention struct {
+	RotationInterval time.Duration
+	NumberOfKeys     int
+}
+
+// Duration returns how long after its creation a key is expected to stay in the secret
+func (r KeyRetention) Duration() time.Duration {
+	return r.RotationInterval * time.Duration(r.NumberOfKeys)
+}
+
+// retentionWarner flags tokens that would outlive the key signing them. The metric
+// counts every such token; the warning is logged once per key to avoid flooding logs.
+type retentionWarner struct {
+	retention KeyRetention
+	mu        sync.Mutex
+	warned    map[string]bool // kids already warned about
+}
+
+func newRetentionWarner(retention KeyRetention) *retentionWarner {
+	return &retentionWarner{retention: retention, warned: make(map[string]bool)}
+}
+
+// check warns when a token expiring at expiresAt outlives the expected retention of kid.
+// Kids are the key's creation time in Unix seconds; other kids are not checked.
+func (w *retentionWarner) check(logger logr.Logger, kid string, expiresAt time.Time) {
+	if w == nil || w.retention.Duration() <= 0 {
+		return
+	}
+	created, err := strconv.ParseInt(kid, 10, 64)
+	if err != nil {
+		return
+	}
+	prunedAt := time.Unix(created, 0).Add(w.retention.Duration())
+	if !expiresAt.After(prunedAt) {
+		return
+	}
+
+	tokensOutlivingKeyTotal.Inc()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.warned[kid] {
+		return
+	}
+	w.warned[kid] = true
+	logger.Error(fmt.Errorf("token expiration %s is after expected key pruning at %s",
+		expiresAt.UTC().Format(time.RFC3339), prunedAt.UTC().Format(time.RFC3339)),
+		"WARNING: issued token outlives the retention of its signing key; it will fail validation once the key is pruned",
+		"kid", kid,
+		"rotationInterval", w.retention.RotationInterval.String(),
+		"numberOfKeys", w.retention.NumberOfKeys)
+}
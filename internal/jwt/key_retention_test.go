@@ -0,0 +1,99 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const keyRetentionTestKey = "test-signing-key-48-bytes-or-more-for-hs384-signing-long"
+
+func TestStandardSigner_KeyRetention(t *testing.T) {
+	now := time.Now()
+	kid := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name       string
+		expiration time.Duration
+		retention  KeyRetention
+		expectWarn bool
+	}{
+		{
+			name:       "long-lived token with short retention",
+			expiration: 24 * time.Hour,
+			retention:  KeyRetention{RotationInterval: time.Hour, NumberOfKeys: 3},
+			expectWarn: true,
+		},
+		{
+			name:       "token expiring before the key is pruned",
+			expiration: time.Hour,
+			retention:  KeyRetention{RotationInterval: 24 * time.Hour, NumberOfKeys: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs []string
+			logger := funcr.New(func(prefix, args string) {
+				logs = append(logs, args)
+			}, funcr.Options{})
+
+			signer := NewStandardSigner("test-issuer", "test-audience", tt.expiration, 0,
+				WithClock(func() time.Time { return now }),
+				WithLogger(logger),
+				WithKeyRetention(tt.retention))
+			require.NoError(t, signer.UpdateKeys(map[string][]byte{kid: []byte(keyRetentionTestKey)}, kid))
+
+			before := testutil.ToFloat64(tokensOutlivingKeyTotal)
+			for range 2 {
+				_, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+				require.NoError(t, err)
+			}
+
+			if tt.expectWarn {
+				assert.Equal(t, 2.0, testutil.ToFloat64(tokensOutlivingKeyTotal)-before)
+				require.Len(t, logs, 1, "warning should be logged once per key")
+				assert.Contains(t, logs[0], "outlives the retention of its signing key")
+				assert.Contains(t, logs[0], kid)
+			} else {
+				assert.Equal(t, 0.0, testutil.ToFloat64(tokensOutlivingKeyTotal)-before)
+				assert.Empty(t, logs)
+			}
+		})
+	}
+}
+
+func TestAsymmetricSigner_KeyRetention(t *testing.T) {
+	now := time.Now()
+	kid := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+
+	var logs []string
+	logger := funcr.New(func(prefix, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{})
+
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "test-issuer", "test-audience", 24*time.Hour, 0,
+		WithAsymmetricClock(func() time.Time { return now }),
+		WithAsymmetricLogger(logger),
+		WithAsymmetricKeyRetention(KeyRetention{RotationInterval: time.Hour, NumberOfKeys: 2}))
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{kid: generateECKey(t)}, kid))
+
+	before := testutil.ToFloat64(tokensOutlivingKeyTotal)
+	_, err = signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(tokensOutlivingKeyTotal)-before)
+	assert.Len(t, logs, 1)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"sync"
+	"sync/atomic"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+)
+
+// KeyUsage counts the tokens signed and validated with one key since it was loaded
+type KeyUsage struct {
+	Signed              uint64
+	ValidationSuccesses uint64
+	ValidationFailures  uint64
+}
+
+// KeyUsageReporter is implemented by signers that count per-kid usage, so operators
+// can tell when a key no longer validates any tokens and is safe to prune
+type KeyUsageReporter interface {
+	SnapshotProvider
+	// KeyUsage returns the counts of each loaded kid
+	KeyUsage() map[string]KeyUsage
+}
+
+// keyUsageCounter tracks KeyUsage per kid. The zero value is ready to use. Counts are
+// atomic, so signing and validation only take the read lock once a kid has been seen.
+type keyUsageCounter struct {
+	mu     sync.RWMutex
+	counts map[string]*kidUsage
+}
+
+// kidUsage holds the counts of one kid
+type kidUsage struct {
+	signed              atomic.Uint64
+	validationSuccesses atomic.Uint64
+	validationFailures  atomic.Uint64
+}
+
+func (u *kidUsage) snapshot() KeyUsage {
+	return KeyUsage{
+		Signed:              u.signed.Load(),
+		ValidationSuccesses: u.validationSuccesses.Load(),
+		ValidationFailures:  u.validationFailures.Load(),
+	}
+}
+
+// entry returns the counts for kid, creating them if needed
+func (c *keyUsageCounter) entry(kid string) *kidUsage {
+	c.mu.RLock()
+	usage, ok := c.counts[kid]
+	c.mu.RUnlock()
+	if ok {
+		return usage
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if usage, ok := c.counts[kid]; ok {
+		return usage
+	}
+	if c.counts == nil {
+		c.counts = make(map[string]*kidUsage)
+	}
+	usage = &kidUsage{}
+	c.counts[kid] = usage
+	return usage
+}
+
+func (c *keyUsageCounter) recordSign(kid string) {
+	c.entry(kid).signed.Add(1)
+}
+
+func (c *keyUsageCounter) recordValidation(kid string, err error) {
+	if err != nil {
+		c.entry(kid).validationFailures.Add(1)
+	} else {
+		c.entry(kid).validationSuccesses.Add(1)
+	}
+}
+
+// report returns a copy of the counts for kids, with zero counts for unused kids
+func (c *keyUsageCounter) report(kids []string) map[string]KeyUsage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	report := make(map[string]KeyUsage, len(kids))
+	for _, kid := range kids {
+		if usage, ok := c.counts[kid]; ok {
+			report[kid] = usage.snapshot()
+		} else {
+			report[kid] = KeyUsage{}
+		}
+	}
+	return report
+}
+
+// prune drops the counts of kids that are no longer loaded
+func (c *keyUsageCounter) prune(loaded func(kid string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for kid := range c.counts {
+		if !loaded(kid) {
+			delete(c.counts, kid)
+		}
+	}
+}
+
+// tokenKid returns the kid header of a token without verifying it, so failed
+// validations can be attributed to a key
+func tokenKid(tokenString string) string {
+	token, _, err := jwt5.NewParser().ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		return ""
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
@@ -0,0 +1,59 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Values of the result label of jwt_key_validations_total
+const (
+	keyValidationSuccess = "success"
+	keyValidationFailure = "failure"
+)
+
+var (
+	keyTokensSignedDesc = prometheus.NewDesc(
+		"jwt_key_tokens_signed_total",
+		"Number of tokens signed with each loaded key since this replica loaded it",
+		[]string{"kid"}, nil,
+	)
+	keyValidationsDesc = prometheus.NewDesc(
+		"jwt_key_validations_total",
+		"Number of token validations against each loaded key since this replica loaded it, by result",
+		[]string{"kid", "result"}, nil,
+	)
+)
+
+// KeyUsageCollector exports a signer's per-kid sign and validation counts, so an alert
+// can catch a replica still signing with a kid that rotation should have retired.
+// Series disappear once their key is pruned from the signer.
+type KeyUsageCollector struct {
+	reporter KeyUsageReporter
+}
+
+// NewKeyUsageCollector creates a collector reporting the key usage of reporter
+func NewKeyUsageCollector(reporter KeyUsageReporter) *KeyUsageCollector {
+	return &KeyUsageCollector{reporter: reporter}
+}
+
+// Describe implements prometheus.Collector
+func (c *KeyUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- keyTokensSignedDesc
+	ch <- keyValidationsDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *KeyUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	for kid, usage := range c.reporter.KeyUsage() {
+		ch <- prometheus.MustNewConstMetric(keyTokensSignedDesc, prometheus.CounterValue,
+			float64(usage.Signed), kid)
+		ch <- prometheus.MustNewConstMetric(keyValidationsDesc, prometheus.CounterValue,
+			float64(usage.ValidationSuccesses), kid, keyValidationSuccess)
+		ch <- prometheus.MustNewConstMetric(keyValidationsDesc, prometheus.CounterValue,
+			float64(usage.ValidationFailures), kid, keyValidationFailure)
+	}
+}
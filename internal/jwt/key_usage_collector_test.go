@@ -0,0 +1,74 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyUsageCollector(t *testing.T) {
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	key := func(c byte) []byte { return []byte(strings.Repeat(string(c), 48)) }
+
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key('a')}, "1000"))
+	oldToken, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key('a'), "2000": key('b')}, "2000"))
+	for range 2 {
+		_, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+		require.NoError(t, err)
+	}
+	_, err = signer.ValidateToken(oldToken)
+	require.NoError(t, err)
+	_, err = signer.ValidateToken(oldToken[:len(oldToken)-2] + "xx")
+	require.Error(t, err)
+
+	expected := `
+# HELP jwt_key_tokens_signed_total Number of tokens signed with each loaded key since this replica loaded it
+# TYPE jwt_key_tokens_signed_total counter
+jwt_key_tokens_signed_total{kid="1000"} 1
+jwt_key_tokens_signed_total{kid="2000"} 2
+# HELP jwt_key_validations_total Number of token validations against each loaded key since this replica loaded it, by result
+# TYPE jwt_key_validations_total counter
+jwt_key_validations_total{kid="1000",result="failure"} 1
+jwt_key_validations_total{kid="1000",result="success"} 1
+jwt_key_validations_total{kid="2000",result="failure"} 0
+jwt_key_validations_total{kid="2000",result="success"} 0
+`
+	require.NoError(t, testutil.CollectAndCompare(NewKeyUsageCollector(signer), strings.NewReader(expected)))
+
+	// Pruning a key drops its series on the next scrape
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"2000": key('b')}, "2000"))
+	assert.Equal(t, 2, testutil.CollectAndCount(NewKeyUsageCollector(signer), "jwt_key_validations_total"))
+}
+
+func TestKeyUsageCounter_Concurrent(t *testing.T) {
+	var counter keyUsageCounter
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				counter.recordSign("1000")
+				counter.recordValidation("1000", nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	usage := counter.report([]string{"1000"})["1000"]
+	assert.Equal(t, uint64(8000), usage.Signed)
+	assert.Equal(t, uint64(8000), usage.ValidationSuccesses)
+}
@@ -0,0 +1,161 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	jwt5 "github.com/golang-jwt/jwt/v5"
+)
+
+// KeyFunc returns the HMAC key for the kid header of a token being validated.
+// Returning an error rejects the token.
+type KeyFunc func(kid string) ([]byte, error)
+
+// KeyFuncValidationOptions configures ValidateTokenWithKeyfunc
+type KeyFuncValidationOptions struct {
+	// Issuer and Audience are required to match the token's iss and aud claims
+	Issuer   string
+	Audience string
+	// Algorithm is the only HMAC algorithm accepted; defaults to DefaultHMACAlgorithm
+	Algorithm string
+	// RequireTypHeader rejects tokens without a typ header
+	RequireTypHeader bool
+	// Now is the time source for expiry checks; defaults to time.Now
+	Now func() time.Time
+	// Leeway is the clock skew tolerated on exp and nbf; defaults to DefaultLeeway
+	Leeway time.Duration
+}
+
+// ValidateTokenWithKeyfunc validates a token with the same claim, algorithm and typ
+// checks as StandardSigner, looking up the key with keyFunc instead of a signing
+// secret. It lets embedders with their own key storage reuse our validation semantics,
+// and returns the same error types.
+func ValidateTokenWithKeyfunc(tokenString string, keyFunc KeyFunc, opts KeyFuncValidationOptions) (*Claims, error) {
+	if keyFunc == nil {
+		return nil, errors.New("key function is required")
+	}
+	algorithm := opts.Algorithm
+	if algorithm == "" {
+		algorithm = DefaultHMACAlgorithm
+	}
+	if err := ValidateHMACAlgorithm(algorithm); err != nil {
+		return nil, err
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	leeway := opts.Leeway
+	if leeway == 0 {
+		leeway = DefaultLeeway
+	}
+
+	return parseHMACToken(tokenString, algorithm, keyFunc, opts.RequireTypHeader, now, leeway,
+		issuerAudienceOptions(opts.Issuer, opts.Audience, false), logr.Discard())
+}
+
+// mapParseError maps a parser error to this package's errors. Other failures are
+// wrapped in ErrInvalidToken with their cause, so a missing or unknown kid can still
+// be told apart with errors.Is.
+func mapParseError(err error) error {
+	switch {
+	case errors.Is(err, jwt5.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt5.ErrTokenSignatureInvalid):
+		return ErrInvalidSignature
+	case errors.Is(err, jwt5.ErrTokenInvalidIssuer):
+		return fmt.Errorf("%w: %w", ErrInvalidToken, ErrWrongIssuer)
+	case errors.Is(err, jwt5.ErrTokenInvalidAudience):
+		return fmt.Errorf("%w: %w", ErrInvalidToken, ErrWrongAudience)
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+}
+
+// parseHMACToken parses and verifies an HMAC-signed token, enforcing algorithm, kid
+// and typ header, and maps parser failures to this package's errors. Callers layer
+// issuer migration and revocation checks on top.
+func parseHMACToken(
+	tokenString string,
+	algorithm string,
+	keyFunc KeyFunc,
+	requireTyp bool,
+	now func() time.Time,
+	leeway time.Duration,
+	extraOpts []jwt5.ParserOption,
+	logger logr.Logger,
+) (*Claims, error) {
+	parserOpts := append([]jwt5.ParserOption{
+		jwt5.WithValidMethods([]string{algorithm}),
+		jwt5.WithLeeway(leeway),
+		jwt5.WithTimeFunc(now),
+	}, extraOpts...)
+
+	token, err := jwt5.ParseWithClaims(
+		tokenString,
+		&Claims{},
+		func(t *jwt5.Token) (any, error) {
+			// Verify algorithm is HMAC
+			if _, ok := t.Method.(*jwt5.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("%w: unexpected signing method %v", ErrAlgorithmNotAllowed, t.Header["alg"])
+			}
+
+			// Enforce the configured algorithm only
+			if t.Method.Alg() != algorithm {
+				return nil, fmt.Errorf("%w: %v, expected %s", ErrAlgorithmNotAllowed, t.Method.Alg(), algorithm)
+			}
+
+			// Extract and validate kid from header
+			kid, ok := t.Header["kid"].(string)
+			if !ok || kid == "" {
+				return nil, ErrMissingKid
+			}
+
+			key, err := keyFunc(kid)
+			if err != nil {
+				return nil, err
+			}
+			if key == nil {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownKid, kid)
+			}
+
+			return key, nil
+		},
+		parserOpts...,
+	)
+
+	if err != nil {
+		if alg, ok := disallowedAlgorithm(token, algorithm); ok {
+			logger.Info("Security audit: rejected token with disallowed signing algorithm",
+				"event", "jwt_algorithm_not_allowed",
+				"presentedAlg", alg,
+				"expectedAlg", algorithm,
+				"kid", token.Header["kid"])
+			return nil, fmt.Errorf("%w: %q", ErrAlgorithmNotAllowed, alg)
+		}
+		return nil, mapParseError(err)
+	}
+
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if err := checkTypHeader(token, requireTyp); err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return nil, ErrInvalidClaims
+	}
+	claims.KeyID, _ = token.Header["kid"].(string)
+
+	return claims, nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const keyfuncTestKey = "test-signing-key-48-bytes-or-more-for-hs384-signing-long"
+
+// externalKeys is a key store owned by the embedder rather than a signing secret
+func externalKeys(keys map[string][]byte) KeyFunc {
+	return func(kid string) ([]byte, error) {
+		key, ok := keys[kid]
+		if !ok {
+			return nil, errors.New("key not in external store")
+		}
+		return key, nil
+	}
+}
+
+func keyfuncTestOptions() KeyFuncValidationOptions {
+	return KeyFuncValidationOptions{Issuer: "test-issuer", Audience: "test-audience"}
+}
+
+func TestValidateTokenWithKeyfunc_ValidToken(t *testing.T) {
+	signer := createTestSigner(keyfuncTestKey, "test-issuer", "test-audience", time.Hour)
+	token, err := signer.GenerateToken(testUser, []string{"group1"}, "uid123", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	var requestedKid string
+	keyFunc := func(kid string) ([]byte, error) {
+		requestedKid = kid
+		return []byte(keyfuncTestKey), nil
+	}
+
+	claims, err := ValidateTokenWithKeyfunc(token, keyFunc, keyfuncTestOptions())
+	require.NoError(t, err)
+	assert.Equal(t, testUser, claims.User)
+	assert.Equal(t, "1234567890", requestedKid)
+	assert.Equal(t, "1234567890", claims.KeyID)
+}
+
+func TestValidateTokenWithKeyfunc_EnforcesHS384(t *testing.T) {
+	token := jwt5.NewWithClaims(jwt5.SigningMethodHS256, &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			Issuer:    "test-issuer",
+			Audience:  jwt5.ClaimStrings{"test-audience"},
+			ExpiresAt: jwt5.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		User: testUser,
+	})
+	token.Header["kid"] = "1000"
+	tokenString, err := token.SignedString([]byte(keyfuncTestKey))
+	require.NoError(t, err)
+
+	_, err = ValidateTokenWithKeyfunc(tokenString, externalKeys(map[string][]byte{"1000": []byte(keyfuncTestKey)}),
+		keyfuncTestOptions())
+	assert.ErrorIs(t, err, ErrAlgorithmNotAllowed)
+}
+
+func TestValidateTokenWithKeyfunc_ErrorTypes(t *testing.T) {
+	now := time.Now()
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+		WithClock(func() time.Time { return now }))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(keyfuncTestKey)}, "1000"))
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	t.Run("wrong key", func(t *testing.T) {
+		keys := externalKeys(map[string][]byte{"1000": []byte(keyfuncTestKey + "-other")})
+		_, err := ValidateTokenWithKeyfunc(token, keys, keyfuncTestOptions())
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("key lookup fails", func(t *testing.T) {
+		_, err := ValidateTokenWithKeyfunc(token, externalKeys(nil), keyfuncTestOptions())
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("unknown kid", func(t *testing.T) {
+		keys := func(string) ([]byte, error) { return nil, nil }
+		_, err := ValidateTokenWithKeyfunc(token, keys, keyfuncTestOptions())
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.ErrorIs(t, err, ErrUnknownKid)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		opts := keyfuncTestOptions()
+		opts.Now = func() time.Time { return now.Add(2 * time.Hour) }
+		_, err := ValidateTokenWithKeyfunc(token, externalKeys(map[string][]byte{"1000": []byte(keyfuncTestKey)}), opts)
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		opts := keyfuncTestOptions()
+		opts.Issuer = "other-issuer"
+		_, err := ValidateTokenWithKeyfunc(token, externalKeys(map[string][]byte{"1000": []byte(keyfuncTestKey)}), opts)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.ErrorIs(t, err, ErrWrongIssuer)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		opts := keyfuncTestOptions()
+		opts.Audience = "other-audience"
+		_, err := ValidateTokenWithKeyfunc(token, externalKeys(map[string][]byte{"1000": []byte(keyfuncTestKey)}), opts)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.ErrorIs(t, err, ErrWrongAudience)
+	})
+}
+
+func TestValidateTokenWithKeyfunc_InvalidOptions(t *testing.T) {
+	_, err := ValidateTokenWithKeyfunc("token", nil, keyfuncTestOptions())
+	assert.Error(t, err)
+
+	opts := keyfuncTestOptions()
+	opts.Algorithm = AlgorithmRS256
+	_, err = ValidateTokenWithKeyfunc("token", externalKeys(nil), opts)
+	assert.Error(t, err)
+}
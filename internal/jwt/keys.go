@@ -6,7 +6,13 @@ Distributed under the terms of the MIT license
 package jwt
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
 	"fmt"
 	"strconv"
 	"strings"
@@ -17,23 +23,96 @@ import (
 const (
 	// KeyPrefix is the prefix for JWT signing keys in the secret
 	KeyPrefix = "jwt-signing-key-"
-	// KeySizeBytes is the size of generated signing keys in bytes (384 bits)
-	// Must be at least 48 bytes for HS384 per RFC 7518 Section 3.2
-	KeySizeBytes = 48
+	// KeySizeBytes is the size of generated signing keys in bytes (512 bits)
+	// Large enough for every supported HMAC algorithm up to HS512 per RFC 7518 Section 3.2
+	KeySizeBytes = 64
+	// MinSigningKeyBytes is the shortest key loaded from the secret: the RFC 7518 minimum
+	// for HS256, the weakest supported algorithm. Keys at least this long but below the
+	// configured algorithm's minimum are still loaded, for validation only.
+	MinSigningKeyBytes = 32
 )
 
+// Encodings of HMAC signing key values in the secret
+const (
+	// KeyEncodingRaw uses the secret value as the key bytes
+	KeyEncodingRaw = "raw"
+	// KeyEncodingBase64 decodes the secret value as standard base64, with padding
+	KeyEncodingBase64 = "base64"
+	// KeyEncodingHex decodes the secret value as hexadecimal
+	KeyEncodingHex = "hex"
+)
+
+// ValidateKeyEncoding returns an error unless encoding is KeyEncodingRaw, KeyEncodingBase64 or KeyEncodingHex
+func ValidateKeyEncoding(encoding string) error {
+	switch encoding {
+	case KeyEncodingRaw, KeyEncodingBase64, KeyEncodingHex:
+		return nil
+	}
+	return fmt.Errorf("unsupported key encoding %q, expected %s, %s or %s",
+		encoding, KeyEncodingRaw, KeyEncodingBase64, KeyEncodingHex)
+}
+
+// DecodeKeyValue returns the key bytes held in a secret value stored with encoding.
+// Surrounding whitespace, such as a trailing newline, is ignored for base64 and hex.
+func DecodeKeyValue(value []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case KeyEncodingRaw, "":
+		return value, nil
+	case KeyEncodingBase64:
+		value = bytes.TrimSpace(value)
+		key := make([]byte, base64.StdEncoding.DecodedLen(len(value)))
+		n, err := base64.StdEncoding.Decode(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64: %w", err)
+		}
+		return key[:n], nil
+	case KeyEncodingHex:
+		value = bytes.TrimSpace(value)
+		key := make([]byte, hex.DecodedLen(len(value)))
+		if _, err := hex.Decode(key, value); err != nil {
+			return nil, fmt.Errorf("invalid hex: %w", err)
+		}
+		return key, nil
+	}
+	return nil, ValidateKeyEncoding(encoding)
+}
+
+// EncodeKeyValue returns the secret value that holds key when stored with encoding,
+// the inverse of DecodeKeyValue
+func EncodeKeyValue(key []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case KeyEncodingRaw, "":
+		return key, nil
+	case KeyEncodingBase64:
+		return []byte(base64.StdEncoding.EncodeToString(key)), nil
+	case KeyEncodingHex:
+		return []byte(hex.EncodeToString(key)), nil
+	}
+	return nil, ValidateKeyEncoding(encoding)
+}
+
 // BuildKeyName creates a key name with the given timestamp
 func BuildKeyName(timestamp int64) string {
-	return fmt.Sprintf("%s%d", KeyPrefix, timestamp)
+	return BuildKeyNameWithPrefix(KeyPrefix, timestamp)
+}
+
+// BuildKeyNameWithPrefix creates a key name with the given prefix and timestamp
+func BuildKeyNameWithPrefix(prefix string, timestamp int64) string {
+	return fmt.Sprintf("%s%d", prefix, timestamp)
 }
 
 // ParseKeyTimestamp extracts the timestamp from a key name
 func ParseKeyTimestamp(keyName string) (int64, error) {
-	if !strings.HasPrefix(keyName, KeyPrefix) {
-		return 0, fmt.Errorf("key name %s does not have prefix %s", keyName, KeyPrefix)
+	return ParseKeyTimestampWithPrefix(keyName, KeyPrefix)
+}
+
+// ParseKeyTimestampWithPrefix extracts the timestamp from a key name with the given prefix
+func ParseKeyTimestampWithPrefix(keyName string, prefix string) (int64, error) {
+	timestampStr, found := strings.CutPrefix(keyName, prefix)
+	if !found {
+		return 0, fmt.Errorf("key name %s does not have prefix %s", keyName, prefix)
 	}
 
-	timestampStr := strings.TrimPrefix(keyName, KeyPrefix)
 	timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse timestamp from %s: %w", keyName, err)
@@ -42,51 +121,188 @@ func ParseKeyTimestamp(keyName string) (int64, error) {
 	return timestamp, nil
 }
 
-// ParseSigningKeysFromSecret extracts all JWT signing keys from a secret
+// MatchKeyPrefix returns the longest of prefixes that keyName starts with, so a key
+// under "jwt-signing-key-v2-" is not mistaken for one under "jwt-signing-key-".
+// KeyPrefix is used when prefixes is empty.
+func MatchKeyPrefix(keyName string, prefixes ...string) (string, bool) {
+	if len(prefixes) == 0 {
+		prefixes = []string{KeyPrefix}
+	}
+	var match string
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(keyName, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	return match, match != ""
+}
+
+// KidIsNewer reports whether kid a is newer than kid b. Kids are Unix timestamps and are
+// compared numerically so differing digit counts order correctly. Kids with the same
+// timestamp (e.g. "1000" and "01000") and kids that are not integers fall back to
+// lexicographic order, so every replica picks the same latest kid.
+func KidIsNewer(a, b string) bool {
+	aTs, aErr := strconv.ParseInt(a, 10, 64)
+	bTs, bErr := strconv.ParseInt(b, 10, 64)
+	if aErr != nil || bErr != nil || aTs == bTs {
+		return a > b
+	}
+	return aTs > bTs
+}
+
+// CheckSecretNamespace verifies that a fetched secret lives in the requested namespace,
+// guarding against a misconfigured client silently returning a same-named secret from elsewhere
+func CheckSecretNamespace(secret *corev1.Secret, namespace string) error {
+	if secret.Namespace != namespace {
+		return fmt.Errorf("%w: secret %s is in namespace %q, expected %q",
+			ErrSecretNamespaceMismatch, secret.Name, secret.Namespace, namespace)
+	}
+	return nil
+}
+
+// ParseSigningKeysFromSecret extracts all HMAC signing keys from a secret
+// Keys shorter than MinSigningKeyBytes are rejected, since tokens they validate could be forged
+// Keys are read under each of prefixes, or KeyPrefix when none are given
 // Returns a map of kid->key, the latest kid, and any error
-func ParseSigningKeysFromSecret(secret *corev1.Secret) (map[string][]byte, string, error) {
+func ParseSigningKeysFromSecret(secret *corev1.Secret, prefixes ...string) (map[string][]byte, string, error) {
+	return ParseEncodedSigningKeysFromSecret(secret, KeyEncodingRaw, prefixes...)
+}
+
+// ParseEncodedSigningKeysFromSecret is ParseSigningKeysFromSecret for secret values
+// stored with encoding, one of the KeyEncoding constants. Values are decoded before the
+// length check, so MinSigningKeyBytes applies to the decoded key.
+func ParseEncodedSigningKeysFromSecret(secret *corev1.Secret, encoding string, prefixes ...string) (map[string][]byte, string, error) {
+	if err := ValidateKeyEncoding(encoding); err != nil {
+		return nil, "", err
+	}
+	entries, latestKid, err := parseKeyEntries(secret, prefixes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	signingKeys := make(map[string][]byte, len(entries))
+	for kid, entry := range entries {
+		key, err := DecodeKeyValue(entry.value, encoding)
+		if err != nil {
+			return nil, "", fmt.Errorf("signing key %s is not valid %s: %w", entry.name, encoding, err)
+		}
+		if len(key) < MinSigningKeyBytes {
+			return nil, "", fmt.Errorf("signing key %s is %d bytes, at least %d required",
+				entry.name, len(key), MinSigningKeyBytes)
+		}
+		signingKeys[kid] = key
+	}
+
+	return signingKeys, latestKid, nil
+}
+
+// secretKeyEntry is a signing key entry read from a secret
+type secretKeyEntry struct {
+	name  string
+	value []byte
+}
+
+// parseKeyEntries extracts the raw value of every signing key entry in a secret.
+// The kid is the name without its prefix, so the same kid under two prefixes must hold the same value.
+// Returns a map of kid->entry, the latest kid, and any error
+func parseKeyEntries(secret *corev1.Secret, prefixes []string) (map[string]secretKeyEntry, string, error) {
 	if secret.Data == nil {
 		return nil, "", fmt.Errorf("secret has no data")
 	}
 
-	signingKeys := make(map[string][]byte)
-	var latestTimestamp int64
+	entries := make(map[string]secretKeyEntry)
 	var latestKid string
 
 	for name, value := range secret.Data {
-		if !strings.HasPrefix(name, KeyPrefix) {
+		prefix, ok := MatchKeyPrefix(name, prefixes...)
+		if !ok {
 			continue
 		}
 
-		timestamp, err := ParseKeyTimestamp(name)
-		if err != nil {
+		if _, err := ParseKeyTimestampWithPrefix(name, prefix); err != nil {
 			return nil, "", fmt.Errorf("invalid key format %s: %w", name, err)
 		}
 
-		kid := strings.TrimPrefix(name, KeyPrefix)
-		signingKeys[kid] = value
+		kid := strings.TrimPrefix(name, prefix)
+		if existing, exists := entries[kid]; exists && !bytes.Equal(existing.value, value) {
+			return nil, "", fmt.Errorf("keys %s and %s share kid %s but differ", existing.name, name, kid)
+		}
+		entries[kid] = secretKeyEntry{name: name, value: value}
 
-		if timestamp > latestTimestamp {
-			latestTimestamp = timestamp
+		// Break timestamp ties by kid so the result does not depend on map iteration order
+		if latestKid == "" || KidIsNewer(kid, latestKid) {
 			latestKid = kid
 		}
 	}
 
-	if len(signingKeys) == 0 {
+	if len(entries) == 0 {
 		return nil, "", fmt.Errorf("no signing keys found in secret")
 	}
 
-	return signingKeys, latestKid, nil
+	return entries, latestKid, nil
 }
 
-// FormatKeyForDisplay formats a key value for safe display (base64 encoded, truncated)
+// ParsePrivateKeysFromSecret extracts PEM-encoded asymmetric signing keys from a secret.
+// Keys use the same naming as HMAC keys; each value holds a PKCS#8, PKCS#1 or SEC 1 private key.
+// Keys are read under each of prefixes, or KeyPrefix when none are given
+// Returns a map of kid->key, the latest kid, and any error
+func ParsePrivateKeysFromSecret(secret *corev1.Secret, prefixes ...string) (map[string]crypto.Signer, string, error) {
+	entries, latestKid, err := parseKeyEntries(secret, prefixes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	privateKeys := make(map[string]crypto.Signer, len(entries))
+	for kid, entry := range entries {
+		key, err := parsePrivateKeyPEM(entry.value)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid private key %s: %w", entry.name, err)
+		}
+		privateKeys[kid] = key
+	}
+
+	return privateKeys, latestKid, nil
+}
+
+// parsePrivateKeyPEM decodes a single PEM block holding an RSA or ECDSA private key
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+// KeyFingerprint returns a short SHA-256 fingerprint of key. It reveals none of the
+// key bytes, so it is safe to serve from debug endpoints.
+func KeyFingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// FormatKeyForDisplay formats a key value for safe logging as its KeyFingerprint and
+// its length, e.g. "sha256:1a2b3c4d5e6f7a8b len=48", revealing no key bytes
 func FormatKeyForDisplay(key []byte) string {
 	if len(key) == 0 {
 		return "<empty>"
 	}
-	encoded := base64.StdEncoding.EncodeToString(key)
-	if len(encoded) > 16 {
-		return encoded[:16] + "..."
-	}
-	return encoded
+	return fmt.Sprintf("%s len=%d", KeyFingerprint(key), len(key))
 }
@@ -6,6 +6,10 @@ Distributed under the terms of the MIT license
 package jwt
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -80,6 +84,7 @@ func TestParseKeyTimestamp(t *testing.T) {
 }
 
 func TestParseSigningKeysFromSecret(t *testing.T) {
+	validKey := []byte(strings.Repeat("k", KeySizeBytes))
 	tests := []struct {
 		name          string
 		secretData    map[string][]byte
@@ -91,7 +96,7 @@ func TestParseSigningKeysFromSecret(t *testing.T) {
 		{
 			name: "single key",
 			secretData: map[string][]byte{
-				"jwt-signing-key-1000": []byte("key1"),
+				"jwt-signing-key-1000": validKey,
 			},
 			expectedKeys: 1,
 			expectedKid:  "1000",
@@ -100,9 +105,9 @@ func TestParseSigningKeysFromSecret(t *testing.T) {
 		{
 			name: "multiple keys",
 			secretData: map[string][]byte{
-				"jwt-signing-key-1000": []byte("key1"),
-				"jwt-signing-key-2000": []byte("key2"),
-				"jwt-signing-key-3000": []byte("key3"),
+				"jwt-signing-key-1000": validKey,
+				"jwt-signing-key-2000": validKey,
+				"jwt-signing-key-3000": validKey,
 			},
 			expectedKeys: 3,
 			expectedKid:  "3000",
@@ -111,14 +116,31 @@ func TestParseSigningKeysFromSecret(t *testing.T) {
 		{
 			name: "mixed keys and other data",
 			secretData: map[string][]byte{
-				"jwt-signing-key-1000": []byte("key1"),
-				"jwt-signing-key-2000": []byte("key2"),
+				"jwt-signing-key-1000": validKey,
+				"jwt-signing-key-2000": validKey,
 				"other-data":           []byte("ignored"),
 			},
 			expectedKeys: 2,
 			expectedKid:  "2000",
 			expectError:  false,
 		},
+		{
+			name: "key below minimum length",
+			secretData: map[string][]byte{
+				"jwt-signing-key-1000": validKey,
+				"jwt-signing-key-2000": []byte("key2"),
+			},
+			expectError:   true,
+			errorContains: "signing key jwt-signing-key-2000 is 4 bytes",
+		},
+		{
+			name: "key at minimum length",
+			secretData: map[string][]byte{
+				"jwt-signing-key-1000": validKey[:MinSigningKeyBytes],
+			},
+			expectedKeys: 1,
+			expectedKid:  "1000",
+		},
 		{
 			name:          "no data",
 			secretData:    nil,
@@ -184,6 +206,127 @@ func TestParseSigningKeysFromSecret(t *testing.T) {
 	}
 }
 
+func TestParseSigningKeysFromSecret_TiedTimestamps(t *testing.T) {
+	validKey := []byte(strings.Repeat("k", KeySizeBytes))
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			"jwt-signing-key-900":   validKey,
+			"jwt-signing-key-01000": validKey,
+			"jwt-signing-key-1000":  validKey,
+			"jwt-signing-key-+1000": validKey,
+		},
+	}
+
+	// Map iteration order varies between calls, so repeat to catch nondeterminism
+	for i := 0; i < 50; i++ {
+		_, latestKid, err := ParseSigningKeysFromSecret(secret)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if latestKid != "1000" {
+			t.Fatalf("Expected tie broken to kid '1000', got '%s'", latestKid)
+		}
+	}
+}
+
+func TestParseSigningKeysFromSecret_MixedPrefixes(t *testing.T) {
+	oldKey := []byte(strings.Repeat("o", KeySizeBytes))
+	newKey := []byte(strings.Repeat("n", KeySizeBytes))
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			"jwt-signing-key-1000":    oldKey,
+			"jwt-signing-key-v2-2000": newKey,
+		},
+	}
+
+	// The new prefix extends the old one, so it must win for keys it matches
+	keys, latestKid, err := ParseSigningKeysFromSecret(secret, "jwt-signing-key-v2-", KeyPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 2 || string(keys["1000"]) != string(oldKey) || string(keys["2000"]) != string(newKey) {
+		t.Errorf("Expected kids 1000 and 2000 with their keys, got %v", keys)
+	}
+	if latestKid != "2000" {
+		t.Errorf("Expected latest kid '2000', got '%s'", latestKid)
+	}
+
+	// Only the new prefix once the migration is over
+	keys, latestKid, err = ParseSigningKeysFromSecret(secret, "jwt-signing-key-v2-")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 1 || latestKid != "2000" {
+		t.Errorf("Expected only kid 2000, got %v (latest '%s')", keys, latestKid)
+	}
+
+	// A kid present under both prefixes must hold the same key
+	secret.Data["jwt-signing-key-v2-1000"] = newKey
+	if _, _, err := ParseSigningKeysFromSecret(secret, "jwt-signing-key-v2-", KeyPrefix); err == nil {
+		t.Error("Expected error for a kid with different keys under two prefixes")
+	}
+	secret.Data["jwt-signing-key-v2-1000"] = oldKey
+	if _, _, err := ParseSigningKeysFromSecret(secret, "jwt-signing-key-v2-", KeyPrefix); err != nil {
+		t.Errorf("Unexpected error for a kid with the same key under two prefixes: %v", err)
+	}
+}
+
+func TestParseEncodedSigningKeysFromSecret(t *testing.T) {
+	key := []byte(strings.Repeat("k", KeySizeBytes))
+	tests := []struct {
+		name     string
+		encoding string
+		value    []byte
+		wantKey  []byte
+		wantErr  string
+	}{
+		{name: "raw", encoding: KeyEncodingRaw, value: key, wantKey: key},
+		{name: "base64", encoding: KeyEncodingBase64, value: []byte(base64.StdEncoding.EncodeToString(key)), wantKey: key},
+		{name: "base64 with trailing newline", encoding: KeyEncodingBase64, value: []byte(base64.StdEncoding.EncodeToString(key) + "\n"), wantKey: key},
+		{name: "hex", encoding: KeyEncodingHex, value: []byte(hex.EncodeToString(key)), wantKey: key},
+		{name: "malformed base64", encoding: KeyEncodingBase64, value: []byte("not*base64"), wantErr: "is not valid base64"},
+		{name: "malformed hex", encoding: KeyEncodingHex, value: []byte("zz" + hex.EncodeToString(key)), wantErr: "is not valid hex"},
+		// The 32 base64 characters would pass as a raw key, but decode to only 24 bytes
+		{name: "decoded too short", encoding: KeyEncodingBase64, value: []byte(base64.StdEncoding.EncodeToString(key[:24])), wantErr: "is 24 bytes"},
+		{name: "unknown encoding", encoding: "base32", value: key, wantErr: "unsupported key encoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				Data: map[string][]byte{"jwt-signing-key-1000": tt.value},
+			}
+			keys, latestKid, err := ParseEncodedSigningKeysFromSecret(secret, tt.encoding)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if latestKid != "1000" || !bytes.Equal(keys["1000"], tt.wantKey) {
+				t.Errorf("Expected kid 1000 with the decoded key, got %v (latest '%s')", keys, latestKid)
+			}
+		})
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	key := []byte("secret-key-material-that-must-not-leak-0123456789")
+	fingerprint := KeyFingerprint(key)
+	if !strings.HasPrefix(fingerprint, "sha256:") || len(fingerprint) != len("sha256:")+16 {
+		t.Errorf("Unexpected fingerprint format %q", fingerprint)
+	}
+	if strings.Contains(fingerprint, base64.StdEncoding.EncodeToString(key)[:8]) {
+		t.Errorf("Fingerprint %q reveals key bytes", fingerprint)
+	}
+	if KeyFingerprint(key) != fingerprint || KeyFingerprint([]byte("other")) == fingerprint {
+		t.Error("Expected fingerprints to be stable and to differ between keys")
+	}
+}
+
 func TestFormatKeyForDisplay(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -198,12 +341,12 @@ func TestFormatKeyForDisplay(t *testing.T) {
 		{
 			name:     "short key",
 			key:      []byte("test"),
-			expected: "dGVzdA==",
+			expected: "sha256:9f86d081884c7d65 len=4",
 		},
 		{
-			name:     "long key truncated",
+			name:     "long key",
 			key:      []byte("this is a very long key that should be truncated"),
-			expected: "dGhpcyBpcyBhIHZl...",
+			expected: "sha256:6ec2848d8b92c61d len=48",
 		},
 	}
 
@@ -213,6 +356,10 @@ func TestFormatKeyForDisplay(t *testing.T) {
 			if result != tt.expected {
 				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
 			}
+			// Log lines must be correlatable with the key usage endpoint
+			if len(tt.key) > 0 && !strings.HasPrefix(result, KeyFingerprint(tt.key)+" ") {
+				t.Errorf("Expected '%s' to start with the key fingerprint", result)
+			}
 		})
 	}
 }
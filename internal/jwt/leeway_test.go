@@ -0,0 +1,117 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// futureToken signs a token on a replica whose clock runs ahead by skew, without
+// backdating nbf so the token is not valid yet on replicas with the correct time
+func futureToken(t *testing.T, now time.Time, skew time.Duration) string {
+	t.Helper()
+	signer := NewStandardSigner("issuer", "audience", time.Hour, 0,
+		WithClock(func() time.Time { return now.Add(skew) }),
+		WithLeeway(0))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(testMigrationKey)}, "1000"))
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	return token
+}
+
+func TestStandardSigner_Leeway(t *testing.T) {
+	now := time.Now()
+	token := futureToken(t, now, 10*time.Second)
+
+	validator := func(opts ...StandardSignerOption) *StandardSigner {
+		opts = append([]StandardSignerOption{WithClock(func() time.Time { return now })}, opts...)
+		signer := NewStandardSigner("issuer", "audience", time.Hour, 0, opts...)
+		require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(testMigrationKey)}, "1000"))
+		return signer
+	}
+
+	// The default leeway does not cover a 10s skew
+	_, err := validator().ValidateToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = validator(WithLeeway(15 * time.Second)).ValidateToken(token)
+	assert.NoError(t, err)
+
+	// A negative leeway is ignored
+	_, err = validator(WithLeeway(-time.Second)).ValidateToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestStandardSigner_BackdatesNotBefore(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	signer := NewStandardSigner("issuer", "audience", time.Hour, 0,
+		WithClock(func() time.Time { return now }),
+		WithLeeway(30*time.Second))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(testMigrationKey)}, "1000"))
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-30*time.Second), claims.NotBefore.Time)
+	assert.Equal(t, now.Add(time.Hour), claims.ExpiresAt.Time)
+
+	// A replica whose clock lags by less than the leeway accepts the token
+	// even if it validates without any leeway of its own
+	lagging := NewStandardSigner("issuer", "audience", time.Hour, 0,
+		WithClock(func() time.Time { return now.Add(-20 * time.Second) }),
+		WithLeeway(0))
+	require.NoError(t, lagging.UpdateKeys(map[string][]byte{"1000": []byte(testMigrationKey)}, "1000"))
+	_, err = lagging.ValidateToken(token)
+	assert.NoError(t, err)
+}
+
+func TestAsymmetricSigner_Leeway(t *testing.T) {
+	now := time.Now()
+	key := generateECKey(t)
+
+	issuer, err := NewAsymmetricSigner(AlgorithmES256, "issuer", "audience", time.Hour, 0,
+		WithAsymmetricClock(func() time.Time { return now.Add(10 * time.Second) }),
+		WithAsymmetricLeeway(0))
+	require.NoError(t, err)
+	require.NoError(t, issuer.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+	token, err := issuer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	strict, err := NewAsymmetricSigner(AlgorithmES256, "issuer", "audience", time.Hour, 0,
+		WithAsymmetricClock(func() time.Time { return now }))
+	require.NoError(t, err)
+	require.NoError(t, strict.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+	_, err = strict.ValidateToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	lenient, err := NewAsymmetricSigner(AlgorithmES256, "issuer", "audience", time.Hour, 0,
+		WithAsymmetricClock(func() time.Time { return now }),
+		WithAsymmetricLeeway(15*time.Second))
+	require.NoError(t, err)
+	require.NoError(t, lenient.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+	_, err = lenient.ValidateToken(token)
+	assert.NoError(t, err)
+}
+
+func TestValidateTokenWithKeyfunc_Leeway(t *testing.T) {
+	now := time.Now()
+	token := futureToken(t, now, 10*time.Second)
+	keys := externalKeys(map[string][]byte{"1000": []byte(testMigrationKey)})
+
+	opts := KeyFuncValidationOptions{Issuer: "issuer", Audience: "audience", Now: func() time.Time { return now }}
+	_, err := ValidateTokenWithKeyfunc(token, keys, opts)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	opts.Leeway = 15 * time.Second
+	_, err = ValidateTokenWithKeyfunc(token, keys, opts)
+	assert.NoError(t, err)
+}
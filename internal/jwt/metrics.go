@@ -0,0 +1,73 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Results of a validation cache lookup
+const (
+	validationCacheHit  = "hit"
+	validationCacheMiss = "miss"
+)
+
+var (
+	// kidBytesChangedTotal counts key updates where an already-loaded kid arrived with different bytes
+	kidBytesChangedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "jwt_kid_bytes_changed_total",
+		Help: "Number of key updates where an already-loaded kid was reloaded with different key bytes",
+	})
+
+	// staleKeyUpdatesTotal counts secret updates ignored because their rotation count was lower than the loaded one
+	staleKeyUpdatesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "jwt_stale_key_updates_total",
+		Help: "Number of secret updates ignored because their rotation count was lower than that of the loaded secret",
+	})
+
+	// tokensOutlivingKeyTotal counts tokens issued with an expiration past the expected pruning of their signing key
+	tokensOutlivingKeyTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "jwt_tokens_outliving_signing_key_total",
+		Help: "Number of tokens issued with an expiration after their signing key is expected to be pruned",
+	})
+
+	// cooloffBlockedTotal counts token generations that failed because every key was still in its cooloff
+	cooloffBlockedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "jwt_cooloff_blocked_token_generations_total",
+		Help: "Number of token generations that failed because no signing key was past the new key cooloff",
+	})
+
+	// signingKidLagTotal counts tokens signed with an older kid than the latest one past its cooloff
+	signingKidLagTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "jwt_signing_kid_lag_total",
+		Help: "Number of tokens signed with an older key than the latest loaded one after the latest key's cooloff had passed",
+	})
+
+	// newKeyUseDelaySeconds reports the configured cooloff before a new key is used for signing
+	newKeyUseDelaySeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jwt_new_key_use_delay_seconds",
+		Help: "Configured cooloff before a newly loaded key is used for signing, in seconds",
+	})
+
+	// validationCacheRequestsTotal counts validation cache lookups by result
+	validationCacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "jwt_validation_cache_requests_total",
+		Help: "Number of validation cache lookups, by result (hit or miss)",
+	}, []string{"result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		kidBytesChangedTotal,
+		staleKeyUpdatesTotal,
+		tokensOutlivingKeyTotal,
+		cooloffBlockedTotal,
+		signingKidLagTotal,
+		newKeyUseDelaySeconds,
+		validationCacheRequestsTotal,
+	)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationStore records revoked token IDs (jti) until the tokens would have expired
+type RevocationStore interface {
+	// Revoke marks jti as revoked. The entry must be kept past expiresAt for as long
+	// as validation tolerates clock skew on exp, after which the token would be
+	// rejected as expired anyway.
+	Revoke(jti string, expiresAt time.Time)
+	// IsRevoked reports whether jti has been revoked
+	IsRevoked(jti string) bool
+}
+
+// MemoryRevocationStore is an in-process RevocationStore. Entries are pruned once
+// past their expiration plus the validation leeway, so memory is bounded by the
+// tokens revoked within one token lifetime. Revocations are not shared between replicas.
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time // map[jti]time the entry may be dropped
+	leeway  time.Duration        // clock skew tolerated on exp, set via WithRevocationLeeway
+	now     func() time.Time
+}
+
+// MemoryRevocationStoreOption configures optional MemoryRevocationStore behavior
+type MemoryRevocationStoreOption func(*MemoryRevocationStore)
+
+// WithRevocationLeeway keeps entries for leeway past the token expiration, matching
+// the clock skew validation tolerates on exp. Defaults to DefaultLeeway.
+func WithRevocationLeeway(leeway time.Duration) MemoryRevocationStoreOption {
+	return func(m *MemoryRevocationStore) {
+		if leeway >= 0 {
+			m.leeway = leeway
+		}
+	}
+}
+
+// NewMemoryRevocationStore creates an empty MemoryRevocationStore
+func NewMemoryRevocationStore(opts ...MemoryRevocationStoreOption) *MemoryRevocationStore {
+	m := &MemoryRevocationStore{
+		entries: make(map[string]time.Time),
+		leeway:  DefaultLeeway,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Revoke implements RevocationStore
+func (m *MemoryRevocationStore) Revoke(jti string, expiresAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Revocations are rare, so prune on write rather than on a timer
+	now := m.now()
+	for id, exp := range m.entries {
+		if now.After(exp) {
+			delete(m.entries, id)
+		}
+	}
+
+	// A token stays valid for the leeway past its exp, so the entry must too
+	dropAt := expiresAt.Add(m.leeway)
+	if dropAt.After(now) && dropAt.After(m.entries[jti]) {
+		m.entries[jti] = dropAt
+	}
+}
+
+// IsRevoked implements RevocationStore
+func (m *MemoryRevocationStore) IsRevoked(jti string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	exp, ok := m.entries[jti]
+	if !ok {
+		return false
+	}
+	if m.now().After(exp) {
+		delete(m.entries, jti)
+		return false
+	}
+	return true
+}
+
+// Len returns the number of revocations currently held
+func (m *MemoryRevocationStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
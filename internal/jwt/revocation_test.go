@@ -0,0 +1,166 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRevocationStore_ExpiresEntries(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRevocationStore()
+	store.now = func() time.Time { return now }
+
+	store.Revoke("a", now.Add(time.Minute))
+	store.Revoke("b", now.Add(time.Hour))
+	assert.True(t, store.IsRevoked("a"))
+	assert.True(t, store.IsRevoked("b"))
+	assert.False(t, store.IsRevoked("c"))
+
+	// Past its expiration an entry is dropped on lookup
+	now = now.Add(2 * time.Minute)
+	assert.False(t, store.IsRevoked("a"))
+	assert.Equal(t, 1, store.Len())
+
+	// and expired entries are pruned whenever a new revocation is added
+	store.Revoke("c", now.Add(time.Minute))
+	now = now.Add(2 * time.Hour)
+	store.Revoke("d", now.Add(time.Minute))
+	assert.Equal(t, 1, store.Len())
+	assert.True(t, store.IsRevoked("d"))
+}
+
+func TestMemoryRevocationStore_IgnoresAlreadyExpired(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	store.Revoke("a", time.Now().Add(-time.Minute))
+	assert.Equal(t, 0, store.Len())
+	assert.False(t, store.IsRevoked("a"))
+}
+
+func TestMemoryRevocationStore_KeepsLaterExpiration(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRevocationStore()
+	store.now = func() time.Time { return now }
+
+	store.Revoke("a", now.Add(time.Hour))
+	store.Revoke("a", now.Add(time.Minute))
+
+	now = now.Add(30 * time.Minute)
+	assert.True(t, store.IsRevoked("a"))
+}
+
+func TestMemoryRevocationStore_KeepsEntriesThroughLeeway(t *testing.T) {
+	now := time.Now()
+	leeway := 10 * time.Second
+	store := NewMemoryRevocationStore(WithRevocationLeeway(leeway))
+	store.now = func() time.Time { return now }
+
+	expiresAt := now.Add(time.Minute)
+	store.Revoke("a", expiresAt)
+
+	// Validation still accepts the token within the leeway past exp
+	now = expiresAt.Add(leeway / 2)
+	assert.True(t, store.IsRevoked("a"))
+	// and revoking it then is still recorded
+	store.Revoke("b", expiresAt)
+	assert.True(t, store.IsRevoked("b"))
+
+	now = expiresAt.Add(leeway + time.Second)
+	assert.False(t, store.IsRevoked("a"))
+}
+
+// TestStandardSigner_ValidateToken_RevokedWithinLeeway tests that a revoked token is
+// still rejected once past exp but inside the validation leeway
+func TestStandardSigner_ValidateToken_RevokedWithinLeeway(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	leeway := 10 * time.Second
+	store := NewMemoryRevocationStore(WithRevocationLeeway(leeway))
+	store.now = clock
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Minute, 0,
+		WithClock(clock), WithLeeway(leeway), WithRevocationStore(store))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long")}, "1000"))
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	store.Revoke(claims.ID, claims.ExpiresAt.Time)
+
+	now = claims.ExpiresAt.Add(leeway / 2)
+	_, err = signer.ValidateToken(token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestStandardSigner_GenerateToken_SetsUniqueJTI(t *testing.T) {
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
+
+	first, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+	require.NoError(t, err)
+	second, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	firstClaims, err := signer.ValidateToken(first)
+	require.NoError(t, err)
+	secondClaims, err := signer.ValidateToken(second)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, firstClaims.ID)
+	assert.NotEqual(t, firstClaims.ID, secondClaims.ID)
+}
+
+func TestStandardSigner_ValidateToken_Revoked(t *testing.T) {
+	key := "test-signing-key-48-bytes-or-more-for-hs384-signing-long"
+	store := NewMemoryRevocationStore()
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithRevocationStore(store))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(key)}, "1000"))
+
+	revoked, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+	require.NoError(t, err)
+	other, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	claims, err := signer.ValidateToken(revoked)
+	require.NoError(t, err)
+	store.Revoke(claims.ID, claims.ExpiresAt.Time)
+
+	_, err = signer.ValidateToken(revoked)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	_, err = signer.ValidateToken(other)
+	assert.NoError(t, err, "revoking one jti must not affect other tokens")
+}
+
+func TestStandardSigner_ValidateToken_NoJTIIsNotRevocable(t *testing.T) {
+	key := []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long")
+	store := NewMemoryRevocationStore()
+	store.Revoke("", time.Now().Add(time.Hour))
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithRevocationStore(store))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key}, "1000"))
+
+	// Token issued before jti was added
+	now := time.Now()
+	legacy := jwt5.NewWithClaims(jwt5.SigningMethodHS384, &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			ExpiresAt: jwt5.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt5.NewNumericDate(now),
+			Issuer:    "test-issuer",
+			Audience:  []string{"test-audience"},
+		},
+		User: testUser,
+	})
+	legacy.Header["kid"] = "1000"
+	tokenString, err := legacy.SignedString(key)
+	require.NoError(t, err)
+
+	_, err = signer.ValidateToken(tokenString)
+	assert.NoError(t, err)
+}
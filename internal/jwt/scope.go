@@ -0,0 +1,32 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import "strings"
+
+// Scopes returns the space-delimited scope claim split into individual scopes
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// MissingScopes returns the scopes in required that the token was not granted,
+// in the order they were required. An empty result means the token has them all.
+func (c *Claims) MissingScopes(required []string) []string {
+	if len(required) == 0 {
+		return nil
+	}
+	granted := make(map[string]bool)
+	for _, scope := range c.Scopes() {
+		granted[scope] = true
+	}
+	var missing []string
+	for _, scope := range required {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,80 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaims_MissingScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    string
+		required []string
+		missing  []string
+	}{
+		{name: "no requirement", scope: "", required: nil, missing: nil},
+		{name: "all granted", scope: "read write", required: []string{"write", "read"}, missing: nil},
+		{name: "one missing", scope: "read", required: []string{"read", "write"}, missing: []string{"write"}},
+		{name: "no scope claim", scope: "", required: []string{"read"}, missing: []string{"read"}},
+		{name: "extra whitespace", scope: " read  write ", required: []string{"write"}, missing: nil},
+		{name: "prefix is not a match", scope: "read:all", required: []string{"read"}, missing: []string{"read"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &Claims{Scope: tt.scope}
+			assert.Equal(t, tt.missing, claims.MissingScopes(tt.required))
+		})
+	}
+}
+
+func TestStandardSigner_WithScope(t *testing.T) {
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithScope("workspace:read workspace:connect"))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long")}, "1000"))
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"workspace:read", "workspace:connect"}, claims.Scopes())
+
+	// Refreshed tokens keep the original scope even if the signer's scope differs
+	claims.Scope = "workspace:read"
+	refreshed, err := signer.GenerateRefreshToken(claims)
+	require.NoError(t, err)
+	refreshedClaims, err := signer.ValidateToken(refreshed)
+	require.NoError(t, err)
+	assert.Equal(t, "workspace:read", refreshedClaims.Scope)
+}
+
+func TestStandardSigner_NoScopeByDefault(t *testing.T) {
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Empty(t, claims.Scope)
+}
+
+func TestAsymmetricSigner_WithScope(t *testing.T) {
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "test-issuer", "test-audience", time.Hour, 0,
+		WithAsymmetricScope("workspace:connect"))
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": generateECKey(t)}, "1000"))
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "workspace:connect", claims.Scope)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// secretKeySets holds the keys last read from each secret a StandardSigner loads, so a
+// change to one secret is merged with the keys of the others
+type secretKeySets struct {
+	mu   sync.Mutex
+	sets map[string]map[string][]byte // map[secretName]map[kid]key
+}
+
+// merge records keys as the content of secretName and returns the union of the keys of
+// all secrets and its latest kid. A kid held by two secrets must have the same bytes;
+// otherwise nothing is recorded and an error is returned.
+func (k *secretKeySets) merge(secretName string, keys map[string][]byte) (map[string][]byte, string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	sets := maps.Clone(k.sets)
+	if sets == nil {
+		sets = make(map[string]map[string][]byte)
+	}
+	sets[secretName] = keys
+
+	merged := make(map[string][]byte)
+	owners := make(map[string]string)
+	var latestKid string
+	for _, name := range slices.Sorted(maps.Keys(sets)) {
+		for kid, key := range sets[name] {
+			if existing, exists := merged[kid]; exists && !bytes.Equal(existing, key) {
+				return nil, "", fmt.Errorf("secrets %s and %s hold different keys for kid %s", owners[kid], name, kid)
+			}
+			merged[kid] = key
+			owners[kid] = name
+			if latestKid == "" || KidIsNewer(kid, latestKid) {
+				latestKid = kid
+			}
+		}
+	}
+
+	k.sets = sets
+	return merged, latestKid, nil
+}
+
+// mergeSigningSecret parses the keys in secret and merges them with the keys last read
+// from the signer's other secrets. Returns the merged keys and their latest kid.
+func (s *StandardSigner) mergeSigningSecret(secret *corev1.Secret) (map[string][]byte, string, error) {
+	keys, _, err := ParseEncodedSigningKeysFromSecret(secret, s.keyEncoding, s.keyPrefixes...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse signing keys from secret %s: %w", secret.Name, err)
+	}
+	return s.secretKeys.merge(secret.Name, keys)
+}
+
+// signingSecretNames returns the primary secret followed by the additional ones
+func (s *StandardSigner) signingSecretNames(secretName string) []string {
+	return append([]string{secretName}, s.additionalSecrets...)
+}
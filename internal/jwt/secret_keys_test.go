@@ -0,0 +1,115 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testKey1000 = "key1-48-bytes-or-more-for-hs384-signing-long-enough"
+	testKey2000 = "key2-48-bytes-or-more-for-hs384-signing-long-enough"
+	testKey3000 = "key3-48-bytes-or-more-for-hs384-signing-long-enough"
+)
+
+func signingSecret(name string, keys map[string]string) *corev1.Secret {
+	data := make(map[string][]byte, len(keys))
+	for kid, key := range keys {
+		data[KeyPrefix+kid] = []byte(key)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data:       data,
+	}
+}
+
+func newSecretsClient(t *testing.T, secrets ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(secrets...).Build()
+}
+
+func TestStandardSigner_RetrieveInitialSecret_AdditionalSecrets(t *testing.T) {
+	tests := []struct {
+		name       string
+		primary    map[string]string
+		additional map[string]string
+		latestKid  string
+	}{
+		{
+			name:       "newest key in primary secret",
+			primary:    map[string]string{"3000": testKey3000},
+			additional: map[string]string{"1000": testKey1000, "2000": testKey2000},
+			latestKid:  "3000",
+		},
+		{
+			name:       "newest key in additional secret",
+			primary:    map[string]string{"1000": testKey1000},
+			additional: map[string]string{"2000": testKey2000, "3000": testKey3000},
+			latestKid:  "3000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtimeClient := newSecretsClient(t,
+				signingSecret("jwt-secret", tt.primary),
+				signingSecret("jwt-archive", tt.additional))
+
+			signer := NewStandardSigner("issuer", "audience", time.Hour, 0, WithAdditionalSecrets("jwt-archive"))
+			require.NoError(t, signer.RetrieveInitialSecret(context.Background(), runtimeClient, "jwt-secret", "default"))
+
+			for _, kid := range []string{"1000", "2000", "3000"} {
+				assert.True(t, signer.IsKnownKid(kid), "kid %s", kid)
+			}
+			token, err := signer.GenerateToken("user", nil, "uid", nil, "/path", "domain", TokenTypeSession, false)
+			require.NoError(t, err)
+			claims, err := signer.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, tt.latestKid, claims.KeyID)
+		})
+	}
+}
+
+func TestStandardSigner_RetrieveInitialSecret_MissingAdditionalSecret(t *testing.T) {
+	runtimeClient := newSecretsClient(t, signingSecret("jwt-secret", map[string]string{"1000": testKey1000}))
+
+	signer := NewStandardSigner("issuer", "audience", time.Hour, 0, WithAdditionalSecrets("jwt-archive"))
+	err := signer.RetrieveInitialSecret(context.Background(), runtimeClient, "jwt-secret", "default")
+	assert.ErrorContains(t, err, "jwt-archive")
+}
+
+func TestStandardSigner_MergeSigningSecret(t *testing.T) {
+	signer := NewStandardSigner("issuer", "audience", time.Hour, 0, WithAdditionalSecrets("jwt-archive"))
+
+	_, _, err := signer.mergeSigningSecret(signingSecret("jwt-archive", map[string]string{"1000": testKey1000}))
+	require.NoError(t, err)
+
+	// An update to the primary secret keeps the archived keys
+	keys, latestKid, err := signer.mergeSigningSecret(signingSecret("jwt-secret", map[string]string{"2000": testKey2000}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"1000": []byte(testKey1000), "2000": []byte(testKey2000)}, keys)
+	assert.Equal(t, "2000", latestKid)
+
+	// A kid with different bytes in two secrets is refused, and the previous content kept
+	_, _, err = signer.mergeSigningSecret(signingSecret("jwt-secret", map[string]string{"1000": testKey3000}))
+	assert.ErrorContains(t, err, "hold different keys for kid 1000")
+	keys, latestKid, err = signer.mergeSigningSecret(signingSecret("jwt-archive", map[string]string{"1000": testKey1000}))
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, "2000", latestKid)
+}
@@ -8,6 +8,10 @@ package jwt
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -15,49 +19,118 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// DefaultSecretWatchSyncTimeout is how long a secret watch may take to sync after the manager starts
+const DefaultSecretWatchSyncTimeout = 2 * time.Minute
+
+// RotationCountAnnotation holds the number of rotations the rotator has performed on a
+// secret. A secret watch ignores an update whose count is lower than that of the version
+// it loaded last, as happens when an informer replays an old version of the secret.
+const RotationCountAnnotation = "workspace.jupyter.org/jwt-rotation-count"
+
 // RegisterSecretWatch registers informer event handlers to watch for secret changes
-// and update the StandardSigner when keys are rotated.
+// and update the StandardSigner when keys are rotated. Additional secrets are watched
+// too, and a change to any secret loads the keys of all of them.
+// See registerSecretWatch for how ctx and syncTimeout apply.
 func (s *StandardSigner) RegisterSecretWatch(
+	ctx context.Context,
+	mgr ctrl.Manager,
+	secretName string,
+	namespace string,
+	syncTimeout time.Duration,
+	logger logr.Logger,
+) error {
+	secretNames := s.signingSecretNames(secretName)
+	return registerSecretWatch(ctx, mgr, secretNames, namespace, syncTimeout, logger, func(secret *corev1.Secret) (int, string, error) {
+		signingKeys, latestKid, err := s.mergeSigningSecret(secret)
+		if err != nil {
+			return 0, "", err
+		}
+		if err := s.UpdateKeys(signingKeys, latestKid); err != nil {
+			return 0, "", fmt.Errorf("failed to update signing keys: %w", err)
+		}
+		return len(signingKeys), latestKid, nil
+	})
+}
+
+// RegisterSecretWatch registers informer event handlers to watch for secret changes
+// and update the AsymmetricSigner, and so the published public keys, when keys are rotated.
+// See registerSecretWatch for how ctx and syncTimeout apply.
+func (s *AsymmetricSigner) RegisterSecretWatch(
+	ctx context.Context,
 	mgr ctrl.Manager,
 	secretName string,
 	namespace string,
+	syncTimeout time.Duration,
 	logger logr.Logger,
 ) error {
+	return registerSecretWatch(ctx, mgr, []string{secretName}, namespace, syncTimeout, logger, func(secret *corev1.Secret) (int, string, error) {
+		signingKeys, latestKid, err := ParsePrivateKeysFromSecret(secret, s.keyPrefixes...)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to parse signing keys: %w", err)
+		}
+		if err := s.UpdateKeys(signingKeys, latestKid); err != nil {
+			return 0, "", fmt.Errorf("failed to update signing keys: %w", err)
+		}
+		return len(signingKeys), latestKid, nil
+	})
+}
+
+// registerSecretWatch adds informer event handlers that call update whenever
+// one of the named secrets is added or changed, unless the change is stale by its
+// RotationCountAnnotation. Getting the informer is bounded by ctx and
+// syncTimeout. Once the manager starts, the handlers must see the secret's initial
+// state within syncTimeout, or the manager stops with an error rather than serving
+// without keys indefinitely.
+func registerSecretWatch(
+	ctx context.Context,
+	mgr ctrl.Manager,
+	secretNames []string,
+	namespace string,
+	syncTimeout time.Duration,
+	logger logr.Logger,
+	update func(secret *corev1.Secret) (keyCount int, latestKid string, err error),
+) error {
+	if syncTimeout <= 0 {
+		return fmt.Errorf("secret watch sync timeout must be positive, got %s", syncTimeout)
+	}
+
 	// Get informer for Secrets from the manager's cache
 	// This provides automatic retry/backoff and reconnection
-	ctx := context.Background()
-	informer, err := mgr.GetCache().GetInformer(ctx, &corev1.Secret{})
+	informerCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	informer, err := mgr.GetCache().GetInformer(informerCtx, &corev1.Secret{})
 	if err != nil {
 		return fmt.Errorf("failed to get secret informer: %w", err)
 	}
 
 	// Helper function to update signer from secret
+	order := &secretUpdateOrder{}
 	updateSignerFromSecret := func(secret *corev1.Secret) {
-		signingKeys, latestKid, err := ParseSigningKeysFromSecret(secret)
-		if err != nil {
-			logger.Error(err, "Failed to parse signing keys")
+		if order.isStale(secret, logger) {
 			return
 		}
-
-		if err := s.UpdateKeys(signingKeys, latestKid); err != nil {
-			logger.Error(err, "Failed to update signing keys")
+		keyCount, latestKid, err := update(secret)
+		if err != nil {
+			logger.Error(err, "Failed to update signing keys from secret")
 			return
 		}
+		order.record(secret)
 
 		logger.Info("Successfully updated signing keys from secret",
-			"keyCount", len(signingKeys),
+			"secret", secret.Name,
+			"keyCount", keyCount,
 			"latestKid", latestKid)
 	}
 
 	// Add event handler with filtering by secret name and namespace
-	_, err = informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+	registration, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			secret, ok := obj.(*corev1.Secret)
 			if !ok {
 				return
 			}
-			// Filter: only process our specific secret
-			if secret.Name == secretName && secret.Namespace == namespace {
+			// Filter: only process our specific secrets
+			if slices.Contains(secretNames, secret.Name) && secret.Namespace == namespace {
 				logger.Info("Secret added event received", "secret", secret.Name, "namespace", secret.Namespace)
 				updateSignerFromSecret(secret)
 			}
@@ -67,8 +140,8 @@ func (s *StandardSigner) RegisterSecretWatch(
 			if !ok {
 				return
 			}
-			// Filter: only process our specific secret
-			if secret.Name == secretName && secret.Namespace == namespace {
+			// Filter: only process our specific secrets
+			if slices.Contains(secretNames, secret.Name) && secret.Namespace == namespace {
 				logger.Info("Secret updated event received", "secret", secret.Name, "namespace", secret.Namespace)
 				updateSignerFromSecret(secret)
 			}
@@ -78,10 +151,10 @@ func (s *StandardSigner) RegisterSecretWatch(
 			if !ok {
 				return
 			}
-			// Filter: only process our specific secret
-			if secret.Name == secretName && secret.Namespace == namespace {
+			// Filter: only process our specific secrets
+			if slices.Contains(secretNames, secret.Name) && secret.Namespace == namespace {
 				logger.Error(fmt.Errorf("secret was deleted"), "JWT secret deleted",
-					"secret", secretName,
+					"secret", secret.Name,
 					"namespace", namespace)
 			}
 		},
@@ -90,6 +163,99 @@ func (s *StandardSigner) RegisterSecretWatch(
 		return fmt.Errorf("failed to add event handler to informer: %w", err)
 	}
 
+	if err := mgr.Add(&secretWatchSyncCheck{
+		hasSynced: registration.HasSynced,
+		timeout:   syncTimeout,
+		logger:    logger,
+	}); err != nil {
+		return fmt.Errorf("failed to add secret watch sync check to manager: %w", err)
+	}
+
 	logger.Info("JWT secret watch event handlers registered")
 	return nil
 }
+
+// secretUpdateOrder tracks the rotation count of the last loaded version of each secret,
+// so a stale version is not loaded over a newer one. Keys removed by hand leave the count
+// unchanged and are applied, so the newest key can be withdrawn. Secrets without the
+// annotation, not managed by the rotator, are always applied.
+type secretUpdateOrder struct {
+	mu     sync.Mutex
+	counts map[string]int64 // map[secret name]rotation count
+}
+
+// rotationCount returns the rotation count of secret, and false if it has none
+func rotationCount(secret *corev1.Secret) (int64, bool) {
+	raw, ok := secret.Annotations[RotationCountAnnotation]
+	if !ok {
+		return 0, false
+	}
+	count, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return count, true
+}
+
+// isStale reports, and logs and counts, whether secret has a lower rotation count than
+// the version of it loaded last
+func (o *secretUpdateOrder) isStale(secret *corev1.Secret, logger logr.Logger) bool {
+	count, ok := rotationCount(secret)
+	if !ok {
+		return false
+	}
+	o.mu.Lock()
+	loaded, known := o.counts[secret.Name]
+	o.mu.Unlock()
+	if !known || count >= loaded {
+		return false
+	}
+	staleKeyUpdatesTotal.Inc()
+	logger.Info("Ignoring secret update older than the loaded keys",
+		"secret", secret.Name,
+		"rotationCount", count,
+		"loadedRotationCount", loaded)
+	return true
+}
+
+// record notes the rotation count of a secret that was loaded
+func (o *secretUpdateOrder) record(secret *corev1.Secret) {
+	count, ok := rotationCount(secret)
+	if !ok {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.counts == nil {
+		o.counts = make(map[string]int64)
+	}
+	o.counts[secret.Name] = count
+}
+
+// secretWatchSyncCheck is a Runnable that fails the manager when the secret watch
+// handlers have not synced within timeout of the manager starting
+type secretWatchSyncCheck struct {
+	hasSynced func() bool
+	timeout   time.Duration
+	logger    logr.Logger
+}
+
+// Start waits for the handlers to sync. It returns nil if ctx is cancelled first,
+// since the manager is then shutting down anyway.
+func (c *secretWatchSyncCheck) Start(ctx context.Context) error {
+	syncCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	if !toolscache.WaitForCacheSync(syncCtx.Done(), c.hasSynced) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("JWT secret watch did not sync within %s", c.timeout)
+	}
+	c.logger.Info("JWT secret watch synced")
+	return nil
+}
+
+// NeedLeaderElection returns false because every replica watches the secret.
+func (c *secretWatchSyncCheck) NeedLeaderElection() bool {
+	return false
+}
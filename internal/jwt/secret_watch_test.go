@@ -0,0 +1,83 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSecretWatchSyncCheck_Synced(t *testing.T) {
+	check := &secretWatchSyncCheck{
+		hasSynced: func() bool { return true },
+		timeout:   time.Second,
+		logger:    logr.Discard(),
+	}
+	assert.NoError(t, check.Start(context.Background()))
+	assert.False(t, check.NeedLeaderElection())
+}
+
+func TestSecretWatchSyncCheck_TimesOut(t *testing.T) {
+	check := &secretWatchSyncCheck{
+		hasSynced: func() bool { return false },
+		timeout:   50 * time.Millisecond,
+		logger:    logr.Discard(),
+	}
+	err := check.Start(context.Background())
+	assert.ErrorContains(t, err, "did not sync within 50ms")
+}
+
+func TestSecretWatchSyncCheck_ShutdownIsNotAnError(t *testing.T) {
+	check := &secretWatchSyncCheck{
+		hasSynced: func() bool { return false },
+		timeout:   time.Minute,
+		logger:    logr.Discard(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, check.Start(ctx))
+}
+
+func TestRegisterSecretWatch_RequiresSyncTimeout(t *testing.T) {
+	signer := NewStandardSigner("issuer", "audience", time.Hour, 0)
+	err := signer.RegisterSecretWatch(context.Background(), nil, "secret", "ns", 0, logr.Discard())
+	assert.ErrorContains(t, err, "sync timeout must be positive")
+}
+
+func TestSecretUpdateOrder(t *testing.T) {
+	secretAt := func(name string, count string) *corev1.Secret {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if count != "" {
+			secret.Annotations = map[string]string{RotationCountAnnotation: count}
+		}
+		return secret
+	}
+	order := &secretUpdateOrder{}
+	before := testutil.ToFloat64(staleKeyUpdatesTotal)
+
+	assert.False(t, order.isStale(secretAt("keys", "5"), logr.Discard()))
+	order.record(secretAt("keys", "5"))
+
+	// A replay of the version before the last rotation is ignored
+	assert.True(t, order.isStale(secretAt("keys", "4"), logr.Discard()))
+	assert.Equal(t, before+1, testutil.ToFloat64(staleKeyUpdatesTotal))
+
+	// Keys edited by hand, e.g. to withdraw the newest key, keep the count and are applied
+	assert.False(t, order.isStale(secretAt("keys", "5"), logr.Discard()))
+	assert.False(t, order.isStale(secretAt("keys", "6"), logr.Discard()))
+
+	// Secrets are tracked separately, and those without a count are always applied
+	assert.False(t, order.isStale(secretAt("other-keys", "1"), logr.Discard()))
+	assert.False(t, order.isStale(secretAt("keys", ""), logr.Discard()))
+	assert.False(t, order.isStale(secretAt("keys", "not-a-number"), logr.Discard()))
+}
@@ -5,9 +5,61 @@ Distributed under the terms of the MIT license
 
 package jwt
 
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
 // Signer handles core JWT operations - encryption-specific
 type Signer interface {
 	GenerateToken(user string, groups []string, uid string, extra map[string][]string, path string, domain string, tokenType string, skipRefresh bool) (string, error)
+	// GenerateTokenContext is GenerateToken bounded by ctx, so signing that leaves the
+	// process, e.g. through a remote key service, can be cancelled or traced. Signers
+	// signing in memory check ctx before signing.
+	GenerateTokenContext(ctx context.Context, user string, groups []string, uid string, extra map[string][]string, path string, domain string, tokenType string, skipRefresh bool) (string, error)
 	GenerateRefreshToken(claims *Claims) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
+	IsKnownKid(kid string) bool
+}
+
+// SecretBackedSigner is a Signer whose keys are loaded from a Kubernetes secret
+// and refreshed whenever the secret changes
+type SecretBackedSigner interface {
+	Signer
+	RetrieveInitialSecret(ctx context.Context, runtimeClient client.Client, secretName string, namespace string) error
+	RegisterSecretWatch(
+		ctx context.Context,
+		mgr ctrl.Manager,
+		secretName string,
+		namespace string,
+		syncTimeout time.Duration,
+		logger logr.Logger,
+	) error
+}
+
+// KeySetPublisher is implemented by signers whose verification keys can be shared publicly
+type KeySetPublisher interface {
+	JWKS() (JSONWebKeySet, error)
+}
+
+// SigningKeyChecker is implemented by signers that can tell whether a key has passed
+// its cooloff, i.e. whether GenerateToken would find a key to sign with
+type SigningKeyChecker interface {
+	HasUsableSigningKey() bool
+}
+
+// ExpiredKeyRemover is implemented by signers that can drop keys past a max retention
+// without waiting for a secret update
+type ExpiredKeyRemover interface {
+	RemoveExpiredKeys() []string
+}
+
+// ValidationOnlyKeyChecker is implemented by signers that keep some keys for
+// validation only, so tokens signed by them are never re-signed on refresh
+type ValidationOnlyKeyChecker interface {
+	IsValidationOnlyKey(kid string) bool
 }
@@ -0,0 +1,61 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// signingKidLagMargin is how long past its cooloff the latest key may go unused for
+// signing before it is reported, leaving room for clock and event delivery jitter
+const signingKidLagMargin = time.Minute
+
+// signingLagWarner flags tokens signed with an older kid than the latest loaded one
+// although the latest key's cooloff has passed, which a watcher stuck between updates
+// or an unusable latest key would cause. The metric counts every such token; the
+// warning is logged once per latest kid to avoid flooding logs.
+type signingLagWarner struct {
+	mu     sync.Mutex
+	warned string // latest kid already warned about
+}
+
+// check warns when signingKid differs from latestKid and latestKid was added more than
+// newKeyUseDelay plus signingKidLagMargin before now
+func (w *signingLagWarner) check(
+	logger logr.Logger,
+	signingKid string,
+	latestKid string,
+	latestAddedAt time.Time,
+	now time.Time,
+	newKeyUseDelay time.Duration,
+) {
+	if signingKid == latestKid || latestAddedAt.IsZero() {
+		return
+	}
+	lag := now.Sub(latestAddedAt) - newKeyUseDelay
+	if lag <= signingKidLagMargin {
+		return
+	}
+
+	signingKidLagTotal.Inc()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.warned == latestKid {
+		return
+	}
+	w.warned = latestKid
+	logger.Error(fmt.Errorf("latest kid %s passed its cooloff %s ago but kid %s still signs",
+		latestKid, lag.Round(time.Second), signingKid),
+		"WARNING: signing with an older key than the latest loaded one; the latest key may be unusable or key updates stuck",
+		"signingKid", signingKid,
+		"latestKid", latestKid,
+		"newKeyUseDelay", newKeyUseDelay.String())
+}
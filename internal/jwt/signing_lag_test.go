@@ -0,0 +1,71 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardSigner_SigningKidLag(t *testing.T) {
+	var logs []string
+	logger := funcr.New(func(prefix, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{})
+	now := time.Now()
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, time.Minute,
+		WithLogger(logger), WithClock(func() time.Time { return now }))
+
+	generate := func() {
+		t.Helper()
+		_, err := signer.GenerateToken(testUser, nil, "uid", nil, "/path", "", TokenTypeSession, false)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+	}, "1000"))
+	now = now.Add(time.Minute)
+	before := testutil.ToFloat64(signingKidLagTotal)
+
+	// A new latest key within its cooloff is expected to lag
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+		"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
+	}, "2000"))
+	generate()
+	assert.Equal(t, before, testutil.ToFloat64(signingKidLagTotal))
+
+	// Once past its cooloff it signs, so there is nothing to report
+	now = now.Add(time.Minute + signingKidLagMargin + time.Second)
+	generate()
+	assert.Equal(t, before, testutil.ToFloat64(signingKidLagTotal))
+	assert.Empty(t, logs)
+
+	// A latest key too short for HS384 never signs, so the older key keeps signing
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
+		"3000": []byte("key3-too-short-for-hs384-signing-only"),
+	}, "3000"))
+	logs = nil
+	now = now.Add(time.Minute + signingKidLagMargin)
+	generate()
+	assert.Equal(t, before, testutil.ToFloat64(signingKidLagTotal), "within the margin")
+
+	now = now.Add(time.Second)
+	generate()
+	generate()
+	assert.Equal(t, before+2, testutil.ToFloat64(signingKidLagTotal))
+	require.Len(t, logs, 1, "warned once per latest kid")
+	assert.Contains(t, logs[0], "WARNING: signing with an older key than the latest loaded one")
+	assert.Contains(t, logs[0], `"signingKid"="2000"`)
+	assert.Contains(t, logs[0], `"latestKid"="3000"`)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto/x509"
+	"sort"
+	"time"
+)
+
+// SnapshotProvider is implemented by signers that expose their loaded key state
+type SnapshotProvider interface {
+	Snapshot() SignerSnapshot
+}
+
+// SignerSnapshot is a deterministic view of a signer's observable state.
+// Key ages are relative to the signer's clock so snapshots compare cleanly in tests.
+type SignerSnapshot struct {
+	// Kids lists all loaded key IDs, sorted
+	Kids []string
+	// LatestKid is the newest key ID reported by the secret
+	LatestKid string
+	// KeyAges maps each kid to the time elapsed since the signer first saw it
+	KeyAges map[string]time.Duration
+	// UsableKids lists the kids beyond the cooloff period, sorted
+	UsableKids []string
+	// SigningKid is the kid GenerateToken would currently use, empty if none
+	SigningKid string
+	// ValidationOnlyKids lists kids whose keys are too short to sign with, sorted
+	ValidationOnlyKids []string
+}
+
+// Snapshot returns the current signer state. The returned value shares no memory with the signer.
+func (s *StandardSigner) Snapshot() SignerSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	signingKid, _ := s.latestKidAndKeyWithCoolOffLocked(now)
+	snapshot := SignerSnapshot{
+		Kids:       make([]string, 0, len(s.signingKeys)),
+		LatestKid:  s.latestKid,
+		KeyAges:    make(map[string]time.Duration, len(s.signingKeys)),
+		UsableKids: []string{},
+		SigningKid: signingKid,
+
+		ValidationOnlyKids: []string{},
+	}
+
+	for kid := range s.signingKeys {
+		snapshot.Kids = append(snapshot.Kids, kid)
+		age := now.Sub(s.keyAddedTimes[kid])
+		snapshot.KeyAges[kid] = age
+		if age >= s.newKeyUseDelay {
+			snapshot.UsableKids = append(snapshot.UsableKids, kid)
+		}
+		if s.validationOnly[kid] {
+			snapshot.ValidationOnlyKids = append(snapshot.ValidationOnlyKids, kid)
+		}
+	}
+	sort.Strings(snapshot.Kids)
+	sort.Strings(snapshot.UsableKids)
+	sort.Strings(snapshot.ValidationOnlyKids)
+
+	return snapshot
+}
+
+// Snapshot returns the current signer state. The returned value shares no memory with the signer.
+// Asymmetric keys are validated when loaded, so ValidationOnlyKids is always empty.
+func (s *AsymmetricSigner) Snapshot() SignerSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	signingKid, _ := s.latestKidAndKeyWithCoolOffLocked(now)
+	snapshot := SignerSnapshot{
+		Kids:       make([]string, 0, len(s.signingKeys)),
+		LatestKid:  s.latestKid,
+		KeyAges:    make(map[string]time.Duration, len(s.signingKeys)),
+		UsableKids: []string{},
+		SigningKid: signingKid,
+
+		ValidationOnlyKids: []string{},
+	}
+
+	for kid := range s.signingKeys {
+		snapshot.Kids = append(snapshot.Kids, kid)
+		age := now.Sub(s.keyAddedTimes[kid])
+		snapshot.KeyAges[kid] = age
+		if age >= s.newKeyUseDelay {
+			snapshot.UsableKids = append(snapshot.UsableKids, kid)
+		}
+	}
+	sort.Strings(snapshot.Kids)
+	sort.Strings(snapshot.UsableKids)
+
+	return snapshot
+}
+
+// KeyInfoProvider is implemented by signers that can describe their loaded keys
+// without exposing key material
+type KeyInfoProvider interface {
+	KeyInfo() LoadedKeyInfo
+}
+
+// LoadedKeyInfo describes the keys a signer has loaded
+type LoadedKeyInfo struct {
+	// LatestKid is the newest key ID reported by the secret
+	LatestKid string
+	// Keys lists the loaded keys, sorted by kid
+	Keys []KeyInfo
+}
+
+// KeyInfo describes one loaded key
+type KeyInfo struct {
+	Kid string
+	// Age is the time elapsed since the signer first saw the key
+	Age time.Duration
+	// Fingerprint identifies the key bytes, see KeyFingerprint
+	Fingerprint string
+}
+
+// KeyInfo returns the loaded kids with their ages and fingerprints, so replicas can be
+// checked for having converged on the same keys after a rotation.
+func (s *StandardSigner) KeyInfo() LoadedKeyInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	info := LoadedKeyInfo{LatestKid: s.latestKid, Keys: make([]KeyInfo, 0, len(s.signingKeys))}
+	for kid, key := range s.signingKeys {
+		info.Keys = append(info.Keys, KeyInfo{
+			Kid:         kid,
+			Age:         now.Sub(s.keyAddedTimes[kid]),
+			Fingerprint: KeyFingerprint(key),
+		})
+	}
+	sort.Slice(info.Keys, func(i, j int) bool { return info.Keys[i].Kid < info.Keys[j].Kid })
+	return info
+}
+
+// KeyInfo returns the loaded kids with their ages and the fingerprints of their public keys.
+func (s *AsymmetricSigner) KeyInfo() LoadedKeyInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	info := LoadedKeyInfo{LatestKid: s.latestKid, Keys: make([]KeyInfo, 0, len(s.signingKeys))}
+	for kid, key := range s.signingKeys {
+		// Keys are checked on load, so their public halves always marshal
+		der, _ := x509.MarshalPKIXPublicKey(key.Public())
+		info.Keys = append(info.Keys, KeyInfo{
+			Kid:         kid,
+			Age:         now.Sub(s.keyAddedTimes[kid]),
+			Fingerprint: KeyFingerprint(der),
+		})
+	}
+	sort.Slice(info.Keys, func(i, j int) bool { return info.Keys[i].Kid < info.Keys[j].Kid })
+	return info
+}
@@ -0,0 +1,218 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced time source for signer tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestStandardSigner_Snapshot_Empty(t *testing.T) {
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 5*time.Second)
+
+	snapshot := signer.Snapshot()
+
+	assert.Equal(t, SignerSnapshot{
+		Kids:               []string{},
+		KeyAges:            map[string]time.Duration{},
+		UsableKids:         []string{},
+		ValidationOnlyKids: []string{},
+	}, snapshot)
+}
+
+func TestStandardSigner_Snapshot_UpdateKeysAndCooloff(t *testing.T) {
+	clock := newFakeClock()
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 30*time.Second, WithClock(clock.Now))
+
+	// Initial load: key is inside cooloff
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+	}, "1000"))
+	assert.Equal(t, SignerSnapshot{
+		Kids:               []string{"1000"},
+		LatestKid:          "1000",
+		KeyAges:            map[string]time.Duration{"1000": 0},
+		UsableKids:         []string{},
+		ValidationOnlyKids: []string{},
+	}, signer.Snapshot())
+
+	// First key leaves cooloff
+	clock.Advance(time.Minute)
+	assert.Equal(t, SignerSnapshot{
+		Kids:               []string{"1000"},
+		LatestKid:          "1000",
+		KeyAges:            map[string]time.Duration{"1000": time.Minute},
+		UsableKids:         []string{"1000"},
+		SigningKid:         "1000",
+		ValidationOnlyKids: []string{},
+	}, signer.Snapshot())
+
+	// Rotation adds a new key which enters cooloff; old key keeps signing
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+		"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
+	}, "2000"))
+	assert.Equal(t, SignerSnapshot{
+		Kids:               []string{"1000", "2000"},
+		LatestKid:          "2000",
+		KeyAges:            map[string]time.Duration{"1000": time.Minute, "2000": 0},
+		UsableKids:         []string{"1000"},
+		SigningKid:         "1000",
+		ValidationOnlyKids: []string{},
+	}, signer.Snapshot())
+
+	// New key leaves cooloff and takes over signing
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, SignerSnapshot{
+		Kids:               []string{"1000", "2000"},
+		LatestKid:          "2000",
+		KeyAges:            map[string]time.Duration{"1000": 90 * time.Second, "2000": 30 * time.Second},
+		UsableKids:         []string{"1000", "2000"},
+		SigningKid:         "2000",
+		ValidationOnlyKids: []string{},
+	}, signer.Snapshot())
+
+	// Pruning the old key removes it from the snapshot
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
+	}, "2000"))
+	assert.Equal(t, SignerSnapshot{
+		Kids:               []string{"2000"},
+		LatestKid:          "2000",
+		KeyAges:            map[string]time.Duration{"2000": 30 * time.Second},
+		UsableKids:         []string{"2000"},
+		SigningKid:         "2000",
+		ValidationOnlyKids: []string{},
+	}, signer.Snapshot())
+}
+
+func TestStandardSigner_Snapshot_IsACopy(t *testing.T) {
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
+
+	snapshot := signer.Snapshot()
+	snapshot.Kids[0] = "mutated"
+	snapshot.KeyAges["mutated"] = time.Hour
+
+	fresh := signer.Snapshot()
+	assert.Equal(t, []string{"1234567890"}, fresh.Kids)
+	assert.NotContains(t, fresh.KeyAges, "mutated")
+}
+
+// TestStandardSigner_Snapshot_ConsistentDuringUpdates tests that the signing kid is read
+// in the same critical section as the loaded keys, so it is always one of the usable kids
+func TestStandardSigner_Snapshot_ConsistentDuringUpdates(t *testing.T) {
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	keySets := []map[string][]byte{
+		{"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough")},
+		{"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough")},
+	}
+	require.NoError(t, signer.UpdateKeys(keySets[0], "1000"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2000; i++ {
+			next := keySets[(i+1)%2]
+			for kid := range next {
+				_ = signer.UpdateKeys(next, kid)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		snapshot := signer.Snapshot()
+		require.Contains(t, snapshot.UsableKids, snapshot.SigningKid)
+	}
+}
+
+func TestAsymmetricSigner_Snapshot(t *testing.T) {
+	clock := newFakeClock()
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "test-issuer", "test-audience", time.Hour, 5*time.Second,
+		WithAsymmetricClock(clock.Now))
+	require.NoError(t, err)
+
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": generateECKey(t)}, "1000"))
+	clock.Advance(10 * time.Second)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{
+		"1000": signer.signingKeys["1000"],
+		"2000": generateECKey(t),
+	}, "2000"))
+
+	snapshot := signer.Snapshot()
+	assert.Equal(t, []string{"1000", "2000"}, snapshot.Kids)
+	assert.Equal(t, "2000", snapshot.LatestKid)
+	assert.Equal(t, []string{"1000"}, snapshot.UsableKids)
+	assert.Equal(t, "1000", snapshot.SigningKid, "new key should not sign during cooloff")
+	assert.Empty(t, snapshot.ValidationOnlyKids)
+}
+
+func TestStandardSigner_KeyInfo(t *testing.T) {
+	clock := newFakeClock()
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithClock(clock.Now))
+	key1 := []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough")
+	key2 := []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough")
+
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key1}, "1000"))
+	clock.Advance(time.Minute)
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key1, "2000": key2}, "2000"))
+	clock.Advance(time.Minute)
+
+	assert.Equal(t, LoadedKeyInfo{
+		LatestKid: "2000",
+		Keys: []KeyInfo{
+			{Kid: "1000", Age: 2 * time.Minute, Fingerprint: KeyFingerprint(key1)},
+			{Kid: "2000", Age: time.Minute, Fingerprint: KeyFingerprint(key2)},
+		},
+	}, signer.KeyInfo())
+}
+
+func TestAsymmetricSigner_KeyInfo(t *testing.T) {
+	clock := newFakeClock()
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "test-issuer", "test-audience", time.Hour, 0,
+		WithAsymmetricClock(clock.Now))
+	require.NoError(t, err)
+	key1, key2 := generateECKey(t), generateECKey(t)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": key1, "2000": key2}, "2000"))
+
+	info := signer.KeyInfo()
+	assert.Equal(t, "2000", info.LatestKid)
+	require.Len(t, info.Keys, 2)
+	assert.Equal(t, "1000", info.Keys[0].Kid)
+	assert.NotEqual(t, info.Keys[0].Fingerprint, info.Keys[1].Fingerprint)
+	assert.Equal(t, info, signer.KeyInfo(), "fingerprints should be stable")
+}
@@ -7,12 +7,18 @@ package jwt
 
 import (
 	"context"
-	"errors"
+	"crypto/subtle"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-logr/logr"
 	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,45 +27,161 @@ import (
 // StandardSigner handles JWT token creation and validation using HMAC
 // Supports multiple signing keys for key rotation
 type StandardSigner struct {
-	signingKeys    map[string][]byte    // map[kid]key
-	keyAddedTimes  map[string]time.Time // map[kid]timestamp when key was added
-	latestKid      string               // newest key ID for signing
-	newKeyUseDelay time.Duration        // cooloff period before using a new key
-	issuer         string
-	audience       string
-	expiration     time.Duration
-	mu             sync.RWMutex // protect key map, keyAddedTimes, and latestKid
+	signingKeys       map[string][]byte    // map[kid]key
+	keyAddedTimes     map[string]time.Time // map[kid]timestamp when key was added
+	validationOnly    map[string]bool      // kids whose keys are too short for the algorithm, never used for signing
+	latestKid         string               // newest key ID for signing
+	newKeyUseDelay    time.Duration        // cooloff period before using a new key
+	issuer            string
+	audiences         []string // first is the constructor audience; all are set on tokens, any is accepted
+	expiration        time.Duration
+	leeway            time.Duration           // clock skew tolerated on validation, overridable via WithLeeway
+	algorithm         string                  // HMAC algorithm, overridable via WithAlgorithm
+	method            *jwt5.SigningMethodHMAC // signing method for algorithm
+	minKeyBytes       int                     // RFC 7518 minimum key length for algorithm
+	now               func() time.Time        // time source, overridable via WithClock
+	logger            logr.Logger
+	requireTyp        bool             // reject tokens without a typ header, overridable via WithRequireTypHeader
+	revocations       RevocationStore  // consulted on validation when set via WithRevocationStore
+	migration         *IssuerMigration // previous issuer/audience accepted, set via WithIssuerMigration
+	claimFallback     *ClaimFallback   // issuer/audience assumed for tokens omitting them, set via WithClaimFallback
+	subjectMatch      *SubjectMatch    // sub/User agreement enforced on validation, set via WithSubjectMatch
+	scope             string           // scope claim stamped on new tokens, set via WithScope
+	keyPrefixes       []string         // secret key name prefixes read, set via WithKeyPrefixes
+	keyEncoding       string           // encoding of secret key values, overridable via WithKeyEncoding
+	retention         *retentionWarner // flags tokens outliving their key, set via WithKeyRetention
+	signingLag        signingLagWarner // flags signing with an older kid than the latest past its cooloff
+	maxKeyRetention   time.Duration    // age after which RemoveExpiredKeys drops a key, set via WithMaxKeyRetention
+	rejectWeakKeys    bool             // fail UpdateKeys on weak key material instead of warning, set via WithRejectWeakKeys
+	forcedKid         string           // kid signing in place of the latest one while usable, set via WithForcedSigningKid
+	additionalSecrets []string         // read-only secrets whose keys are merged in, set via WithAdditionalSecrets
+	secretKeys        secretKeySets    // keys last read from each secret, merged on every load
+	usage             keyUsageCounter  // per-kid sign and validation counts
+	keyGeneration     atomic.Uint64    // incremented by every UpdateKeys
+	mu                sync.RWMutex     // protect key map, keyAddedTimes, validationOnly, and latestKid
+}
+
+// Accepted values of the typ header, compared case-insensitively
+const (
+	TypHeaderJWT         = "JWT"
+	TypHeaderAccessToken = "at+jwt"
+)
+
+// DefaultLeeway is the clock skew tolerated on the exp and nbf claims when validating.
+// New tokens have nbf backdated by the same amount.
+const DefaultLeeway = 5 * time.Second
+
+// HMAC signing algorithms supported by StandardSigner
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmHS384 = "HS384"
+	AlgorithmHS512 = "HS512"
+	// DefaultHMACAlgorithm is used when no algorithm is configured
+	DefaultHMACAlgorithm = AlgorithmHS384
+)
+
+// hmacAlgorithms maps each supported algorithm to its signing method and the minimum
+// key length in bytes, which RFC 7518 Section 3.2 sets to the hash output size
+var hmacAlgorithms = map[string]struct {
+	method      *jwt5.SigningMethodHMAC
+	minKeyBytes int
+}{
+	AlgorithmHS256: {method: jwt5.SigningMethodHS256, minKeyBytes: 32},
+	AlgorithmHS384: {method: jwt5.SigningMethodHS384, minKeyBytes: 48},
+	AlgorithmHS512: {method: jwt5.SigningMethodHS512, minKeyBytes: 64},
+}
+
+// ValidateHMACAlgorithm returns an error if alg is not a supported HMAC algorithm
+func ValidateHMACAlgorithm(alg string) error {
+	if _, ok := hmacAlgorithms[alg]; !ok {
+		return fmt.Errorf("unsupported HMAC algorithm %q, must be %s, %s or %s",
+			alg, AlgorithmHS256, AlgorithmHS384, AlgorithmHS512)
+	}
+	return nil
 }
 
 // NewStandardSigner creates a new StandardSigner without initial keys.
 // Keys must be loaded by calling RetrieveInitialSecret() before use.
-func NewStandardSigner(issuer string, audience string, expiration time.Duration, newKeyUseDelay time.Duration) *StandardSigner {
-	return &StandardSigner{
+// Tokens are signed with HS384 unless another algorithm is set with WithAlgorithm.
+func NewStandardSigner(
+	issuer string,
+	audience string,
+	expiration time.Duration,
+	newKeyUseDelay time.Duration,
+	opts ...StandardSignerOption,
+) *StandardSigner {
+	s := &StandardSigner{
 		signingKeys:    make(map[string][]byte),
 		keyAddedTimes:  make(map[string]time.Time),
+		validationOnly: make(map[string]bool),
 		latestKid:      "",
 		newKeyUseDelay: newKeyUseDelay,
 		issuer:         issuer,
-		audience:       audience,
+		audiences:      []string{audience},
 		expiration:     expiration,
+		leeway:         DefaultLeeway,
+		algorithm:      DefaultHMACAlgorithm,
+		keyEncoding:    KeyEncodingRaw,
+		now:            time.Now,
+		logger:         logr.Discard(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	// Callers validate with ValidateHMACAlgorithm; fall back rather than fail construction
+	if err := ValidateHMACAlgorithm(s.algorithm); err != nil {
+		s.logger.Error(err, "Falling back to default HMAC algorithm", "algorithm", DefaultHMACAlgorithm)
+		s.algorithm = DefaultHMACAlgorithm
+	}
+	if err := ValidateKeyEncoding(s.keyEncoding); err != nil {
+		s.logger.Error(err, "Falling back to raw key encoding")
+		s.keyEncoding = KeyEncodingRaw
+	}
+	s.method = hmacAlgorithms[s.algorithm].method
+	s.minKeyBytes = hmacAlgorithms[s.algorithm].minKeyBytes
+	newKeyUseDelaySeconds.Set(newKeyUseDelay.Seconds())
+	if s.forcedKid != "" {
+		s.logger.Info("WARNING: forced signing kid is active; tokens are signed with it instead of the latest key while it is loaded and past its cooloff",
+			"forcedKid", s.forcedKid)
+	}
+	return s
+}
+
+// Algorithm returns the JWS algorithm this signer uses
+func (s *StandardSigner) Algorithm() string {
+	return s.algorithm
 }
 
 // getLatestKidAndKeyWithCoolOff returns the latest key ID and signing key that have passed the cooloff period
+// Validation-only keys are never selected
+// A forced kid that is loaded and beyond cooloff is returned in place of the latest one
 // Returns empty kid and nil key if no key is beyond the cooloff period
 // This combines kid lookup and key retrieval in a single lock to avoid double locking
 func (s *StandardSigner) getLatestKidAndKeyWithCoolOff() (string, []byte) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return s.latestKidAndKeyWithCoolOffLocked(s.now())
+}
+
+// latestKidAndKeyWithCoolOffLocked is getLatestKidAndKeyWithCoolOff at the given time.
+// Caller must hold s.mu.
+func (s *StandardSigner) latestKidAndKeyWithCoolOffLocked(now time.Time) (string, []byte) {
+	if addedTime, ok := s.keyAddedTimes[s.forcedKid]; ok && s.forcedKid != "" && !s.validationOnly[s.forcedKid] &&
+		now.Sub(addedTime) >= s.newKeyUseDelay {
+		return s.forcedKid, s.signingKeys[s.forcedKid]
+	}
 
-	now := time.Now()
 	var usableKid string
 
 	for kid, addedTime := range s.keyAddedTimes {
+		if s.validationOnly[kid] {
+			continue
+		}
 		timeSinceAdded := now.Sub(addedTime)
 		if timeSinceAdded >= s.newKeyUseDelay {
 			// This key is beyond cooloff, check if it's the latest usable one
-			if usableKid == "" || kid > usableKid {
+			if usableKid == "" || KidIsNewer(kid, usableKid) {
 				usableKid = kid
 			}
 		}
@@ -72,6 +194,21 @@ func (s *StandardSigner) getLatestKidAndKeyWithCoolOff() (string, []byte) {
 	return usableKid, s.signingKeys[usableKid]
 }
 
+// checkSigningLag compares signingKid with the latest loaded kid, see signingLagWarner
+func (s *StandardSigner) checkSigningLag(signingKid string) {
+	s.mu.RLock()
+	latestKid, latestAddedAt := s.latestKid, s.keyAddedTimes[s.latestKid]
+	s.mu.RUnlock()
+	s.signingLag.check(s.logger, signingKid, latestKid, latestAddedAt, s.now(), s.newKeyUseDelay)
+}
+
+// HasUsableSigningKey reports whether a key has passed the cooloff period, so
+// GenerateToken can sign
+func (s *StandardSigner) HasUsableSigningKey() bool {
+	kid, _ := s.getLatestKidAndKeyWithCoolOff()
+	return kid != ""
+}
+
 // GenerateToken creates a new JWT token for the given user and groups
 // Uses the latest signing key that has passed the cooloff period (newKeyUseDelay)
 // This ensures all pods have time to receive new keys via watch before they're used for signing
@@ -84,8 +221,27 @@ func (s *StandardSigner) GenerateToken(
 	domain string,
 	tokenType string,
 	skipRefresh bool) (string, error) {
-	now := time.Now().UTC()
-	return s.generateTokenWithIssuedAt(username, groups, uid, extra, path, domain, tokenType, skipRefresh, now)
+	return s.GenerateTokenContext(context.Background(), username, groups, uid, extra, path, domain, tokenType, skipRefresh)
+}
+
+// GenerateTokenContext is GenerateToken, failing with ctx's error if it is done before signing
+func (s *StandardSigner) GenerateTokenContext(
+	ctx context.Context,
+	username string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+	skipRefresh bool) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	now := s.now().UTC()
+	return s.generateTokenWithIssuedAt(
+		username, groups, uid, extra, path, domain, tokenType, skipRefresh, s.scope, now, s.expiration,
+	)
 }
 
 // GenerateRefreshToken creates a new JWT token preserving the original IssuedAt
@@ -100,12 +256,12 @@ func (s *StandardSigner) GenerateRefreshToken(claims *Claims) (string, error) {
 	}
 	return s.generateTokenWithIssuedAt(
 		claims.User, claims.Groups, claims.UID, claims.Extra,
-		claims.Path, claims.Domain, claims.TokenType, false, claims.IssuedAt.Time,
+		claims.Path, claims.Domain, claims.TokenType, false, claims.Scope, claims.IssuedAt.Time, s.expiration,
 	)
 }
 
 // generateTokenWithIssuedAt is the internal token generation method that accepts
-// both skipRefresh and issuedAt parameters.
+// skipRefresh, scope, issuedAt and expiration parameters.
 func (s *StandardSigner) generateTokenWithIssuedAt(
 	username string,
 	groups []string,
@@ -115,103 +271,258 @@ func (s *StandardSigner) generateTokenWithIssuedAt(
 	domain string,
 	tokenType string,
 	skipRefresh bool,
-	issuedAt time.Time) (string, error) {
+	scope string,
+	issuedAt time.Time,
+	expiration time.Duration) (string, error) {
 	usableKid, signingKey := s.getLatestKidAndKeyWithCoolOff()
 	if usableKid == "" || signingKey == nil {
+		cooloffBlockedTotal.Inc()
 		return "", fmt.Errorf("no signing key available beyond cooloff period (%v)", s.newKeyUseDelay)
 	}
+	if usableKid != s.forcedKid {
+		s.checkSigningLag(usableKid)
+	}
 
-	now := time.Now().UTC()
 	claims := &Claims{
-		RegisteredClaims: jwt5.RegisteredClaims{
-			ExpiresAt: jwt5.NewNumericDate(now.Add(s.expiration)),
-			IssuedAt:  jwt5.NewNumericDate(issuedAt),
-			NotBefore: jwt5.NewNumericDate(now),
-			Issuer:    s.issuer,
-			Audience:  []string{s.audience},
-			Subject:   username,
-		},
-		User:        username,
-		Groups:      groups,
-		UID:         uid,
-		Extra:       extra,
-		Path:        path,
-		Domain:      domain,
-		TokenType:   tokenType,
-		SkipRefresh: skipRefresh,
-	}
-
-	// Use HS384 and add kid to header
-	token := jwt5.NewWithClaims(jwt5.SigningMethodHS384, claims)
+		RegisteredClaims: registeredClaims(s.issuer, s.audiences, username, s.now().UTC(), issuedAt, expiration, s.leeway),
+		User:             username,
+		Groups:           groups,
+		UID:              uid,
+		Extra:            extra,
+		Path:             path,
+		Domain:           domain,
+		TokenType:        tokenType,
+		SkipRefresh:      skipRefresh,
+		Scope:            scope,
+	}
+
+	s.retention.check(s.logger, usableKid, claims.ExpiresAt.Time)
+
+	// Use the configured algorithm and add kid and typ to header
+	token := jwt5.NewWithClaims(s.method, claims)
 	token.Header["kid"] = usableKid
+	token.Header["typ"] = TypHeaderJWT
 
-	return token.SignedString(signingKey)
+	signed, err := token.SignedString(signingKey)
+	if err != nil {
+		return "", err
+	}
+	s.usage.recordSign(usableKid)
+	return signed, nil
 }
 
-// ValidateToken validates and parses the token
-// Requires kid header and validates using the corresponding key
-func (s *StandardSigner) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt5.ParseWithClaims(
-		tokenString,
-		&Claims{},
-		func(t *jwt5.Token) (any, error) {
-			// Verify algorithm is HMAC
-			if _, ok := t.Method.(*jwt5.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-			}
+// registeredClaims builds the standard claims shared by all signers,
+// including a random jti so individual tokens can be revoked
+func registeredClaims(
+	issuer string,
+	audiences []string,
+	subject string,
+	now time.Time,
+	issuedAt time.Time,
+	expiration time.Duration,
+	leeway time.Duration) jwt5.RegisteredClaims {
+	return jwt5.RegisteredClaims{
+		ExpiresAt: jwt5.NewNumericDate(now.Add(expiration)),
+		IssuedAt:  jwt5.NewNumericDate(issuedAt),
+		NotBefore: jwt5.NewNumericDate(now.Add(-leeway)),
+		Issuer:    issuer,
+		Audience:  slices.Clone(audiences),
+		Subject:   subject,
+		ID:        uuid.NewString(),
+	}
+}
 
-			// Enforce HS384 only (AWS security requirement)
-			if t.Method.Alg() != "HS384" {
-				return nil, fmt.Errorf("unexpected algorithm: %v, expected HS384", t.Method.Alg())
-			}
+// checkRevoked returns ErrTokenRevoked if the token's jti is in the store.
+// Tokens issued before jti was added carry no ID and cannot be revoked individually.
+func checkRevoked(store RevocationStore, claims *Claims) error {
+	if store != nil && claims.ID != "" && store.IsRevoked(claims.ID) {
+		return ErrTokenRevoked
+	}
+	return nil
+}
 
-			// Extract and validate kid from header
-			kid, ok := t.Header["kid"].(string)
-			if !ok || kid == "" {
-				return nil, fmt.Errorf("missing or invalid kid in token header")
-			}
+// WithExpirationCap returns a Signer that shares this signer's keys but issues tokens
+// expiring after at most maxExpiration. The signer itself is returned when the cap is
+// not positive or not shorter than the configured expiration.
+func (s *StandardSigner) WithExpirationCap(maxExpiration time.Duration) Signer {
+	if maxExpiration <= 0 || maxExpiration >= s.expiration {
+		return s
+	}
+	return &expirationCappedSigner{StandardSigner: s, expiration: maxExpiration}
+}
 
-			// Lookup key by kid
-			s.mu.RLock()
-			key := s.signingKeys[kid]
-			s.mu.RUnlock()
+// expirationCappedSigner issues tokens with a shorter expiration than its
+// underlying StandardSigner. Validation is delegated unchanged.
+type expirationCappedSigner struct {
+	*StandardSigner
+	expiration time.Duration
+}
 
-			if key == nil {
-				return nil, fmt.Errorf("unknown key ID: %s", kid)
-			}
+// GenerateToken creates a new JWT token using the capped expiration
+func (c *expirationCappedSigner) GenerateToken(
+	username string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+	skipRefresh bool) (string, error) {
+	return c.GenerateTokenContext(context.Background(), username, groups, uid, extra, path, domain, tokenType, skipRefresh)
+}
 
-			return key, nil
-		},
-		jwt5.WithIssuer(s.issuer),
-		jwt5.WithAudience(s.audience),
-		jwt5.WithValidMethods([]string{"HS384"}),
-		jwt5.WithLeeway(5*time.Second),
+// GenerateTokenContext creates a new JWT token using the capped expiration, failing
+// with ctx's error if it is done before signing
+func (c *expirationCappedSigner) GenerateTokenContext(
+	ctx context.Context,
+	username string,
+	groups []string,
+	uid string,
+	extra map[string][]string,
+	path string,
+	domain string,
+	tokenType string,
+	skipRefresh bool) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	now := c.now().UTC()
+	return c.generateTokenWithIssuedAt(
+		username, groups, uid, extra, path, domain, tokenType, skipRefresh, c.scope, now, c.expiration,
 	)
+}
 
+// GenerateRefreshToken creates a refresh token using the capped expiration
+func (c *expirationCappedSigner) GenerateRefreshToken(claims *Claims) (string, error) {
+	if claims == nil {
+		return "", fmt.Errorf("claims cannot be nil")
+	}
+	if claims.IssuedAt == nil {
+		return "", fmt.Errorf("claims.IssuedAt cannot be nil")
+	}
+	return c.generateTokenWithIssuedAt(
+		claims.User, claims.Groups, claims.UID, claims.Extra,
+		claims.Path, claims.Domain, claims.TokenType, false, claims.Scope, claims.IssuedAt.Time, c.expiration,
+	)
+}
+
+// IsValidationOnlyKey reports whether kid is a key that validates tokens but never signs
+func (s *StandardSigner) IsValidationOnlyKey(kid string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.validationOnly[kid]
+}
+
+// ValidateToken validates and parses the token
+// Requires kid header and validates using the corresponding key
+func (s *StandardSigner) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := s.validateToken(tokenString)
+	var kid string
+	if claims != nil {
+		kid = claims.KeyID
+	} else {
+		kid = tokenKid(tokenString)
+	}
+	// Only count loaded kids, so forged kid headers cannot grow the counters
+	if s.IsKnownKid(kid) {
+		s.usage.recordValidation(kid, err)
+	}
+	return claims, err
+}
+
+// IsKnownKid reports whether kid is a loaded key, so callers caching decisions
+// about a token can drop them once its signing key is pruned
+func (s *StandardSigner) IsKnownKid(kid string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.signingKeys[kid]
+	return ok
+}
+
+// KeyUsage returns the sign and validation counts of each loaded key
+func (s *StandardSigner) KeyUsage() map[string]KeyUsage {
+	s.mu.RLock()
+	kids := make([]string, 0, len(s.signingKeys))
+	for kid := range s.signingKeys {
+		kids = append(kids, kid)
+	}
+	s.mu.RUnlock()
+	return s.usage.report(kids)
+}
+
+// validateToken parses and checks the token; ValidateToken adds usage accounting
+func (s *StandardSigner) validateToken(tokenString string) (*Claims, error) {
+	migrating := s.migration.Active(s.now())
+	checkManually := migrating || s.claimFallback != nil || len(s.audiences) > 1
+	claims, err := parseHMACToken(tokenString, s.algorithm, s.lookupKey, s.requireTyp, s.now, s.leeway,
+		issuerAudienceOptions(s.issuer, s.audiences[0], checkManually), s.logger)
 	if err != nil {
-		if errors.Is(err, jwt5.ErrTokenExpired) {
-			return nil, ErrTokenExpired
+		return nil, err
+	}
+
+	if checkManually {
+		s.claimFallback.apply(claims)
+		migration := s.migration
+		if !migrating {
+			migration = nil
 		}
-		if errors.Is(err, jwt5.ErrTokenSignatureInvalid) {
-			return nil, ErrInvalidSignature
+		if err := migration.check(claims, s.issuer, s.audiences); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 
-	if !token.Valid {
-		return nil, ErrInvalidToken
+	if err := s.subjectMatch.check(claims); err != nil {
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(*Claims)
-	if !ok {
-		return nil, ErrInvalidClaims
+	if err := checkRevoked(s.revocations, claims); err != nil {
+		return nil, err
 	}
 
 	return claims, nil
 }
 
+// lookupKey returns the key for kid, or nil if it is unknown
+func (s *StandardSigner) lookupKey(kid string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signingKeys[kid], nil
+}
+
+// checkTypHeader verifies the typ header is an accepted value. A missing typ is
+// tolerated unless requireTyp is set.
+func checkTypHeader(token *jwt5.Token, requireTyp bool) error {
+	raw, present := token.Header["typ"]
+	if !present {
+		if requireTyp {
+			return fmt.Errorf("%w: missing", ErrInvalidTypHeader)
+		}
+		return nil
+	}
+	typ, ok := raw.(string)
+	if !ok || (!strings.EqualFold(typ, TypHeaderJWT) && !strings.EqualFold(typ, TypHeaderAccessToken)) {
+		return fmt.Errorf("%w: %v", ErrInvalidTypHeader, raw)
+	}
+	return nil
+}
+
+// disallowedAlgorithm reports the alg header of a token that failed parsing
+// because it was presented with an algorithm other than the expected one.
+func disallowedAlgorithm(token *jwt5.Token, expected string) (string, bool) {
+	if token == nil {
+		return "", false
+	}
+	alg, ok := token.Header["alg"].(string)
+	if !ok || alg == expected {
+		return "", false
+	}
+	return alg, true
+}
+
 // UpdateKeys atomically updates the signing keys
 // This is called when the secret watcher detects changes
+// Weak key material is logged when a kid is first seen, or rejected with
+// ErrWeakKeyMaterial when WithRejectWeakKeys is set
 func (s *StandardSigner) UpdateKeys(signingKeys map[string][]byte, latestKid string) error {
 	if len(signingKeys) == 0 {
 		return fmt.Errorf("signingKeys cannot be empty")
@@ -219,15 +530,49 @@ func (s *StandardSigner) UpdateKeys(signingKeys map[string][]byte, latestKid str
 	if _, ok := signingKeys[latestKid]; !ok {
 		return fmt.Errorf("latestKid %s not found in signingKeys", latestKid)
 	}
+	if s.rejectWeakKeys {
+		for _, kid := range slices.Sorted(maps.Keys(signingKeys)) {
+			if err := validateKeyMaterial(kid, signingKeys[kid], s.minKeyBytes); err != nil {
+				return err
+			}
+		}
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Track timestamps for new keys
-	now := time.Now()
+	now := s.now()
 	newKeyAddedTimes := make(map[string]time.Time)
+	newValidationOnly := make(map[string]bool)
+
+	for kid, key := range signingKeys {
+		// Kids must be immutable: new bytes under a known kid break tokens signed with the old bytes
+		if oldKey, exists := s.signingKeys[kid]; exists && subtle.ConstantTimeCompare(oldKey, key) != 1 {
+			kidBytesChangedTotal.Inc()
+			s.logger.Error(fmt.Errorf("kid %s reused with different key bytes", kid),
+				"Signing key bytes changed for an existing kid; tokens signed with the previous bytes will fail validation",
+				"kid", kid,
+				"previousKey", FormatKeyForDisplay(oldKey),
+				"newKey", FormatKeyForDisplay(key))
+		}
+
+		// Keys too short for the algorithm are kept so existing tokens still validate, but never sign
+		if len(key) < s.minKeyBytes {
+			newValidationOnly[kid] = true
+			if !s.validationOnly[kid] {
+				s.logger.Error(fmt.Errorf("key for kid %s is %d bytes, %s requires at least %d",
+					kid, len(key), s.algorithm, s.minKeyBytes),
+					"Signing key too short; it will only be used to validate tokens",
+					"kid", kid)
+			}
+		} else if _, known := s.signingKeys[kid]; !known {
+			if err := validateKeyMaterial(kid, key, s.minKeyBytes); err != nil {
+				s.logger.Error(err, "Signing key looks like a placeholder; replace it with random bytes",
+					"kid", kid)
+			}
+		}
 
-	for kid := range signingKeys {
 		if oldTime, exists := s.keyAddedTimes[kid]; exists {
 			// Key already existed, preserve its original timestamp
 			newKeyAddedTimes[kid] = oldTime
@@ -239,12 +584,79 @@ func (s *StandardSigner) UpdateKeys(signingKeys map[string][]byte, latestKid str
 
 	s.signingKeys = signingKeys
 	s.keyAddedTimes = newKeyAddedTimes
+	s.usage.prune(func(kid string) bool { return signingKeys[kid] != nil })
+	s.validationOnly = newValidationOnly
 	s.latestKid = latestKid
+	s.keyGeneration.Add(1)
+
+	if s.forcedKid != "" && (signingKeys[s.forcedKid] == nil || newValidationOnly[s.forcedKid]) {
+		s.logger.Error(fmt.Errorf("forced signing kid %s is not a loaded signing key", s.forcedKid),
+			"Forced signing kid unavailable; signing with the latest key instead",
+			"forcedKid", s.forcedKid, "latestKid", latestKid)
+	}
 
 	return nil
 }
 
-// RetrieveInitialSecret loads the initial JWT signing keys from the Kubernetes secret.
+// RemoveExpiredKeys drops loaded keys added more than the max key retention ago, without
+// waiting for a secret update. It lets a pod whose secret watch has stopped delivering
+// events converge on the expected key window. The latest kid is never removed.
+// Returns the removed kids, sorted; does nothing when no max retention is set.
+func (s *StandardSigner) RemoveExpiredKeys() []string {
+	if s.maxKeyRetention <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-s.maxKeyRetention)
+	var removed []string
+	for kid, addedAt := range s.keyAddedTimes {
+		if kid == s.latestKid || !addedAt.Before(cutoff) {
+			continue
+		}
+		removed = append(removed, kid)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	slices.Sort(removed)
+
+	// Copy rather than mutate: signingKeys is the map the caller passed to UpdateKeys
+	signingKeys := maps.Clone(s.signingKeys)
+	keyAddedTimes := maps.Clone(s.keyAddedTimes)
+	validationOnly := maps.Clone(s.validationOnly)
+	for _, kid := range removed {
+		delete(signingKeys, kid)
+		delete(keyAddedTimes, kid)
+		delete(validationOnly, kid)
+	}
+
+	s.signingKeys = signingKeys
+	s.keyAddedTimes = keyAddedTimes
+	s.validationOnly = validationOnly
+	s.usage.prune(func(kid string) bool { return signingKeys[kid] != nil })
+	s.keyGeneration.Add(1)
+
+	s.logger.Info("Removed signing keys past the max key retention without a secret update",
+		"kids", removed, "maxKeyRetention", s.maxKeyRetention)
+	return removed
+}
+
+// KeyGeneration returns the number of times the keys have been updated
+func (s *StandardSigner) KeyGeneration() uint64 {
+	return s.keyGeneration.Load()
+}
+
+// AcceptedUntil returns the end of the issuer migration window for claims accepted only
+// through the previous issuer or audience, or the zero time otherwise
+func (s *StandardSigner) AcceptedUntil(claims *Claims) time.Time {
+	return s.migration.acceptedUntil(claims, s.issuer, s.audiences)
+}
+
+// RetrieveInitialSecret loads the initial JWT signing keys from the Kubernetes secret,
+// merged with the keys of any additional secrets.
 // This is called when the HTTP server starts to ensure keys are loaded before accepting requests.
 func (s *StandardSigner) RetrieveInitialSecret(
 	ctx context.Context,
@@ -252,20 +664,18 @@ func (s *StandardSigner) RetrieveInitialSecret(
 	secretName string,
 	namespace string,
 ) error {
-	// Get secret
-	secret := &corev1.Secret{}
-	err := runtimeClient.Get(ctx, types.NamespacedName{
-		Name:      secretName,
-		Namespace: namespace,
-	}, secret)
-	if err != nil {
-		return fmt.Errorf("failed to get JWT signing secret %s: %w", secretName, err)
-	}
+	var signingKeys map[string][]byte
+	var latestKid string
+	for _, name := range s.signingSecretNames(secretName) {
+		secret, err := getSigningSecret(ctx, runtimeClient, name, namespace)
+		if err != nil {
+			return err
+		}
 
-	// Parse signing keys from secret
-	signingKeys, latestKid, err := ParseSigningKeysFromSecret(secret)
-	if err != nil {
-		return fmt.Errorf("failed to parse signing keys from secret: %w", err)
+		signingKeys, latestKid, err = s.mergeSigningSecret(secret)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Update signer with initial keys
@@ -275,3 +685,24 @@ func (s *StandardSigner) RetrieveInitialSecret(
 
 	return nil
 }
+
+// getSigningSecret fetches the secret holding the JWT signing keys
+func getSigningSecret(
+	ctx context.Context,
+	runtimeClient client.Client,
+	secretName string,
+	namespace string,
+) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := runtimeClient.Get(ctx, types.NamespacedName{
+		Name:      secretName,
+		Namespace: namespace,
+	}, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get JWT signing secret %s: %w", secretName, err)
+	}
+	if err := CheckSecretNamespace(secret, namespace); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
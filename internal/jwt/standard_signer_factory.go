@@ -31,7 +31,8 @@ func (f *StandardSignerFactory) Signer() *StandardSigner {
 }
 
 // CreateSigner returns the shared StandardSigner for compatible access strategies.
-// Accepts "" (auto/default) and "k8s-native" handlers.
+// Accepts "" (auto/default) and "k8s-native" handlers. When the strategy sets a
+// TokenTTL shorter than the signer's expiration, issued tokens are capped to it.
 func (f *StandardSignerFactory) CreateSigner(accessStrategy *workspacev1alpha1.WorkspaceAccessStrategy) (Signer, error) {
 	if accessStrategy == nil {
 		return f.signer, nil
@@ -40,6 +41,9 @@ func (f *StandardSignerFactory) CreateSigner(accessStrategy *workspacev1alpha1.W
 	handler := accessStrategy.Spec.CreateConnectionHandler
 	switch handler {
 	case "", "k8s-native":
+		if ttl := accessStrategy.Spec.TokenTTL; ttl != nil {
+			return f.signer.WithExpirationCap(ttl.Duration), nil
+		}
 		return f.signer, nil
 	default:
 		return nil, fmt.Errorf("unsupported connection handler: %s", handler)
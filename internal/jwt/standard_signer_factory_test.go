@@ -12,6 +12,7 @@ import (
 	workspacev1alpha1 "github.com/jupyter-infra/jupyter-k8s/api/v1alpha1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func newTestStandardSignerFactory() (*StandardSignerFactory, *StandardSigner) {
@@ -124,3 +125,52 @@ func TestStandardSignerFactory_CreateSigner_GenerateValidateRoundTrip(t *testing
 	assert.Equal(t, "example.com", claims.Domain)
 	assert.Equal(t, TokenTypeBootstrap, claims.TokenType)
 }
+
+func TestStandardSignerFactory_CreateSigner_TokenTTLShortensExpiry(t *testing.T) {
+	factory, _ := newTestStandardSignerFactory()
+
+	accessStrategy := &workspacev1alpha1.WorkspaceAccessStrategy{
+		Spec: workspacev1alpha1.WorkspaceAccessStrategySpec{
+			CreateConnectionHandler: "k8s-native",
+			TokenTTL:                &metav1.Duration{Duration: time.Minute},
+		},
+	}
+
+	signer, err := factory.CreateSigner(accessStrategy)
+	require.NoError(t, err)
+
+	token, err := signer.GenerateToken("testuser", nil, "uid1", nil, "/path", "example.com", TokenTypeBootstrap, false)
+	require.NoError(t, err)
+
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	// nbf is backdated by the leeway
+	assert.Equal(t, time.Minute+DefaultLeeway, claims.ExpiresAt.Sub(claims.NotBefore.Time))
+
+	refreshed, err := signer.GenerateRefreshToken(claims)
+	require.NoError(t, err)
+	refreshedClaims, err := signer.ValidateToken(refreshed)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute+DefaultLeeway, refreshedClaims.ExpiresAt.Sub(refreshedClaims.NotBefore.Time))
+}
+
+func TestStandardSignerFactory_CreateSigner_TokenTTLLongerThanDefault(t *testing.T) {
+	factory, signer := newTestStandardSignerFactory()
+
+	accessStrategy := &workspacev1alpha1.WorkspaceAccessStrategy{
+		Spec: workspacev1alpha1.WorkspaceAccessStrategySpec{
+			TokenTTL: &metav1.Duration{Duration: time.Hour},
+		},
+	}
+
+	result, err := factory.CreateSigner(accessStrategy)
+	require.NoError(t, err)
+	assert.Equal(t, signer, result)
+
+	token, err := result.GenerateToken("testuser", nil, "uid1", nil, "/path", "example.com", TokenTypeBootstrap, false)
+	require.NoError(t, err)
+
+	claims, err := result.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute+DefaultLeeway, claims.ExpiresAt.Sub(claims.NotBefore.Time))
+}
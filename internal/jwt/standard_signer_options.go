@@ -0,0 +1,172 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// StandardSignerOption configures optional StandardSigner behavior
+type StandardSignerOption func(*StandardSigner)
+
+// WithClock overrides the time source used for cooloff tracking, token timestamps
+// and validation. Intended for tests; defaults to time.Now.
+func WithClock(now func() time.Time) StandardSignerOption {
+	return func(s *StandardSigner) {
+		if now != nil {
+			s.now = now
+		}
+	}
+}
+
+// WithLogger sets the logger used for security audit events such as rejected algorithms.
+// Defaults to a discarding logger.
+func WithLogger(logger logr.Logger) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.logger = logger
+	}
+}
+
+// WithAlgorithm sets the HMAC algorithm used to sign and validate tokens: HS256, HS384 or HS512.
+// Defaults to HS384. Keys shorter than the algorithm's RFC 7518 minimum are only used for validation.
+func WithAlgorithm(algorithm string) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.algorithm = algorithm
+	}
+}
+
+// WithLeeway sets the clock skew tolerated on the exp and nbf claims, and by which nbf
+// is backdated on new tokens. Defaults to DefaultLeeway.
+func WithLeeway(leeway time.Duration) StandardSignerOption {
+	return func(s *StandardSigner) {
+		if leeway >= 0 {
+			s.leeway = leeway
+		}
+	}
+}
+
+// WithRevocationStore sets the store consulted by ValidateToken; tokens whose jti is
+// revoked fail with ErrTokenRevoked. Defaults to no revocation checks.
+func WithRevocationStore(store RevocationStore) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.revocations = store
+	}
+}
+
+// WithIssuerMigration accepts the previous issuer and audience until the migration
+// window elapses, so renaming them does not invalidate live sessions.
+// Defaults to accepting only the configured values.
+func WithIssuerMigration(migration IssuerMigration) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.migration = &migration
+	}
+}
+
+// WithAdditionalAudiences sets further audiences on generated tokens besides the one
+// passed to NewStandardSigner, and accepts tokens carrying any of them.
+// Defaults to the single constructor audience.
+func WithAdditionalAudiences(audiences ...string) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.audiences = appendAudiences(s.audiences, audiences)
+	}
+}
+
+// WithClaimFallback assumes the given issuer and audience for tokens that omit them.
+// Only intended for trusted integrations; defaults to requiring both claims.
+func WithClaimFallback(fallback ClaimFallback) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.claimFallback = &fallback
+	}
+}
+
+// WithSubjectMatch rejects tokens whose sub claim differs from the User claim.
+// Defaults to no comparison.
+func WithSubjectMatch(match SubjectMatch) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.subjectMatch = &match
+	}
+}
+
+// WithKeyPrefixes reads signing keys stored under any of prefixes, so keys written
+// under a new prefix and keys under the old one both load while migrating.
+// Defaults to KeyPrefix.
+func WithKeyPrefixes(prefixes ...string) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.keyPrefixes = prefixes
+	}
+}
+
+// WithKeyRetention warns and counts in jwt_tokens_outliving_signing_key_total when a
+// token is issued with an expiration after its signing key is expected to be pruned.
+// Defaults to no check.
+func WithKeyRetention(retention KeyRetention) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.retention = newRetentionWarner(retention)
+	}
+}
+
+// WithMaxKeyRetention sets how long a key may stay loaded before RemoveExpiredKeys
+// drops it, measured from when this signer first saw it. Defaults to no limit.
+func WithMaxKeyRetention(maxRetention time.Duration) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.maxKeyRetention = maxRetention
+	}
+}
+
+// WithRejectWeakKeys makes UpdateKeys, and so RetrieveInitialSecret, fail with
+// ErrWeakKeyMaterial when a key is too short for the algorithm, all zeros or a repeated
+// short pattern. Defaults to false: such keys are only logged.
+func WithRejectWeakKeys(reject bool) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.rejectWeakKeys = reject
+	}
+}
+
+// WithForcedSigningKid signs with kid instead of the latest key whenever kid is loaded,
+// long enough for the algorithm and past its cooloff, e.g. to pin a pod to a known-good
+// key during an incident. Otherwise the latest key signs as usual. Defaults to no kid.
+func WithForcedSigningKid(kid string) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.forcedKid = kid
+	}
+}
+
+// WithAdditionalSecrets merges the keys of the named secrets, in the signer's namespace,
+// with those of its primary secret, e.g. to keep archived keys in a separate secret.
+// They are watched like the primary one and only read. The latest kid is the newest in
+// any secret. Defaults to the primary secret only.
+func WithAdditionalSecrets(names ...string) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.additionalSecrets = names
+	}
+}
+
+// WithKeyEncoding sets how key values in the secret are encoded: KeyEncodingRaw,
+// KeyEncodingBase64 or KeyEncodingHex. Values are decoded before use, and the minimum
+// key lengths apply to the decoded bytes. Defaults to KeyEncodingRaw.
+func WithKeyEncoding(encoding string) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.keyEncoding = encoding
+	}
+}
+
+// WithScope sets the space-delimited scope claim on generated tokens.
+// Refreshed tokens keep the scope of the token they replace. Defaults to no scope.
+func WithScope(scope string) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.scope = scope
+	}
+}
+
+// WithRequireTypHeader controls whether tokens without a typ header are rejected.
+// Defaults to false so tokens from issuers that omit typ are still accepted.
+func WithRequireTypHeader(require bool) StandardSignerOption {
+	return func(s *StandardSigner) {
+		s.requireTyp = require
+	}
+}
@@ -7,17 +7,24 @@ package jwt
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr/funcr"
 	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 const testUser = "testuser"
@@ -35,7 +42,7 @@ func createTestSigner(key, issuer, audience string, expiration time.Duration) *S
 }
 
 func TestStandardSigner_GenerateValidateRoundtrip(t *testing.T) {
-	signer := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "test-audience", time.Hour)
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
 
 	// Generate token
 	token, err := signer.GenerateToken(testUser, []string{"group1", "group2"}, "uid123", nil, "/path", "domain.com", TokenTypeSession, false)
@@ -71,7 +78,7 @@ func TestStandardSigner_GenerateValidateRoundtrip(t *testing.T) {
 }
 
 func TestStandardSigner_ValidateToken_ExpiredToken(t *testing.T) {
-	signer := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "test-audience", -time.Hour) // Negative expiration
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", -time.Hour) // Negative expiration
 
 	// Generate expired token
 	token, err := signer.GenerateToken(testUser, []string{}, "uid", nil, "", "", "", false)
@@ -90,8 +97,8 @@ func TestStandardSigner_ValidateToken_ExpiredToken(t *testing.T) {
 }
 
 func TestStandardSigner_ValidateToken_InvalidSignature(t *testing.T) {
-	signer1 := createTestSigner("key1-32-characters-long-enough", "test-issuer", "test-audience", time.Hour)
-	signer2 := createTestSigner("key2-32-characters-long-enough", "test-issuer", "test-audience", time.Hour)
+	signer1 := createTestSigner("key1-48-bytes-or-more-for-hs384-signing-long-enough", "test-issuer", "test-audience", time.Hour)
+	signer2 := createTestSigner("key2-48-bytes-or-more-for-hs384-signing-long-enough", "test-issuer", "test-audience", time.Hour)
 
 	// Generate token with signer1
 	token, err := signer1.GenerateToken(testUser, []string{}, "uid", nil, "", "", "", false)
@@ -110,8 +117,8 @@ func TestStandardSigner_ValidateToken_InvalidSignature(t *testing.T) {
 }
 
 func TestStandardSigner_ValidateToken_WrongIssuer(t *testing.T) {
-	signer1 := createTestSigner("test-signing-key-32-characters-long", "issuer1", "test-audience", time.Hour)
-	signer2 := createTestSigner("test-signing-key-32-characters-long", "issuer2", "test-audience", time.Hour)
+	signer1 := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "issuer1", "test-audience", time.Hour)
+	signer2 := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "issuer2", "test-audience", time.Hour)
 
 	// Generate token with signer1
 	token, err := signer1.GenerateToken(testUser, []string{}, "uid", nil, "", "", "", false)
@@ -125,14 +132,14 @@ func TestStandardSigner_ValidateToken_WrongIssuer(t *testing.T) {
 		t.Fatal("Expected error for wrong issuer")
 	}
 	// Should be wrapped ErrInvalidToken
-	if !strings.Contains(err.Error(), "invalid token") {
-		t.Errorf("Expected error containing 'invalid token', got %v", err)
+	if !errors.Is(err, ErrInvalidToken) || !errors.Is(err, ErrWrongIssuer) {
+		t.Errorf("Expected ErrInvalidToken wrapping ErrWrongIssuer, got %v", err)
 	}
 }
 
 func TestStandardSigner_ValidateToken_WrongAudience(t *testing.T) {
-	signer1 := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "audience1", time.Hour)
-	signer2 := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "audience2", time.Hour)
+	signer1 := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "audience1", time.Hour)
+	signer2 := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "audience2", time.Hour)
 
 	// Generate token with signer1
 	token, err := signer1.GenerateToken(testUser, []string{}, "uid", nil, "", "", "", false)
@@ -146,13 +153,13 @@ func TestStandardSigner_ValidateToken_WrongAudience(t *testing.T) {
 		t.Fatal("Expected error for wrong audience")
 	}
 	// Should be wrapped ErrInvalidToken
-	if !strings.Contains(err.Error(), "invalid token") {
-		t.Errorf("Expected error containing 'invalid token', got %v", err)
+	if !errors.Is(err, ErrInvalidToken) || !errors.Is(err, ErrWrongAudience) {
+		t.Errorf("Expected ErrInvalidToken wrapping ErrWrongAudience, got %v", err)
 	}
 }
 
 func TestStandardSigner_ValidateToken_InvalidFormat(t *testing.T) {
-	signer := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "test-audience", time.Hour)
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
 
 	// Try to validate malformed token
 	_, err := signer.ValidateToken("not.a.jwt")
@@ -182,7 +189,7 @@ func TestStandardSigner_ValidateToken_WrongSigningMethod(t *testing.T) {
 	token := jwt5.NewWithClaims(jwt5.SigningMethodRS256, claims)
 	tokenString, _ := token.SignedString([]byte("fake-key")) // This will create invalid token
 
-	signer := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "test-audience", time.Hour)
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
 
 	_, err := signer.ValidateToken(tokenString)
 	if err == nil {
@@ -191,7 +198,7 @@ func TestStandardSigner_ValidateToken_WrongSigningMethod(t *testing.T) {
 }
 
 func TestStandardSigner_ValidateToken_EmptyToken(t *testing.T) {
-	signer := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "test-audience", time.Hour)
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
 
 	_, err := signer.ValidateToken("")
 	if err == nil {
@@ -205,9 +212,9 @@ func TestStandardSigner_ValidateToken_EmptyToken(t *testing.T) {
 func TestStandardSigner_MultipleKeys_Validation(t *testing.T) {
 	// Create signer with multiple keys
 	signingKeys := map[string][]byte{
-		"1000": []byte("key1-32-characters-long-enough"),
-		"2000": []byte("key2-32-characters-long-enough"),
-		"3000": []byte("key3-32-characters-long-enough"),
+		"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+		"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
+		"3000": []byte("key3-48-bytes-or-more-for-hs384-signing-long-enough"),
 	}
 	latestKid := "3000"
 	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
@@ -233,7 +240,7 @@ func TestStandardSigner_MultipleKeys_Validation(t *testing.T) {
 func TestStandardSigner_UpdateKeys_HotReload(t *testing.T) {
 	// Create initial signer with one key
 	initialKeys := map[string][]byte{
-		"1000": []byte("initial-key-32-characters-long"),
+		"1000": []byte("initial-key-48-bytes-or-more-for-hs384-signing-long"),
 	}
 	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 	_ = signer.UpdateKeys(initialKeys, "1000")
@@ -246,8 +253,8 @@ func TestStandardSigner_UpdateKeys_HotReload(t *testing.T) {
 
 	// Update keys to include old and new keys
 	updatedKeys := map[string][]byte{
-		"1000": []byte("initial-key-32-characters-long"),
-		"2000": []byte("new-key-32-characters-long-here"),
+		"1000": []byte("initial-key-48-bytes-or-more-for-hs384-signing-long"),
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
 	}
 	if err := signer.UpdateKeys(updatedKeys, "2000"); err != nil {
 		t.Fatalf("Failed to update keys: %v", err)
@@ -280,8 +287,8 @@ func TestStandardSigner_UpdateKeys_HotReload(t *testing.T) {
 func TestStandardSigner_UpdateKeys_KeyRemoval(t *testing.T) {
 	// Create signer with two keys
 	initialKeys := map[string][]byte{
-		"1000": []byte("old-key-32-characters-long-here"),
-		"2000": []byte("new-key-32-characters-long-here"),
+		"1000": []byte("old-key-48-bytes-or-more-for-hs384-signing-long-here"),
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
 	}
 	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 	_ = signer.UpdateKeys(initialKeys, "2000")
@@ -289,7 +296,7 @@ func TestStandardSigner_UpdateKeys_KeyRemoval(t *testing.T) {
 	// Generate token with old key manually by creating signer with only old key
 	oldKeySigner := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 	_ = oldKeySigner.UpdateKeys(
-		map[string][]byte{"1000": []byte("old-key-32-characters-long-here")},
+		map[string][]byte{"1000": []byte("old-key-48-bytes-or-more-for-hs384-signing-long-here")},
 		"1000",
 	)
 	oldToken, err := oldKeySigner.GenerateToken(testUser, []string{}, "uid", nil, "", "", "", false)
@@ -305,7 +312,7 @@ func TestStandardSigner_UpdateKeys_KeyRemoval(t *testing.T) {
 
 	// Update keys to remove old key
 	updatedKeys := map[string][]byte{
-		"2000": []byte("new-key-32-characters-long-here"),
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
 	}
 	if err := signer.UpdateKeys(updatedKeys, "2000"); err != nil {
 		t.Fatalf("Failed to update keys: %v", err)
@@ -316,14 +323,14 @@ func TestStandardSigner_UpdateKeys_KeyRemoval(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error validating old token after key removal")
 	}
-	if !strings.Contains(err.Error(), "unknown key ID") {
-		t.Errorf("Expected error about unknown key ID, got %v", err)
+	if !errors.Is(err, ErrUnknownKid) {
+		t.Errorf("Expected ErrUnknownKid, got %v", err)
 	}
 }
 
 func TestStandardSigner_ValidateToken_MissingKidHeader(t *testing.T) {
 	signingKeys := map[string][]byte{
-		"1000": []byte("test-signing-key-32-characters-long"),
+		"1000": []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long"),
 	}
 	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 	_ = signer.UpdateKeys(signingKeys, "1000")
@@ -342,7 +349,7 @@ func TestStandardSigner_ValidateToken_MissingKidHeader(t *testing.T) {
 
 	// Create token without kid header
 	token := jwt5.NewWithClaims(jwt5.SigningMethodHS384, claims)
-	tokenString, err := token.SignedString([]byte("test-signing-key-32-characters-long"))
+	tokenString, err := token.SignedString([]byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long"))
 	if err != nil {
 		t.Fatalf("Failed to create test token: %v", err)
 	}
@@ -352,15 +359,18 @@ func TestStandardSigner_ValidateToken_MissingKidHeader(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for missing kid header")
 	}
-	if !strings.Contains(err.Error(), "kid") {
-		t.Errorf("Expected error about missing kid, got: %v", err)
+	if !errors.Is(err, ErrMissingKid) {
+		t.Errorf("Expected ErrMissingKid, got: %v", err)
+	}
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrMissingKid to be wrapped in ErrInvalidToken, got: %v", err)
 	}
 }
 
 func TestStandardSigner_ValidateToken_UnknownKid(t *testing.T) {
 	signingKeys := map[string][]byte{
-		"1000": []byte("test-signing-key-32-characters-long"),
-		"2000": []byte("another-key-32-characters-long-1"),
+		"1000": []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long"),
+		"2000": []byte("another-key-48-bytes-or-more-for-hs384-signing-long-1"),
 	}
 	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 	_ = signer.UpdateKeys(signingKeys, "2000")
@@ -368,7 +378,7 @@ func TestStandardSigner_ValidateToken_UnknownKid(t *testing.T) {
 	// Create a signer with a different kid
 	otherSigner := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 	_ = otherSigner.UpdateKeys(
-		map[string][]byte{"9999": []byte("unknown-key-32-characters-long-1")},
+		map[string][]byte{"9999": []byte("unknown-key-48-bytes-or-more-for-hs384-signing-long-1")},
 		"9999",
 	)
 
@@ -383,14 +393,17 @@ func TestStandardSigner_ValidateToken_UnknownKid(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for unknown kid")
 	}
-	if !strings.Contains(err.Error(), "unknown key ID") {
-		t.Errorf("Expected error about unknown key ID, got: %v", err)
+	if !errors.Is(err, ErrUnknownKid) {
+		t.Errorf("Expected ErrUnknownKid, got: %v", err)
+	}
+	if errors.Is(err, ErrMissingKid) {
+		t.Errorf("Expected an unknown kid not to match ErrMissingKid, got: %v", err)
 	}
 }
 
 func TestStandardSigner_HS384Algorithm(t *testing.T) {
 	signingKeys := map[string][]byte{
-		"1000": []byte("test-signing-key-32-characters-long"),
+		"1000": []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long"),
 	}
 	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 	_ = signer.UpdateKeys(signingKeys, "1000")
@@ -415,11 +428,11 @@ func TestStandardSigner_HS384Algorithm(t *testing.T) {
 func TestStandardSigner_UpdateKeys_LatestKidNotInMap(t *testing.T) {
 	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 	_ = signer.UpdateKeys(map[string][]byte{
-		"1000": []byte("key-32-characters-long-enough-here"),
+		"1000": []byte("key-48-bytes-or-more-for-hs384-signing-long-enough-here"),
 	}, "1000")
 
 	err := signer.UpdateKeys(map[string][]byte{
-		"1000": []byte("key-32-characters-long-enough-here"),
+		"1000": []byte("key-48-bytes-or-more-for-hs384-signing-long-enough-here"),
 	}, "9999") // latestKid not in map
 
 	if err == nil {
@@ -443,10 +456,88 @@ func TestStandardSigner_UpdateKeys_EmptyKeys(t *testing.T) {
 	}
 }
 
+func TestStandardSigner_CooloffBlockedMetrics(t *testing.T) {
+	now := time.Now()
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 90*time.Second,
+		WithClock(func() time.Time { return now }))
+	assert.Equal(t, 90.0, testutil.ToFloat64(newKeyUseDelaySeconds))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+	}, "1000"))
+
+	before := testutil.ToFloat64(cooloffBlockedTotal)
+	_, err := signer.GenerateToken(testUser, nil, "uid", nil, "/path", "", TokenTypeSession, false)
+	require.Error(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(cooloffBlockedTotal))
+
+	now = now.Add(90 * time.Second)
+	_, err = signer.GenerateToken(testUser, nil, "uid", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(cooloffBlockedTotal))
+}
+
+func TestStandardSigner_ForcedSigningKid(t *testing.T) {
+	signingKeys := map[string][]byte{
+		"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+		"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
+	}
+	signingKid := func(t *testing.T, signer *StandardSigner) string {
+		t.Helper()
+		token, err := signer.GenerateToken(testUser, nil, "uid", nil, "/path", "", TokenTypeSession, false)
+		require.NoError(t, err)
+		claims, err := signer.ValidateToken(token)
+		require.NoError(t, err)
+		return claims.KeyID
+	}
+
+	t.Run("present", func(t *testing.T) {
+		var logs []string
+		logger := funcr.New(func(prefix, args string) {
+			logs = append(logs, args)
+		}, funcr.Options{})
+		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+			WithLogger(logger), WithForcedSigningKid("1000"))
+		require.Len(t, logs, 1)
+		assert.Contains(t, logs[0], "WARNING: forced signing kid is active")
+
+		require.NoError(t, signer.UpdateKeys(signingKeys, "2000"))
+		assert.Equal(t, "1000", signingKid(t, signer))
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		var logs []string
+		logger := funcr.New(func(prefix, args string) {
+			logs = append(logs, args)
+		}, funcr.Options{})
+		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+			WithLogger(logger), WithForcedSigningKid("500"))
+
+		require.NoError(t, signer.UpdateKeys(signingKeys, "2000"))
+		assert.Equal(t, "2000", signingKid(t, signer))
+		require.Len(t, logs, 2)
+		assert.Contains(t, logs[1], "forced signing kid 500 is not a loaded signing key")
+	})
+
+	t.Run("within cooloff", func(t *testing.T) {
+		now := time.Now()
+		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, time.Minute,
+			WithClock(func() time.Time { return now }), WithForcedSigningKid("1000"))
+		require.NoError(t, signer.UpdateKeys(map[string][]byte{"2000": signingKeys["2000"]}, "2000"))
+
+		// The forced kid is added later, and is ignored until its cooloff has passed
+		now = now.Add(time.Minute)
+		require.NoError(t, signer.UpdateKeys(signingKeys, "2000"))
+		assert.Equal(t, "2000", signingKid(t, signer))
+
+		now = now.Add(time.Minute)
+		assert.Equal(t, "1000", signingKid(t, signer))
+	})
+}
+
 func TestStandardSigner_CoolOffKeySelection(t *testing.T) {
 	t.Run("no keys beyond cooloff returns error", func(t *testing.T) {
 		signingKeys := map[string][]byte{
-			"1000": []byte("key1-32-characters-long-enough"),
+			"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
 		}
 		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 5*time.Second)
 		_ = signer.UpdateKeys(signingKeys, "1000")
@@ -459,6 +550,10 @@ func TestStandardSigner_CoolOffKeySelection(t *testing.T) {
 		}
 		signer.mu.Unlock()
 
+		if signer.HasUsableSigningKey() {
+			t.Error("Expected no usable signing key while all keys are within cooloff")
+		}
+
 		// Should fail to generate token since all keys are within cooloff
 		_, err := signer.GenerateToken(testUser, []string{}, "uid", nil, "", "", "", false)
 		if err == nil {
@@ -471,8 +566,8 @@ func TestStandardSigner_CoolOffKeySelection(t *testing.T) {
 
 	t.Run("one key beyond cooloff is used", func(t *testing.T) {
 		signingKeys := map[string][]byte{
-			"1000": []byte("key1-32-characters-long-enough"),
-			"2000": []byte("key2-32-characters-long-enough"),
+			"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+			"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
 		}
 		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 2*time.Second)
 		_ = signer.UpdateKeys(signingKeys, "2000")
@@ -485,6 +580,10 @@ func TestStandardSigner_CoolOffKeySelection(t *testing.T) {
 		}
 		signer.mu.Unlock()
 
+		if !signer.HasUsableSigningKey() {
+			t.Error("Expected a usable signing key once one key is beyond cooloff")
+		}
+
 		token, err := signer.GenerateToken(testUser, []string{}, "uid", nil, "", "", "", false)
 		if err != nil {
 			t.Fatalf("Expected token generation to succeed, got error: %v", err)
@@ -499,9 +598,9 @@ func TestStandardSigner_CoolOffKeySelection(t *testing.T) {
 
 	t.Run("multiple keys beyond cooloff returns latest", func(t *testing.T) {
 		signingKeys := map[string][]byte{
-			"1000": []byte("key1-32-characters-long-enough"),
-			"2000": []byte("key2-32-characters-long-enough"),
-			"3000": []byte("key3-32-characters-long-enough"),
+			"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+			"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
+			"3000": []byte("key3-48-bytes-or-more-for-hs384-signing-long-enough"),
 		}
 		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 2*time.Second)
 		_ = signer.UpdateKeys(signingKeys, "3000")
@@ -529,9 +628,9 @@ func TestStandardSigner_CoolOffKeySelection(t *testing.T) {
 
 	t.Run("zero cooloff period makes all keys immediately usable", func(t *testing.T) {
 		signingKeys := map[string][]byte{
-			"1000": []byte("key1-32-characters-long-enough"),
-			"2000": []byte("key2-32-characters-long-enough"),
-			"3000": []byte("key3-32-characters-long-enough"),
+			"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+			"2000": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
+			"3000": []byte("key3-48-bytes-or-more-for-hs384-signing-long-enough"),
 		}
 		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 		_ = signer.UpdateKeys(signingKeys, "3000")
@@ -559,9 +658,9 @@ func TestStandardSigner_CoolOffKeySelection(t *testing.T) {
 
 	t.Run("lexicographic ordering selects latest", func(t *testing.T) {
 		signingKeys := map[string][]byte{
-			"1000": []byte("key1-32-characters-long-enough"),
-			"1500": []byte("key2-32-characters-long-enough"),
-			"2000": []byte("key3-32-characters-long-enough"),
+			"1000": []byte("key1-48-bytes-or-more-for-hs384-signing-long-enough"),
+			"1500": []byte("key2-48-bytes-or-more-for-hs384-signing-long-enough"),
+			"2000": []byte("key3-48-bytes-or-more-for-hs384-signing-long-enough"),
 		}
 		signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 1*time.Second)
 		_ = signer.UpdateKeys(signingKeys, "2000")
@@ -588,7 +687,7 @@ func TestStandardSigner_CoolOffKeySelection(t *testing.T) {
 	})
 
 	t.Run("returns both kid and signing key correctly", func(t *testing.T) {
-		keyData := []byte("test-signing-key-32-characters-l")
+		keyData := []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-l")
 		signingKeys := map[string][]byte{
 			"1000": keyData,
 		}
@@ -622,7 +721,7 @@ func TestStandardSigner_CoolOffKeySelection(t *testing.T) {
 func TestStandardSigner_NewKeyUseDelay(t *testing.T) {
 	// Create signer with 2 second cooloff period
 	initialKeys := map[string][]byte{
-		"1000": []byte("initial-key-32-characters-long"),
+		"1000": []byte("initial-key-48-bytes-or-more-for-hs384-signing-long"),
 	}
 	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 2*time.Second)
 	_ = signer.UpdateKeys(initialKeys, "1000")
@@ -646,8 +745,8 @@ func TestStandardSigner_NewKeyUseDelay(t *testing.T) {
 
 	// Add a new key
 	updatedKeys := map[string][]byte{
-		"1000": []byte("initial-key-32-characters-long"),
-		"2000": []byte("new-key-32-characters-long-here"),
+		"1000": []byte("initial-key-48-bytes-or-more-for-hs384-signing-long"),
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
 	}
 	if err := signer.UpdateKeys(updatedKeys, "2000"); err != nil {
 		t.Fatalf("Failed to update keys: %v", err)
@@ -692,7 +791,7 @@ func TestStandardSigner_NewKeyUseDelay(t *testing.T) {
 
 func TestStandardSigner_ConcurrentAccess(t *testing.T) {
 	signingKeys := map[string][]byte{
-		"1000": []byte("test-signing-key-32-characters-long"),
+		"1000": []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long"),
 	}
 	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
 	_ = signer.UpdateKeys(signingKeys, "1000")
@@ -727,8 +826,8 @@ func TestStandardSigner_ConcurrentAccess(t *testing.T) {
 		// Concurrent key updates
 		go func() {
 			newKeys := map[string][]byte{
-				"1000": []byte("test-signing-key-32-characters-long"),
-				"2000": []byte("new-key-32-characters-long-here1"),
+				"1000": []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long"),
+				"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here1"),
 			}
 			if err := signer.UpdateKeys(newKeys, "2000"); err != nil {
 				t.Errorf("Failed to update keys: %v", err)
@@ -753,8 +852,8 @@ func TestStandardSigner_RetrieveInitialSecret_Success(t *testing.T) {
 			Namespace: "default",
 		},
 		Data: map[string][]byte{
-			"jwt-signing-key-1000": []byte("key-value-at-least-48-bytes-long-for-hs384-ok!!"),
-			"jwt-signing-key-2000": []byte("newer-key-at-least-48-bytes-long-for-hs384-ok!"),
+			"jwt-signing-key-1000": []byte("key-value-at-least-48-bytes-long-for-hs384-ok!!!"),
+			"jwt-signing-key-2000": []byte("newer-key-at-least-48-bytes-long-for-hs384-ok!!!"),
 		},
 	}
 
@@ -793,6 +892,44 @@ func TestStandardSigner_RetrieveInitialSecret_SecretNotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to get JWT signing secret")
 }
 
+func TestStandardSigner_RetrieveInitialSecret_NamespaceMismatch(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jwt-secret",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": []byte("key-value-at-least-48-bytes-long-for-hs384-ok!!!"),
+		},
+	}
+
+	// A client that returns a same-named secret from another namespace
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(secret).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if err := c.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				obj.SetNamespace("other")
+				return nil
+			},
+		}).
+		Build()
+
+	signer := NewStandardSigner("issuer", "audience", time.Hour, 0)
+
+	err := signer.RetrieveInitialSecret(context.Background(), fakeClient, "jwt-secret", "default")
+	assert.ErrorIs(t, err, ErrSecretNamespaceMismatch)
+
+	_, err = signer.GenerateToken("user", nil, "uid", nil, "/path", "domain", TokenTypeSession, false)
+	assert.Error(t, err, "keys from a misrouted secret must not be loaded")
+}
+
 func TestStandardSigner_RetrieveInitialSecret_NoSigningKeys(t *testing.T) {
 	scheme := runtime.NewScheme()
 	require.NoError(t, corev1.AddToScheme(scheme))
@@ -820,7 +957,7 @@ func TestStandardSigner_RetrieveInitialSecret_NoSigningKeys(t *testing.T) {
 }
 
 func TestGenerateToken_WithSkipRefreshTrue(t *testing.T) {
-	signer := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "test-audience", time.Hour)
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
 	token, err := signer.GenerateToken(testUser, []string{"group1"}, "uid123", nil, "/path", "domain.com", TokenTypeSession, true)
 	require.NoError(t, err)
 
@@ -829,8 +966,23 @@ func TestGenerateToken_WithSkipRefreshTrue(t *testing.T) {
 	assert.True(t, claims.SkipRefresh, "Expected SkipRefresh to be true")
 }
 
+func TestGenerateTokenContext_Cancelled(t *testing.T) {
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	token, err := signer.GenerateTokenContext(ctx, testUser, []string{"group1"}, "uid123", nil, "/path", "domain.com", TokenTypeSession, false)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, token)
+
+	token, err = signer.GenerateTokenContext(context.Background(), testUser, []string{"group1"}, "uid123", nil, "/path", "domain.com", TokenTypeSession, false)
+	require.NoError(t, err)
+	_, err = signer.ValidateToken(token)
+	require.NoError(t, err)
+}
+
 func TestGenerateToken_WithSkipRefreshFalse(t *testing.T) {
-	signer := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "test-audience", time.Hour)
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
 	token, err := signer.GenerateToken(testUser, []string{"group1"}, "uid123", nil, "/path", "domain.com", TokenTypeSession, false)
 	require.NoError(t, err)
 
@@ -840,7 +992,7 @@ func TestGenerateToken_WithSkipRefreshFalse(t *testing.T) {
 }
 
 func TestGenerateRefreshToken_PreservesIssuedAt(t *testing.T) {
-	signer := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "test-audience", time.Hour)
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
 
 	// Generate original token
 	originalToken, err := signer.GenerateToken(testUser, []string{"group1"}, "uid123", nil, "/path", "domain.com", TokenTypeSession, false)
@@ -869,8 +1021,470 @@ func TestGenerateRefreshToken_PreservesIssuedAt(t *testing.T) {
 }
 
 func TestGenerateRefreshToken_NilClaims(t *testing.T) {
-	signer := createTestSigner("test-signing-key-32-characters-long", "test-issuer", "test-audience", time.Hour)
+	signer := createTestSigner("test-signing-key-48-bytes-or-more-for-hs384-signing-long", "test-issuer", "test-audience", time.Hour)
 	_, err := signer.GenerateRefreshToken(nil)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "claims cannot be nil")
 }
+
+func TestStandardSigner_ValidateToken_AlgorithmNotAllowed(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	claims := &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			ExpiresAt: jwt5.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt5.NewNumericDate(now),
+			Issuer:    "test-issuer",
+			Audience:  []string{"test-audience"},
+		},
+		User: testUser,
+	}
+	hmacKey := []byte("test-signing-key-48-bytes-or-more-for-hs384-signing-long")
+
+	tests := []struct {
+		name   string
+		method jwt5.SigningMethod
+		key    any
+	}{
+		{name: "none", method: jwt5.SigningMethodNone, key: jwt5.UnsafeAllowNoneSignatureType},
+		{name: "RS256", method: jwt5.SigningMethodRS256, key: rsaKey},
+		{name: "HS256", method: jwt5.SigningMethodHS256, key: hmacKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs []string
+			logger := funcr.New(func(prefix, args string) {
+				logs = append(logs, args)
+			}, funcr.Options{})
+
+			signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithLogger(logger))
+			require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": hmacKey}, "1000"))
+
+			token := jwt5.NewWithClaims(tt.method, claims)
+			token.Header["kid"] = "1000"
+			tokenString, err := token.SignedString(tt.key)
+			require.NoError(t, err)
+
+			_, err = signer.ValidateToken(tokenString)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrAlgorithmNotAllowed)
+
+			require.Len(t, logs, 1)
+			assert.Contains(t, logs[0], `"event"="jwt_algorithm_not_allowed"`)
+			assert.Contains(t, logs[0], `"presentedAlg"="`+tt.method.Alg()+`"`)
+			assert.Contains(t, logs[0], `"expectedAlg"="HS384"`)
+		})
+	}
+}
+
+func TestStandardSigner_UpdateKeys_KidBytesChanged(t *testing.T) {
+	var logs []string
+	logger := funcr.New(func(prefix, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{})
+
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithLogger(logger))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("original-key-48-bytes-or-more-for-hs384-signing-long"),
+	}, "1000"))
+
+	before := testutil.ToFloat64(kidBytesChangedTotal)
+
+	// Reloading identical bytes is not a change
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("original-key-48-bytes-or-more-for-hs384-signing-long"),
+		"2000": []byte("another-key-48-bytes-or-more-for-hs384-signing-long1"),
+	}, "2000"))
+	assert.Empty(t, logs)
+	assert.Equal(t, before, testutil.ToFloat64(kidBytesChangedTotal))
+
+	// Reusing kid 1000 with different bytes triggers the warning and metric
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("replaced-key-48-bytes-or-more-for-hs384-signing-long"),
+		"2000": []byte("another-key-48-bytes-or-more-for-hs384-signing-long1"),
+	}, "2000"))
+	require.Len(t, logs, 1)
+	assert.Contains(t, logs[0], `"kid"="1000"`)
+	assert.Contains(t, logs[0], "kid 1000 reused with different key bytes")
+	assert.Equal(t, before+1, testutil.ToFloat64(kidBytesChangedTotal))
+}
+
+func TestStandardSigner_ValidateToken_TypHeader(t *testing.T) {
+	key := []byte("test-signing-key-at-least-48-bytes-long-for-hs384!")
+	now := time.Now().UTC()
+	claims := &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			ExpiresAt: jwt5.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt5.NewNumericDate(now),
+			Issuer:    "test-issuer",
+			Audience:  []string{"test-audience"},
+		},
+		User: testUser,
+	}
+
+	tests := []struct {
+		name       string
+		typ        any // nil removes the header
+		requireTyp bool
+		wantErr    bool
+	}{
+		{name: "JWT", typ: "JWT"},
+		{name: "lowercase jwt", typ: "jwt"},
+		{name: "access token", typ: "at+jwt"},
+		{name: "missing tolerated", typ: nil},
+		{name: "missing required", typ: nil, requireTyp: true, wantErr: true},
+		{name: "wrong typ", typ: "JOSE", wantErr: true},
+		{name: "non-string typ", typ: 42, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithRequireTypHeader(tt.requireTyp))
+			require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key}, "1000"))
+
+			token := jwt5.NewWithClaims(jwt5.SigningMethodHS384, claims)
+			token.Header["kid"] = "1000"
+			if tt.typ == nil {
+				delete(token.Header, "typ")
+			} else {
+				token.Header["typ"] = tt.typ
+			}
+			tokenString, err := token.SignedString(key)
+			require.NoError(t, err)
+
+			_, err = signer.ValidateToken(tokenString)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidTypHeader)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStandardSigner_GenerateToken_SetsTypHeader(t *testing.T) {
+	signer := createTestSigner("test-signing-key-at-least-48-bytes-long-for-hs384!", "test-issuer", "test-audience", time.Hour)
+
+	tokenString, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	token, _, err := jwt5.NewParser().ParseUnverified(tokenString, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, TypHeaderJWT, token.Header["typ"])
+}
+
+func TestStandardSigner_UpdateKeys_AppliesRemovalOfNewestKey(t *testing.T) {
+	newerKeys := map[string][]byte{
+		"1000": []byte("initial-key-48-bytes-or-more-for-hs384-signing-long"),
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
+	}
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	require.NoError(t, signer.UpdateKeys(newerKeys, "2000"))
+	compromised, err := signer.GenerateToken(testUser, nil, "uid", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	generation := signer.KeyGeneration()
+
+	// The newest key is withdrawn on purpose, e.g. because it leaked
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": newerKeys["1000"]}, "1000"))
+
+	assert.Greater(t, signer.KeyGeneration(), generation)
+	_, err = signer.ValidateToken(compromised)
+	assert.Error(t, err)
+
+	token, err := signer.GenerateToken(testUser, nil, "uid", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", claims.KeyID)
+}
+
+func TestStandardSigner_UpdateKeys_ShortKeyIsValidationOnly(t *testing.T) {
+	var logs []string
+	logger := funcr.New(func(prefix, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{})
+
+	validKey := []byte("test-signing-key-at-least-48-bytes-long-for-hs384!")
+	shortKey := []byte("short-manual-key-32-bytes-long!!")
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithLogger(logger))
+
+	// The short key is the newest, but must never be selected for signing
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": validKey, "2000": shortKey}, "2000"))
+	require.Len(t, logs, 1)
+	assert.Contains(t, logs[0], `"kid"="2000"`)
+	assert.Equal(t, []string{"2000"}, signer.Snapshot().ValidationOnlyKids)
+
+	tokenString, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+	require.NoError(t, err)
+	token, _, err := jwt5.NewParser().ParseUnverified(tokenString, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "1000", token.Header["kid"])
+
+	// A token previously signed with the short key still validates
+	now := time.Now().UTC()
+	legacy := jwt5.NewWithClaims(jwt5.SigningMethodHS384, &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			ExpiresAt: jwt5.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt5.NewNumericDate(now),
+			Issuer:    "test-issuer",
+			Audience:  []string{"test-audience"},
+		},
+		User: testUser,
+	})
+	legacy.Header["kid"] = "2000"
+	legacyString, err := legacy.SignedString(shortKey)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(legacyString)
+	require.NoError(t, err)
+	assert.Equal(t, testUser, claims.User)
+	assert.Equal(t, "2000", claims.KeyID)
+	assert.True(t, signer.IsValidationOnlyKey("2000"))
+	assert.False(t, signer.IsValidationOnlyKey("1000"))
+
+	// Reloading the same keys does not repeat the warning
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": validKey, "2000": shortKey}, "2000"))
+	assert.Len(t, logs, 1)
+}
+
+func TestStandardSigner_UpdateKeys_OnlyShortKeys(t *testing.T) {
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte("too-short")}, "1000"))
+
+	_, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+	assert.Error(t, err)
+}
+
+func TestStandardSigner_CoolOffKeySelection_NumericKids(t *testing.T) {
+	tests := []struct {
+		name     string
+		kids     []string
+		expected string
+	}{
+		{name: "legacy 9-digit kid is older", kids: []string{"999999999", "1700000000"}, expected: "1700000000"},
+		{name: "11-digit kid is newer", kids: []string{"9999999999", "10000000000"}, expected: "10000000000"},
+		{name: "non-numeric kid falls back to lexicographic", kids: []string{"1700000000", "manual"}, expected: "manual"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := make(map[string][]byte, len(tt.kids))
+			for _, kid := range tt.kids {
+				keys[kid] = []byte("signing-key-for-" + kid + "-at-least-48-bytes-long-hs384!")
+			}
+			signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+			require.NoError(t, signer.UpdateKeys(keys, tt.kids[len(tt.kids)-1]))
+
+			kid, key := signer.getLatestKidAndKeyWithCoolOff()
+			assert.Equal(t, tt.expected, kid)
+			assert.Equal(t, keys[tt.expected], key)
+		})
+	}
+}
+
+func TestKidIsNewer(t *testing.T) {
+	assert.True(t, KidIsNewer("1700000000", "999999999"))
+	assert.False(t, KidIsNewer("999999999", "1700000000"))
+	assert.False(t, KidIsNewer("1700000000", "1700000000"))
+	assert.True(t, KidIsNewer("b", "a"))
+	// Equal timestamps are ordered by kid
+	assert.True(t, KidIsNewer("1700000000", "01700000000"))
+	assert.False(t, KidIsNewer("01700000000", "1700000000"))
+}
+
+func TestStandardSigner_Algorithm(t *testing.T) {
+	key64 := []byte("test-signing-key-with-at-least-64-bytes-for-hs512-signing-ok-!!!")
+	require.Len(t, key64, 64)
+
+	for _, alg := range []string{AlgorithmHS256, AlgorithmHS384, AlgorithmHS512} {
+		t.Run(alg, func(t *testing.T) {
+			signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithAlgorithm(alg))
+			require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key64}, "1000"))
+			assert.Equal(t, alg, signer.Algorithm())
+
+			tokenString, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+			require.NoError(t, err)
+			token, _, err := jwt5.NewParser().ParseUnverified(tokenString, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, alg, token.Header["alg"])
+
+			claims, err := signer.ValidateToken(tokenString)
+			require.NoError(t, err)
+			assert.Equal(t, testUser, claims.User)
+		})
+	}
+}
+
+func TestStandardSigner_Algorithm_DefaultIsHS384(t *testing.T) {
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	assert.Equal(t, AlgorithmHS384, signer.Algorithm())
+}
+
+func TestStandardSigner_Algorithm_UnsupportedFallsBackToDefault(t *testing.T) {
+	var logs []string
+	logger := funcr.New(func(prefix, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{})
+
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithAlgorithm("none"), WithLogger(logger))
+	assert.Equal(t, DefaultHMACAlgorithm, signer.Algorithm())
+	assert.Len(t, logs, 1)
+	assert.Error(t, ValidateHMACAlgorithm("none"))
+	assert.Error(t, ValidateHMACAlgorithm("RS256"))
+}
+
+func TestStandardSigner_Algorithm_RejectsOtherHMACAlgorithms(t *testing.T) {
+	key64 := []byte("test-signing-key-with-at-least-64-bytes-for-hs512-signing-ok-!!!")
+	hs256 := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithAlgorithm(AlgorithmHS256))
+	hs512 := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithAlgorithm(AlgorithmHS512))
+	require.NoError(t, hs256.UpdateKeys(map[string][]byte{"1000": key64}, "1000"))
+	require.NoError(t, hs512.UpdateKeys(map[string][]byte{"1000": key64}, "1000"))
+
+	tokenString, err := hs256.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	_, err = hs512.ValidateToken(tokenString)
+	assert.ErrorIs(t, err, ErrAlgorithmNotAllowed)
+}
+
+func TestStandardSigner_Algorithm_MinimumKeyLength(t *testing.T) {
+	key48 := []byte("test-signing-key-48-bytes-or-more-for-hs384-sign")
+	require.Len(t, key48, 48)
+
+	tests := []struct {
+		alg            string
+		validationOnly bool
+	}{
+		{alg: AlgorithmHS256, validationOnly: false},
+		{alg: AlgorithmHS384, validationOnly: false},
+		{alg: AlgorithmHS512, validationOnly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, WithAlgorithm(tt.alg))
+			require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": key48}, "1000"))
+
+			_, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "example.com", TokenTypeSession, false)
+			if tt.validationOnly {
+				assert.Error(t, err, "a 48-byte key is below the HS512 minimum and must not sign")
+				assert.Equal(t, []string{"1000"}, signer.Snapshot().ValidationOnlyKids)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStandardSigner_RetrieveInitialSecret_MixedPrefixes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": []byte("test-signing-key-at-least-48-bytes-long-for-hs384!"),
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	ctx := context.Background()
+
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+		WithKeyPrefixes("jwt-key-v2-", KeyPrefix))
+	require.NoError(t, signer.RetrieveInitialSecret(ctx, fakeClient, "test-secret", "default"))
+	oldToken, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+
+	// The rotator starts writing keys under the new prefix
+	secret.Data["jwt-key-v2-2000"] = []byte("new-signing-key-at-least-48-bytes-long-for-hs384!")
+	require.NoError(t, fakeClient.Update(ctx, secret))
+	require.NoError(t, signer.RetrieveInitialSecret(ctx, fakeClient, "test-secret", "default"))
+
+	_, err = signer.ValidateToken(oldToken)
+	assert.NoError(t, err, "tokens signed under the old prefix still validate")
+
+	newToken, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	claims, err := signer.ValidateToken(newToken)
+	require.NoError(t, err)
+	assert.Equal(t, "2000", claims.KeyID)
+}
+
+func TestStandardSigner_IsKnownKid_RemovedByUpdateKeys(t *testing.T) {
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("old-key-48-bytes-or-more-for-hs384-signing-long-here"),
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
+	}, "2000"))
+	assert.True(t, signer.IsKnownKid("1000"))
+	assert.True(t, signer.IsKnownKid("2000"))
+
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
+		"3000": []byte("next-key-48-bytes-or-more-for-hs384-signing-long-here"),
+	}, "3000"))
+	assert.False(t, signer.IsKnownKid("1000"), "pruned kid should no longer be known")
+	assert.True(t, signer.IsKnownKid("2000"))
+	assert.True(t, signer.IsKnownKid("3000"))
+}
+
+func TestStandardSigner_RemoveExpiredKeys(t *testing.T) {
+	now := time.Now()
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+		WithClock(func() time.Time { return now }), WithMaxKeyRetention(time.Hour))
+	oldKey := []byte("old-key-48-bytes-or-more-for-hs384-signing-long-here")
+	midKey := []byte("mid-key-48-bytes-or-more-for-hs384-signing-long-here")
+	newKey := []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here")
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": oldKey}, "1000"))
+
+	now = now.Add(45 * time.Minute)
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": oldKey, "2000": midKey}, "2000"))
+	now = now.Add(10 * time.Minute)
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": oldKey, "2000": midKey, "3000": newKey}, "3000"))
+	generation := signer.KeyGeneration()
+
+	// Nothing has been loaded for longer than the max retention yet
+	assert.Empty(t, signer.RemoveExpiredKeys())
+
+	now = now.Add(10 * time.Minute)
+	assert.Equal(t, []string{"1000"}, signer.RemoveExpiredKeys())
+	assert.False(t, signer.IsKnownKid("1000"))
+	assert.True(t, signer.IsKnownKid("2000"))
+	assert.True(t, signer.IsKnownKid("3000"))
+	assert.Greater(t, signer.KeyGeneration(), generation)
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	_, err = signer.ValidateToken(token)
+	assert.NoError(t, err)
+}
+
+func TestStandardSigner_RemoveExpiredKeys_KeepsLatestKid(t *testing.T) {
+	now := time.Now()
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+		WithClock(func() time.Time { return now }), WithMaxKeyRetention(time.Hour))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("old-key-48-bytes-or-more-for-hs384-signing-long-here"),
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
+	}, "2000"))
+
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, []string{"1000"}, signer.RemoveExpiredKeys())
+	assert.True(t, signer.IsKnownKid("2000"), "latest kid must never be removed")
+	assert.Empty(t, signer.RemoveExpiredKeys())
+}
+
+func TestStandardSigner_RemoveExpiredKeys_Disabled(t *testing.T) {
+	now := time.Now()
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+		WithClock(func() time.Time { return now }))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{
+		"1000": []byte("old-key-48-bytes-or-more-for-hs384-signing-long-here"),
+		"2000": []byte("new-key-48-bytes-or-more-for-hs384-signing-long-here"),
+	}, "2000"))
+
+	now = now.Add(24 * time.Hour)
+	assert.Empty(t, signer.RemoveExpiredKeys())
+	assert.True(t, signer.IsKnownKid("1000"))
+}
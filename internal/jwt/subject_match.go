@@ -0,0 +1,33 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import "fmt"
+
+// SubjectMatch requires the sub claim to equal the User claim. Tokens issued here
+// always carry both with the same value, so a mismatch marks a malformed or forged token.
+type SubjectMatch struct {
+	// RequireSubject also rejects tokens that carry a User claim but no sub claim.
+	// When false, a missing subject is accepted.
+	RequireSubject bool
+}
+
+// check verifies the subject and user claims agree when both are present
+func (m *SubjectMatch) check(claims *Claims) error {
+	if m == nil || claims.User == "" {
+		return nil
+	}
+	if claims.Subject == "" {
+		if m.RequireSubject {
+			return fmt.Errorf("%w: missing subject", ErrSubjectMismatch)
+		}
+		return nil
+	}
+	if claims.Subject != claims.User {
+		return ErrSubjectMismatch
+	}
+	return nil
+}
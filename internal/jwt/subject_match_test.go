@@ -0,0 +1,104 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const subjectMatchTestKey = "test-signing-key-48-bytes-or-more-for-hs384-signing-long"
+
+// tokenWithSubject signs a token whose sub claim is set independently of its User claim
+func tokenWithSubject(t *testing.T, subject string, user string) string {
+	t.Helper()
+	token := jwt5.NewWithClaims(jwt5.SigningMethodHS384, &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			Issuer:    "test-issuer",
+			Audience:  jwt5.ClaimStrings{"test-audience"},
+			Subject:   subject,
+			ExpiresAt: jwt5.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		User: user,
+	})
+	token.Header["kid"] = "1000"
+	signed, err := token.SignedString([]byte(subjectMatchTestKey))
+	require.NoError(t, err)
+	return signed
+}
+
+func TestStandardSigner_SubjectMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		match     *SubjectMatch
+		subject   string
+		expectErr bool
+	}{
+		{name: "matching subject", match: &SubjectMatch{}, subject: testUser},
+		{name: "mismatched subject", match: &SubjectMatch{}, subject: "someone-else", expectErr: true},
+		{name: "absent subject accepted", match: &SubjectMatch{}, subject: ""},
+		{name: "absent subject required", match: &SubjectMatch{RequireSubject: true}, subject: "", expectErr: true},
+		{name: "mismatch ignored when disabled", match: nil, subject: "someone-else"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []StandardSignerOption
+			if tt.match != nil {
+				opts = append(opts, WithSubjectMatch(*tt.match))
+			}
+			signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0, opts...)
+			require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(subjectMatchTestKey)}, "1000"))
+
+			_, err := signer.ValidateToken(tokenWithSubject(t, tt.subject, testUser))
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrSubjectMismatch)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStandardSigner_SubjectMatch_OwnTokensPass(t *testing.T) {
+	signer := NewStandardSigner("test-issuer", "test-audience", time.Hour, 0,
+		WithSubjectMatch(SubjectMatch{RequireSubject: true}))
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(subjectMatchTestKey)}, "1000"))
+
+	token, err := signer.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession, false)
+	require.NoError(t, err)
+	_, err = signer.ValidateToken(token)
+	assert.NoError(t, err)
+}
+
+func TestAsymmetricSigner_SubjectMatch(t *testing.T) {
+	key := generateECKey(t)
+	signer, err := NewAsymmetricSigner(AlgorithmES256, "test-issuer", "test-audience", time.Hour, 0,
+		WithAsymmetricSubjectMatch(SubjectMatch{}))
+	require.NoError(t, err)
+	require.NoError(t, signer.UpdateKeys(map[string]crypto.Signer{"1000": key}, "1000"))
+
+	token := jwt5.NewWithClaims(jwt5.SigningMethodES256, &Claims{
+		RegisteredClaims: jwt5.RegisteredClaims{
+			Issuer:    "test-issuer",
+			Audience:  jwt5.ClaimStrings{"test-audience"},
+			Subject:   "someone-else",
+			ExpiresAt: jwt5.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		User: testUser,
+	})
+	token.Header["kid"] = "1000"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	_, err = signer.ValidateToken(signed)
+	assert.ErrorIs(t, err, ErrSubjectMismatch)
+}
@@ -27,6 +27,42 @@ var (
 	ErrInvalidSignature = errors.New("invalid token signature")
 	ErrInvalidClaims    = errors.New("invalid token claims")
 	ErrDomainMismatch   = errors.New("token domain mismatch")
+	// ErrAlgorithmNotAllowed is returned when a token's alg header is not the algorithm
+	// this verifier accepts (e.g. "none" or RS256 presented to an HMAC verifier).
+	ErrAlgorithmNotAllowed = errors.New("token signing algorithm not allowed")
+	// ErrInvalidTypHeader is returned when a token's typ header is missing (when required)
+	// or is not one of the accepted values.
+	ErrInvalidTypHeader = errors.New("invalid token typ header")
+	// ErrTokenRevoked is returned when a token's jti has been added to the revocation list
+	ErrTokenRevoked = errors.New("token revoked")
+	// ErrSecretNamespaceMismatch is returned when a fetched signing secret does not live
+	// in the namespace it was requested from
+	ErrSecretNamespaceMismatch = errors.New("secret namespace mismatch")
+	// ErrValidationOnlyKey is returned when a token signed by a validation-only key is
+	// presented for refresh. Such keys never sign, so the user must re-authenticate.
+	ErrValidationOnlyKey = errors.New("token signed by a validation-only key")
+	// ErrSubjectMismatch is returned when subject matching is enabled and a token's sub
+	// claim differs from its User claim, or is missing when a subject is required
+	ErrSubjectMismatch = errors.New("token subject does not match user")
+	// ErrRefreshNotAllowed is returned when refreshing a token marked SkipRefresh
+	ErrRefreshNotAllowed = errors.New("token is not refreshable")
+	// ErrSigningNotSupported is returned when a verify-only signer is asked to issue a token
+	ErrSigningNotSupported = errors.New("signing not supported in verify-only mode")
+
+	// Validation failures below are returned wrapped in ErrInvalidToken, so callers can
+	// match either the general or the specific error with errors.Is
+
+	// ErrMissingKid is returned when a token has no kid header, or it is not a string
+	ErrMissingKid = errors.New("missing or invalid kid in token header")
+	// ErrUnknownKid is returned when a token's kid names no loaded key
+	ErrUnknownKid = errors.New("unknown key ID")
+	// ErrWrongIssuer is returned when a token's iss claim is not an accepted issuer
+	ErrWrongIssuer = errors.New("token issuer not accepted")
+	// ErrWrongAudience is returned when a token's aud claim holds no accepted audience
+	ErrWrongAudience = errors.New("token audience not accepted")
+	// ErrWrongAlgorithm is an alias of ErrAlgorithmNotAllowed, named like the other
+	// validation errors
+	ErrWrongAlgorithm = ErrAlgorithmNotAllowed
 )
 
 // Claims represents the JWT claims for our auth token
@@ -40,4 +76,8 @@ type Claims struct {
 	Domain      string              `json:"Domain,omitempty"`
 	TokenType   string              `json:"TokenType,omitempty"`
 	SkipRefresh bool                `json:"SkipRefresh,omitempty"`
+	// Scope is the space-delimited list of scopes granted to the token (RFC 8693)
+	Scope string `json:"scope,omitempty"`
+	// KeyID is the kid header of the validated token; it is not part of the payload
+	KeyID string `json:"-"`
 }
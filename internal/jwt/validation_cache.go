@@ -0,0 +1,161 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"container/list"
+	"slices"
+	"sync"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+)
+
+// KeyGenerationProvider is implemented by signers that count key updates, so cached
+// validations can be dropped whenever the loaded keys change
+type KeyGenerationProvider interface {
+	// KeyGeneration increases every time the signer's keys are updated
+	KeyGeneration() uint64
+}
+
+// AcceptanceDeadlineProvider is implemented by signers that accept some tokens only
+// until a deadline before they expire, so cached validations do not outlive it
+type AcceptanceDeadlineProvider interface {
+	// AcceptedUntil returns when claims stop being accepted regardless of their expiry,
+	// or the zero time if only their expiry applies
+	AcceptedUntil(claims *Claims) time.Time
+}
+
+// validationCache is an LRU cache of validated tokens, keyed by the raw token string.
+// Entries expire with their token and are all dropped when the signer's key
+// generation changes, since a pruned key invalidates the tokens it signed.
+type validationCache struct {
+	maxEntries  int
+	revocations RevocationStore // consulted on every hit so revocations apply immediately
+	mu          sync.Mutex
+	generation  uint64
+	entries     map[string]*list.Element
+	order       *list.List // front is most recently used
+}
+
+// validationCacheEntry is the value stored in the LRU list
+type validationCacheEntry struct {
+	token     string
+	claims    Claims
+	expiresAt time.Time
+}
+
+func newValidationCache(maxEntries int, revocations RevocationStore) *validationCache {
+	return &validationCache{
+		maxEntries:  maxEntries,
+		revocations: revocations,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// get returns a copy of the cached claims for token, or nil on a miss
+func (c *validationCache) get(token string, generation uint64, now time.Time) (*Claims, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetIfStale(generation)
+	elem, ok := c.entries[token]
+	if !ok {
+		validationCacheRequestsTotal.WithLabelValues(validationCacheMiss).Inc()
+		return nil, nil
+	}
+	entry := elem.Value.(*validationCacheEntry)
+	if !now.Before(entry.expiresAt) {
+		c.remove(elem)
+		validationCacheRequestsTotal.WithLabelValues(validationCacheMiss).Inc()
+		return nil, nil
+	}
+
+	validationCacheRequestsTotal.WithLabelValues(validationCacheHit).Inc()
+	claims := cloneClaims(&entry.claims)
+	if err := checkRevoked(c.revocations, &claims); err != nil {
+		c.remove(elem)
+		return nil, err
+	}
+	c.order.MoveToFront(elem)
+	return &claims, nil
+}
+
+// put caches claims validated under generation until the token expires, or until
+// acceptedUntil when it is set and earlier
+func (c *validationCache) put(token string, claims *Claims, generation uint64, acceptedUntil time.Time) {
+	if claims == nil || claims.ExpiresAt == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Validated before a key update that another lookup already observed
+	if generation < c.generation {
+		return
+	}
+	c.resetIfStale(generation)
+	if elem, ok := c.entries[token]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+	expiresAt := claims.ExpiresAt.Time
+	if !acceptedUntil.IsZero() && acceptedUntil.Before(expiresAt) {
+		expiresAt = acceptedUntil
+	}
+	c.entries[token] = c.order.PushFront(&validationCacheEntry{
+		token:     token,
+		claims:    cloneClaims(claims),
+		expiresAt: expiresAt,
+	})
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// resetIfStale drops every entry once the keys have changed. Callers hold mu.
+func (c *validationCache) resetIfStale(generation uint64) {
+	if generation <= c.generation {
+		return
+	}
+	c.generation = generation
+	clear(c.entries)
+	c.order.Init()
+}
+
+// remove drops elem from the cache. Callers hold mu.
+func (c *validationCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*validationCacheEntry).token)
+}
+
+// cloneClaims copies claims along with their slices, maps and dates, so cached claims
+// share no memory with the callers they are returned to
+func cloneClaims(claims *Claims) Claims {
+	clone := *claims
+	clone.Audience = slices.Clone(claims.Audience)
+	clone.ExpiresAt = cloneNumericDate(claims.ExpiresAt)
+	clone.NotBefore = cloneNumericDate(claims.NotBefore)
+	clone.IssuedAt = cloneNumericDate(claims.IssuedAt)
+	clone.Groups = slices.Clone(claims.Groups)
+	if claims.Extra != nil {
+		clone.Extra = make(map[string][]string, len(claims.Extra))
+		for key, values := range claims.Extra {
+			clone.Extra[key] = slices.Clone(values)
+		}
+	}
+	return clone
+}
+
+func cloneNumericDate(date *jwt5.NumericDate) *jwt5.NumericDate {
+	if date == nil {
+		return nil
+	}
+	clone := *date
+	return &clone
+}
@@ -0,0 +1,183 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	jwt5 "github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validationCacheTestKey = "test-signing-key-48-bytes-or-more-for-hs384-signing-long"
+
+// countingSigner counts the validations that reach the underlying signer
+type countingSigner struct {
+	*StandardSigner
+	validations int
+}
+
+func (s *countingSigner) ValidateToken(tokenString string) (*Claims, error) {
+	s.validations++
+	return s.StandardSigner.ValidateToken(tokenString)
+}
+
+func newCachedManager(t *testing.T, maxEntries int, revocations RevocationStore) (*Manager, *countingSigner) {
+	t.Helper()
+	signer := &countingSigner{StandardSigner: NewStandardSigner("test-issuer", "test-audience", time.Hour, 0)}
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"1000": []byte(validationCacheTestKey)}, "1000"))
+	return NewManager(signer, true, 15*time.Minute, 12*time.Hour, WithValidationCache(maxEntries, revocations)), signer
+}
+
+func TestManager_ValidationCache_HitSkipsSigner(t *testing.T) {
+	manager, signer := newCachedManager(t, 10, nil)
+	token, err := manager.GenerateToken(testUser, []string{"group1"}, "uid", nil, "/path", "", TokenTypeSession)
+	require.NoError(t, err)
+
+	hits := testutil.ToFloat64(validationCacheRequestsTotal.WithLabelValues(validationCacheHit))
+	misses := testutil.ToFloat64(validationCacheRequestsTotal.WithLabelValues(validationCacheMiss))
+
+	first, err := manager.ValidateToken(token)
+	require.NoError(t, err)
+	second, err := manager.ValidateToken(token)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, signer.validations)
+	assert.Equal(t, first.User, second.User)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, 1.0, testutil.ToFloat64(validationCacheRequestsTotal.WithLabelValues(validationCacheHit))-hits)
+	assert.Equal(t, 1.0, testutil.ToFloat64(validationCacheRequestsTotal.WithLabelValues(validationCacheMiss))-misses)
+
+	// Callers get their own copy of the claims
+	second.User = "modified"
+	third, err := manager.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, testUser, third.User)
+}
+
+func TestManager_ValidationCache_InvalidatedByKeyUpdate(t *testing.T) {
+	manager, signer := newCachedManager(t, 10, nil)
+	token, err := manager.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession)
+	require.NoError(t, err)
+	_, err = manager.ValidateToken(token)
+	require.NoError(t, err)
+
+	// The key that signed the token is pruned
+	require.NoError(t, signer.UpdateKeys(map[string][]byte{"2000": []byte(validationCacheTestKey + "-new")}, "2000"))
+
+	_, err = manager.ValidateToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, 2, signer.validations)
+}
+
+func TestManager_ValidationCache_RevokedOnHit(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	manager, _ := newCachedManager(t, 10, store)
+	token, err := manager.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession)
+	require.NoError(t, err)
+	claims, err := manager.ValidateToken(token)
+	require.NoError(t, err)
+
+	store.Revoke(claims.ID, claims.ExpiresAt.Time)
+
+	_, err = manager.ValidateToken(token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestManager_ValidationCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	manager, signer := newCachedManager(t, 2, nil)
+	var tokens []string
+	for range 3 {
+		token, err := manager.GenerateToken(testUser, nil, "", nil, "/path", "", TokenTypeSession)
+		require.NoError(t, err)
+		tokens = append(tokens, token)
+	}
+
+	for _, token := range tokens[:2] {
+		_, err := manager.ValidateToken(token)
+		require.NoError(t, err)
+	}
+	// Touch the first token so the second is the least recently used
+	_, err := manager.ValidateToken(tokens[0])
+	require.NoError(t, err)
+	_, err = manager.ValidateToken(tokens[2])
+	require.NoError(t, err)
+	require.Equal(t, 3, signer.validations)
+
+	_, err = manager.ValidateToken(tokens[0])
+	require.NoError(t, err)
+	assert.Equal(t, 3, signer.validations, "first token should still be cached")
+
+	_, err = manager.ValidateToken(tokens[1])
+	require.NoError(t, err)
+	assert.Equal(t, 4, signer.validations, "second token should have been evicted")
+}
+
+func TestValidationCache_ExpiredEntryMisses(t *testing.T) {
+	cache := newValidationCache(10, nil)
+	now := time.Now()
+	claims := &Claims{User: testUser}
+	claims.ExpiresAt = jwt5.NewNumericDate(now.Add(time.Minute))
+
+	cache.put("token", claims, 0, time.Time{})
+	cached, err := cache.get("token", 0, now)
+	require.NoError(t, err)
+	require.NotNil(t, cached)
+
+	cached, err = cache.get("token", 0, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+}
+
+func TestValidationCache_ClaimsAreDeepCopied(t *testing.T) {
+	cache := newValidationCache(10, nil)
+	now := time.Now()
+	claims := &Claims{
+		User:   testUser,
+		Groups: []string{"group1"},
+		Extra:  map[string][]string{"team": {"a"}},
+	}
+	claims.Audience = jwt5.ClaimStrings{"test-audience"}
+	claims.ExpiresAt = jwt5.NewNumericDate(now.Add(time.Minute))
+
+	cache.put("token", claims, 0, time.Time{})
+	claims.Groups[0] = "mutated"
+	claims.Extra["team"][0] = "mutated"
+	claims.Audience[0] = "mutated"
+
+	cached, err := cache.get("token", 0, now)
+	require.NoError(t, err)
+	require.NotNil(t, cached)
+	cached.Groups[0] = "mutated"
+	cached.Extra["team"] = append(cached.Extra["team"][:0], "mutated")
+	cached.Audience[0] = "mutated"
+
+	fresh, err := cache.get("token", 0, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"group1"}, fresh.Groups)
+	assert.Equal(t, map[string][]string{"team": {"a"}}, fresh.Extra)
+	assert.Equal(t, jwt5.ClaimStrings{"test-audience"}, fresh.Audience)
+}
+
+func TestValidationCache_EntryCappedAtAcceptanceDeadline(t *testing.T) {
+	cache := newValidationCache(10, nil)
+	now := time.Now()
+	claims := &Claims{User: testUser}
+	claims.ExpiresAt = jwt5.NewNumericDate(now.Add(time.Hour))
+
+	// The token was accepted under an issuer migration ending before it expires
+	cache.put("token", claims, 0, now.Add(time.Minute))
+	cached, err := cache.get("token", 0, now)
+	require.NoError(t, err)
+	require.NotNil(t, cached)
+
+	cached, err = cache.get("token", 0, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+}
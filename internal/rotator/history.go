@@ -0,0 +1,102 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package rotator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RotationHistoryAnnotation holds a JSON array of recent rotations on the secret
+	RotationHistoryAnnotation = "workspace.jupyter.org/jwt-rotation-history"
+
+	// LastRotatedAnnotation holds the RFC3339 time of the most recent rotation
+	LastRotatedAnnotation = "workspace.jupyter.org/jwt-last-rotated"
+
+	// RotationCountAnnotation holds the number of rotations performed on the secret;
+	// signers use it to tell stale secret updates from newer ones
+	RotationCountAnnotation = jwt.RotationCountAnnotation
+
+	// RotationHistoryLimit is the maximum number of entries kept in the rotation history
+	RotationHistoryLimit = 10
+)
+
+// RotationRecord is a single entry of the rotation history annotation
+type RotationRecord struct {
+	RotatedAt string `json:"rotatedAt"`
+	AddedKid  string `json:"addedKid"`
+}
+
+// GetRotationHistory returns the rotation history recorded on the secret, oldest first
+func GetRotationHistory(secret *corev1.Secret) ([]RotationRecord, error) {
+	raw, ok := secret.Annotations[RotationHistoryAnnotation]
+	if !ok || raw == "" {
+		return []RotationRecord{}, nil
+	}
+
+	var history []RotationRecord
+	if err := json.Unmarshal([]byte(raw), &history); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", RotationHistoryAnnotation, err)
+	}
+	return history, nil
+}
+
+// appendRotationHistory records a rotation on the secret, keeping at most
+// RotationHistoryLimit entries. A malformed existing history is discarded.
+func appendRotationHistory(secret *corev1.Secret, rotatedAt time.Time, addedKid string) error {
+	history, err := GetRotationHistory(secret)
+	if err != nil {
+		log.Printf("Warning: resetting rotation history on secret %s/%s: %v\n", secret.Namespace, secret.Name, err)
+		history = []RotationRecord{}
+	}
+
+	history = append(history, RotationRecord{
+		RotatedAt: rotatedAt.UTC().Format(time.RFC3339),
+		AddedKid:  addedKid,
+	})
+	if len(history) > RotationHistoryLimit {
+		history = history[len(history)-RotationHistoryLimit:]
+	}
+
+	encoded, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to encode rotation history: %w", err)
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[RotationHistoryAnnotation] = string(encoded)
+	return nil
+}
+
+// stampRotationAnnotations sets the last-rotated time and increments the rotation count
+// on the secret. A malformed existing count restarts from zero.
+func stampRotationAnnotations(secret *corev1.Secret, rotatedAt time.Time) {
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+
+	count := 0
+	if raw, ok := secret.Annotations[RotationCountAnnotation]; ok {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			log.Printf("Warning: resetting rotation count on secret %s/%s: invalid value %q\n",
+				secret.Namespace, secret.Name, raw)
+		} else {
+			count = parsed
+		}
+	}
+
+	secret.Annotations[LastRotatedAnnotation] = rotatedAt.UTC().Format(time.RFC3339)
+	secret.Annotations[RotationCountAnnotation] = strconv.Itoa(count + 1)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package rotator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func getRotationHistory(t *testing.T, k8sClient client.Client) []RotationRecord {
+	t.Helper()
+	secret := &corev1.Secret{}
+	err := k8sClient.Get(context.Background(), types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, secret)
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	history, err := GetRotationHistory(secret)
+	if err != nil {
+		t.Fatalf("GetRotationHistory failed: %v", err)
+	}
+	return history
+}
+
+func TestRotateSecret_RotationHistoryGrows(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := getTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testSecretName, Namespace: testNamespace},
+	})
+
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			time.Sleep(1 * time.Second) // Ensure different timestamps
+		}
+		if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0); err != nil {
+			t.Fatalf("RotateSecret failed on iteration %d: %v", i, err)
+		}
+	}
+
+	history := getRotationHistory(t, k8sClient)
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(history))
+	}
+	if history[0].AddedKid >= history[1].AddedKid {
+		t.Errorf("Expected history ordered oldest first, got %v", history)
+	}
+	latest, err := time.Parse(time.RFC3339, history[1].RotatedAt)
+	if err != nil {
+		t.Fatalf("Invalid rotatedAt %q: %v", history[1].RotatedAt, err)
+	}
+	if history[1].AddedKid != fmt.Sprintf("%d", latest.Unix()) {
+		t.Errorf("Expected addedKid %d to match rotatedAt, got %s", latest.Unix(), history[1].AddedKid)
+	}
+}
+
+func TestRotateSecret_RotationHistoryTrimmed(t *testing.T) {
+	ctx := context.Background()
+
+	seeded := make([]RotationRecord, RotationHistoryLimit)
+	for i := range seeded {
+		seeded[i] = RotationRecord{
+			RotatedAt: time.Unix(int64(1000+i), 0).UTC().Format(time.RFC3339),
+			AddedKid:  fmt.Sprintf("%d", 1000+i),
+		}
+	}
+	encoded, _ := json.Marshal(seeded)
+
+	k8sClient := getTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testSecretName,
+			Namespace:   testNamespace,
+			Annotations: map[string]string{RotationHistoryAnnotation: string(encoded)},
+		},
+	})
+
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	history := getRotationHistory(t, k8sClient)
+	if len(history) != RotationHistoryLimit {
+		t.Fatalf("Expected %d history entries, got %d", RotationHistoryLimit, len(history))
+	}
+	if history[0].AddedKid != "1001" {
+		t.Errorf("Expected oldest entry to be trimmed, first entry is %s", history[0].AddedKid)
+	}
+	if history[len(history)-1].AddedKid == seeded[len(seeded)-1].AddedKid {
+		t.Errorf("Expected the new rotation to be appended last")
+	}
+}
+
+func TestRotateSecret_MalformedRotationHistoryReset(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := getTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testSecretName,
+			Namespace:   testNamespace,
+			Annotations: map[string]string{RotationHistoryAnnotation: "not-json"},
+		},
+	})
+
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	history := getRotationHistory(t, k8sClient)
+	if len(history) != 1 {
+		t.Errorf("Expected malformed history to be replaced by 1 entry, got %d", len(history))
+	}
+}
+
+func getSecretAnnotations(t *testing.T, k8sClient client.Client) map[string]string {
+	t.Helper()
+	secret := &corev1.Secret{}
+	err := k8sClient.Get(context.Background(), types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, secret)
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	return secret.Annotations
+}
+
+func TestRotateSecret_StampsRotationAnnotations(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := getTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testSecretName,
+			Namespace:   testNamespace,
+			Annotations: map[string]string{RotationCountAnnotation: "4"},
+		},
+	})
+
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	annotations := getSecretAnnotations(t, k8sClient)
+	if annotations[RotationCountAnnotation] != "5" {
+		t.Errorf("Expected rotation count 5, got %q", annotations[RotationCountAnnotation])
+	}
+	history := getRotationHistory(t, k8sClient)
+	if len(history) != 1 {
+		t.Fatalf("Expected 1 history entry, got %d", len(history))
+	}
+	if annotations[LastRotatedAnnotation] != history[0].RotatedAt {
+		t.Errorf("Expected last rotated %s to match history, got %q",
+			history[0].RotatedAt, annotations[LastRotatedAnnotation])
+	}
+}
+
+func TestRotateSecret_MalformedRotationCountReset(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := getTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testSecretName,
+			Namespace:   testNamespace,
+			Annotations: map[string]string{RotationCountAnnotation: "many"},
+		},
+	})
+
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	if count := getSecretAnnotations(t, k8sClient)[RotationCountAnnotation]; count != "1" {
+		t.Errorf("Expected malformed rotation count to restart at 1, got %q", count)
+	}
+}
+
+func TestRotateSecret_SkippedRotationKeepsAnnotations(t *testing.T) {
+	ctx := context.Background()
+	lastRotated := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	k8sClient := getTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+			Annotations: map[string]string{
+				LastRotatedAnnotation:   lastRotated,
+				RotationCountAnnotation: "4",
+			},
+		},
+		Data: map[string][]byte{
+			fmt.Sprintf("jwt-signing-key-%d", time.Now().Add(-time.Minute).Unix()): []byte("key"),
+		},
+	})
+
+	err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0, WithMaxKeyAge(time.Hour))
+	if err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	annotations := getSecretAnnotations(t, k8sClient)
+	if annotations[RotationCountAnnotation] != "4" {
+		t.Errorf("Expected skipped rotation to keep count 4, got %q", annotations[RotationCountAnnotation])
+	}
+	if annotations[LastRotatedAnnotation] != lastRotated {
+		t.Errorf("Expected skipped rotation to keep last rotated %s, got %q",
+			lastRotated, annotations[LastRotatedAnnotation])
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package rotator
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Values of the result label on rotationsTotal
+const (
+	rotationResultSuccess = "success"
+	rotationResultFailure = "failure"
+	rotationResultSkipped = "skipped"
+)
+
+var (
+	// rotationsTotal counts RotateSecret calls by outcome
+	rotationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "jwt_rotator_rotations_total",
+		Help: "Number of signing key rotations, by result",
+	}, []string{"result"})
+
+	// signingKeys is the number of valid signing keys last seen in the secret
+	signingKeys = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jwt_rotator_signing_keys",
+		Help: "Number of valid signing keys in the secret after the last rotation attempt",
+	})
+
+	// newestKeyAgeSeconds is the age of the newest signing key last seen in the secret
+	newestKeyAgeSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jwt_rotator_newest_key_age_seconds",
+		Help: "Age in seconds of the newest signing key in the secret after the last rotation attempt",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(MetricsCollectors()...)
+}
+
+// MetricsCollectors returns the rotator metrics, for pushing them without the
+// client metrics that share the controller-runtime registry
+func MetricsCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		rotationsTotal,
+		signingKeys,
+		newestKeyAgeSeconds,
+	}
+}
+
+// recordKeyMetrics sets the key count and newest key age gauges from the parsed keys
+func recordKeyMetrics(keys []keyEntry, now time.Time) {
+	signingKeys.Set(float64(len(keys)))
+	if len(keys) == 0 {
+		return
+	}
+	newest := keys[0].timestamp
+	for _, k := range keys[1:] {
+		newest = max(newest, k.timestamp)
+	}
+	newestKeyAgeSeconds.Set(now.Sub(time.Unix(newest, 0)).Seconds())
+}
@@ -0,0 +1,85 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package rotator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestRotateSecret_RecordsSuccessMetrics(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testSecretName, Namespace: testNamespace},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": []byte("key1"),
+			"jwt-signing-key-2000": []byte("key2"),
+			"jwt-signing-key-3000": []byte("key3"),
+		},
+	}
+	k8sClient := getTestClient(secret)
+	before := testutil.ToFloat64(rotationsTotal.WithLabelValues(rotationResultSuccess))
+
+	if err := RotateSecret(context.Background(), k8sClient, testSecretName, testNamespace, 2, 0); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	if got := testutil.ToFloat64(rotationsTotal.WithLabelValues(rotationResultSuccess)); got != before+1 {
+		t.Errorf("Expected success counter %v, got %v", before+1, got)
+	}
+	if got := testutil.ToFloat64(signingKeys); got != 2 {
+		t.Errorf("Expected 2 signing keys, got %v", got)
+	}
+	if got := testutil.ToFloat64(newestKeyAgeSeconds); got < 0 || got > 5 {
+		t.Errorf("Expected newest key age near zero after rotation, got %v", got)
+	}
+}
+
+func TestRotateSecret_RecordsFailureMetrics(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testSecretName, Namespace: testNamespace},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": []byte("key1"),
+		},
+	}
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fakeclient.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(secret).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(context.Context, client.WithWatch, client.Object, ...client.UpdateOption) error {
+				return errors.New("update rejected")
+			},
+		}).
+		Build()
+	before := testutil.ToFloat64(rotationsTotal.WithLabelValues(rotationResultFailure))
+
+	if err := RotateSecret(context.Background(), k8sClient, testSecretName, testNamespace, 2, 0); err == nil {
+		t.Fatal("Expected RotateSecret to fail")
+	}
+
+	if got := testutil.ToFloat64(rotationsTotal.WithLabelValues(rotationResultFailure)); got != before+1 {
+		t.Errorf("Expected failure counter %v, got %v", before+1, got)
+	}
+	// The gauges keep describing the unrotated secret, so a stale key can be alerted on
+	if got := testutil.ToFloat64(signingKeys); got != 1 {
+		t.Errorf("Expected 1 signing key, got %v", got)
+	}
+	expectedAge := time.Since(time.Unix(1000, 0)).Seconds()
+	if got := testutil.ToFloat64(newestKeyAgeSeconds); got < expectedAge-5 || got > expectedAge+5 {
+		t.Errorf("Expected newest key age around %v, got %v", expectedAge, got)
+	}
+}
@@ -0,0 +1,40 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package rotator
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MinimumNumberOfKeys computes the smallest number of keys to retain so that every
+// token signed before a key is pruned has already expired: ceil(tokenTTL / rotationInterval) + 1.
+// The +1 ensures the previous signing key is always available during rotation.
+func MinimumNumberOfKeys(tokenTTL, rotationInterval time.Duration) (int, error) {
+	if rotationInterval <= 0 {
+		return 0, fmt.Errorf("rotationInterval must be > 0, got %s", rotationInterval)
+	}
+	if tokenTTL < 0 {
+		return 0, fmt.Errorf("tokenTTL must be >= 0, got %s", tokenTTL)
+	}
+	return int(math.Ceil(float64(tokenTTL)/float64(rotationInterval))) + 1, nil
+}
+
+// CheckRetention verifies that numberOfKeys covers the maximum token lifetime given the
+// rotation interval. Returns an error describing the gap when retention is too low, since
+// pruning would then remove keys that still have valid tokens in flight.
+func CheckRetention(numberOfKeys int, tokenTTL, rotationInterval time.Duration) error {
+	minKeys, err := MinimumNumberOfKeys(tokenTTL, rotationInterval)
+	if err != nil {
+		return err
+	}
+	if numberOfKeys < minKeys {
+		return fmt.Errorf("numberOfKeys=%d is too low to cover tokenTTL=%s with rotationInterval=%s, need at least %d",
+			numberOfKeys, tokenTTL, rotationInterval, minKeys)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package rotator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMinimumNumberOfKeys(t *testing.T) {
+	tests := []struct {
+		name             string
+		tokenTTL         time.Duration
+		rotationInterval time.Duration
+		expected         int
+		expectError      bool
+	}{
+		{
+			name:             "ttl shorter than interval",
+			tokenTTL:         5 * time.Minute,
+			rotationInterval: 15 * time.Minute,
+			expected:         2,
+		},
+		{
+			name:             "ttl equal to interval",
+			tokenTTL:         15 * time.Minute,
+			rotationInterval: 15 * time.Minute,
+			expected:         2,
+		},
+		{
+			name:             "ttl not a multiple of interval",
+			tokenTTL:         time.Hour,
+			rotationInterval: 25 * time.Minute,
+			expected:         4,
+		},
+		{
+			name:             "zero interval",
+			tokenTTL:         time.Hour,
+			rotationInterval: 0,
+			expectError:      true,
+		},
+		{
+			name:             "negative ttl",
+			tokenTTL:         -time.Hour,
+			rotationInterval: time.Minute,
+			expectError:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := MinimumNumberOfKeys(tt.tokenTTL, tt.rotationInterval)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if n != tt.expected {
+				t.Errorf("Expected %d keys, got %d", tt.expected, n)
+			}
+		})
+	}
+}
+
+func TestCheckRetention(t *testing.T) {
+	tests := []struct {
+		name             string
+		numberOfKeys     int
+		tokenTTL         time.Duration
+		rotationInterval time.Duration
+		expectError      bool
+	}{
+		{
+			name:             "adequate retention",
+			numberOfKeys:     6,
+			tokenTTL:         time.Hour,
+			rotationInterval: 15 * time.Minute,
+			expectError:      false,
+		},
+		{
+			name:             "exactly minimum retention",
+			numberOfKeys:     5,
+			tokenTTL:         time.Hour,
+			rotationInterval: 15 * time.Minute,
+			expectError:      false,
+		},
+		{
+			name:             "inadequate retention",
+			numberOfKeys:     3,
+			tokenTTL:         time.Hour,
+			rotationInterval: 15 * time.Minute,
+			expectError:      true,
+		},
+		{
+			name:             "single key cannot cover any rotation",
+			numberOfKeys:     1,
+			tokenTTL:         time.Minute,
+			rotationInterval: time.Hour,
+			expectError:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRetention(tt.numberOfKeys, tt.tokenTTL, tt.rotationInterval)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				if !contains(err.Error(), "too low") {
+					t.Errorf("Expected error about retention being too low, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
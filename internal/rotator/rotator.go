@@ -11,13 +11,17 @@ import (
 	"crypto/rand"
 	"fmt"
 	"log"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -33,17 +37,228 @@ func GenerateKey() ([]byte, error) {
 // keyEntry represents a signing key with its timestamp
 type keyEntry struct {
 	name      string
+	kid       string
 	timestamp int64
 	value     []byte
 }
 
+// Event reasons recorded on the rotated secret
+const (
+	EventReasonKeyRotated   = "KeyRotated"
+	EventReasonMalformedKey = "MalformedKey"
+)
+
+// RotateOption configures optional RotateSecret behavior
+type RotateOption func(*rotateOptions)
+
+// rotateOptions holds the settings applied by RotateOption
+type rotateOptions struct {
+	recorder          record.EventRecorder
+	keyPrefix         string
+	legacyKeyPrefixes []string
+	maxKeyAge         time.Duration
+	keyEncoding       string
+}
+
+// prefixes returns every prefix signing keys are read under, the target prefix first
+func (o *rotateOptions) prefixes() []string {
+	return append([]string{o.keyPrefix}, o.legacyKeyPrefixes...)
+}
+
+// WithEventRecorder records a Normal KeyRotated event on the secret after each rotation,
+// and a Warning MalformedKey event for each key entry skipped because its name does not
+// parse. Defaults to recording no events.
+func WithEventRecorder(recorder record.EventRecorder) RotateOption {
+	return func(o *rotateOptions) {
+		o.recorder = recorder
+	}
+}
+
+// WithKeyPrefix writes new keys under prefix instead of jwt.KeyPrefix, to migrate the
+// key naming. Signers must read the new prefix before the rotator starts writing it.
+func WithKeyPrefix(prefix string) RotateOption {
+	return func(o *rotateOptions) {
+		o.keyPrefix = prefix
+	}
+}
+
+// WithLegacyKeyPrefixes treats keys under prefixes as signing keys too, so keys written
+// before a prefix migration count towards numberOfKeys and are pruned as they age out.
+// Once none remain, the legacy prefixes can be dropped. Defaults to none.
+func WithLegacyKeyPrefixes(prefixes ...string) RotateOption {
+	return func(o *rotateOptions) {
+		o.legacyKeyPrefixes = prefixes
+	}
+}
+
+// WithMaxKeyAge only adds a key once the newest key is at least maxKeyAge old, so a
+// rotator running more often than keys should rotate does not churn the key set. Keys
+// beyond numberOfKeys are still pruned when the rotation is skipped. Defaults to zero,
+// which adds a key on every rotation.
+func WithMaxKeyAge(maxKeyAge time.Duration) RotateOption {
+	return func(o *rotateOptions) {
+		o.maxKeyAge = maxKeyAge
+	}
+}
+
+// WithKeyEncoding writes new keys with encoding, one of the jwt.KeyEncoding constants,
+// and refuses to rotate a secret holding a key that does not decode with it. Set it to
+// the signers' JWT_KEY_ENCODING. Defaults to jwt.KeyEncodingRaw.
+func WithKeyEncoding(encoding string) RotateOption {
+	return func(o *rotateOptions) {
+		o.keyEncoding = encoding
+	}
+}
+
+// RotationPlan describes the changes a rotation would make to a secret
+type RotationPlan struct {
+	// Skipped is set when no key would be added because the newest key is younger than
+	// the max key age; NewKeyName and NewKid are then empty
+	Skipped bool
+	// NewestKeyAge is the age of the newest existing key, zero when there is none
+	NewestKeyAge time.Duration
+	// NewKeyName is the data entry the new key would be written to
+	NewKeyName string
+	// NewKid is the kid of the new key
+	NewKid string
+	// PrunedKeys lists the key entries that would be removed, oldest first
+	PrunedKeys []string
+	// DeferredKeys lists keys beyond numberOfKeys kept because they are younger than minKeyAge
+	DeferredKeys []string
+	// MalformedKeys lists entries under a key prefix whose names do not parse; they are left as is
+	MalformedKeys []string
+	// RemainingKeys is the number of data entries the secret would hold afterwards
+	RemainingKeys int
+}
+
+// rotationResult describes the outcome of a successful rotation attempt
+type rotationResult struct {
+	secret *corev1.Secret
+	plan   *RotationPlan
+}
+
 // RotateSecret performs key rotation on a Kubernetes secret
-// It generates a new key, adds it to the secret, and prunes old keys beyond numberOfKeys
-func RotateSecret(ctx context.Context, k8sClient client.Client, secretName string, namespace string, numberOfKeys int) error {
+// It generates a new key, adds it to the secret, prunes old keys beyond numberOfKeys
+// and records the rotation in the RotationHistoryAnnotation.
+// Keys younger than minKeyAge are never pruned, even if that keeps more than numberOfKeys;
+// set it to at least the signers' new key use delay so no key is removed while still in cooloff.
+// The previous latest key is never pruned by the rotation that replaces it.
+// The read-modify-write is retried with a fresh copy of the secret on update conflicts.
+func RotateSecret(
+	ctx context.Context,
+	k8sClient client.Client,
+	secretName string,
+	namespace string,
+	numberOfKeys int,
+	minKeyAge time.Duration,
+	opts ...RotateOption,
+) error {
+	options, err := resolveRotateOptions(numberOfKeys, minKeyAge, opts)
+	if err != nil {
+		return err
+	}
+
+	var result *rotationResult
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		result, err = rotateSecretOnce(ctx, k8sClient, secretName, namespace, numberOfKeys, minKeyAge, options)
+		return err
+	})
+	if err != nil {
+		rotationsTotal.WithLabelValues(rotationResultFailure).Inc()
+		return err
+	}
+	if result.plan.Skipped {
+		rotationsTotal.WithLabelValues(rotationResultSkipped).Inc()
+		log.Printf("Rotation skipped, newest key age %s < threshold %s: %d keys remaining in secret %s/%s\n",
+			result.plan.NewestKeyAge, options.maxKeyAge, result.plan.RemainingKeys, namespace, secretName)
+	} else {
+		rotationsTotal.WithLabelValues(rotationResultSuccess).Inc()
+		log.Printf("Successfully rotated keys in secret %s/%s: added key %s, %d keys remaining\n",
+			namespace, secretName, result.plan.NewKeyName, result.plan.RemainingKeys)
+	}
+
+	// Record events only for the attempt that was persisted, not for retried ones
+	if options.recorder != nil {
+		for _, name := range result.plan.MalformedKeys {
+			options.recorder.Eventf(result.secret, corev1.EventTypeWarning, EventReasonMalformedKey,
+				"Skipped malformed signing key %s", name)
+		}
+		if !result.plan.Skipped {
+			options.recorder.Eventf(result.secret, corev1.EventTypeNormal, EventReasonKeyRotated,
+				"Added signing key %s, pruned %d keys", result.plan.NewKid, len(result.plan.PrunedKeys))
+		}
+	}
+
+	return nil
+}
+
+// RotateSecretDryRun computes the rotation RotateSecret would perform now, without
+// generating a key or updating the secret, so operators can check which keys would be
+// added and pruned before enabling rotation. It takes the same arguments and options;
+// the event recorder is ignored.
+func RotateSecretDryRun(
+	ctx context.Context,
+	k8sClient client.Client,
+	secretName string,
+	namespace string,
+	numberOfKeys int,
+	minKeyAge time.Duration,
+	opts ...RotateOption,
+) (*RotationPlan, error) {
+	options, err := resolveRotateOptions(numberOfKeys, minKeyAge, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretName, err)
+	}
+	if err := jwt.CheckSecretNamespace(secret, namespace); err != nil {
+		return nil, err
+	}
+
+	keys, malformedKeys := parseKeys(secret, options.prefixes())
+	return planRotation(secret, keys, malformedKeys, numberOfKeys, minKeyAge, options, time.Now().UTC())
+}
+
+// resolveRotateOptions validates the rotation arguments and applies opts over the defaults
+func resolveRotateOptions(numberOfKeys int, minKeyAge time.Duration, opts []RotateOption) (*rotateOptions, error) {
 	if numberOfKeys < 1 {
-		return fmt.Errorf("numberOfKeys must be at least 1, got %d", numberOfKeys)
+		return nil, fmt.Errorf("numberOfKeys must be at least 1, got %d", numberOfKeys)
+	}
+	if minKeyAge < 0 {
+		return nil, fmt.Errorf("minKeyAge must not be negative, got %s", minKeyAge)
 	}
 
+	options := &rotateOptions{keyPrefix: jwt.KeyPrefix, keyEncoding: jwt.KeyEncodingRaw}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.keyPrefix == "" {
+		return nil, fmt.Errorf("key prefix must not be empty")
+	}
+	if err := jwt.ValidateKeyEncoding(options.keyEncoding); err != nil {
+		return nil, err
+	}
+	if options.maxKeyAge < 0 {
+		return nil, fmt.Errorf("maxKeyAge must not be negative, got %s", options.maxKeyAge)
+	}
+	return options, nil
+}
+
+// rotateSecretOnce performs a single read-modify-write rotation attempt.
+// Update errors are wrapped with %w so RetryOnConflict can detect conflicts.
+func rotateSecretOnce(
+	ctx context.Context,
+	k8sClient client.Client,
+	secretName string,
+	namespace string,
+	numberOfKeys int,
+	minKeyAge time.Duration,
+	options *rotateOptions,
+) (*rotationResult, error) {
 	// Get current secret
 	secret := &corev1.Secret{}
 	err := k8sClient.Get(ctx, types.NamespacedName{
@@ -51,90 +266,228 @@ func RotateSecret(ctx context.Context, k8sClient client.Client, secretName strin
 		Namespace: namespace,
 	}, secret)
 	if err != nil {
-		return fmt.Errorf("failed to get secret %s: %w", secretName, err)
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretName, err)
+	}
+	if err := jwt.CheckSecretNamespace(secret, namespace); err != nil {
+		return nil, err
 	}
 
 	if secret.Data == nil {
 		secret.Data = make(map[string][]byte)
 	}
 
-	// Parse existing keys
+	keys, malformedKeys := parseKeys(secret, options.prefixes())
+
+	// Reflect the current keys, so a failed rotation still reports the newest key's age
+	recordKeyMetrics(keys, time.Now())
+
+	rotatedAt := time.Now().UTC()
+	plan, err := planRotation(secret, keys, malformedKeys, numberOfKeys, minKeyAge, options, rotatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing to write when the rotation is skipped and no key is due for pruning
+	if plan.Skipped && len(plan.PrunedKeys) == 0 {
+		return &rotationResult{secret: secret, plan: plan}, nil
+	}
+
+	// Generate new key
+	if !plan.Skipped {
+		newKey, err := GenerateKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate new key: %w", err)
+		}
+		value, err := jwt.EncodeKeyValue(newKey, options.keyEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode new key: %w", err)
+		}
+		secret.Data[plan.NewKeyName] = value
+	}
+
+	// Prune old keys
+	for _, name := range plan.PrunedKeys {
+		delete(secret.Data, name)
+	}
+	if len(plan.PrunedKeys) > 0 {
+		log.Printf("Pruned %d old keys: %v\n", len(plan.PrunedKeys), plan.PrunedKeys)
+	}
+	if len(plan.DeferredKeys) > 0 {
+		log.Printf("Deferred pruning of %d keys younger than %s: %v\n",
+			len(plan.DeferredKeys), minKeyAge, plan.DeferredKeys)
+	}
+
+	if !plan.Skipped {
+		if err := appendRotationHistory(secret, rotatedAt, plan.NewKid); err != nil {
+			return nil, err
+		}
+		stampRotationAnnotations(secret, rotatedAt)
+	}
+
+	// Update secret, so the annotations land atomically with the key changes
+	err = k8sClient.Update(ctx, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update secret %s: %w", secretName, err)
+	}
+
+	var remaining []keyEntry
+	if !plan.Skipped {
+		remaining = append(remaining, keyEntry{name: plan.NewKeyName, kid: plan.NewKid, timestamp: rotatedAt.Unix()})
+	}
+	for _, k := range keys {
+		if _, ok := secret.Data[k.name]; ok {
+			remaining = append(remaining, k)
+		}
+	}
+	recordKeyMetrics(remaining, rotatedAt)
+
+	return &rotationResult{secret: secret, plan: plan}, nil
+}
+
+// parseKeys returns the signing keys in secret under any of prefixes, and the sorted
+// names of entries under a prefix whose timestamps do not parse
+func parseKeys(secret *corev1.Secret, prefixes []string) ([]keyEntry, []string) {
 	keys := make([]keyEntry, 0, len(secret.Data))
+	var malformedKeys []string
 	for name, value := range secret.Data {
-		if !strings.HasPrefix(name, jwt.KeyPrefix) {
+		prefix, ok := jwt.MatchKeyPrefix(name, prefixes...)
+		if !ok {
 			continue
 		}
 
-		timestamp, err := jwt.ParseKeyTimestamp(name)
+		timestamp, err := jwt.ParseKeyTimestampWithPrefix(name, prefix)
 		if err != nil {
 			// Log warning but continue - don't fail rotation due to malformed key
 			log.Printf("Warning: skipping malformed key %s: %v\n", name, err)
+			malformedKeys = append(malformedKeys, name)
 			continue
 		}
 
 		keys = append(keys, keyEntry{
 			name:      name,
+			kid:       strings.TrimPrefix(name, prefix),
 			timestamp: timestamp,
 			value:     value,
 		})
 	}
 
-	// Sort keys by timestamp (oldest first)
-	sort.Slice(keys, func(i, j int) bool {
-		return keys[i].timestamp < keys[j].timestamp
-	})
-
-	// Generate new key
-	newKey, err := GenerateKey()
-	if err != nil {
-		return fmt.Errorf("failed to generate new key: %w", err)
-	}
-
-	now := time.Now().UTC().Unix()
-	newKeyName := jwt.BuildKeyName(now)
+	sort.Strings(malformedKeys)
+	return keys, malformedKeys
+}
 
-	// Check if key with this timestamp already exists (clock skew or very fast rotation)
+// planRotation decides which key a rotation at rotatedAt adds under the key prefix and
+// which of keys it prunes. No key is added while the newest one is younger than the max
+// key age, and none is planned when an existing key does not decode with the key
+// encoding. It does not modify secret or keys.
+func planRotation(
+	secret *corev1.Secret,
+	keys []keyEntry,
+	malformedKeys []string,
+	numberOfKeys int,
+	minKeyAge time.Duration,
+	options *rotateOptions,
+	rotatedAt time.Time,
+) (*RotationPlan, error) {
+	now := rotatedAt.Unix()
+	plan := &RotationPlan{MalformedKeys: malformedKeys}
+
+	// A new key in another encoding than the existing ones would break the signers
 	for _, k := range keys {
-		if k.name == newKeyName {
-			return fmt.Errorf("key with timestamp %d already exists, refusing to overwrite", now)
+		if _, err := jwt.DecodeKeyValue(k.value, options.keyEncoding); err != nil {
+			return nil, fmt.Errorf("key %s is not valid %s, refusing to rotate: %w", k.name, options.keyEncoding, err)
 		}
 	}
 
-	// Add new key
-	secret.Data[newKeyName] = newKey
-	keys = append(keys, keyEntry{
-		name:      newKeyName,
-		timestamp: now,
-		value:     newKey,
-	})
+	all := make([]keyEntry, 0, len(keys)+1)
+	all = append(all, keys...)
+	sortKeysOldestFirst(all)
+	previousLatest := ""
+	if len(all) > 0 {
+		previousLatest = all[len(all)-1].name
+		plan.NewestKeyAge = rotatedAt.Sub(time.Unix(all[len(all)-1].timestamp, 0))
+		plan.Skipped = plan.NewestKeyAge < options.maxKeyAge
+	}
 
-	// Re-sort after adding new key
-	sort.Slice(keys, func(i, j int) bool {
-		return keys[i].timestamp < keys[j].timestamp
-	})
+	if !plan.Skipped {
+		plan.NewKeyName = jwt.BuildKeyNameWithPrefix(options.keyPrefix, now)
+		plan.NewKid = strconv.FormatInt(now, 10)
 
-	// Keep only the latest numberOfKeys keys
-	if len(keys) > numberOfKeys {
-		keysToRemove := keys[:len(keys)-numberOfKeys]
-		for _, k := range keysToRemove {
-			delete(secret.Data, k.name)
+		// Check if key with this timestamp already exists (clock skew or very fast rotation),
+		// under any prefix since the kid would be shared
+		for _, k := range keys {
+			if k.kid == plan.NewKid {
+				return nil, fmt.Errorf("key with timestamp %d already exists, refusing to overwrite", now)
+			}
 		}
-		log.Printf("Pruned %d old keys: %v\n", len(keysToRemove), getKeyNames(keysToRemove))
+
+		all = append(all, keyEntry{name: plan.NewKeyName, kid: plan.NewKid, timestamp: now})
+		sortKeysOldestFirst(all)
 	}
 
-	// Update secret
-	err = k8sClient.Update(ctx, secret)
-	if err != nil {
-		return fmt.Errorf("failed to update secret %s: %w", secretName, err)
+	// Keep only the latest numberOfKeys keys, deferring any younger than minKeyAge
+	if len(all) > numberOfKeys {
+		keysToRemove, deferredKeys := splitByMinAge(all[:len(all)-numberOfKeys], rotatedAt, minKeyAge)
+		// With numberOfKeys=1 the previous latest key would go along with the rotation;
+		// keep it so tokens it signed still validate while signers switch to the new key
+		if n := len(keysToRemove); !plan.Skipped && n > 0 && keysToRemove[n-1].name == previousLatest {
+			log.Printf("Keeping previous latest key %s alongside the new key\n", previousLatest)
+			keysToRemove = keysToRemove[:n-1]
+		}
+		plan.PrunedKeys = getKeyNames(keysToRemove)
+		plan.DeferredKeys = getKeyNames(deferredKeys)
+	}
+	if err := checkKeyOverlap(plan, all, previousLatest); err != nil {
+		return nil, err
 	}
 
-	remainingKeys := len(secret.Data)
-	log.Printf("Successfully rotated keys in secret %s/%s: added key %s, %d keys remaining\n",
-		secret.Namespace, secretName, newKeyName, remainingKeys)
+	plan.RemainingKeys = len(secret.Data) - len(plan.PrunedKeys)
+	if _, exists := secret.Data[plan.NewKeyName]; !plan.Skipped && !exists {
+		plan.RemainingKeys++
+	}
+	return plan, nil
+}
 
+// checkKeyOverlap guards the invariant that a rotation never leaves the secret without
+// signing keys: the new key, if any, and the previous latest key must both survive the
+// pruning, so the error surfaces before the secret is updated.
+func checkKeyOverlap(plan *RotationPlan, keys []keyEntry, previousLatest string) error {
+	pruned := make(map[string]bool, len(plan.PrunedKeys))
+	for _, name := range plan.PrunedKeys {
+		pruned[name] = true
+	}
+	if !plan.Skipped && (plan.NewKeyName == "" || pruned[plan.NewKeyName]) {
+		return fmt.Errorf("rotation would not keep the new key %q, refusing to update", plan.NewKeyName)
+	}
+	if previousLatest != "" && pruned[previousLatest] {
+		return fmt.Errorf("rotation would prune the latest key %s, refusing to update", previousLatest)
+	}
+	if len(keys) > 0 && len(plan.PrunedKeys) >= len(keys) {
+		return fmt.Errorf("rotation would leave no signing keys, refusing to update")
+	}
 	return nil
 }
 
+// sortKeysOldestFirst orders keys by timestamp, breaking ties like jwt.KidIsNewer so the
+// key kept as newest is the one the signers treat as latest
+func sortKeysOldestFirst(keys []keyEntry) {
+	sort.Slice(keys, func(i, j int) bool {
+		return jwt.KidIsNewer(keys[j].kid, keys[i].kid)
+	})
+}
+
+// splitByMinAge separates prunable keys from those created less than minKeyAge before now
+func splitByMinAge(keys []keyEntry, now time.Time, minKeyAge time.Duration) ([]keyEntry, []keyEntry) {
+	var prunable, deferred []keyEntry
+	for _, k := range keys {
+		if now.Sub(time.Unix(k.timestamp, 0)) < minKeyAge {
+			deferred = append(deferred, k)
+			continue
+		}
+		prunable = append(prunable, k)
+	}
+	return prunable, deferred
+}
+
 // getKeyNames extracts key names from keyEntry slice for logging
 func getKeyNames(keys []keyEntry) []string {
 	names := make([]string, len(keys))
@@ -144,67 +497,169 @@ func getKeyNames(keys []keyEntry) []string {
 	return names
 }
 
-// ValidateSecret checks if a secret has valid JWT signing keys
-func ValidateSecret(ctx context.Context, k8sClient client.Client, secretName string, namespace string) error {
+// nearMissKeyPattern matches data entry names that look like a hand-edited signing key
+// (wrong case, missing or different separators) but lack the exact jwt.KeyPrefix
+var nearMissKeyPattern = regexp.MustCompile(`(?i)^\s*jwt[-_.]?sign(ing)?[-_.]?keys?[-_.]*\d+\s*$`)
+
+// SecretReport summarizes the contents of a JWT signing secret
+type SecretReport struct {
+	// KeyCount is the number of valid JWT signing keys
+	KeyCount int
+	// OrphanedKeys lists data entries that are not JWT signing keys, sorted
+	OrphanedKeys []string
+	// NearMissKeys lists orphaned entries that look like misnamed signing keys, sorted
+	NearMissKeys []string
+}
+
+// ValidateSecret checks if a secret has valid JWT signing keys under any of prefixes,
+// or jwt.KeyPrefix when none are given.
+// Unexpected non-key data entries are logged but do not fail validation.
+func ValidateSecret(ctx context.Context, k8sClient client.Client, secretName string, namespace string, prefixes ...string) error {
+	report, err := InspectSecret(ctx, k8sClient, secretName, namespace, prefixes...)
+	if err != nil {
+		return err
+	}
+
+	if len(report.OrphanedKeys) > 0 {
+		log.Printf("Warning: secret %s/%s contains %d unexpected data entries: %v\n",
+			namespace, secretName, len(report.OrphanedKeys), report.OrphanedKeys)
+	}
+	for _, name := range report.NearMissKeys {
+		log.Printf("Warning: secret %s/%s entry %q looks like a signing key but is ignored; expected name %s<timestamp>\n",
+			namespace, secretName, name, jwt.KeyPrefix)
+	}
+
+	return nil
+}
+
+// InspectSecret validates a secret like ValidateSecret and returns a report of its contents
+func InspectSecret(ctx context.Context, k8sClient client.Client, secretName string, namespace string, prefixes ...string) (*SecretReport, error) {
 	secret := &corev1.Secret{}
 	err := k8sClient.Get(ctx, types.NamespacedName{
 		Name:      secretName,
 		Namespace: namespace,
 	}, secret)
 	if err != nil {
-		return fmt.Errorf("failed to get secret: %w", err)
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+	if err := jwt.CheckSecretNamespace(secret, namespace); err != nil {
+		return nil, err
 	}
 
 	if secret.Data == nil {
-		return fmt.Errorf("secret has no data")
+		return nil, fmt.Errorf("secret has no data")
 	}
 
-	keyCount := 0
+	report := &SecretReport{OrphanedKeys: []string{}, NearMissKeys: []string{}}
 	for name := range secret.Data {
-		if strings.HasPrefix(name, jwt.KeyPrefix) {
-			_, err := jwt.ParseKeyTimestamp(name)
-			if err != nil {
-				return fmt.Errorf("invalid key %s: %w", name, err)
+		prefix, ok := jwt.MatchKeyPrefix(name, prefixes...)
+		if !ok {
+			report.OrphanedKeys = append(report.OrphanedKeys, name)
+			if nearMissKeyPattern.MatchString(name) {
+				report.NearMissKeys = append(report.NearMissKeys, name)
 			}
-			keyCount++
+			continue
 		}
+		_, err := jwt.ParseKeyTimestampWithPrefix(name, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s: %w", name, err)
+		}
+		report.KeyCount++
 	}
+	sort.Strings(report.OrphanedKeys)
+	sort.Strings(report.NearMissKeys)
 
-	if keyCount == 0 {
-		return fmt.Errorf("secret has no valid JWT signing keys")
+	if report.KeyCount == 0 {
+		if len(report.NearMissKeys) > 0 {
+			return nil, fmt.Errorf("secret has no valid JWT signing keys; entries %v look misnamed, expected %s<timestamp>",
+				report.NearMissKeys, jwt.KeyPrefix)
+		}
+		return nil, fmt.Errorf("secret has no valid JWT signing keys")
 	}
 
-	return nil
+	return report, nil
 }
 
-// GetLatestKeyID returns the kid (timestamp) of the most recent key in the secret
-func GetLatestKeyID(secret *corev1.Secret) (string, error) {
-	if secret.Data == nil {
-		return "", fmt.Errorf("secret has no data")
+// PruneStrayKeys removes non-key data entries from the secret whose names appear in allowlist.
+// JWT signing keys, under any of prefixes or jwt.KeyPrefix when none are given, are never
+// removed, even if allowlisted. Returns the names that were removed.
+func PruneStrayKeys(
+	ctx context.Context,
+	k8sClient client.Client,
+	secretName string,
+	namespace string,
+	allowlist []string,
+	prefixes ...string,
+) ([]string, error) {
+	secret := &corev1.Secret{}
+	err := k8sClient.Get(ctx, types.NamespacedName{
+		Name:      secretName,
+		Namespace: namespace,
+	}, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretName, err)
+	}
+	if err := jwt.CheckSecretNamespace(secret, namespace); err != nil {
+		return nil, err
 	}
 
-	var latestTimestamp int64
-	var latestKid string
-
-	for name := range secret.Data {
-		if !strings.HasPrefix(name, jwt.KeyPrefix) {
+	removed := []string{}
+	for _, name := range allowlist {
+		if _, isKey := jwt.MatchKeyPrefix(name, prefixes...); isKey {
 			continue
 		}
-
-		timestamp, err := jwt.ParseKeyTimestamp(name)
-		if err != nil {
-			continue // Skip malformed keys
+		if _, ok := secret.Data[name]; ok {
+			delete(secret.Data, name)
+			removed = append(removed, name)
 		}
+	}
+	sort.Strings(removed)
 
-		if timestamp > latestTimestamp {
-			latestTimestamp = timestamp
-			latestKid = strings.TrimPrefix(name, jwt.KeyPrefix)
-		}
+	if len(removed) == 0 {
+		return removed, nil
 	}
 
-	if latestKid == "" {
-		return "", fmt.Errorf("no valid JWT signing keys found")
+	if err := k8sClient.Update(ctx, secret); err != nil {
+		return nil, fmt.Errorf("failed to update secret %s: %w", secretName, err)
 	}
 
+	log.Printf("Pruned %d stray entries from secret %s/%s: %v\n", len(removed), namespace, secretName, removed)
+	return removed, nil
+}
+
+// KeyAge is the age of a signing key in a secret, from its timestamp
+type KeyAge struct {
+	Name string
+	Kid  string
+	Age  time.Duration
+}
+
+// GetKeyAges returns the age at now of each signing key in the secret under any of
+// prefixes, or jwt.KeyPrefix when none are given, oldest first. Keys whose timestamps
+// do not parse are skipped.
+func GetKeyAges(secret *corev1.Secret, now time.Time, prefixes ...string) []KeyAge {
+	keys, _ := parseKeys(secret, prefixes)
+	sortKeysOldestFirst(keys)
+	ages := make([]KeyAge, len(keys))
+	for i, k := range keys {
+		ages[i] = KeyAge{Name: k.name, Kid: k.kid, Age: now.Sub(time.Unix(k.timestamp, 0))}
+	}
+	return ages
+}
+
+// GetLatestKeyID returns the kid (timestamp) of the most recent key in the secret
+// under any of prefixes, or jwt.KeyPrefix when none are given. It reads the secret
+// exactly as the signers do, so it fails on any secret the signers would reject.
+func GetLatestKeyID(secret *corev1.Secret, prefixes ...string) (string, error) {
+	return GetLatestEncodedKeyID(secret, jwt.KeyEncodingRaw, prefixes...)
+}
+
+// GetLatestEncodedKeyID is GetLatestKeyID for key values stored with encoding, one of
+// the jwt.KeyEncoding constants
+func GetLatestEncodedKeyID(secret *corev1.Secret, encoding string, prefixes ...string) (string, error) {
+	_, latestKid, err := jwt.ParseEncodedSigningKeysFromSecret(secret, encoding, prefixes...)
+	if err != nil {
+		return "", fmt.Errorf("no valid JWT signing keys found: %w", err)
+	}
 	return latestKid, nil
 }
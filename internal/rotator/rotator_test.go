@@ -6,17 +6,28 @@ Distributed under the terms of the MIT license
 package rotator
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jupyter-infra/jupyter-k8s/internal/jwt"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 const (
@@ -66,7 +77,7 @@ func TestRotateSecret_NewSecret(t *testing.T) {
 	k8sClient := getTestClient(secret)
 
 	// Rotate secret
-	err := RotateSecret(ctx, k8sClient, secretName, testNamespace, 3)
+	err := RotateSecret(ctx, k8sClient, secretName, testNamespace, 3, 0)
 	if err != nil {
 		t.Fatalf("RotateSecret failed: %v", err)
 	}
@@ -110,7 +121,7 @@ func TestRotateSecret_AddAndPruneKeys(t *testing.T) {
 	// Rotate 4 times (should end up with 3 keys due to pruning)
 	for i := 0; i < 4; i++ {
 		time.Sleep(1 * time.Second) // Ensure different timestamps (unix timestamp precision is 1 second)
-		err := RotateSecret(ctx, k8sClient, secretName, testNamespace, numberOfKeys)
+		err := RotateSecret(ctx, k8sClient, secretName, testNamespace, numberOfKeys, 0)
 		if err != nil {
 			t.Fatalf("RotateSecret failed on iteration %d: %v", i, err)
 		}
@@ -142,11 +153,421 @@ func TestRotateSecret_AddAndPruneKeys(t *testing.T) {
 	}
 }
 
+func TestRotateSecret_KeyPrefixMigration(t *testing.T) {
+	ctx := context.Background()
+	const newPrefix = "jwt-key-v2-"
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": make([]byte, jwt.KeySizeBytes),
+			"jwt-signing-key-2000": make([]byte, jwt.KeySizeBytes),
+		},
+	}
+	k8sClient := getTestClient(secret)
+
+	err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 2, 0,
+		WithKeyPrefix(newPrefix), WithLegacyKeyPrefixes(jwt.KeyPrefix))
+	if err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	updatedSecret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret); err != nil {
+		t.Fatalf("Failed to get updated secret: %v", err)
+	}
+
+	// The new key uses the target prefix, and legacy keys count towards numberOfKeys
+	var newKeys []string
+	for name := range updatedSecret.Data {
+		if hasPrefix(name, newPrefix) {
+			newKeys = append(newKeys, name)
+		}
+	}
+	if len(newKeys) != 1 {
+		t.Fatalf("Expected one key under %s, got %v", newPrefix, newKeys)
+	}
+	if _, ok := updatedSecret.Data["jwt-signing-key-1000"]; ok {
+		t.Error("Expected oldest legacy key to be pruned")
+	}
+	if _, ok := updatedSecret.Data["jwt-signing-key-2000"]; !ok {
+		t.Error("Expected newest legacy key to be kept")
+	}
+
+	latestKid, err := GetLatestKeyID(updatedSecret, newPrefix, jwt.KeyPrefix)
+	if err != nil {
+		t.Fatalf("GetLatestKeyID failed: %v", err)
+	}
+	if newPrefix+latestKid != newKeys[0] {
+		t.Errorf("Expected latest kid from %s, got %s", newKeys[0], latestKid)
+	}
+
+	// Signers reading both prefixes see both keys
+	if err := ValidateSecret(ctx, k8sClient, testSecretName, testNamespace, newPrefix, jwt.KeyPrefix); err != nil {
+		t.Errorf("ValidateSecret failed: %v", err)
+	}
+	report, err := InspectSecret(ctx, k8sClient, testSecretName, testNamespace, newPrefix, jwt.KeyPrefix)
+	if err != nil {
+		t.Fatalf("InspectSecret failed: %v", err)
+	}
+	if report.KeyCount != 2 || len(report.OrphanedKeys) != 0 {
+		t.Errorf("Expected 2 keys and no orphaned entries, got %+v", report)
+	}
+}
+
+func TestRotateSecret_DefersPruningOfYoungKeys(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Unix()
+	oldKey := jwt.BuildKeyName(1000)
+	youngKey := jwt.BuildKeyName(now - 60)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			oldKey:   []byte("key1"),
+			youngKey: []byte("key2"),
+		},
+	}
+	k8sClient := getTestClient(secret)
+
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	// numberOfKeys=1 would prune both existing keys, but the young one is within minKeyAge
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 1, time.Hour); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	updatedSecret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret); err != nil {
+		t.Fatalf("Failed to get updated secret: %v", err)
+	}
+	if _, ok := updatedSecret.Data[oldKey]; ok {
+		t.Errorf("Expected key %s older than minKeyAge to be pruned", oldKey)
+	}
+	if _, ok := updatedSecret.Data[youngKey]; !ok {
+		t.Errorf("Expected key %s younger than minKeyAge to be kept", youngKey)
+	}
+	keyCount := 0
+	for name := range updatedSecret.Data {
+		if hasPrefix(name, jwt.KeyPrefix) {
+			keyCount++
+		}
+	}
+	if keyCount != 2 {
+		t.Errorf("Expected 2 keys (new and deferred), got %d", keyCount)
+	}
+	if !contains(logBuf.String(), "Deferred pruning of 1 keys younger than 1h0m0s") {
+		t.Errorf("Expected deferred pruning to be logged, got: %s", logBuf.String())
+	}
+}
+
+func TestRotateSecret_MaxKeyAge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Unix()
+	oldKey := jwt.BuildKeyName(1000)
+	middleKey := jwt.BuildKeyName(2000)
+	newestKey := jwt.BuildKeyName(now - 600)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			oldKey:    []byte("key1"),
+			middleKey: []byte("key2"),
+			newestKey: []byte("key3"),
+		},
+	}
+	k8sClient := getTestClient(secret)
+
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	// The newest key is 10m old, so a 1h max age skips the new key but still prunes to 2 keys
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 2, 0, WithMaxKeyAge(time.Hour)); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	updatedSecret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret); err != nil {
+		t.Fatalf("Failed to get updated secret: %v", err)
+	}
+	want := []string{middleKey, newestKey}
+	sort.Strings(want)
+	var got []string
+	for name := range updatedSecret.Data {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected keys %v, got %v", want, got)
+	}
+	if _, ok := updatedSecret.Annotations[RotationHistoryAnnotation]; ok {
+		t.Error("Expected a skipped rotation not to be recorded in the rotation history")
+	}
+	if !contains(logBuf.String(), "Rotation skipped, newest key age 10m") ||
+		!contains(logBuf.String(), "< threshold 1h0m0s") {
+		t.Errorf("Expected skipped rotation to be logged, got: %s", logBuf.String())
+	}
+
+	// Once the newest key reaches the max age, a key is added again
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 2, 0, WithMaxKeyAge(5*time.Minute)); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret); err != nil {
+		t.Fatalf("Failed to get updated secret: %v", err)
+	}
+	if _, ok := updatedSecret.Data[middleKey]; ok {
+		t.Errorf("Expected %s to be pruned after the new key was added", middleKey)
+	}
+	if len(updatedSecret.Data) != 2 {
+		t.Errorf("Expected 2 keys, got %v", updatedSecret.Data)
+	}
+}
+
+func TestRotateSecret_MaxKeyAgeWithoutKeys(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+	}
+	k8sClient := getTestClient(secret)
+
+	plan, err := RotateSecretDryRun(ctx, k8sClient, testSecretName, testNamespace, 2, 0, WithMaxKeyAge(time.Hour))
+	if err != nil {
+		t.Fatalf("RotateSecretDryRun failed: %v", err)
+	}
+	if plan.Skipped || plan.NewKeyName == "" {
+		t.Errorf("Expected a key to be added to a secret without keys, got %+v", plan)
+	}
+
+	if _, err := RotateSecretDryRun(ctx, k8sClient, testSecretName, testNamespace, 2, 0, WithMaxKeyAge(-time.Hour)); err == nil {
+		t.Error("Expected error for negative max key age")
+	}
+}
+
+// TestRotateSecret_KeyEncodingRoundTrip tests that keys written with a key encoding load
+// in signers reading the same encoding
+func TestRotateSecret_KeyEncodingRoundTrip(t *testing.T) {
+	for _, encoding := range []string{jwt.KeyEncodingRaw, jwt.KeyEncodingBase64, jwt.KeyEncodingHex} {
+		t.Run(encoding, func(t *testing.T) {
+			ctx := context.Background()
+			existing, err := GenerateKey()
+			if err != nil {
+				t.Fatalf("GenerateKey failed: %v", err)
+			}
+			value, err := jwt.EncodeKeyValue(existing, encoding)
+			if err != nil {
+				t.Fatalf("EncodeKeyValue failed: %v", err)
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: testSecretName, Namespace: testNamespace},
+				Data:       map[string][]byte{"jwt-signing-key-1000": value},
+			}
+			k8sClient := getTestClient(secret)
+
+			if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0, WithKeyEncoding(encoding)); err != nil {
+				t.Fatalf("RotateSecret failed: %v", err)
+			}
+
+			updated := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updated); err != nil {
+				t.Fatalf("Failed to get updated secret: %v", err)
+			}
+			keys, latestKid, err := jwt.ParseEncodedSigningKeysFromSecret(updated, encoding)
+			if err != nil {
+				t.Fatalf("Signers cannot load the rotated secret: %v", err)
+			}
+			if len(keys) != 2 {
+				t.Fatalf("Expected 2 keys, got %d", len(keys))
+			}
+			if !bytes.Equal(keys["1000"], existing) {
+				t.Error("Existing key did not decode to its original bytes")
+			}
+			if len(keys[latestKid]) != jwt.KeySizeBytes {
+				t.Errorf("Expected new key to decode to %d bytes, got %d", jwt.KeySizeBytes, len(keys[latestKid]))
+			}
+
+			validatedKid, err := GetLatestEncodedKeyID(updated, encoding)
+			if err != nil {
+				t.Fatalf("GetLatestEncodedKeyID failed: %v", err)
+			}
+			if validatedKid != latestKid {
+				t.Errorf("Expected latest kid %s, got %s", latestKid, validatedKid)
+			}
+		})
+	}
+}
+
+func TestRotateSecret_KeyEncodingMismatch(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testSecretName, Namespace: testNamespace},
+		Data:       map[string][]byte{"jwt-signing-key-1000": []byte("not-hex-encoded-key-material!!!!")},
+	}
+	k8sClient := getTestClient(secret)
+
+	if _, err := RotateSecretDryRun(ctx, k8sClient, testSecretName, testNamespace, 3, 0,
+		WithKeyEncoding(jwt.KeyEncodingHex)); err == nil {
+		t.Error("Expected dry run to fail for a key that does not decode")
+	}
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0,
+		WithKeyEncoding(jwt.KeyEncodingHex)); err == nil {
+		t.Error("Expected rotation to fail for a key that does not decode")
+	}
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0,
+		WithKeyEncoding("base32")); err == nil {
+		t.Error("Expected rotation to fail for an unsupported key encoding")
+	}
+
+	unchanged := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, unchanged); err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if len(unchanged.Data) != 1 {
+		t.Errorf("Expected the secret to be left unchanged, got %d entries", len(unchanged.Data))
+	}
+}
+
+func TestRotateSecretDryRun(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Unix()
+	youngKey := jwt.BuildKeyName(now - 60)
+	data := map[string][]byte{
+		"jwt-signing-key-1000":    []byte("key1"),
+		"jwt-signing-key-2000":    []byte("key2"),
+		youngKey:                  []byte("key3"),
+		"jwt-signing-key-invalid": []byte("malformed"),
+		"other-key":               []byte("other"),
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: data,
+	}
+	k8sClient := getTestClient(secret)
+
+	plan, err := RotateSecretDryRun(ctx, k8sClient, testSecretName, testNamespace, 1, time.Hour)
+	if err != nil {
+		t.Fatalf("RotateSecretDryRun failed: %v", err)
+	}
+
+	if plan.NewKeyName != jwt.KeyPrefix+plan.NewKid {
+		t.Errorf("Expected new key name to match kid %s, got %s", plan.NewKid, plan.NewKeyName)
+	}
+	if ts, err := strconv.ParseInt(plan.NewKid, 10, 64); err != nil || ts < now {
+		t.Errorf("Expected new kid to be the current timestamp, got %s", plan.NewKid)
+	}
+	if want := []string{"jwt-signing-key-1000", "jwt-signing-key-2000"}; !reflect.DeepEqual(plan.PrunedKeys, want) {
+		t.Errorf("Expected pruned keys %v, got %v", want, plan.PrunedKeys)
+	}
+	if want := []string{youngKey}; !reflect.DeepEqual(plan.DeferredKeys, want) {
+		t.Errorf("Expected deferred keys %v, got %v", want, plan.DeferredKeys)
+	}
+	if want := []string{"jwt-signing-key-invalid"}; !reflect.DeepEqual(plan.MalformedKeys, want) {
+		t.Errorf("Expected malformed keys %v, got %v", want, plan.MalformedKeys)
+	}
+	// youngKey, the new key, the malformed entry and other-key
+	if plan.RemainingKeys != 4 {
+		t.Errorf("Expected 4 remaining entries, got %d", plan.RemainingKeys)
+	}
+
+	updatedSecret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret); err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if !reflect.DeepEqual(updatedSecret.Data, data) {
+		t.Errorf("Expected dry run to leave the secret unchanged, got %v", updatedSecret.Data)
+	}
+}
+
+func TestRotateSecretDryRun_InvalidNumberOfKeys(t *testing.T) {
+	k8sClient := getTestClient()
+	if _, err := RotateSecretDryRun(context.Background(), k8sClient, testSecretName, testNamespace, 0, 0); err == nil {
+		t.Error("Expected error for numberOfKeys=0")
+	}
+}
+
+func TestRotateSecret_NegativeMinKeyAge(t *testing.T) {
+	k8sClient := getTestClient()
+
+	err := RotateSecret(context.Background(), k8sClient, testSecretName, testNamespace, 3, -time.Second)
+	if err == nil || !contains(err.Error(), "minKeyAge must not be negative") {
+		t.Errorf("Expected negative minKeyAge error, got: %v", err)
+	}
+}
+
+func TestRotateSecret_RetriesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	updates := 0
+	k8sClient := fakeclient.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(secret).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				updates++
+				if updates == 1 {
+					return apierrors.NewConflict(corev1.Resource("secrets"), obj.GetName(), errors.New("modified concurrently"))
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+
+	recorder := record.NewFakeRecorder(10)
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 3, 0, WithEventRecorder(recorder)); err != nil {
+		t.Fatalf("RotateSecret should succeed after a conflict, got: %v", err)
+	}
+	if updates != 2 {
+		t.Errorf("Expected 2 update attempts, got %d", updates)
+	}
+	if events := drainEvents(recorder); len(events) != 1 {
+		t.Errorf("Expected a single event for the persisted attempt, got %v", events)
+	}
+
+	updatedSecret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret); err != nil {
+		t.Fatalf("Failed to get updated secret: %v", err)
+	}
+	keyCount := 0
+	for name := range updatedSecret.Data {
+		if hasPrefix(name, jwt.KeyPrefix) {
+			keyCount++
+		}
+	}
+	if keyCount != 1 {
+		t.Errorf("Expected 1 key after rotation, got %d", keyCount)
+	}
+}
+
 func TestRotateSecret_InvalidNumberOfKeys(t *testing.T) {
 	k8sClient := getTestClient()
 	ctx := context.Background()
 
-	err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 0)
+	err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 0, 0)
 	if err == nil {
 		t.Fatal("Expected error for numberOfKeys=0")
 	}
@@ -160,7 +581,7 @@ func TestRotateSecret_SecretNotFound(t *testing.T) {
 	k8sClient := getTestClient()
 	ctx := context.Background()
 
-	err := RotateSecret(ctx, k8sClient, "nonexistent-secret", testNamespace, 3)
+	err := RotateSecret(ctx, k8sClient, "nonexistent-secret", testNamespace, 3, 0)
 	if err == nil {
 		t.Fatal("Expected error for nonexistent secret")
 	}
@@ -170,6 +591,69 @@ func TestRotateSecret_SecretNotFound(t *testing.T) {
 	}
 }
 
+// getMisroutingClient returns a fake client whose Get reports the secret as living in another namespace
+func getMisroutingClient(updates *int, objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	return fakeclient.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if err := c.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				obj.SetNamespace("other-namespace")
+				return nil
+			},
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				*updates++
+				return c.Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+}
+
+func TestRotateSecret_NamespaceMismatch(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+	}
+	updates := 0
+	k8sClient := getMisroutingClient(&updates, secret)
+
+	err := RotateSecret(context.Background(), k8sClient, testSecretName, testNamespace, 3, 0)
+	if !errors.Is(err, jwt.ErrSecretNamespaceMismatch) {
+		t.Fatalf("Expected namespace mismatch error, got: %v", err)
+	}
+	if updates != 0 {
+		t.Errorf("Expected no update of a secret in the wrong namespace, got %d", updates)
+	}
+}
+
+func TestInspectSecret_NamespaceMismatch(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			jwt.BuildKeyName(1000): []byte("key"),
+		},
+	}
+	updates := 0
+	k8sClient := getMisroutingClient(&updates, secret)
+
+	if _, err := InspectSecret(context.Background(), k8sClient, testSecretName, testNamespace); !errors.Is(err, jwt.ErrSecretNamespaceMismatch) {
+		t.Errorf("Expected namespace mismatch error from InspectSecret, got: %v", err)
+	}
+	if _, err := PruneStrayKeys(context.Background(), k8sClient, testSecretName, testNamespace, []string{"stray"}); !errors.Is(err, jwt.ErrSecretNamespaceMismatch) {
+		t.Errorf("Expected namespace mismatch error from PruneStrayKeys, got: %v", err)
+	}
+}
+
 func TestRotateSecret_MalformedKeysSkipped(t *testing.T) {
 	ctx := context.Background()
 	secretName := testSecretName
@@ -191,7 +675,7 @@ func TestRotateSecret_MalformedKeysSkipped(t *testing.T) {
 	k8sClient := getTestClient(secret)
 
 	// Rotation should succeed and skip malformed keys
-	err := RotateSecret(ctx, k8sClient, secretName, testNamespace, 3)
+	err := RotateSecret(ctx, k8sClient, secretName, testNamespace, 3, 0)
 	if err != nil {
 		t.Fatalf("RotateSecret should skip malformed keys, but failed: %v", err)
 	}
@@ -219,6 +703,52 @@ func TestRotateSecret_MalformedKeysSkipped(t *testing.T) {
 	}
 }
 
+func TestRotateSecret_RecordsEvents(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000":    []byte("key1"),
+			"jwt-signing-key-2000":    []byte("key2"),
+			"jwt-signing-key-3000":    []byte("key3"),
+			"jwt-signing-key-invalid": []byte("malformed"),
+		},
+	}
+	k8sClient := getTestClient(secret)
+	recorder := record.NewFakeRecorder(10)
+
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 2, 0, WithEventRecorder(recorder)); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	updatedSecret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret); err != nil {
+		t.Fatalf("Failed to get updated secret: %v", err)
+	}
+	// The malformed entry is carried over, so the signers' parser would reject this secret
+	var newKid string
+	for name := range updatedSecret.Data {
+		if ts, err := jwt.ParseKeyTimestamp(name); err == nil && ts > 3000 {
+			newKid = strings.TrimPrefix(name, jwt.KeyPrefix)
+		}
+	}
+	if newKid == "" {
+		t.Fatal("Expected a newly added key")
+	}
+
+	events := drainEvents(recorder)
+	expected := []string{
+		"Warning MalformedKey Skipped malformed signing key jwt-signing-key-invalid",
+		"Normal KeyRotated Added signing key " + newKid + ", pruned 2 keys",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+}
+
 func TestValidateSecret(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -290,7 +820,172 @@ func TestValidateSecret(t *testing.T) {
 	}
 }
 
+func TestInspectSecret_ReportsOrphanedKeys(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": []byte("key1"),
+			"jwt-signing-key-2000": []byte("key2"),
+			"other-key":            []byte("stale"),
+			"config.yaml":          []byte("leftover"),
+		},
+	}
+	k8sClient := getTestClient(secret)
+
+	report, err := InspectSecret(ctx, k8sClient, testSecretName, testNamespace)
+	if err != nil {
+		t.Fatalf("InspectSecret failed: %v", err)
+	}
+	if report.KeyCount != 2 {
+		t.Errorf("Expected 2 keys, got %d", report.KeyCount)
+	}
+	expected := []string{"config.yaml", "other-key"}
+	if len(report.OrphanedKeys) != len(expected) {
+		t.Fatalf("Expected orphaned keys %v, got %v", expected, report.OrphanedKeys)
+	}
+	for i, name := range expected {
+		if report.OrphanedKeys[i] != name {
+			t.Errorf("Expected orphaned keys %v, got %v", expected, report.OrphanedKeys)
+		}
+	}
+
+	// Orphaned entries are reported but do not fail validation
+	if err := ValidateSecret(ctx, k8sClient, testSecretName, testNamespace); err != nil {
+		t.Errorf("ValidateSecret should not fail on orphaned keys: %v", err)
+	}
+}
+
+func TestValidateSecret_WarnsOnNearMissKeyNames(t *testing.T) {
+	tests := []struct {
+		name         string
+		secretData   map[string][]byte
+		expectNear   []string
+		expectWarned bool
+	}{
+		{
+			name: "correctly named key",
+			secretData: map[string][]byte{
+				"jwt-signing-key-1000": []byte("key1"),
+			},
+			expectNear: []string{},
+		},
+		{
+			name: "missing dash before timestamp",
+			secretData: map[string][]byte{
+				"jwt-signing-key-1000": []byte("key1"),
+				"jwt-signing-key2000":  []byte("key2"),
+			},
+			expectNear:   []string{"jwt-signing-key2000"},
+			expectWarned: true,
+		},
+		{
+			name: "wrong case and separators",
+			secretData: map[string][]byte{
+				"jwt-signing-key-1000": []byte("key1"),
+				"JWT_SIGNING_KEY_2000": []byte("key2"),
+				"other-key":            []byte("unrelated"),
+			},
+			expectNear:   []string{"JWT_SIGNING_KEY_2000"},
+			expectWarned: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: testSecretName, Namespace: testNamespace},
+				Data:       tt.secretData,
+			}
+			k8sClient := getTestClient(secret)
+
+			report, err := InspectSecret(ctx, k8sClient, testSecretName, testNamespace)
+			if err != nil {
+				t.Fatalf("InspectSecret failed: %v", err)
+			}
+			if !reflect.DeepEqual(report.NearMissKeys, tt.expectNear) {
+				t.Errorf("Expected near-miss keys %v, got %v", tt.expectNear, report.NearMissKeys)
+			}
+
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			if err := ValidateSecret(ctx, k8sClient, testSecretName, testNamespace); err != nil {
+				t.Fatalf("ValidateSecret failed: %v", err)
+			}
+			warned := contains(buf.String(), "looks like a signing key")
+			if warned != tt.expectWarned {
+				t.Errorf("Expected near-miss warning %v, got log %q", tt.expectWarned, buf.String())
+			}
+		})
+	}
+}
+
+func TestValidateSecret_OnlyNearMissKeys(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testSecretName, Namespace: testNamespace},
+		Data: map[string][]byte{
+			"jwt-signing-key1000": []byte("key1"),
+		},
+	}
+	k8sClient := getTestClient(secret)
+
+	err := ValidateSecret(context.Background(), k8sClient, testSecretName, testNamespace)
+	if err == nil {
+		t.Fatal("Expected error but got none")
+	}
+	if !contains(err.Error(), "no valid JWT signing keys") || !contains(err.Error(), "jwt-signing-key1000") {
+		t.Errorf("Expected error naming the misnamed entry, got '%s'", err.Error())
+	}
+}
+
+func TestPruneStrayKeys(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": []byte("key1"),
+			"other-key":            []byte("stale"),
+			"keep-me":              []byte("important"),
+		},
+	}
+	k8sClient := getTestClient(secret)
+
+	removed, err := PruneStrayKeys(ctx, k8sClient, testSecretName, testNamespace,
+		[]string{"other-key", "missing-key", "jwt-signing-key-1000"})
+	if err != nil {
+		t.Fatalf("PruneStrayKeys failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "other-key" {
+		t.Errorf("Expected only other-key to be removed, got %v", removed)
+	}
+
+	updatedSecret := &corev1.Secret{}
+	err = k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret)
+	if err != nil {
+		t.Fatalf("Failed to get updated secret: %v", err)
+	}
+	if _, ok := updatedSecret.Data["other-key"]; ok {
+		t.Error("Expected other-key to be pruned")
+	}
+	if _, ok := updatedSecret.Data["keep-me"]; !ok {
+		t.Error("Expected non-allowlisted entry keep-me to be retained")
+	}
+	if _, ok := updatedSecret.Data["jwt-signing-key-1000"]; !ok {
+		t.Error("Expected signing key to be retained even when allowlisted")
+	}
+}
+
 func TestGetLatestKeyID(t *testing.T) {
+	key := make([]byte, jwt.KeySizeBytes)
 	tests := []struct {
 		name          string
 		secretData    map[string][]byte
@@ -301,7 +996,7 @@ func TestGetLatestKeyID(t *testing.T) {
 		{
 			name: "single key",
 			secretData: map[string][]byte{
-				"jwt-signing-key-1000": []byte("key1"),
+				"jwt-signing-key-1000": key,
 			},
 			expectedKid: "1000",
 			expectError: false,
@@ -309,9 +1004,9 @@ func TestGetLatestKeyID(t *testing.T) {
 		{
 			name: "multiple keys - latest is last",
 			secretData: map[string][]byte{
-				"jwt-signing-key-1000": []byte("key1"),
-				"jwt-signing-key-2000": []byte("key2"),
-				"jwt-signing-key-3000": []byte("key3"),
+				"jwt-signing-key-1000": key,
+				"jwt-signing-key-2000": key,
+				"jwt-signing-key-3000": key,
 			},
 			expectedKid: "3000",
 			expectError: false,
@@ -319,9 +1014,9 @@ func TestGetLatestKeyID(t *testing.T) {
 		{
 			name: "multiple keys - latest is first",
 			secretData: map[string][]byte{
-				"jwt-signing-key-5000": []byte("key5"),
-				"jwt-signing-key-2000": []byte("key2"),
-				"jwt-signing-key-1000": []byte("key1"),
+				"jwt-signing-key-5000": key,
+				"jwt-signing-key-2000": key,
+				"jwt-signing-key-1000": key,
 			},
 			expectedKid: "5000",
 			expectError: false,
@@ -341,15 +1036,31 @@ func TestGetLatestKeyID(t *testing.T) {
 			errorContains: "no valid JWT signing keys found",
 		},
 		{
-			name: "mixed valid and invalid keys",
+			name: "malformed key name",
 			secretData: map[string][]byte{
-				"jwt-signing-key-1000":    []byte("key1"),
-				"jwt-signing-key-invalid": []byte("badkey"),
-				"jwt-signing-key-3000":    []byte("key3"),
+				"jwt-signing-key-1000":    key,
+				"jwt-signing-key-invalid": key,
+				"jwt-signing-key-3000":    key,
 			},
-			expectedKid: "3000",
+			expectError:   true,
+			errorContains: "invalid key format",
+		},
+		{
+			name: "key timestamp zero",
+			secretData: map[string][]byte{
+				"jwt-signing-key-0": key,
+			},
+			expectedKid: "0",
 			expectError: false,
 		},
+		{
+			name: "key too short for the signers",
+			secretData: map[string][]byte{
+				"jwt-signing-key-1000": []byte("short"),
+			},
+			expectError:   true,
+			errorContains: "at least",
+		},
 	}
 
 	for _, tt := range tests {
@@ -383,8 +1094,198 @@ func TestGetLatestKeyID(t *testing.T) {
 	}
 }
 
+func TestGetLatestKeyID_TiedTimestamps(t *testing.T) {
+	key := make([]byte, jwt.KeySizeBytes)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"jwt-signing-key-900":   key,
+			"jwt-signing-key-01000": key,
+			"jwt-signing-key-1000":  key,
+			"jwt-signing-key-+1000": key,
+		},
+	}
+
+	// Map iteration order varies between calls, so repeat to catch nondeterminism
+	for i := 0; i < 50; i++ {
+		kid, err := GetLatestKeyID(secret)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		_, signerKid, err := jwt.ParseSigningKeysFromSecret(secret)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if kid != "1000" || signerKid != kid {
+			t.Fatalf("Expected rotator and signer to agree on kid '1000', got '%s' and '%s'", kid, signerKid)
+		}
+	}
+}
+
+func TestGetKeyAges(t *testing.T) {
+	key := make([]byte, jwt.KeySizeBytes)
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			"jwt-signing-key-3000":    key,
+			"jwt-signing-key-1000":    key,
+			"jwt-signing-key-invalid": key,
+			"other-entry":             []byte("value"),
+		},
+	}
+
+	ages := GetKeyAges(secret, time.Unix(4000, 0))
+	expected := []KeyAge{
+		{Name: "jwt-signing-key-1000", Kid: "1000", Age: 3000 * time.Second},
+		{Name: "jwt-signing-key-3000", Kid: "3000", Age: 1000 * time.Second},
+	}
+	if !reflect.DeepEqual(ages, expected) {
+		t.Errorf("Expected %v, got %v", expected, ages)
+	}
+}
+
+func TestRotateSecret_KeepsTiebreakWinnerWhenPruning(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"jwt-signing-key-01000": []byte("key1"),
+			"jwt-signing-key-1000":  []byte("key2"),
+		},
+	}
+	k8sClient := getTestClient(secret)
+
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 2, 0); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	updatedSecret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret); err != nil {
+		t.Fatalf("Failed to get updated secret: %v", err)
+	}
+	if _, ok := updatedSecret.Data["jwt-signing-key-1000"]; !ok {
+		t.Error("Expected the tie-break winner jwt-signing-key-1000 to be kept")
+	}
+	if _, ok := updatedSecret.Data["jwt-signing-key-01000"]; ok {
+		t.Error("Expected the tie-break loser jwt-signing-key-01000 to be pruned")
+	}
+}
+
+func TestRotateSecret_SingleKeyKeepsOverlap(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSecretName,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"jwt-signing-key-1000": []byte("key1"),
+			"jwt-signing-key-2000": []byte("key2"),
+		},
+	}
+	k8sClient := getTestClient(secret)
+
+	plan, err := RotateSecretDryRun(ctx, k8sClient, testSecretName, testNamespace, 1, 0)
+	if err != nil {
+		t.Fatalf("RotateSecretDryRun failed: %v", err)
+	}
+	if want := []string{"jwt-signing-key-1000"}; !reflect.DeepEqual(plan.PrunedKeys, want) {
+		t.Errorf("Expected pruned keys %v, got %v", want, plan.PrunedKeys)
+	}
+
+	if err := RotateSecret(ctx, k8sClient, testSecretName, testNamespace, 1, 0); err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+
+	updatedSecret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: testSecretName, Namespace: testNamespace}, updatedSecret); err != nil {
+		t.Fatalf("Failed to get updated secret: %v", err)
+	}
+	if _, ok := updatedSecret.Data[plan.NewKeyName]; !ok {
+		t.Errorf("Expected the new key %s to remain", plan.NewKeyName)
+	}
+	if _, ok := updatedSecret.Data["jwt-signing-key-2000"]; !ok {
+		t.Error("Expected the previous latest key to remain alongside the new key")
+	}
+	if _, ok := updatedSecret.Data["jwt-signing-key-1000"]; ok {
+		t.Error("Expected the oldest key to be pruned")
+	}
+}
+
+func TestCheckKeyOverlap(t *testing.T) {
+	keys := []keyEntry{
+		{name: "jwt-signing-key-1000", kid: "1000", timestamp: 1000},
+		{name: "jwt-signing-key-2000", kid: "2000", timestamp: 2000},
+	}
+
+	tests := []struct {
+		name        string
+		plan        *RotationPlan
+		expectError bool
+	}{
+		{
+			name: "new and previous latest kept",
+			plan: &RotationPlan{NewKeyName: "jwt-signing-key-3000", PrunedKeys: []string{"jwt-signing-key-1000"}},
+		},
+		{
+			name:        "previous latest pruned",
+			plan:        &RotationPlan{NewKeyName: "jwt-signing-key-3000", PrunedKeys: []string{"jwt-signing-key-2000"}},
+			expectError: true,
+		},
+		{
+			name:        "new key pruned",
+			plan:        &RotationPlan{NewKeyName: "jwt-signing-key-3000", PrunedKeys: []string{"jwt-signing-key-3000"}},
+			expectError: true,
+		},
+		{
+			name:        "no new key",
+			plan:        &RotationPlan{},
+			expectError: true,
+		},
+		{
+			name: "skipped rotation keeps the latest key",
+			plan: &RotationPlan{Skipped: true, PrunedKeys: []string{"jwt-signing-key-1000"}},
+		},
+		{
+			name:        "skipped rotation prunes every key",
+			plan:        &RotationPlan{Skipped: true, PrunedKeys: []string{"jwt-signing-key-1000", "jwt-signing-key-2000"}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkKeyOverlap(tt.plan, keys, "jwt-signing-key-2000")
+			if tt.expectError && err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
 // Helper functions
 
+// drainEvents returns the events buffered in a FakeRecorder
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) > 0 && len(substr) > 0 && (s == substr || hasSubstring(s, substr))
 }
@@ -0,0 +1,61 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package rotator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Scheduler rotates on a fixed interval for as long as its context lives, so the
+// rotator can run as a long-lived Deployment instead of a CronJob. It implements
+// controller-runtime's Runnable and only runs on the elected leader, so replicas do
+// not rotate the same secret twice.
+type Scheduler struct {
+	// Interval is the time slept before each rotation
+	Interval time.Duration
+	// Rotate performs one rotation. Errors are logged and retried at the next interval.
+	Rotate func(ctx context.Context) error
+}
+
+// NewScheduler creates a Scheduler calling rotate every interval
+func NewScheduler(interval time.Duration, rotate func(ctx context.Context) error) (*Scheduler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("rotation interval must be > 0, got %s", interval)
+	}
+	if rotate == nil {
+		return nil, fmt.Errorf("rotate function is required")
+	}
+	return &Scheduler{Interval: interval, Rotate: rotate}, nil
+}
+
+// Start sleeps, rotates and repeats until ctx is cancelled, e.g. on SIGTERM, and then
+// returns nil without waiting for the rest of the interval
+func (s *Scheduler) Start(ctx context.Context) error {
+	timer := time.NewTimer(s.Interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Stopping scheduled rotation: %v\n", context.Cause(ctx))
+			return nil
+		case <-timer.C:
+		}
+
+		if err := s.Rotate(ctx); err != nil {
+			log.Printf("Warning: scheduled rotation failed, retrying in %s: %v\n", s.Interval, err)
+		}
+		timer.Reset(s.Interval)
+	}
+}
+
+// NeedLeaderElection reports that only the leader may rotate
+func (s *Scheduler) NeedLeaderElection() bool {
+	return true
+}
@@ -0,0 +1,76 @@
+/*
+Copyright (c) Amazon Web Services
+Distributed under the terms of the MIT license
+*/
+
+package rotator
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewScheduler_Invalid(t *testing.T) {
+	rotate := func(context.Context) error { return nil }
+	if _, err := NewScheduler(0, rotate); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+	if _, err := NewScheduler(time.Minute, nil); err == nil {
+		t.Error("Expected error for nil rotate function")
+	}
+}
+
+func TestScheduler_RotatesEveryInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	scheduler, err := NewScheduler(10*time.Millisecond, func(context.Context) error {
+		// Failed rotations do not stop the loop
+		if calls.Add(1) == 3 {
+			cancel()
+		}
+		return errors.New("transient failure")
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+
+	if err := scheduler.Start(ctx); err != nil {
+		t.Errorf("Start() error = %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("Expected 3 rotations, got %d", got)
+	}
+	if !scheduler.NeedLeaderElection() {
+		t.Error("Expected scheduler to require leader election")
+	}
+}
+
+func TestScheduler_StopsMidSleep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	scheduler, err := NewScheduler(time.Hour, func(context.Context) error {
+		t.Error("Rotate should not be called before the interval elapses")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Start(ctx) }()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return after the context was cancelled")
+	}
+}